@@ -2,7 +2,12 @@ package config
 
 import (
 	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/audit"
+	"github.com/ZnNr/user-reward-controller/internal/scheduler"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 // Config содержит конфигурацию приложения, включая настройки базы данных и сервера.
@@ -12,7 +17,37 @@ type Config struct {
 	DBUser     string // Пользователь базы данных
 	DBPassword string // Пароль базы данных
 	DBName     string // Имя базы данных
+	DBURL      string // Строка подключения к базе данных; если задана, параметры DB* выше не используются
 	ServerPort string // Порт сервера приложения
+	GRPCPort   string // Порт gRPC сервера
+
+	TrustedProxies []string // Адреса и подсети (CIDR) обратных прокси, которым доверяется заголовок X-Forwarded-For
+
+	MigrationsDir string // Каталог с миграциями схемы базы данных
+
+	EventsHeartbeat   time.Duration // Интервал heartbeat-комментариев в SSE-потоке
+	EventsHistorySize int           // Количество последних событий, доступных для возобновления по Last-Event-ID
+	EventsBufferSize  int           // Размер буфера событий на одно SSE-соединение
+	EventsLeaderboard time.Duration // Минимальный интервал между пересчетами таблицы лидеров для подписчиков
+
+	SoftDeleteRetention time.Duration // Срок хранения мягко удаленных пользователей и задач до окончательного удаления
+	PurgeSchedule       string        // Расписание очистки мягко удаленных записей (cron или @every)
+
+	MailSender           string        // Способ отправки писем: log или file
+	MailDir              string        // Каталог для писем при MailSender=file
+	VerificationTokenTTL time.Duration // Срок действия токена подтверждения email
+	VerificationResend   time.Duration // Минимальный интервал между повторными отправками письма подтверждения одному пользователю; 0 отключает ограничение
+	VerificationURL      string        // Адрес страницы подтверждения, к которому дописывается токен
+
+	CampaignSyncSchedule string // Расписание переключения статусов кампаний
+
+	DueSweepSchedule string        // Расписание поиска просроченных задач и отправки напоминаний
+	DueGracePeriod   time.Duration // Отсрочка после срока, в течение которой задачу еще можно выполнить
+	DueReminderLead  time.Duration // За сколько до срока напоминать исполнителям; 0 отключает напоминания
+
+	JobInstance         string        // Имя реплики в истории запусков фоновых заданий; по умолчанию hostname
+	JobHistoryRetention time.Duration // Срок хранения истории запусков фоновых заданий
+	JobHistorySchedule  string        // Расписание очистки истории запусков
 }
 
 // Load загружает конфигурацию из переменных окружения
@@ -37,12 +72,45 @@ func LoadConfig() (*Config, error) {
 		DBUser:     getEnv("DB_USER", "postgres"),
 		DBPassword: getEnv("DB_PASSWORD", "postgres"),
 		DBName:     getEnv("DB_NAME", "user_reward_db"),
+		DBURL:      getEnv("DATABASE_URL", ""),
 		ServerPort: getEnv("SERVER_PORT", "8080"),
+		GRPCPort:   getEnv("GRPC_PORT", "9090"),
+
+		TrustedProxies: getEnvList("TRUSTED_PROXIES"),
+
+		MigrationsDir: getEnv("MIGRATIONS_DIR", "migration"),
+
+		EventsHeartbeat:   getEnvDuration("EVENTS_HEARTBEAT", 15*time.Second),
+		EventsHistorySize: getEnvInt("EVENTS_HISTORY_SIZE", 1024),
+		EventsBufferSize:  getEnvInt("EVENTS_BUFFER_SIZE", 64),
+		EventsLeaderboard: getEnvDuration("EVENTS_LEADERBOARD_INTERVAL", time.Second),
+
+		SoftDeleteRetention: getEnvDuration("SOFT_DELETE_RETENTION", 30*24*time.Hour),
+		PurgeSchedule:       getEnv("PURGE_SCHEDULE", "@hourly"),
+
+		MailSender:           getEnv("MAIL_SENDER", "log"),
+		MailDir:              getEnv("MAIL_DIR", "./mail"),
+		VerificationTokenTTL: getEnvDuration("VERIFICATION_TOKEN_TTL", 24*time.Hour),
+		VerificationResend:   getEnvDuration("VERIFICATION_RESEND_INTERVAL", time.Minute),
+		VerificationURL:      getEnv("VERIFICATION_URL", "http://localhost:8080/verify?token="),
+
+		CampaignSyncSchedule: getEnv("CAMPAIGN_SYNC_SCHEDULE", "* * * * *"),
+
+		DueSweepSchedule: getEnv("DUE_SWEEP_SCHEDULE", "* * * * *"),
+		DueGracePeriod:   getEnvDuration("DUE_GRACE_PERIOD", 0),
+		DueReminderLead:  getEnvDuration("DUE_REMINDER_LEAD", 24*time.Hour),
+
+		JobInstance:         getEnv("JOB_INSTANCE", defaultInstanceName()),
+		JobHistoryRetention: getEnvDuration("JOB_HISTORY_RETENTION", 7*24*time.Hour),
+		JobHistorySchedule:  getEnv("JOB_HISTORY_SCHEDULE", "@daily"),
 	}, nil
 }
 
 // GetDBConnString формирует строку подключения к базе данных.
 func (c *Config) GetDBConnString() string {
+	if c.DBURL != "" {
+		return c.DBURL
+	}
 	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		c.DBHost, c.DBPort, c.DBUser, c.DBPassword, c.DBName)
 }
@@ -55,22 +123,115 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
-// Validate проверяет, что важные параметры конфигурации заполнены.
-func (c *Config) Validate() error {
-	if c.DBHost == "" {
-		return fmt.Errorf("DBHost cannot be empty")
+// getEnvInt возвращает целочисленное значение переменной окружения или значение по умолчанию.
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
 	}
-	if c.DBPort == "" {
-		return fmt.Errorf("DBPort cannot be empty")
+	return defaultValue
+}
+
+// getEnvList возвращает список значений переменной окружения, перечисленных через запятую.
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
 	}
-	if c.DBUser == "" {
-		return fmt.Errorf("DBUser cannot be empty")
+	return values
+}
+
+// getEnvDuration возвращает длительность из переменной окружения (например, "15s") или значение по умолчанию.
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, err := time.ParseDuration(os.Getenv(key)); err == nil {
+		return value
 	}
-	if c.DBName == "" {
-		return fmt.Errorf("DBName cannot be empty")
+	return defaultValue
+}
+
+// Validate проверяет, что важные параметры конфигурации заполнены.
+func (c *Config) Validate() error {
+	if c.DBURL == "" {
+		if c.DBHost == "" {
+			return fmt.Errorf("DBHost cannot be empty")
+		}
+		if c.DBPort == "" {
+			return fmt.Errorf("DBPort cannot be empty")
+		}
+		if c.DBUser == "" {
+			return fmt.Errorf("DBUser cannot be empty")
+		}
+		if c.DBName == "" {
+			return fmt.Errorf("DBName cannot be empty")
+		}
 	}
 	if c.ServerPort == "" {
 		return fmt.Errorf("ServerPort cannot be empty")
 	}
+	if c.GRPCPort == "" {
+		return fmt.Errorf("GRPCPort cannot be empty")
+	}
+	if c.MigrationsDir == "" {
+		return fmt.Errorf("MigrationsDir cannot be empty")
+	}
+	if c.GRPCPort == c.ServerPort {
+		return fmt.Errorf("GRPCPort must differ from ServerPort")
+	}
+	if c.EventsHeartbeat <= 0 {
+		return fmt.Errorf("EventsHeartbeat must be positive")
+	}
+	if c.EventsHistorySize <= 0 {
+		return fmt.Errorf("EventsHistorySize must be positive")
+	}
+	if c.EventsBufferSize <= 0 {
+		return fmt.Errorf("EventsBufferSize must be positive")
+	}
+	if c.EventsLeaderboard <= 0 {
+		return fmt.Errorf("EventsLeaderboard must be positive")
+	}
+	if c.SoftDeleteRetention < 0 {
+		return fmt.Errorf("SoftDeleteRetention cannot be negative")
+	}
+	if c.MailSender != "log" && c.MailSender != "file" {
+		return fmt.Errorf("MailSender must be log or file")
+	}
+	if c.VerificationTokenTTL <= 0 {
+		return fmt.Errorf("VerificationTokenTTL must be positive")
+	}
+	if c.VerificationResend < 0 {
+		return fmt.Errorf("VerificationResend cannot be negative")
+	}
+	if c.DueGracePeriod < 0 {
+		return fmt.Errorf("DueGracePeriod cannot be negative")
+	}
+	if c.DueReminderLead < 0 {
+		return fmt.Errorf("DueReminderLead cannot be negative")
+	}
+	if _, err := audit.ParseTrustedProxies(c.TrustedProxies); err != nil {
+		return fmt.Errorf("TrustedProxies is invalid: %w", err)
+	}
+	if c.JobHistoryRetention <= 0 {
+		return fmt.Errorf("JobHistoryRetention must be positive")
+	}
+	for _, schedule := range []struct{ name, spec string }{
+		{"PurgeSchedule", c.PurgeSchedule},
+		{"CampaignSyncSchedule", c.CampaignSyncSchedule},
+		{"DueSweepSchedule", c.DueSweepSchedule},
+		{"JobHistorySchedule", c.JobHistorySchedule},
+	} {
+		if _, err := scheduler.Parse(schedule.spec); err != nil {
+			return fmt.Errorf("%s is invalid: %w", schedule.name, err)
+		}
+	}
 	return nil
 }
+
+// defaultInstanceName возвращает имя реплики по умолчанию: hostname и PID процесса.
+func defaultInstanceName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
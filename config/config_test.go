@@ -0,0 +1,32 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// DATABASE_URL заменяет отдельные параметры подключения, а каталог миграций по умолчанию - migration
+func TestDatabaseURL(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://app:secret@db:5432/rewards?sslmode=disable")
+	t.Setenv("MIGRATIONS_DIR", "")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if got := cfg.GetDBConnString(); got != "postgres://app:secret@db:5432/rewards?sslmode=disable" {
+		t.Fatalf("connection string %q", got)
+	}
+	if cfg.MigrationsDir != "migration" {
+		t.Fatalf("migrations dir %q", cfg.MigrationsDir)
+	}
+
+	cfg.DBHost = ""
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("empty DBHost with DATABASE_URL: %v", err)
+	}
+	cfg.DBURL = ""
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "DBHost") {
+		t.Fatalf("empty DB_HOST without DATABASE_URL: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/logging"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// Metadata описывает инициатора действия, попадающего в журнал аудита
+type Metadata struct {
+	Actor     string // Идентификатор инициатора (subject токена)
+	RequestID string // Идентификатор запроса
+	IP        string // IP-адрес клиента
+}
+
+type metadataKey struct{}
+
+// WithMetadata возвращает контекст с данными об инициаторе действия
+func WithMetadata(ctx context.Context, md Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, md)
+}
+
+// FromContext возвращает данные об инициаторе действия; для фоновых операций актор - "system"
+func FromContext(ctx context.Context) Metadata {
+	if md, ok := ctx.Value(metadataKey{}).(Metadata); ok {
+		return md
+	}
+	return Metadata{Actor: "system", RequestID: logging.RequestID(ctx)}
+}
+
+// Middleware сохраняет в контексте запроса актора, идентификатор запроса и IP клиента.
+// Функция actor определяет инициатора по запросу (например, по JWT токену); proxies - обратные прокси,
+// которым доверяется заголовок X-Forwarded-For.
+func Middleware(actor func(*http.Request) string, proxies TrustedProxies) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			md := Metadata{
+				Actor:     actor(r),
+				RequestID: logging.RequestID(r.Context()),
+				IP:        proxies.ClientIP(r),
+			}
+			next.ServeHTTP(w, r.WithContext(WithMetadata(r.Context(), md)))
+		})
+	}
+}
+
+// TrustedProxies - адреса и подсети обратных прокси, которым доверяется заголовок X-Forwarded-For
+type TrustedProxies []netip.Prefix
+
+// ParseTrustedProxies разбирает список адресов ("10.0.0.1") и подсетей в нотации CIDR ("10.0.0.0/8")
+func ParseTrustedProxies(values []string) (TrustedProxies, error) {
+	proxies := make(TrustedProxies, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if strings.Contains(value, "/") {
+			prefix, err := netip.ParsePrefix(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+			}
+			proxies = append(proxies, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+		}
+		proxies = append(proxies, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return proxies, nil
+}
+
+// trusts проверяет, что адрес принадлежит одному из доверенных прокси
+func (p TrustedProxies) trusts(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range p {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP возвращает IP клиента. Заголовок X-Forwarded-For учитывается, только если соединение
+// установлено доверенным прокси: адреса из заголовка просматриваются справа налево, и клиентом
+// считается первый адрес, не принадлежащий доверенным прокси. Иначе используется адрес соединения,
+// так как заголовок мог выставить сам клиент.
+func (p TrustedProxies) ClientIP(r *http.Request) string {
+	remote := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(remote); err == nil {
+		remote = host
+	}
+	if !p.trusts(remote) {
+		return remote
+	}
+
+	client := remote
+	forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if hop == "" {
+			continue
+		}
+		if _, err := netip.ParseAddr(hop); err != nil {
+			// Дальше заголовку доверять нельзя; последний проверенный адрес - ближайший к клиенту
+			break
+		}
+		client = hop
+		if !p.trusts(hop) {
+			break
+		}
+	}
+	return client
+}
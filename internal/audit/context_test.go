@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", " 192.168.1.5", "::ffff:172.16.0.1"})
+	if err != nil {
+		t.Fatalf("parse trusted proxies: %v", err)
+	}
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded []string
+		want      string
+	}{
+		{"direct connection", "203.0.113.7:5000", nil, "203.0.113.7"},
+		{"spoofed header from a client", "203.0.113.7:5000", []string{"1.2.3.4"}, "203.0.113.7"},
+		{"trusted proxy", "10.1.2.3:80", []string{"198.51.100.9"}, "198.51.100.9"},
+		{"client-supplied prefix is skipped", "10.1.2.3:80", []string{"1.2.3.4, 198.51.100.9"}, "198.51.100.9"},
+		{"chain of trusted proxies", "10.1.2.3:80", []string{"198.51.100.9, 192.168.1.5", "10.9.9.9"}, "198.51.100.9"},
+		{"only trusted hops", "10.1.2.3:80", []string{"10.4.4.4"}, "10.4.4.4"},
+		{"garbage hop", "10.1.2.3:80", []string{"1.2.3.4, evil, 10.4.4.4"}, "10.4.4.4"},
+		{"trusted proxy without header", "10.1.2.3:80", nil, "10.1.2.3"},
+		{"mapped IPv4 proxy", "[::ffff:172.16.0.1]:80", []string{"2001:db8::1"}, "2001:db8::1"},
+		{"remote without port", "192.168.1.5", []string{"198.51.100.9"}, "198.51.100.9"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if got := proxies.ClientIP(r); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	// Без настроенных прокси заголовок игнорируется
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.1.2.3:80"
+	r.Header.Set("X-Forwarded-For", "1.2.3.4")
+	if got := TrustedProxies(nil).ClientIP(r); got != "10.1.2.3" {
+		t.Fatalf("no trusted proxies: got %s", got)
+	}
+
+	for _, invalid := range []string{"10.0.0.0/33", "proxy.local"} {
+		if _, err := ParseTrustedProxies([]string{invalid}); err == nil {
+			t.Fatalf("%q parsed as a trusted proxy", invalid)
+		}
+	}
+}
@@ -1,18 +1,25 @@
 package errors
 
-import "fmt"
+import (
+	stderrors "errors"
+	"fmt"
+	"strings"
+)
 
 // ErrorType - тип для обозначения категории ошибки.
 type ErrorType string
 
 // Определение различных типов ошибок.
 const (
-	NotFound      ErrorType = "NOT_FOUND"
-	BadRequest    ErrorType = "BAD_REQUEST"
-	Internal      ErrorType = "INTERNAL"
-	Validation    ErrorType = "VALIDATION"
-	AlreadyExists ErrorType = "ALREADY_EXISTS"
-	InvalidToken  ErrorType = "INVALID_TOKEN" // Новая ошибка для недействительного токена
+	NotFound        ErrorType = "NOT_FOUND"
+	BadRequest      ErrorType = "BAD_REQUEST"
+	Internal        ErrorType = "INTERNAL"
+	Validation      ErrorType = "VALIDATION"
+	AlreadyExists   ErrorType = "ALREADY_EXISTS"
+	InvalidToken    ErrorType = "INVALID_TOKEN"     // Новая ошибка для недействительного токена
+	Forbidden       ErrorType = "FORBIDDEN"         // Недостаточно прав для выполнения действия
+	Conflict        ErrorType = "CONFLICT"          // Действие противоречит текущему состоянию ресурса
+	TooManyRequests ErrorType = "TOO_MANY_REQUESTS" // Превышена допустимая частота запросов
 
 	ErrMsgInvalidInput = "invalid input parameters"
 	ErrMsgInternal     = "internal server error"
@@ -22,27 +29,80 @@ const (
 
 // StatusCode - мапа с кодами статуса для каждого типа ошибки.
 var StatusCode = map[ErrorType]int{
-	NotFound:      404,
-	BadRequest:    400,
-	Internal:      500,
-	Validation:    422,
-	AlreadyExists: 409,
-	InvalidToken:  401, // Код состояния для недействительного токена
+	NotFound:        404,
+	BadRequest:      400,
+	Internal:        500,
+	Validation:      422,
+	AlreadyExists:   409,
+	InvalidToken:    401, // Код состояния для недействительного токена
+	Forbidden:       403,
+	Conflict:        409,
+	TooManyRequests: 429,
+}
+
+// Стабильные машиночитаемые коды ошибок, которые клиенты могут сравнивать вместо текста сообщения.
+// Ошибка без собственного кода получает код своего типа (см. TypeCode).
+const (
+	CodeInvalidParameters       = "invalid_parameters"        // Параметры пути или строки запроса не соответствуют спецификации
+	CodeInvalidBody             = "invalid_body"              // Тело запроса не разбирается как JSON нужного вида
+	CodeValidationFailed        = "validation_failed"         // Поля запроса нарушают ограничения
+	CodeRouteNotFound           = "route_not_found"           // Для запроса нет маршрута
+	CodeMethodNotAllowed        = "method_not_allowed"        // Маршрут не поддерживает метод запроса
+	CodeInvalidStatusTransition = "invalid_status_transition" // Переход задачи из текущего статуса в запрошенный недопустим
+	CodeInsufficientBalance     = "insufficient_balance"      // Списание сделало бы баланс отрицательным
+)
+
+// typeCodes - коды ошибок по умолчанию для каждого типа.
+var typeCodes = map[ErrorType]string{
+	NotFound:        "not_found",
+	BadRequest:      "bad_request",
+	Internal:        "internal_error",
+	Validation:      CodeValidationFailed,
+	AlreadyExists:   "already_exists",
+	InvalidToken:    "invalid_token",
+	Forbidden:       "forbidden",
+	Conflict:        "conflict",
+	TooManyRequests: "too_many_requests",
+}
+
+// TypeCode возвращает код ошибки по умолчанию для типа errorType.
+func TypeCode(errorType ErrorType) string {
+	if code, exists := typeCodes[errorType]; exists {
+		return code
+	}
+	return strings.ToLower(string(errorType))
+}
+
+// FieldError - нарушение в одном поле запроса.
+type FieldError struct {
+	In      string `json:"in"`      // Часть запроса: path, query, header или body
+	Field   string `json:"field"`   // Имя параметра или путь к полю тела; пустой для тела целиком
+	Message string `json:"message"` // Нарушенное ограничение
 }
 
 // Error - структура, представляющая ошибку с дополнительной информацией.
 type Error struct {
-	Type    ErrorType // Тип ошибки
-	Message string    // Сообщение об ошибке
-	Err     error     // Вложенная ошибка, если есть
+	Type    ErrorType    // Тип ошибки
+	Code    string       // Машиночитаемый код; пустой - код типа ошибки
+	Message string       // Сообщение об ошибке
+	Fields  []FieldError // Нарушения по полям запроса, если ошибка вызвана ими
+	Err     error        // Вложенная ошибка, если есть
 }
 
 // Error - метод для реализации интерфейса error.
 func (e *Error) Error() string {
+	message := e.Message
+	if len(e.Fields) > 0 {
+		violations := make([]string, 0, len(e.Fields))
+		for _, f := range e.Fields {
+			violations = append(violations, f.Field+" "+f.Message)
+		}
+		message += " (" + strings.Join(violations, "; ") + ")"
+	}
 	if e.Err != nil {
-		return fmt.Sprintf("%s: %v", e.Message, e.Err)
+		return fmt.Sprintf("%s: %v", message, e.Err)
 	}
-	return e.Message
+	return message
 }
 
 // Status - метод возвращает код статуса для ошибки.
@@ -53,6 +113,20 @@ func (e *Error) Status() int {
 	return 500 // Если тип ошибки не определен, возвращаем код по умолчанию.
 }
 
+// ErrorCode - метод возвращает машиночитаемый код ошибки.
+func (e *Error) ErrorCode() string {
+	if e.Code != "" {
+		return e.Code
+	}
+	return TypeCode(e.Type)
+}
+
+// WithCode - метод задает ошибке собственный код вместо кода ее типа.
+func (e *Error) WithCode(code string) *Error {
+	e.Code = code
+	return e
+}
+
 // NewError создает новую ошибку с заданным типом и сообщением.
 func NewError(errorType ErrorType, message string, err error) *Error {
 	return &Error{
@@ -92,9 +166,37 @@ func NewInvalidToken(message string, err error) *Error {
 	return NewError(InvalidToken, message, err) // Новая функция для недействительного токена
 }
 
-// Проверки типов ошибок.
+func NewForbidden(message string, err error) *Error {
+	return NewError(Forbidden, message, err)
+}
+
+func NewConflict(message string, err error) *Error {
+	return NewError(Conflict, message, err)
+}
+
+func NewTooManyRequests(message string, err error) *Error {
+	return NewError(TooManyRequests, message, err)
+}
+
+// NewFieldsValidation создает ошибку валидации со списком нарушений по полям.
+func NewFieldsValidation(message string, fields []FieldError) *Error {
+	e := NewError(Validation, message, nil)
+	e.Fields = fields
+	return e
+}
+
+// As возвращает первую ошибку *Error в цепочке err, в том числе обернутую через fmt.Errorf("%w").
+func As(err error) (*Error, bool) {
+	var e *Error
+	if stderrors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+// Проверки типов ошибок. Обернутые ошибки классифицируются по первой *Error в цепочке.
 func IsErrorType(err error, errorType ErrorType) bool {
-	if e, ok := err.(*Error); ok {
+	if e, ok := As(err); ok {
 		return e.Type == errorType
 	}
 	return false
@@ -0,0 +1,215 @@
+package events
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Названия топиков, на которые могут подписываться клиенты.
+const (
+	TopicLeaderboard = "leaderboard" // Изменения рангов в таблице лидеров
+	userTopicPrefix  = "user:"       // Префикс персонального топика пользователя
+)
+
+// Типы событий, передаваемые в поле event SSE-потока.
+const (
+	EventLeaderboard = "leaderboard.updated"
+	EventBalance     = "balance.updated"
+)
+
+// UserTopic возвращает имя персонального топика пользователя.
+func UserTopic(userID string) string {
+	return userTopicPrefix + userID
+}
+
+// IsUserTopic проверяет, является ли топик персональным топиком пользователя, и возвращает ID пользователя.
+func IsUserTopic(topic string) (string, bool) {
+	if !strings.HasPrefix(topic, userTopicPrefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(topic, userTopicPrefix), true
+}
+
+// Event представляет одно событие, доставляемое подписчикам
+type Event struct {
+	ID        uint64          // Монотонно возрастающий идентификатор (используется в Last-Event-ID)
+	Topic     string          // Топик, в который опубликовано событие
+	Type      string          // Тип события
+	Data      json.RawMessage // Полезная нагрузка в формате JSON
+	CreatedAt time.Time       // Время публикации
+}
+
+// Publisher публикует события для подключенных клиентов.
+type Publisher interface {
+	Publish(topic, eventType string, payload interface{})
+}
+
+// Subscription представляет подписку одного соединения на набор топиков
+type Subscription struct {
+	C      <-chan Event // Канал доставки событий, закрывается при отписке или остановке брокера
+	ch     chan Event
+	topics map[string]struct{}
+	once   sync.Once
+}
+
+// matches проверяет, подписано ли соединение на топик.
+func (s *Subscription) matches(topic string) bool {
+	_, ok := s.topics[topic]
+	return ok
+}
+
+// close закрывает канал подписки ровно один раз.
+func (s *Subscription) close() {
+	s.once.Do(func() { close(s.ch) })
+}
+
+// Broker распределяет события по подписчикам и хранит ограниченную историю для возобновления потока
+type Broker struct {
+	mu          sync.RWMutex
+	logger      *zap.Logger
+	subscribers map[*Subscription]struct{}
+	history     []Event // Кольцевой буфер последних событий
+	head        int     // Позиция для записи следующего события в history
+	size        int     // Количество событий в history
+	lastID      uint64
+	bufferSize  int
+	closed      bool
+}
+
+// NewBroker создает брокер с историей заданного размера и буфером указанной длины на каждое соединение.
+func NewBroker(historySize, bufferSize int, logger *zap.Logger) *Broker {
+	if historySize <= 0 {
+		historySize = 1
+	}
+	if bufferSize <= 0 {
+		bufferSize = 1
+	}
+	return &Broker{
+		logger:      logger,
+		subscribers: make(map[*Subscription]struct{}),
+		history:     make([]Event, historySize),
+		bufferSize:  bufferSize,
+	}
+}
+
+// Publish сериализует payload и рассылает событие всем подписчикам топика.
+// Подписчик, чей буфер переполнен, отключается, чтобы медленный клиент не блокировал остальных.
+func (b *Broker) Publish(topic, eventType string, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		b.logger.Error("Failed to marshal event payload", zap.String("topic", topic), zap.Error(err))
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+
+	b.lastID++
+	event := Event{
+		ID:        b.lastID,
+		Topic:     topic,
+		Type:      eventType,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+	b.remember(event)
+
+	for sub := range b.subscribers {
+		if !sub.matches(topic) {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			b.logger.Warn("Dropping slow event subscriber", zap.String("topic", topic))
+			delete(b.subscribers, sub)
+			sub.close()
+		}
+	}
+}
+
+// remember сохраняет событие в кольцевой буфер истории.
+func (b *Broker) remember(event Event) {
+	b.history[b.head] = event
+	b.head = (b.head + 1) % len(b.history)
+	if b.size < len(b.history) {
+		b.size++
+	}
+}
+
+// Subscribe регистрирует подписку на топики. Если lastEventID больше нуля, в канал сначала
+// помещаются сохраненные в истории события с большим ID (в пределах размера буфера соединения).
+func (b *Broker) Subscribe(topics []string, lastEventID uint64) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &Subscription{topics: make(map[string]struct{}, len(topics))}
+	for _, topic := range topics {
+		sub.topics[topic] = struct{}{}
+	}
+
+	var replay []Event
+	if lastEventID > 0 {
+		replay = b.replay(sub, lastEventID)
+	}
+
+	capacity := b.bufferSize
+	if len(replay) > capacity {
+		capacity = len(replay)
+	}
+	sub.ch = make(chan Event, capacity)
+	sub.C = sub.ch
+
+	for _, event := range replay {
+		sub.ch <- event
+	}
+
+	if b.closed {
+		sub.close()
+		return sub
+	}
+	b.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// replay возвращает события из истории, следующие за lastEventID и подходящие подписке.
+func (b *Broker) replay(sub *Subscription, lastEventID uint64) []Event {
+	var events []Event
+	start := (b.head - b.size + len(b.history)) % len(b.history)
+	for i := 0; i < b.size; i++ {
+		event := b.history[(start+i)%len(b.history)]
+		if event.ID > lastEventID && sub.matches(event.Topic) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// Unsubscribe отменяет подписку и закрывает ее канал.
+func (b *Broker) Unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subscribers, sub)
+	sub.close()
+}
+
+// Close останавливает брокер и закрывает все подписки, чтобы открытые потоки завершились.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for sub := range b.subscribers {
+		sub.close()
+	}
+	b.subscribers = make(map[*Subscription]struct{})
+}
@@ -0,0 +1,137 @@
+package events
+
+import (
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// drain читает из подписки уже доставленные события, не дожидаясь новых
+func drain(sub *Subscription) (ids []uint64, open bool) {
+	for {
+		select {
+		case event, ok := <-sub.C:
+			if !ok {
+				return ids, false
+			}
+			ids = append(ids, event.ID)
+		default:
+			return ids, true
+		}
+	}
+}
+
+// publish публикует события с ID 1..n, чередуя топики leaderboard и user:erin
+func publish(b *Broker, n int) {
+	for i := 1; i <= n; i++ {
+		topic := TopicLeaderboard
+		if i%2 == 0 {
+			topic = UserTopic("erin")
+		}
+		b.Publish(topic, EventBalance, map[string]int{"n": i})
+	}
+}
+
+func TestBrokerReplay(t *testing.T) {
+	both := []string{TopicLeaderboard, UserTopic("erin")}
+	tests := []struct {
+		name        string
+		history     int
+		published   int
+		topics      []string
+		lastEventID uint64
+		want        []uint64
+	}{
+		{"no Last-Event-ID", 8, 5, both, 0, nil},
+		{"after the last event", 8, 5, both, 5, nil},
+		{"events after Last-Event-ID", 8, 5, both, 2, []uint64{3, 4, 5}},
+		{"only subscribed topics", 8, 5, []string{UserTopic("erin")}, 1, []uint64{2, 4}},
+		{"ring wrapped", 4, 10, both, 7, []uint64{8, 9, 10}},
+		{"stale Last-Event-ID", 4, 10, both, 2, []uint64{7, 8, 9, 10}},
+		{"wrapped exactly once", 5, 10, both, 1, []uint64{6, 7, 8, 9, 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBroker(tt.history, 2, zap.NewNop())
+			publish(b, tt.published)
+
+			sub := b.Subscribe(tt.topics, tt.lastEventID)
+			got, open := drain(sub)
+			if !reflect.DeepEqual(got, tt.want) || !open {
+				t.Fatalf("replayed %v (open %t), want %v", got, open, tt.want)
+			}
+
+			// Живые события продолжают нумерацию истории
+			b.Publish(TopicLeaderboard, EventLeaderboard, nil)
+			b.Publish(UserTopic("erin"), EventBalance, nil)
+			want := []uint64{uint64(tt.published + 1), uint64(tt.published + 2)}
+			if len(tt.topics) == 1 {
+				want = want[1:]
+			}
+			if live, open := drain(sub); !reflect.DeepEqual(live, want) || !open {
+				t.Fatalf("live events %v (open %t), want %v", live, open, want)
+			}
+		})
+	}
+}
+
+func TestBrokerDropsSlowSubscriber(t *testing.T) {
+	b := NewBroker(8, 2, zap.NewNop())
+	slow := b.Subscribe([]string{TopicLeaderboard}, 0)
+	fast := b.Subscribe([]string{TopicLeaderboard}, 0)
+	other := b.Subscribe([]string{UserTopic("erin")}, 0)
+
+	for i := 0; i < 3; i++ {
+		b.Publish(TopicLeaderboard, EventLeaderboard, i)
+		if i < 2 {
+			if ids, _ := drain(fast); len(ids) != 1 {
+				t.Fatalf("fast subscriber got %v", ids)
+			}
+		}
+	}
+
+	// Медленный подписчик получает то, что успело попасть в буфер, после чего канал закрывается
+	if ids, open := drain(slow); !reflect.DeepEqual(ids, []uint64{1, 2}) || open {
+		t.Fatalf("slow subscriber: %v, open %t", ids, open)
+	}
+	if ids, open := drain(fast); !reflect.DeepEqual(ids, []uint64{3}) || !open {
+		t.Fatalf("fast subscriber: %v, open %t", ids, open)
+	}
+	if ids, open := drain(other); ids != nil || !open {
+		t.Fatalf("subscriber of another topic: %v, open %t", ids, open)
+	}
+
+	// Отключенный подписчик больше не получает событий, а повторная отписка безопасна
+	b.Publish(TopicLeaderboard, EventLeaderboard, 3)
+	b.Unsubscribe(slow)
+	if ids, _ := drain(fast); !reflect.DeepEqual(ids, []uint64{4}) {
+		t.Fatalf("fast subscriber after the drop: %v", ids)
+	}
+}
+
+func TestBrokerClose(t *testing.T) {
+	b := NewBroker(8, 4, zap.NewNop())
+	publish(b, 3)
+	subs := []*Subscription{
+		b.Subscribe([]string{TopicLeaderboard}, 0),
+		b.Subscribe([]string{UserTopic("erin")}, 1),
+	}
+	b.Close()
+	b.Close()
+
+	if ids, open := drain(subs[0]); ids != nil || open {
+		t.Fatalf("subscriber after Close: %v, open %t", ids, open)
+	}
+	// Уже поставленные в очередь события дочитываются до закрытия канала
+	if ids, open := drain(subs[1]); !reflect.DeepEqual(ids, []uint64{2}) || open {
+		t.Fatalf("replaying subscriber after Close: %v, open %t", ids, open)
+	}
+
+	b.Publish(TopicLeaderboard, EventLeaderboard, nil)
+	late := b.Subscribe([]string{TopicLeaderboard}, 1)
+	if ids, open := drain(late); !reflect.DeepEqual(ids, []uint64{3}) || open {
+		t.Fatalf("subscriber after Close: %v, open %t", ids, open)
+	}
+	b.Unsubscribe(late)
+}
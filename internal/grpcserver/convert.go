@@ -0,0 +1,133 @@
+package grpcserver
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pb"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// timestampOrNil преобразует время в Timestamp, пропуская нулевые значения.
+func timestampOrNil(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// timePtr преобразует Timestamp в указатель на время (nil, если значение не задано).
+func timePtr(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+// taskStatuses сопоставляет статусы задачи модели и protobuf. Статусы перечисляются явно, чтобы
+// статус модели, которого нет в api/proto, не уходил клиентам неизвестным значением перечисления.
+var taskStatuses = map[models.TaskStatus]pb.TaskStatus{
+	models.NotStarted: pb.TaskStatus_TASK_STATUS_NOT_STARTED,
+	models.InProgress: pb.TaskStatus_TASK_STATUS_IN_PROGRESS,
+	models.Completed:  pb.TaskStatus_TASK_STATUS_COMPLETED,
+	models.Canceled:   pb.TaskStatus_TASK_STATUS_CANCELED,
+	models.Expired:    pb.TaskStatus_TASK_STATUS_EXPIRED,
+}
+
+// toPBTaskStatus преобразует статус задачи; неизвестный статус передается как TASK_STATUS_UNSPECIFIED.
+func toPBTaskStatus(status models.TaskStatus) pb.TaskStatus {
+	return taskStatuses[status]
+}
+
+// fromPBTaskStatus преобразует статус задачи из запроса; TASK_STATUS_UNSPECIFIED означает, что статус не задан.
+func fromPBTaskStatus(status pb.TaskStatus) (models.TaskStatus, error) {
+	if status == pb.TaskStatus_TASK_STATUS_UNSPECIFIED {
+		return 0, nil
+	}
+	for modelStatus, pbStatus := range taskStatuses {
+		if pbStatus == status {
+			return modelStatus, nil
+		}
+	}
+	return 0, errors.NewBadRequest(fmt.Sprintf("unknown task status %d", status), nil)
+}
+
+func toPBUser(user *models.User) *pb.User {
+	if user == nil {
+		return nil
+	}
+	return &pb.User{
+		Id:             user.ID,
+		Username:       user.Username,
+		Email:          user.Email,
+		Balance:        user.Balance,
+		Referrals:      int32(user.Referrals),
+		ReferralCode:   user.ReferralCode,
+		TasksCompleted: int32(user.TasksCompleted),
+		CreatedAt:      timestampOrNil(user.CreatedAt),
+		UpdatedAt:      timestampOrNil(user.UpdatedAt),
+		LastVisit:      timestampOrNil(user.LastVisit),
+		VisitCount:     int32(user.VisitCount),
+		Bio:            user.Bio,
+		TimeZone:       user.TimeZone,
+		Status:         pb.UserStatus(user.Status),
+	}
+}
+
+func toPBTopUser(user *models.TopUser) *pb.TopUser {
+	if user == nil {
+		return nil
+	}
+	return &pb.TopUser{
+		User: toPBUser(&user.User),
+		Rank: int32(user.Rank),
+	}
+}
+
+func toPBUserSummary(summary *models.UserSummary) *pb.UserSummary {
+	return &pb.UserSummary{
+		Id:             summary.ID,
+		Username:       summary.Username,
+		Email:          summary.Email,
+		Balance:        summary.Balance,
+		Referrals:      int32(summary.Referrals),
+		TasksCompleted: int32(summary.TasksCompleted),
+		CreatedAt:      timestampOrNil(summary.CreatedAt),
+	}
+}
+
+func toPBTask(task *models.Task) *pb.Task {
+	if task == nil {
+		return nil
+	}
+	pbTask := &pb.Task{
+		TaskId:      task.TaskID,
+		Title:       task.Title,
+		Description: task.Description,
+		CreatedAt:   timestampOrNil(task.CreatedAt),
+		UpdatedAt:   timestampOrNil(task.UpdatedAt),
+		Status:      toPBTaskStatus(task.Status),
+		AssigneeId:  task.AssigneeID,
+	}
+	if task.DueDate != nil {
+		pbTask.DueDate = timestamppb.New(*task.DueDate)
+	}
+	return pbTask
+}
+
+func toPBReferral(referral *models.Referral) *pb.Referral {
+	if referral == nil {
+		return nil
+	}
+	return &pb.Referral{
+		ReferralId: referral.ReferralID,
+		UserId:     referral.UserID,
+		Code:       referral.Code,
+		CreatedAt:  timestampOrNil(referral.CreatedAt),
+		UpdatedAt:  timestampOrNil(referral.UpdatedAt),
+	}
+}
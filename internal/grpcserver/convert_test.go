@@ -0,0 +1,35 @@
+package grpcserver
+
+import (
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pb"
+	"testing"
+)
+
+// Каждый статус модели передается собственным значением перечисления и читается обратно
+func TestTaskStatusMapping(t *testing.T) {
+	for status := models.NotStarted; status <= models.Expired; status++ {
+		pbStatus := toPBTaskStatus(status)
+		if pbStatus == pb.TaskStatus_TASK_STATUS_UNSPECIFIED {
+			t.Fatalf("%s has no protobuf value", status)
+		}
+		if _, known := pb.TaskStatus_name[int32(pbStatus)]; !known {
+			t.Fatalf("%s maps to undefined enum value %d", status, pbStatus)
+		}
+		back, err := fromPBTaskStatus(pbStatus)
+		if err != nil || back != status {
+			t.Fatalf("%s round trips to %s, %v", status, back, err)
+		}
+	}
+
+	if got := toPBTaskStatus(models.TaskStatus(42)); got != pb.TaskStatus_TASK_STATUS_UNSPECIFIED {
+		t.Fatalf("unknown status maps to %s", got)
+	}
+	if status, err := fromPBTaskStatus(pb.TaskStatus_TASK_STATUS_UNSPECIFIED); err != nil || status != 0 {
+		t.Fatalf("unspecified maps to %d, %v", status, err)
+	}
+	if _, err := fromPBTaskStatus(pb.TaskStatus(42)); !errors.IsErrorType(err, errors.BadRequest) {
+		t.Fatalf("unknown enum value: %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package grpcserver
+
+import (
+	"errors"
+	apperrors "github.com/ZnNr/user-reward-controller/internal/errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// statusCode сопоставляет типы ошибок приложения с кодами gRPC, аналогично errors.StatusCode для HTTP.
+var statusCode = map[apperrors.ErrorType]codes.Code{
+	apperrors.NotFound:        codes.NotFound,
+	apperrors.BadRequest:      codes.InvalidArgument,
+	apperrors.Internal:        codes.Internal,
+	apperrors.Validation:      codes.InvalidArgument,
+	apperrors.AlreadyExists:   codes.AlreadyExists,
+	apperrors.InvalidToken:    codes.Unauthenticated,
+	apperrors.Forbidden:       codes.PermissionDenied,
+	apperrors.Conflict:        codes.FailedPrecondition,
+	apperrors.TooManyRequests: codes.ResourceExhausted,
+}
+
+// toStatus преобразует ошибку слоя сервисов в gRPC статус.
+// Внутренние ошибки не раскрывают подробностей клиенту.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var appErr *apperrors.Error
+	if !errors.As(err, &appErr) {
+		return status.Error(codes.Internal, apperrors.ErrMsgInternal)
+	}
+
+	code, ok := statusCode[appErr.Type]
+	if !ok || code == codes.Internal {
+		return status.Error(codes.Internal, apperrors.ErrMsgInternal)
+	}
+	return status.Error(code, appErr.Error())
+}
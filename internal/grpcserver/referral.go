@@ -0,0 +1,74 @@
+package grpcserver
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
+	"github.com/ZnNr/user-reward-controller/internal/pb"
+	"github.com/ZnNr/user-reward-controller/internal/service"
+
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// ReferralServer реализует pb.ReferralServiceServer поверх service.ReferralService
+type ReferralServer struct {
+	pb.UnimplementedReferralServiceServer
+	service *service.ReferralService
+	logger  *zap.Logger
+}
+
+// NewReferralServer returns a new instance of ReferralServer
+func NewReferralServer(service *service.ReferralService, logger *zap.Logger) *ReferralServer {
+	return &ReferralServer{service: service, logger: logger}
+}
+
+func (s *ReferralServer) CreateReferral(_ context.Context, req *pb.CreateReferralRequest) (*pb.Referral, error) {
+	referral, err := s.service.CreateReferral(&models.CreateReferralRequest{UserID: req.GetUserId(), Code: req.GetCode()})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPBReferral(referral), nil
+}
+
+func (s *ReferralServer) GetReferral(_ context.Context, req *pb.GetReferralRequest) (*pb.Referral, error) {
+	referral, err := s.service.GetReferral(req.GetReferralId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPBReferral(referral), nil
+}
+
+func (s *ReferralServer) ListReferrals(_ context.Context, req *pb.ListReferralsRequest) (*pb.ListReferralsResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, toStatus(errors.NewBadRequest("User ID is required", nil))
+	}
+
+	// Запрос gRPC не поддерживает курсоры, поэтому возвращаются все рефералы
+	referrals, err := s.service.GetReferralsByUserID(req.GetUserId(), pagination.Page{})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	response := &pb.ListReferralsResponse{Referrals: make([]*pb.Referral, 0, len(referrals.Referrals))}
+	for i := range referrals.Referrals {
+		response.Referrals = append(response.Referrals, toPBReferral(&referrals.Referrals[i]))
+	}
+	return response, nil
+}
+
+func (s *ReferralServer) UpdateReferral(_ context.Context, req *pb.UpdateReferralRequest) (*pb.Referral, error) {
+	referral, err := s.service.UpdateReferral(req.GetReferralId(), &models.UpdateReferralRequest{ReferralID: req.GetReferralId(), Code: req.GetCode()})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPBReferral(referral), nil
+}
+
+func (s *ReferralServer) DeleteReferral(_ context.Context, req *pb.DeleteReferralRequest) (*emptypb.Empty, error) {
+	if err := s.service.DeleteReferral(req.GetReferralId()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
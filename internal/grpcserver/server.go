@@ -0,0 +1,34 @@
+package grpcserver
+
+import (
+	"github.com/ZnNr/user-reward-controller/internal/pb"
+	"github.com/ZnNr/user-reward-controller/internal/service"
+	"github.com/ZnNr/user-reward-controller/internal/service/auth"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// NewServer создает gRPC сервер с интерсепторами авторизации и регистрирует на нем
+// реализации UserService, TaskService и ReferralService поверх общего слоя сервисов.
+func NewServer(
+	userSvc *service.UserService,
+	taskSvc *service.TaskService,
+	referralSvc *service.ReferralService,
+	logger *zap.Logger,
+) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(auth.UnaryAuthInterceptor),
+		grpc.ChainStreamInterceptor(auth.StreamAuthInterceptor),
+	)
+
+	pb.RegisterUserServiceServer(server, NewUserServer(userSvc, logger))
+	pb.RegisterTaskServiceServer(server, NewTaskServer(taskSvc, logger))
+	pb.RegisterReferralServiceServer(server, NewReferralServer(referralSvc, logger))
+
+	// Reflection позволяет использовать grpcurl и подобные инструменты без .proto файлов
+	reflection.Register(server)
+
+	return server
+}
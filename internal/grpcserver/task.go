@@ -0,0 +1,157 @@
+package grpcserver
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pb"
+	"github.com/ZnNr/user-reward-controller/internal/service"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// TaskServer реализует pb.TaskServiceServer поверх service.TaskService
+type TaskServer struct {
+	pb.UnimplementedTaskServiceServer
+	service *service.TaskService
+	logger  *zap.Logger
+}
+
+// NewTaskServer returns a new instance of TaskServer
+func NewTaskServer(service *service.TaskService, logger *zap.Logger) *TaskServer {
+	return &TaskServer{service: service, logger: logger}
+}
+
+func (s *TaskServer) ListTasks(ctx context.Context, req *pb.ListTasksRequest) (*pb.ListTasksResponse, error) {
+	status, err := fromPBTaskStatus(req.GetStatus())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	filter := &models.TaskFilter{
+		Title:         req.GetTitle(),
+		Description:   req.GetDescription(),
+		Status:        status,
+		AssigneeID:    req.GetAssigneeId(),
+		CreatedAfter:  timePtr(req.GetCreatedAfter()),
+		CreatedBefore: timePtr(req.GetCreatedBefore()),
+		DueAfter:      timePtr(req.GetDueAfter()),
+		DueBefore:     timePtr(req.GetDueBefore()),
+		Page:          int(req.GetPage()),
+		PageSize:      int(req.GetPageSize()),
+	}
+
+	response, err := s.service.GetTasks(ctx, filter)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	tasks := make([]*pb.Task, 0, len(response.Tasks))
+	for i := range response.Tasks {
+		tasks = append(tasks, toPBTask(&response.Tasks[i]))
+	}
+	return &pb.ListTasksResponse{
+		Tasks:      tasks,
+		Page:       int32(response.Page),
+		TotalPages: int32(response.TotalPages),
+		TotalItems: int32(response.TotalItems),
+		PageSize:   int32(response.PageSize),
+	}, nil
+}
+
+func (s *TaskServer) GetTask(ctx context.Context, req *pb.GetTaskRequest) (*pb.Task, error) {
+	task, err := s.service.GetTaskByID(ctx, req.GetTaskId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPBTask(task), nil
+}
+
+func (s *TaskServer) CreateTask(ctx context.Context, req *pb.CreateTaskRequest) (*pb.Task, error) {
+	status, err := fromPBTaskStatus(req.GetStatus())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	task, err := s.service.CreateTask(ctx, &models.CreateTaskRequest{
+		BaseTaskRequest: models.BaseTaskRequest{
+			Title:       req.GetTitle(),
+			Description: req.GetDescription(),
+			DueDate:     timePtr(req.GetDueDate()),
+			Status:      status,
+			AssigneeID:  req.AssigneeId,
+		},
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPBTask(task), nil
+}
+
+func (s *TaskServer) UpdateTask(ctx context.Context, req *pb.UpdateTaskRequest) (*pb.Task, error) {
+	taskID, err := uuid.Parse(req.GetTaskId())
+	if err != nil {
+		return nil, toStatus(errors.NewBadRequest("invalid task ID", err))
+	}
+	status, err := fromPBTaskStatus(req.GetStatus())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	task, err := s.service.UpdateTask(ctx, taskID, &models.UpdateTaskRequest{
+		TaskID: req.GetTaskId(),
+		BaseTaskRequest: models.BaseTaskRequest{
+			Title:       req.GetTitle(),
+			Description: req.GetDescription(),
+			DueDate:     timePtr(req.GetDueDate()),
+			Status:      status,
+			AssigneeID:  req.AssigneeId,
+		},
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPBTask(task), nil
+}
+
+func (s *TaskServer) UpdateTaskStatus(ctx context.Context, req *pb.UpdateTaskStatusRequest) (*pb.Task, error) {
+	userID, err := uuid.Parse(req.GetUserId())
+	if err != nil {
+		return nil, toStatus(errors.NewBadRequest("invalid user ID", err))
+	}
+	status, err := fromPBTaskStatus(req.GetStatus())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	task, err := s.service.UpdateTaskStatus(ctx, req.GetTaskId(), status, userID)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPBTask(task), nil
+}
+
+func (s *TaskServer) DeleteTask(ctx context.Context, req *pb.DeleteTaskRequest) (*emptypb.Empty, error) {
+	if err := s.service.DeleteTask(ctx, req.GetTaskId()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *TaskServer) GetDescription(ctx context.Context, req *pb.GetDescriptionRequest) (*pb.DescriptionResponse, error) {
+	taskID, err := uuid.Parse(req.GetTaskId())
+	if err != nil {
+		return nil, toStatus(errors.NewBadRequest("invalid task ID", err))
+	}
+
+	response, err := s.service.GetDescription(ctx, taskID, int(req.GetPage()), int(req.GetPageSize()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.DescriptionResponse{
+		Description: response.Description,
+		CurrentPage: int32(response.CurrentPage),
+		TotalPages:  int32(response.TotalPages),
+		PageSize:    int32(response.PageSize),
+	}, nil
+}
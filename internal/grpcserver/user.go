@@ -0,0 +1,158 @@
+package grpcserver
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pb"
+	"github.com/ZnNr/user-reward-controller/internal/service"
+
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// UserServer реализует pb.UserServiceServer поверх service.UserService
+type UserServer struct {
+	pb.UnimplementedUserServiceServer
+	service *service.UserService
+	logger  *zap.Logger
+}
+
+// NewUserServer returns a new instance of UserServer
+func NewUserServer(service *service.UserService, logger *zap.Logger) *UserServer {
+	return &UserServer{service: service, logger: logger}
+}
+
+func (s *UserServer) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	// Запрос gRPC не поддерживает курсоры, поэтому возвращаются все пользователи
+	filter := &models.UserFilter{
+		Username: req.GetUsername(),
+		Status:   models.UserStatus(req.GetStatus()),
+	}
+
+	response, err := s.service.GetUsers(ctx, filter)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	users := make([]*pb.User, 0, len(response.Users))
+	for _, user := range response.Users {
+		users = append(users, toPBUser(user))
+	}
+	return &pb.ListUsersResponse{Users: users, Count: int32(response.Count)}, nil
+}
+
+func (s *UserServer) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.User, error) {
+	user, err := s.service.GetUserByID(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPBUser(user), nil
+}
+
+func (s *UserServer) GetUserByEmail(ctx context.Context, req *pb.GetUserByEmailRequest) (*pb.User, error) {
+	user, err := s.service.GetUserByEmail(ctx, req.GetEmail())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPBUser(user), nil
+}
+
+func (s *UserServer) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.User, error) {
+	user, err := s.service.CreateUser(ctx, &models.CreateUserRequest{
+		Username:     req.GetUsername(),
+		Email:        req.GetEmail(),
+		ReferralCode: req.GetReferralCode(),
+		Bio:          req.GetBio(),
+		TimeZone:     req.GetTimeZone(),
+		Status:       models.UserStatus(req.GetStatus()),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPBUser(user), nil
+}
+
+func (s *UserServer) UpdateUser(ctx context.Context, req *pb.UpdateUserRequest) (*pb.User, error) {
+	update := &models.UpdateUserRequest{
+		UserID:       req.GetId(),
+		Username:     req.Username,
+		Email:        req.Email,
+		Balance:      req.Balance,
+		ReferralCode: req.ReferralCode,
+		Bio:          req.Bio,
+		TimeZone:     req.TimeZone,
+	}
+	if req.Status != nil {
+		status := models.UserStatus(req.GetStatus())
+		update.Status = &status
+	}
+
+	user, err := s.service.UpdateUser(ctx, update)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPBUser(user), nil
+}
+
+func (s *UserServer) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*emptypb.Empty, error) {
+	if err := s.service.DeleteUser(ctx, req.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *UserServer) UpdateBalance(ctx context.Context, req *pb.UpdateBalanceRequest) (*emptypb.Empty, error) {
+	if err := s.service.UpdateBalance(ctx, req.GetId(), req.GetAmount()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *UserServer) GetUserFullInfo(ctx context.Context, req *pb.GetUserRequest) (*pb.UserFullInfo, error) {
+	info, err := s.service.GetUserFullInfo(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &pb.UserFullInfo{Info: info}, nil
+}
+
+func (s *UserServer) GetUserSummary(ctx context.Context, req *pb.GetUserRequest) (*pb.UserSummary, error) {
+	summary, err := s.service.GetUserSummary(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPBUserSummary(summary), nil
+}
+
+func (s *UserServer) InviteUser(ctx context.Context, req *pb.InviteUserRequest) (*emptypb.Empty, error) {
+	if err := s.service.InviteUser(ctx, req.GetInviterId(), req.GetInviteeEmail()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *UserServer) GetLeaderByBalance(ctx context.Context, _ *emptypb.Empty) (*pb.TopUser, error) {
+	leader, err := s.service.GetLeaderByBalance(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toPBTopUser(leader), nil
+}
+
+func (s *UserServer) GetTopUsers(ctx context.Context, req *pb.GetTopUsersRequest) (*pb.TopUsersResponse, error) {
+	limit := int(req.GetLimit())
+	if limit == 0 {
+		limit = 10 // Значение по умолчанию, как в HTTP API
+	}
+
+	topUsers, err := s.service.GetTopUsers(ctx, limit, int(req.GetOffset()))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	users := make([]*pb.TopUser, 0, len(topUsers.Users))
+	for i := range topUsers.Users {
+		users = append(users, toPBTopUser(&topUsers.Users[i]))
+	}
+	return &pb.TopUsersResponse{Users: users, Count: int32(topUsers.Count)}, nil
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/service"
+
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// AuditHandler отдает записи журнала аудита
+type AuditHandler struct {
+	BaseHandler
+	service *service.AuditService
+}
+
+// NewAuditHandler returns a new instance of AuditHandler
+func NewAuditHandler(service *service.AuditService, logger *zap.Logger) *AuditHandler {
+	return &AuditHandler{
+		BaseHandler: BaseHandler{logger: logger},
+		service:     service,
+	}
+}
+
+// GetEntries handles GET /admin/audit?actor=&target_id=&from=&to=&limit=
+func (h *AuditHandler) GetEntries(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling GetAuditEntries request")
+
+	filter := &models.AuditFilter{
+		Actor:    r.URL.Query().Get("actor"),
+		TargetID: r.URL.Query().Get("target_id"),
+	}
+
+	var err error
+	if filter.From, err = getQueryParamDate(r, "from"); err != nil {
+		h.handleError(w, r, errors.NewBadRequest("invalid from, expected RFC3339", err))
+		return
+	}
+	if filter.To, err = getQueryParamDate(r, "to"); err != nil {
+		h.handleError(w, r, errors.NewBadRequest("invalid to, expected RFC3339", err))
+		return
+	}
+	if filter.Limit, err = getQueryParamInt(r, "limit", 0); err != nil {
+		h.handleError(w, r, errors.NewBadRequest("invalid limit", err))
+		return
+	}
+
+	entries, err := h.service.GetEntries(r.Context(), filter)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, entries)
+}
+
+// Verify handles GET /admin/audit/verify
+func (h *AuditHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling VerifyAuditLog request")
+
+	result, err := h.service.Verify(r.Context())
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, result)
+}
@@ -2,7 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
-	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/problem"
 	"go.uber.org/zap"
 	"net/http"
 )
@@ -12,24 +12,11 @@ type BaseHandler struct {
 	logger *zap.Logger
 }
 
-// handleError handles error responses
-func (h *BaseHandler) handleError(w http.ResponseWriter, err error) {
+// handleError handles error responses: every error is written as application/problem+json,
+// field validation errors keep the v1 status 400
+func (h *BaseHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
 	h.logger.Error("Handling error", zap.Error(err))
-	var status int
-	var errorResponse map[string]string
-
-	if errors.IsNotFound(err) {
-		status = http.StatusNotFound
-		errorResponse = map[string]string{"error": err.Error()}
-	} else if errors.IsBadRequest(err) {
-		status = http.StatusBadRequest
-		errorResponse = map[string]string{"error": err.Error()}
-	} else {
-		status = http.StatusInternalServerError
-		errorResponse = map[string]string{"error": "internal server error"}
-	}
-
-	h.respondWithJSON(w, status, errorResponse)
+	problem.Write(w, r, problem.ForV1(problem.FromError(err)))
 }
 
 // respondWithJSON writes a JSON response to the ResponseWriter
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/service"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// CampaignHandler обрабатывает запросы к кампаниям
+type CampaignHandler struct {
+	BaseHandler
+	service *service.CampaignService
+}
+
+// NewCampaignHandler returns a new instance of CampaignHandler
+func NewCampaignHandler(service *service.CampaignService, logger *zap.Logger) *CampaignHandler {
+	return &CampaignHandler{
+		BaseHandler: BaseHandler{logger: logger},
+		service:     service,
+	}
+}
+
+// CreateCampaign handles POST /campaigns
+func (h *CampaignHandler) CreateCampaign(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling CreateCampaign request")
+
+	var req models.CampaignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, errors.NewBadRequest("Invalid request body", err))
+		return
+	}
+
+	campaign, err := h.service.CreateCampaign(r.Context(), &req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, campaign)
+}
+
+// UpdateCampaign handles PUT /campaigns/{campaign_id}
+func (h *CampaignHandler) UpdateCampaign(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling UpdateCampaign request")
+
+	var req models.CampaignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, errors.NewBadRequest("Invalid request body", err))
+		return
+	}
+
+	campaign, err := h.service.UpdateCampaign(r.Context(), mux.Vars(r)["campaign_id"], &req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, campaign)
+}
+
+// GetCampaign handles GET /campaigns/{campaign_id}
+func (h *CampaignHandler) GetCampaign(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling GetCampaign request")
+
+	campaign, err := h.service.GetCampaign(r.Context(), mux.Vars(r)["campaign_id"])
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, campaign)
+}
+
+// GetCampaigns handles GET /campaigns?status=&limit=&offset=
+func (h *CampaignHandler) GetCampaigns(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling GetCampaigns request")
+
+	filter := &models.CampaignFilter{Status: models.CampaignStatus(r.URL.Query().Get("status"))}
+
+	var err error
+	if filter.Limit, err = getQueryParamInt(r, "limit", 0); err != nil {
+		h.handleError(w, r, errors.NewBadRequest("invalid limit", err))
+		return
+	}
+	if filter.Offset, err = getQueryParamInt(r, "offset", 0); err != nil {
+		h.handleError(w, r, errors.NewBadRequest("invalid offset", err))
+		return
+	}
+
+	campaigns, err := h.service.GetCampaigns(r.Context(), filter)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, campaigns)
+}
+
+// GetCampaignStats handles GET /campaigns/{campaign_id}/stats
+func (h *CampaignHandler) GetCampaignStats(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling GetCampaignStats request")
+
+	stats, err := h.service.GetCampaignStats(r.Context(), mux.Vars(r)["campaign_id"])
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, stats)
+}
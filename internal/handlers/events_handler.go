@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/events"
+	"github.com/ZnNr/user-reward-controller/internal/service/auth"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EventsHandler отдает поток Server-Sent Events с обновлениями таблицы лидеров и балансов
+type EventsHandler struct {
+	BaseHandler
+	broker    *events.Broker
+	heartbeat time.Duration
+}
+
+// NewEventsHandler returns a new instance of EventsHandler
+func NewEventsHandler(broker *events.Broker, heartbeat time.Duration, logger *zap.Logger) *EventsHandler {
+	return &EventsHandler{
+		BaseHandler: BaseHandler{logger: logger},
+		broker:      broker,
+		heartbeat:   heartbeat,
+	}
+}
+
+// Stream handles GET /events/stream?topics=leaderboard,user:{id}.
+// Топик user:{id} доступен только самому пользователю и администратору.
+func (h *EventsHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling Stream request")
+
+	topics, err := parseTopics(r.Context(), r.URL.Query().Get("topics"))
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	lastEventID, err := parseLastEventID(r)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	// Поток живет дольше WriteTimeout сервера, поэтому снимаем дедлайн записи для этого соединения
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		h.logger.Warn("Failed to reset write deadline for event stream", zap.Error(err))
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	sub := h.broker.Subscribe(topics, lastEventID)
+	defer h.broker.Unsubscribe(sub)
+
+	if _, err := fmt.Fprint(w, ": connected\n\n"); err != nil {
+		return
+	}
+	if err := rc.Flush(); err != nil {
+		h.logger.Error("Streaming is not supported by the response writer", zap.Error(err))
+		return
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				// Брокер остановлен или соединение отключено как слишком медленное
+				return
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent записывает событие в формате text/event-stream.
+func writeEvent(w http.ResponseWriter, event events.Event) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+	return err
+}
+
+// parseTopics разбирает список топиков и проверяет, что каждый из них поддерживается,
+// а на топики пользователей подписывается сам пользователь или администратор.
+func parseTopics(ctx context.Context, raw string) ([]string, error) {
+	var topics []string
+	for _, topic := range strings.Split(raw, ",") {
+		topic = strings.TrimSpace(topic)
+		if topic == "" {
+			continue
+		}
+		if userID, ok := events.IsUserTopic(topic); ok {
+			if _, err := uuid.Parse(userID); err != nil {
+				return nil, errors.NewBadRequest("invalid user ID in topic "+topic, err)
+			}
+			if !auth.IsUserOrAdmin(ctx, userID) {
+				return nil, errors.NewForbidden("only the user or an administrator can subscribe to topic "+topic, nil)
+			}
+		} else if topic != events.TopicLeaderboard {
+			return nil, errors.NewBadRequest("unknown topic "+topic, nil)
+		}
+		topics = append(topics, topic)
+	}
+	if len(topics) == 0 {
+		return nil, errors.NewBadRequest("at least one topic is required", nil)
+	}
+	return topics, nil
+}
+
+// parseLastEventID читает ID последнего полученного события из заголовка Last-Event-ID
+// или параметра last_event_id (EventSource не позволяет задать заголовок при первом подключении).
+func parseLastEventID(r *http.Request) (uint64, error) {
+	value := r.Header.Get("Last-Event-ID")
+	if value == "" {
+		value = r.URL.Query().Get("last_event_id")
+	}
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, errors.NewBadRequest("invalid Last-Event-ID", err)
+	}
+	return id, nil
+}
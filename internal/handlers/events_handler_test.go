@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/service/auth"
+	"testing"
+)
+
+func TestParseTopicsAccess(t *testing.T) {
+	const erin, frank = "9a0e6a5e-2b9f-4c1d-8e2a-7d3b5c4f1e09", "0b6f2c1d-7e4a-4f3b-9c8d-1a2b3c4d5e6f"
+	user := auth.WithPrincipal(context.Background(), auth.Principal{Subject: erin})
+	admin := auth.WithPrincipal(context.Background(), auth.Principal{Subject: "root", Role: auth.RoleAdmin})
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		topics string
+		want   errors.ErrorType
+	}{
+		{"anonymous leaderboard", context.Background(), "leaderboard", ""},
+		{"anonymous user topic", context.Background(), "user:" + erin, errors.Forbidden},
+		{"own topic", user, "leaderboard, user:" + erin, ""},
+		{"another user's topic", user, "user:" + erin + ",user:" + frank, errors.Forbidden},
+		{"admin", admin, "user:" + erin + ",user:" + frank, ""},
+		{"invalid user ID", admin, "user:erin", errors.BadRequest},
+		{"unknown topic", admin, "balances", errors.BadRequest},
+		{"no topics", admin, " , ", errors.BadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTopics(tt.ctx, tt.topics)
+			if tt.want == "" && err != nil || tt.want != "" && !errors.IsErrorType(err, tt.want) {
+				t.Fatalf("got %v, want %s", err, tt.want)
+			}
+		})
+	}
+}
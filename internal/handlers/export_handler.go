@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/service"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+)
+
+// exportTimeout - максимальная длительность одной выгрузки
+const exportTimeout = 30 * time.Minute
+
+// ExportHandler отдает выгрузки пользователей, задач и журнала баланса
+type ExportHandler struct {
+	BaseHandler
+	service *service.ExportService
+}
+
+// NewExportHandler returns a new instance of ExportHandler
+func NewExportHandler(service *service.ExportService, logger *zap.Logger) *ExportHandler {
+	return &ExportHandler{
+		BaseHandler: BaseHandler{logger: logger},
+		service:     service,
+	}
+}
+
+// Export handles GET /admin/export/{kind}?format=csv|ndjson&from=&to=&status=&user_id=
+func (h *ExportHandler) Export(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling Export request")
+
+	kind := models.ExportKind(mux.Vars(r)["kind"])
+	format := models.ExportFormat(r.URL.Query().Get("format"))
+	if format == "" {
+		format = models.ExportFormatCSV
+	}
+
+	filter, err := h.exportFilter(r, kind)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	if err := service.ValidateExport(r.Context(), kind, format, filter); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	// Выгрузка может длиться дольше WriteTimeout сервера
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(exportTimeout)); err != nil {
+		h.logger.Warn("Failed to extend write deadline for export", zap.Error(err))
+	}
+
+	contentType, extension := "text/csv; charset=utf-8", "csv"
+	if format == models.ExportFormatNDJSON {
+		contentType, extension = "application/x-ndjson", "ndjson"
+	}
+	filename := fmt.Sprintf("%s-%s.%s", kind, time.Now().UTC().Format("20060102T150405Z"), extension)
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("Cache-Control", "no-store")
+
+	out := &trackingWriter{ResponseWriter: w}
+	if err := h.service.Export(r.Context(), kind, format, filter, out); err != nil {
+		if !out.written {
+			w.Header().Del("Content-Disposition")
+			h.handleError(w, r, err)
+			return
+		}
+		// Ответ уже частично отправлен: статус изменить нельзя, клиент получит обрезанный файл
+		h.logger.Error("Export interrupted after response started", zap.String("kind", string(kind)), zap.Error(err))
+	}
+}
+
+// exportFilter читает параметры фильтрации выгрузки из запроса.
+func (h *ExportHandler) exportFilter(r *http.Request, kind models.ExportKind) (*models.ExportFilter, error) {
+	filter := &models.ExportFilter{UserID: r.URL.Query().Get("user_id")}
+
+	var err error
+	if filter.From, err = getQueryParamDate(r, "from"); err != nil {
+		return nil, errors.NewBadRequest("Invalid from format", err)
+	}
+	if filter.To, err = getQueryParamDate(r, "to"); err != nil {
+		return nil, errors.NewBadRequest("Invalid to format", err)
+	}
+	if raw := r.URL.Query().Get("status"); raw != "" {
+		if filter.Status, err = service.ParseExportStatus(kind, raw); err != nil {
+			return nil, err
+		}
+	}
+	return filter, nil
+}
+
+// trackingWriter запоминает, была ли уже начата запись тела ответа
+type trackingWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (t *trackingWriter) Write(b []byte) (int, error) {
+	t.written = true
+	return t.ResponseWriter.Write(b)
+}
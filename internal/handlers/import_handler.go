@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/service"
+
+	"go.uber.org/zap"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// importTimeout - максимальная длительность обработки одного запроса импорта
+const importTimeout = 10 * time.Minute
+
+// ImportHandler обрабатывает массовый импорт пользователей и задач
+type ImportHandler struct {
+	BaseHandler
+	service *service.ImportService
+}
+
+// NewImportHandler returns a new instance of ImportHandler
+func NewImportHandler(service *service.ImportService, logger *zap.Logger) *ImportHandler {
+	return &ImportHandler{
+		BaseHandler: BaseHandler{logger: logger},
+		service:     service,
+	}
+}
+
+// ImportUsers handles POST /admin/import/users
+func (h *ImportHandler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling ImportUsers request")
+
+	format, dryRun, err := h.importParams(w, r)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	report, err := h.service.ImportUsers(r.Context(), r.Body, format, dryRun)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, report)
+}
+
+// ImportTasks handles POST /admin/import/tasks
+func (h *ImportHandler) ImportTasks(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling ImportTasks request")
+
+	format, dryRun, err := h.importParams(w, r)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	report, err := h.service.ImportTasks(r.Context(), r.Body, format, dryRun)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, report)
+}
+
+// importParams определяет формат потока и режим dry-run и продлевает дедлайны соединения,
+// так как большие файлы не успевают обработаться за стандартные таймауты сервера.
+func (h *ImportHandler) importParams(w http.ResponseWriter, r *http.Request) (models.ImportFormat, bool, error) {
+	format, err := importFormat(r)
+	if err != nil {
+		return "", false, err
+	}
+
+	dryRun := false
+	if raw := r.URL.Query().Get("dry_run"); raw != "" {
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			return "", false, errors.NewBadRequest("Invalid dry_run value", err)
+		}
+	}
+
+	deadline := time.Now().Add(importTimeout)
+	rc := http.NewResponseController(w)
+	if err := rc.SetReadDeadline(deadline); err != nil {
+		h.logger.Warn("Failed to extend read deadline for import", zap.Error(err))
+	}
+	if err := rc.SetWriteDeadline(deadline); err != nil {
+		h.logger.Warn("Failed to extend write deadline for import", zap.Error(err))
+	}
+
+	return format, dryRun, nil
+}
+
+// importFormat выбирает формат по параметру format или по заголовку Content-Type.
+func importFormat(r *http.Request) (models.ImportFormat, error) {
+	switch r.URL.Query().Get("format") {
+	case "csv":
+		return models.ImportFormatCSV, nil
+	case "jsonl", "ndjson":
+		return models.ImportFormatJSONL, nil
+	case "":
+	default:
+		return "", errors.NewBadRequest("format must be csv or jsonl", nil)
+	}
+
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return "", errors.NewBadRequest("Content-Type must be text/csv or application/x-ndjson", err)
+	}
+	switch mediaType {
+	case "text/csv", "application/csv":
+		return models.ImportFormatCSV, nil
+	case "application/x-ndjson", "application/jsonl", "application/x-jsonlines", "application/jsonlines":
+		return models.ImportFormatJSONL, nil
+	default:
+		return "", errors.NewBadRequest("Content-Type must be text/csv or application/x-ndjson", nil)
+	}
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/service"
+
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// JobHandler отдает историю запусков фоновых заданий
+type JobHandler struct {
+	BaseHandler
+	service *service.JobService
+}
+
+// NewJobHandler returns a new instance of JobHandler
+func NewJobHandler(service *service.JobService, logger *zap.Logger) *JobHandler {
+	return &JobHandler{
+		BaseHandler: BaseHandler{logger: logger},
+		service:     service,
+	}
+}
+
+// GetRuns handles GET /admin/jobs/runs?job=&status=&limit=
+func (h *JobHandler) GetRuns(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling GetJobRuns request")
+
+	filter := &models.JobRunFilter{
+		JobName: r.URL.Query().Get("job"),
+		Status:  models.JobRunStatus(r.URL.Query().Get("status")),
+	}
+
+	var err error
+	if filter.Limit, err = getQueryParamInt(r, "limit", 0); err != nil {
+		h.handleError(w, r, errors.NewBadRequest("invalid limit", err))
+		return
+	}
+
+	runs, err := h.service.GetRuns(r.Context(), filter)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, runs)
+}
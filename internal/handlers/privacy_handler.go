@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/service"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// PrivacyHandler обрабатывает запросы субъектов персональных данных
+type PrivacyHandler struct {
+	BaseHandler
+	service *service.PrivacyService
+}
+
+// NewPrivacyHandler returns a new instance of PrivacyHandler
+func NewPrivacyHandler(service *service.PrivacyService, logger *zap.Logger) *PrivacyHandler {
+	return &PrivacyHandler{
+		BaseHandler: BaseHandler{logger: logger},
+		service:     service,
+	}
+}
+
+// ExportUserData handles GET /users/{user_id}/data-export?format=json|zip
+func (h *PrivacyHandler) ExportUserData(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling ExportUserData request")
+
+	id := mux.Vars(r)["user_id"]
+	format := models.DataExportFormat(r.URL.Query().Get("format"))
+	if format == "" {
+		format = models.DataExportJSON
+	}
+	if format != models.DataExportJSON && format != models.DataExportZIP {
+		h.handleError(w, r, errors.NewBadRequest("format must be json or zip", nil))
+		return
+	}
+
+	data, err := h.service.ExportUserData(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if format == models.DataExportJSON {
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "user-"+id+".json"))
+		h.respondWithJSON(w, http.StatusOK, data)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "user-"+id+".zip"))
+	w.WriteHeader(http.StatusOK)
+	if err := service.WriteDataExportZip(w, data); err != nil {
+		// Заголовки уже отправлены, поэтому ошибку можно только записать в лог
+		h.logger.Error("Failed to write data export archive", zap.String("userID", id), zap.Error(err))
+	}
+}
+
+// AnonymizeUser handles POST /users/{user_id}/anonymize
+func (h *PrivacyHandler) AnonymizeUser(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling AnonymizeUser request")
+
+	user, err := h.service.AnonymizeUser(r.Context(), mux.Vars(r)["user_id"])
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, user)
+}
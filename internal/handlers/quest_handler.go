@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/service"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// QuestHandler обрабатывает запросы к квестам
+type QuestHandler struct {
+	BaseHandler
+	service *service.QuestService
+}
+
+// NewQuestHandler returns a new instance of QuestHandler
+func NewQuestHandler(service *service.QuestService, logger *zap.Logger) *QuestHandler {
+	return &QuestHandler{
+		BaseHandler: BaseHandler{logger: logger},
+		service:     service,
+	}
+}
+
+// CreateQuest handles POST /quests
+func (h *QuestHandler) CreateQuest(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling CreateQuest request")
+
+	var req models.QuestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, errors.NewBadRequest("Invalid request body", err))
+		return
+	}
+
+	quest, err := h.service.CreateQuest(r.Context(), &req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, quest)
+}
+
+// GetQuest handles GET /quests/{quest_id}
+func (h *QuestHandler) GetQuest(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling GetQuest request")
+
+	quest, err := h.service.GetQuest(r.Context(), mux.Vars(r)["quest_id"])
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, quest)
+}
+
+// GetQuests handles GET /quests?limit=&offset=
+func (h *QuestHandler) GetQuests(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling GetQuests request")
+
+	limit, err := getQueryParamInt(r, "limit", 0)
+	if err != nil {
+		h.handleError(w, r, errors.NewBadRequest("invalid limit", err))
+		return
+	}
+	offset, err := getQueryParamInt(r, "offset", 0)
+	if err != nil {
+		h.handleError(w, r, errors.NewBadRequest("invalid offset", err))
+		return
+	}
+
+	quests, err := h.service.GetQuests(r.Context(), limit, offset)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, quests)
+}
+
+// GetUserQuestProgress handles GET /users/{user_id}/quests/{quest_id}
+func (h *QuestHandler) GetUserQuestProgress(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling GetUserQuestProgress request")
+
+	vars := mux.Vars(r)
+	progress, err := h.service.GetUserQuestProgress(r.Context(), vars["user_id"], vars["quest_id"])
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, progress)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"github.com/ZnNr/user-reward-controller/internal/errors"
 	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
 	"github.com/ZnNr/user-reward-controller/internal/service"
 
 	"github.com/google/uuid"
@@ -32,13 +33,13 @@ func (h *ReferralHandler) CreateReferral(w http.ResponseWriter, r *http.Request)
 
 	var req models.CreateReferralRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.handleError(w, errors.NewBadRequest("Invalid request body", err))
+		h.handleError(w, r, errors.NewBadRequest("Invalid request body", err))
 		return
 	}
 
-	referral, err := h.service.CreateReferral(req.UserID, req.Code)
+	referral, err := h.service.CreateReferral(&req)
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
@@ -53,13 +54,13 @@ func (h *ReferralHandler) GetReferral(w http.ResponseWriter, r *http.Request) {
 	idStr := vars["referral_id"]
 	referralID, err := uuid.Parse(idStr)
 	if err != nil {
-		h.handleError(w, errors.NewBadRequest("Invalid referral ID", err))
+		h.handleError(w, r, errors.NewBadRequest("Invalid referral ID", err))
 		return
 	}
 
 	referral, err := h.service.GetReferral(referralID.String())
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
@@ -74,19 +75,19 @@ func (h *ReferralHandler) UpdateReferral(w http.ResponseWriter, r *http.Request)
 	idStr := vars["referral_id"]
 	referralID, err := uuid.Parse(idStr)
 	if err != nil {
-		h.handleError(w, errors.NewBadRequest("Invalid referral ID", err))
+		h.handleError(w, r, errors.NewBadRequest("Invalid referral ID", err))
 		return
 	}
 
 	var req models.UpdateReferralRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.handleError(w, errors.NewBadRequest("Invalid request body", err))
+		h.handleError(w, r, errors.NewBadRequest("Invalid request body", err))
 		return
 	}
 
-	referral, err := h.service.UpdateReferral(referralID.String(), req.Code)
+	referral, err := h.service.UpdateReferral(referralID.String(), &req)
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
@@ -101,7 +102,7 @@ func (h *ReferralHandler) DeleteReferral(w http.ResponseWriter, r *http.Request)
 	idStr := vars["referral_id"]
 
 	if err := h.service.DeleteReferral(idStr); err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
@@ -114,15 +115,22 @@ func (h *ReferralHandler) GetReferralsByUserID(w http.ResponseWriter, r *http.Re
 
 	userID := r.URL.Query().Get("user_id")
 	if userID == "" {
-		h.handleError(w, errors.NewBadRequest("User ID is required", nil))
+		h.handleError(w, r, errors.NewBadRequest("User ID is required", nil))
 		return
 	}
 
-	referrals, err := h.service.GetReferralsByUserID(userID)
+	page, err := pagination.FromRequest(r)
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
-	h.respondWithJSON(w, http.StatusOK, referrals)
+	response, err := h.service.GetReferralsByUserID(userID, page)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	pagination.SetLinkHeader(w, r, response.Info)
+	h.respondWithJSON(w, http.StatusOK, response)
 }
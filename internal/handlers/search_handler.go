@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/service"
+
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// SearchHandler обрабатывает полнотекстовый поиск
+type SearchHandler struct {
+	BaseHandler
+	service *service.SearchService
+}
+
+// NewSearchHandler returns a new instance of SearchHandler
+func NewSearchHandler(service *service.SearchService, logger *zap.Logger) *SearchHandler {
+	return &SearchHandler{
+		BaseHandler: BaseHandler{logger: logger},
+		service:     service,
+	}
+}
+
+// Search handles GET /search?q=&type=all|tasks|users&limit=&offset=
+func (h *SearchHandler) Search(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling Search request")
+
+	filter := &models.SearchFilter{
+		Query: r.URL.Query().Get("q"),
+		Type:  models.SearchType(r.URL.Query().Get("type")),
+	}
+
+	var err error
+	if filter.Limit, err = getQueryParamInt(r, "limit", 0); err != nil {
+		h.handleError(w, r, errors.NewBadRequest("invalid limit", err))
+		return
+	}
+	if filter.Offset, err = getQueryParamInt(r, "offset", 0); err != nil {
+		h.handleError(w, r, errors.NewBadRequest("invalid offset", err))
+		return
+	}
+
+	response, err := h.service.Search(r.Context(), filter)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/service"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// SubmissionHandler обрабатывает заявки на выполнение задач и их модерацию
+type SubmissionHandler struct {
+	BaseHandler
+	service *service.SubmissionService
+}
+
+// NewSubmissionHandler returns a new instance of SubmissionHandler
+func NewSubmissionHandler(service *service.SubmissionService, logger *zap.Logger) *SubmissionHandler {
+	return &SubmissionHandler{
+		BaseHandler: BaseHandler{logger: logger},
+		service:     service,
+	}
+}
+
+// Submit handles POST /tasks/{task_id}/submissions
+func (h *SubmissionHandler) Submit(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling CreateSubmission request")
+
+	var req models.CreateSubmissionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, errors.NewBadRequest("Invalid request body", err))
+		return
+	}
+
+	submission, err := h.service.Submit(r.Context(), mux.Vars(r)["task_id"], &req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusCreated, submission)
+}
+
+// GetQueue handles GET /moderation/submissions?status=&task_id=&user_id=&limit=&offset=
+func (h *SubmissionHandler) GetQueue(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling GetSubmissions request")
+
+	filter := &models.SubmissionFilter{
+		Status: models.SubmissionStatus(r.URL.Query().Get("status")),
+		TaskID: r.URL.Query().Get("task_id"),
+		UserID: r.URL.Query().Get("user_id"),
+	}
+
+	var err error
+	if filter.Limit, err = getQueryParamInt(r, "limit", 0); err != nil {
+		h.handleError(w, r, errors.NewBadRequest("invalid limit", err))
+		return
+	}
+	if filter.Offset, err = getQueryParamInt(r, "offset", 0); err != nil {
+		h.handleError(w, r, errors.NewBadRequest("invalid offset", err))
+		return
+	}
+
+	submissions, err := h.service.GetQueue(r.Context(), filter)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, submissions)
+}
+
+// Approve handles POST /moderation/submissions/{submission_id}/approve
+func (h *SubmissionHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling ApproveSubmission request")
+	h.review(w, r, h.service.Approve)
+}
+
+// Reject handles POST /moderation/submissions/{submission_id}/reject
+func (h *SubmissionHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling RejectSubmission request")
+	h.review(w, r, h.service.Reject)
+}
+
+// review разбирает необязательное тело с причиной решения и применяет решение модератора.
+func (h *SubmissionHandler) review(w http.ResponseWriter, r *http.Request,
+	apply func(ctx context.Context, id string, req *models.ReviewSubmissionRequest) (*models.Submission, error)) {
+	var req models.ReviewSubmissionRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.handleError(w, r, errors.NewBadRequest("Invalid request body", err))
+			return
+		}
+	}
+
+	submission, err := apply(r.Context(), mux.Vars(r)["submission_id"], &req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, submission)
+}
@@ -5,13 +5,16 @@ import (
 	"fmt"
 	"github.com/ZnNr/user-reward-controller/internal/errors"
 	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
 	"github.com/ZnNr/user-reward-controller/internal/service"
+	"github.com/ZnNr/user-reward-controller/internal/service/auth"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -36,13 +39,13 @@ func (h *TaskHandler) GetTaskByID(w http.ResponseWriter, r *http.Request) {
 	idStr := vars["task_id"]
 	taskID, err := uuid.Parse(idStr)
 	if err != nil {
-		h.handleError(w, errors.NewBadRequest("Invalid task ID", err))
+		h.handleError(w, r, errors.NewBadRequest("Invalid task ID", err))
 		return
 	}
 
 	task, err := h.service.GetTaskByID(r.Context(), taskID.String())
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
@@ -57,38 +60,59 @@ func (h *TaskHandler) GetTasks(w http.ResponseWriter, r *http.Request) {
 		Title:       r.URL.Query().Get("title"),
 		Description: r.URL.Query().Get("description"),
 		AssigneeID:  r.URL.Query().Get("assignee_id"),
+		CampaignID:  r.URL.Query().Get("campaign_id"),
 	}
 
-	// Преобразование статуса
+	// Преобразование статусов: задача подходит, если ее статус - любой из перечисленных через запятую
 	statusStr := r.URL.Query().Get("status")
 	if statusStr != "" {
-		status, err := ParseTaskStatus(statusStr)
+		for _, name := range strings.Split(statusStr, ",") {
+			status, err := ParseTaskStatus(strings.TrimSpace(name))
+			if err != nil {
+				h.handleError(w, r, errors.NewBadRequest("Invalid status", err))
+				return
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	// Номер страницы (page) сохранен для совместимости; без него выборка идет по курсору
+	if r.URL.Query().Has("page") {
+		if err := h.getPaginationParams(r, filter); err != nil {
+			h.handleError(w, r, err)
+			return
+		}
+	} else {
+		page, err := pagination.FromRequest(r)
 		if err != nil {
-			h.handleError(w, err)
+			h.handleError(w, r, err)
 			return
 		}
-		filter.Status = status
+		filter.Pagination = &page
 	}
 
-	// Получение параметров страницы и размера страницы с учётом значений по умолчанию
-	if err := h.getPaginationParams(r, filter); err != nil {
-		h.handleError(w, err)
+	// Получение параметров даты
+	if err := h.getDateParams(r, filter); err != nil {
+		h.handleError(w, r, err)
 		return
 	}
 
-	// Получение параметров даты
-	if err := h.getDateParams(r, filter); err != nil {
-		h.handleError(w, err)
+	// Мягко удаленные задачи показываются только по явному запросу администратора
+	includeDeleted, err := getQueryParamBool(r, "include_deleted")
+	if err != nil {
+		h.handleError(w, r, err)
 		return
 	}
+	filter.IncludeDeleted = includeDeleted
 
 	// Получение задач из сервиса
 	response, err := h.service.GetTasks(r.Context(), filter)
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
+	pagination.SetLinkHeader(w, r, response.Info)
 	h.respondWithJSON(w, http.StatusOK, response)
 }
 
@@ -137,6 +161,18 @@ func getQueryParamInt(r *http.Request, param string, defaultValue int) (int, err
 	return strconv.Atoi(valueStr)
 }
 
+func getQueryParamBool(r *http.Request, param string) (bool, error) {
+	valueStr := r.URL.Query().Get(param)
+	if valueStr == "" {
+		return false, nil
+	}
+	value, err := strconv.ParseBool(valueStr)
+	if err != nil {
+		return false, errors.NewBadRequest(fmt.Sprintf("invalid %s value", param), err)
+	}
+	return value, nil
+}
+
 func getQueryParamDate(r *http.Request, param string) (*time.Time, error) {
 	valueStr := r.URL.Query().Get(param)
 	if valueStr == "" {
@@ -154,13 +190,13 @@ func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
 
 	var req models.CreateTaskRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.handleError(w, errors.NewBadRequest("Invalid request body", err))
+		h.handleError(w, r, errors.NewBadRequest("Invalid request body", err))
 		return
 	}
 
 	task, err := h.service.CreateTask(r.Context(), &req)
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
@@ -174,19 +210,19 @@ func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
 	idStr := vars["task_id"]
 	taskId, err := uuid.Parse(idStr)
 	if err != nil {
-		h.handleError(w, errors.NewBadRequest("Invalid task ID", err))
+		h.handleError(w, r, errors.NewBadRequest("Invalid task ID", err))
 		return
 	}
 
 	var req models.UpdateTaskRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.handleError(w, errors.NewBadRequest("Invalid request body", err))
+		h.handleError(w, r, errors.NewBadRequest("Invalid request body", err))
 		return
 	}
 
 	task, err := h.service.UpdateTask(r.Context(), taskId, &req)
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
@@ -202,26 +238,26 @@ func (h *TaskHandler) UpdateTaskStatus(w http.ResponseWriter, r *http.Request) {
 
 	taskId, err := uuid.Parse(idStr)
 	if err != nil {
-		h.handleError(w, errors.NewBadRequest("Invalid task ID", err))
+		h.handleError(w, r, errors.NewBadRequest("Invalid task ID", err))
 		return
 	}
 
 	userID, err := uuid.Parse(userIdStr) // Конвертируем строку userId в uuid
 	if err != nil {
-		h.handleError(w, errors.NewBadRequest("Invalid user ID", err))
+		h.handleError(w, r, errors.NewBadRequest("Invalid user ID", err))
 		return
 	}
 
 	newStatusStr := r.URL.Query().Get("status")
 	newStatus, err := strconv.Atoi(newStatusStr)
 	if err != nil {
-		h.handleError(w, errors.NewBadRequest("Invalid status value", err))
+		h.handleError(w, r, errors.NewBadRequest("Invalid status value", err))
 		return
 	}
 	taskStatus := models.TaskStatus(newStatus) // Преобразование int в TaskStatus
 	updatedTask, err := h.service.UpdateTaskStatus(r.Context(), taskId.String(), taskStatus, userID)
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
@@ -235,13 +271,168 @@ func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
 	idStr := vars["task_id"]
 
 	if err := h.service.DeleteTask(r.Context(), idStr); err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TaskHandler) RestoreTask(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling RestoreTask request")
+
+	vars := mux.Vars(r)
+	idStr := vars["task_id"]
+
+	task, err := h.service.RestoreTask(r.Context(), idStr)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, task)
+}
+
+func (h *TaskHandler) GetTaskHistory(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling GetTaskHistory request")
+
+	vars := mux.Vars(r)
+	idStr := vars["task_id"]
+
+	history, err := h.service.GetTaskHistory(r.Context(), idStr)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, history)
+}
+
+// dependenciesResponse - прямые зависимости задачи
+type dependenciesResponse struct {
+	TaskID    string   `json:"task_id"`
+	DependsOn []string `json:"depends_on"`
+}
+
+func (h *TaskHandler) AddDependencies(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling AddDependencies request")
+
+	vars := mux.Vars(r)
+	idStr := vars["task_id"]
+
+	var req models.DependencyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, errors.NewBadRequest("Invalid request body", err))
+		return
+	}
+
+	dependsOn, err := h.service.AddDependencies(r.Context(), idStr, &req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, dependenciesResponse{TaskID: idStr, DependsOn: dependsOn})
+}
+
+func (h *TaskHandler) GetDependencies(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling GetDependencies request")
+
+	vars := mux.Vars(r)
+	idStr := vars["task_id"]
+
+	dependsOn, err := h.service.GetDependencies(r.Context(), idStr)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, dependenciesResponse{TaskID: idStr, DependsOn: dependsOn})
+}
+
+func (h *TaskHandler) RemoveDependency(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling RemoveDependency request")
+
+	vars := mux.Vars(r)
+	if err := h.service.RemoveDependency(r.Context(), vars["task_id"], vars["depends_on_id"]); err != nil {
+		h.handleError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (h *TaskHandler) GetTaskAvailability(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling GetTaskAvailability request")
+
+	vars := mux.Vars(r)
+	availability, err := h.service.GetTaskAvailability(r.Context(), vars["user_id"], vars["task_id"])
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, availability)
+}
+
+// claimRequest - тело запроса на взятие задачи; без user_id задачу берет текущий пользователь
+type claimRequest struct {
+	UserID string `json:"user_id"`
+}
+
+func (h *TaskHandler) ClaimTask(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling ClaimTask request")
+
+	vars := mux.Vars(r)
+	idStr := vars["task_id"]
+
+	var req claimRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.handleError(w, r, errors.NewBadRequest("Invalid request body", err))
+			return
+		}
+	}
+	if req.UserID == "" {
+		if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+			req.UserID = principal.Subject
+		}
+	}
+
+	assignment, err := h.service.ClaimTask(r.Context(), idStr, req.UserID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, assignment)
+}
+
+func (h *TaskHandler) GetUserTasks(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling GetUserTasks request")
+
+	vars := mux.Vars(r)
+	userID := vars["user_id"]
+
+	var status models.TaskStatus
+	if raw := r.URL.Query().Get("status"); raw != "" {
+		code, err := strconv.Atoi(raw)
+		if err != nil {
+			h.handleError(w, r, errors.NewBadRequest("Invalid status value", err))
+			return
+		}
+		status = models.TaskStatus(code)
+	}
+
+	tasks, err := h.service.GetUserTasks(r.Context(), userID, status)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, tasks)
+}
+
 func (h *TaskHandler) GetDescription(w http.ResponseWriter, r *http.Request) {
 	h.logger.Debug("Handling GetDescription request")
 
@@ -249,7 +440,7 @@ func (h *TaskHandler) GetDescription(w http.ResponseWriter, r *http.Request) {
 	idStr := vars["task_id"]
 	id, err := uuid.Parse(idStr)
 	if err != nil {
-		h.handleError(w, errors.NewBadRequest("Invalid task ID", err))
+		h.handleError(w, r, errors.NewBadRequest("Invalid task ID", err))
 		return
 	}
 
@@ -258,7 +449,7 @@ func (h *TaskHandler) GetDescription(w http.ResponseWriter, r *http.Request) {
 		if parsedPage, err := strconv.Atoi(pageStr); err == nil {
 			page = parsedPage
 		} else {
-			h.handleError(w, errors.NewBadRequest("Invalid page query", err))
+			h.handleError(w, r, errors.NewBadRequest("Invalid page query", err))
 			return
 		}
 	}
@@ -266,14 +457,14 @@ func (h *TaskHandler) GetDescription(w http.ResponseWriter, r *http.Request) {
 		if parsedPageSize, err := strconv.Atoi(pageSizeStr); err == nil {
 			pageSize = parsedPageSize
 		} else {
-			h.handleError(w, errors.NewBadRequest("Invalid page size query", err))
+			h.handleError(w, r, errors.NewBadRequest("Invalid page size query", err))
 			return
 		}
 	}
 
 	response, err := h.service.GetDescription(r.Context(), id, page, pageSize)
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
@@ -290,6 +481,8 @@ func ParseTaskStatus(statusStr string) (models.TaskStatus, error) {
 		return models.Completed, nil
 	case "Canceled":
 		return models.Canceled, nil
+	case "Expired":
+		return models.Expired, nil
 	default:
 		return 0, fmt.Errorf("unknown status: %s", statusStr)
 	}
@@ -2,14 +2,18 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"github.com/ZnNr/user-reward-controller/internal/errors"
 	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
 	"github.com/ZnNr/user-reward-controller/internal/service"
 
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // UserHandler with service interface
@@ -29,17 +33,125 @@ func NewUserHandler(service *service.UserService, logger *zap.Logger) *UserHandl
 func (h *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
 	h.logger.Debug("Handling GetUsers request")
 
-	filter := &models.User{} // Задайте фильтр для поиска пользователей
+	filter, err := ParseUserFilter(r)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
 
 	response, err := h.service.GetUsers(r.Context(), filter)
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
+	pagination.SetLinkHeader(w, r, response.Info)
 	h.respondWithJSON(w, http.StatusOK, response)
 }
 
+// ParseUserFilter читает фильтр, сортировку и страницу списка пользователей из параметров запроса.
+// Даты передаются в формате RFC 3339, сортировка - списком полей через запятую, "-" перед полем
+// означает убывание: sort=-balance,username.
+func ParseUserFilter(r *http.Request) (*models.UserFilter, error) {
+	query := r.URL.Query()
+	filter := &models.UserFilter{
+		Username:    query.Get("username"),
+		EmailDomain: query.Get("email_domain"),
+	}
+
+	var err error
+	if status := query.Get("status"); status != "" {
+		if filter.Status, err = ParseUserStatus(status); err != nil {
+			return nil, errors.NewBadRequest("Invalid status", err)
+		}
+	}
+	if filter.MinBalance, err = getOptionalQueryParam(r, "min_balance", parseFloat); err != nil {
+		return nil, err
+	}
+	if filter.MaxBalance, err = getOptionalQueryParam(r, "max_balance", parseFloat); err != nil {
+		return nil, err
+	}
+	if filter.MinReferrals, err = getOptionalQueryParam(r, "min_referrals", strconv.Atoi); err != nil {
+		return nil, err
+	}
+	if filter.MaxReferrals, err = getOptionalQueryParam(r, "max_referrals", strconv.Atoi); err != nil {
+		return nil, err
+	}
+	if filter.HasReferrer, err = getOptionalQueryParam(r, "has_referrer", strconv.ParseBool); err != nil {
+		return nil, err
+	}
+	if filter.CreatedAfter, err = getOptionalQueryParam(r, "created_after", parseRFC3339); err != nil {
+		return nil, err
+	}
+	if filter.CreatedBefore, err = getOptionalQueryParam(r, "created_before", parseRFC3339); err != nil {
+		return nil, err
+	}
+	if filter.LastVisitBefore, err = getOptionalQueryParam(r, "last_visit_before", parseRFC3339); err != nil {
+		return nil, err
+	}
+
+	// Мягко удаленные пользователи показываются только по явному запросу администратора
+	if filter.IncludeDeleted, err = getQueryParamBool(r, "include_deleted"); err != nil {
+		return nil, err
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		if filter.Sort, err = parseUserSort(sort); err != nil {
+			return nil, err
+		}
+	}
+
+	if filter.Pagination, err = pagination.FromRequest(r); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// parseUserSort разбирает список полей сортировки вида "-balance,username"
+func parseUserSort(raw string) ([]models.UserSort, error) {
+	var sorts []models.UserSort
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		sort := models.UserSort{Field: models.UserSortField(strings.TrimPrefix(field, "-"))}
+		sort.Desc = strings.HasPrefix(field, "-")
+		if sort.Field == "" {
+			return nil, errors.NewBadRequest("Invalid sort", nil)
+		}
+		sorts = append(sorts, sort)
+	}
+	return sorts, nil
+}
+
+// ParseUserStatus принимает название статуса пользователя без учета регистра или его числовой код
+func ParseUserStatus(statusStr string) (models.UserStatus, error) {
+	if code, err := strconv.Atoi(statusStr); err == nil {
+		return models.UserStatus(code), nil
+	}
+	for _, status := range []models.UserStatus{models.Active, models.Suspended, models.Banned, models.Pending} {
+		if strings.EqualFold(status.String(), statusStr) {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown status: %s", statusStr)
+}
+
+// getOptionalQueryParam возвращает значение параметра, разобранное parse, или nil, если параметр не передан
+func getOptionalQueryParam[T any](r *http.Request, param string, parse func(string) (T, error)) (*T, error) {
+	valueStr := r.URL.Query().Get(param)
+	if valueStr == "" {
+		return nil, nil
+	}
+	value, err := parse(valueStr)
+	if err != nil {
+		return nil, errors.NewBadRequest(fmt.Sprintf("invalid %s value", param), err)
+	}
+	return &value, nil
+}
+
+func parseFloat(s string) (float64, error) { return strconv.ParseFloat(s, 64) }
+
+func parseRFC3339(s string) (time.Time, error) { return time.Parse(time.RFC3339, s) }
+
 func (h *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
 	h.logger.Debug("Handling GetUserByID request")
 
@@ -48,7 +160,7 @@ func (h *UserHandler) GetUserByID(w http.ResponseWriter, r *http.Request) {
 
 	user, err := h.service.GetUserByID(r.Context(), id)
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
@@ -60,13 +172,13 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 
 	var req models.CreateUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.handleError(w, errors.NewBadRequest("Invalid request body", err))
+		h.handleError(w, r, errors.NewBadRequest("Invalid request body", err))
 		return
 	}
 
 	user, err := h.service.CreateUser(r.Context(), &req)
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
@@ -81,7 +193,7 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 
 	var req models.UpdateUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.handleError(w, errors.NewBadRequest("Invalid request body", err))
+		h.handleError(w, r, errors.NewBadRequest("Invalid request body", err))
 		return
 	}
 
@@ -89,7 +201,7 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 
 	updatedUser, err := h.service.UpdateUser(r.Context(), &req)
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
@@ -103,13 +215,28 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	id := vars["user_id"]
 
 	if err := h.service.DeleteUser(r.Context(), id); err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (h *UserHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling RestoreUser request")
+
+	vars := mux.Vars(r)
+	id := vars["user_id"]
+
+	user, err := h.service.RestoreUser(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, user)
+}
+
 func (h *UserHandler) GetUserByEmail(w http.ResponseWriter, r *http.Request) {
 	h.logger.Debug("Handling GetUserByEmail request")
 
@@ -117,7 +244,7 @@ func (h *UserHandler) GetUserByEmail(w http.ResponseWriter, r *http.Request) {
 
 	user, err := h.service.GetUserByEmail(r.Context(), email)
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
@@ -133,12 +260,12 @@ func (h *UserHandler) UpdateBalance(w http.ResponseWriter, r *http.Request) {
 	amountStr := r.URL.Query().Get("amount")
 	amount, err := strconv.ParseFloat(amountStr, 64)
 	if err != nil {
-		h.handleError(w, errors.NewBadRequest("Invalid amount value", err))
+		h.handleError(w, r, errors.NewBadRequest("Invalid amount value", err))
 		return
 	}
 
 	if err := h.service.UpdateBalance(r.Context(), id, amount); err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
@@ -153,7 +280,7 @@ func (h *UserHandler) GetUserFullInfo(w http.ResponseWriter, r *http.Request) {
 
 	userInfo, err := h.service.GetUserFullInfo(r.Context(), id)
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
@@ -168,7 +295,7 @@ func (h *UserHandler) GetUserSummary(w http.ResponseWriter, r *http.Request) {
 
 	summary, err := h.service.GetUserSummary(r.Context(), id)
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
@@ -178,11 +305,6 @@ func (h *UserHandler) GetUserSummary(w http.ResponseWriter, r *http.Request) {
 func (h *UserHandler) InviteUser(w http.ResponseWriter, r *http.Request) {
 	h.logger.Debug("Handling InviteUser request")
 
-	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-
 	var inviteRequest struct {
 		InviterID    string `json:"inviter_id"`
 		InviteeEmail string `json:"invitee_email"`
@@ -190,16 +312,14 @@ func (h *UserHandler) InviteUser(w http.ResponseWriter, r *http.Request) {
 
 	// Декодируем JSON-запрос в структуру
 	if err := json.NewDecoder(r.Body).Decode(&inviteRequest); err != nil {
-		h.logger.Error("Failed to decode request", zap.Error(err))
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		h.handleError(w, r, errors.NewBadRequest("Invalid request payload", err))
 		return
 	}
 	defer r.Body.Close()
 
 	ctx := r.Context()
 	if err := h.service.InviteUser(ctx, inviteRequest.InviterID, inviteRequest.InviteeEmail); err != nil {
-		h.logger.Error("Failed to invite user", zap.Error(err))
-		http.Error(w, "Failed to invite user", http.StatusInternalServerError)
+		h.handleError(w, r, err)
 		return
 	}
 
@@ -213,7 +333,7 @@ func (h *UserHandler) GetLeaderByBalance(w http.ResponseWriter, r *http.Request)
 
 	leader, err := h.service.GetLeaderByBalance(r.Context())
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
@@ -223,21 +343,38 @@ func (h *UserHandler) GetLeaderByBalance(w http.ResponseWriter, r *http.Request)
 func (h *UserHandler) GetTopUsers(w http.ResponseWriter, r *http.Request) {
 	h.logger.Debug("Handling GetTopUsers request")
 
+	// Смещение (offset) сохранено для совместимости; без него выборка идет по курсору
+	if !r.URL.Query().Has("offset") {
+		page, err := pagination.FromRequest(r)
+		if err != nil {
+			h.handleError(w, r, err)
+			return
+		}
+		topUsers, err := h.service.GetTopUsersPage(r.Context(), page)
+		if err != nil {
+			h.handleError(w, r, err)
+			return
+		}
+		pagination.SetLinkHeader(w, r, topUsers.Info)
+		h.respondWithJSON(w, http.StatusOK, topUsers)
+		return
+	}
+
 	limit, err := getQueryParamInt(r, "limit", 10)
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
 	offset, err := getQueryParamInt(r, "offset", 0)
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
 	topUsers, err := h.service.GetTopUsers(r.Context(), limit, offset)
 	if err != nil {
-		h.handleError(w, err)
+		h.handleError(w, r, err)
 		return
 	}
 
@@ -0,0 +1,106 @@
+package v2
+
+import (
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
+	"github.com/ZnNr/user-reward-controller/internal/service"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// CreateReferralRequest - тело запроса на создание реферального кода
+type CreateReferralRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+	Code   string `json:"code" validate:"required,max=255"`
+}
+
+// UpdateReferralRequest - тело запроса на изменение реферального кода
+type UpdateReferralRequest struct {
+	Code string `json:"code" validate:"required,max=255"`
+}
+
+// ReferralHandler обслуживает реферальные коды
+type ReferralHandler struct {
+	baseHandler
+	service *service.ReferralService
+}
+
+// NewReferralHandler создает обработчик реферальных кодов API /v2
+func NewReferralHandler(service *service.ReferralService, logger *zap.Logger) *ReferralHandler {
+	return &ReferralHandler{
+		baseHandler: baseHandler{logger: logger},
+		service:     service,
+	}
+}
+
+// GetReferrals возвращает страницу реферальных кодов пользователя user_id
+func (h *ReferralHandler) GetReferrals(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		h.handleError(w, r, errors.NewBadRequest("user_id is required", nil))
+		return
+	}
+
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	response, err := h.service.GetReferralsByUserID(userID, page)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	h.respondList(w, r, convert(response.Referrals, newReferral), response.Count, response.Info)
+}
+
+func (h *ReferralHandler) CreateReferral(w http.ResponseWriter, r *http.Request) {
+	var body CreateReferralRequest
+	if err := decode(r, &body); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	referral, err := h.service.CreateReferral(&models.CreateReferralRequest{UserID: body.UserID, Code: body.Code})
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	h.respond(w, http.StatusCreated, newReferral(referral))
+}
+
+func (h *ReferralHandler) GetReferral(w http.ResponseWriter, r *http.Request) {
+	referral, err := h.service.GetReferral(mux.Vars(r)["referral_id"])
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	h.respond(w, http.StatusOK, newReferral(referral))
+}
+
+func (h *ReferralHandler) UpdateReferral(w http.ResponseWriter, r *http.Request) {
+	var body UpdateReferralRequest
+	if err := decode(r, &body); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	referral, err := h.service.UpdateReferral(mux.Vars(r)["referral_id"], &models.UpdateReferralRequest{Code: body.Code})
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	h.respond(w, http.StatusOK, newReferral(referral))
+}
+
+func (h *ReferralHandler) DeleteReferral(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteReferral(mux.Vars(r)["referral_id"]); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
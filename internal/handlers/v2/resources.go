@@ -0,0 +1,242 @@
+package v2
+
+import (
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"time"
+)
+
+// Ресурсы API /v2. Поля всех ресурсов названы в snake_case, идентификатор ресурса - id,
+// ссылки на другие ресурсы - <ресурс>_id, статусы передаются названиями, а не числовыми кодами.
+
+// User - пользователь
+type User struct {
+	ID             string     `json:"id"`
+	Username       string     `json:"username"`
+	Email          string     `json:"email"`
+	Balance        float64    `json:"balance"`
+	Referrals      int        `json:"referrals"`
+	ReferralCode   string     `json:"referral_code,omitempty"`
+	TasksCompleted int        `json:"tasks_completed"`
+	Bio            string     `json:"bio,omitempty"`
+	TimeZone       string     `json:"time_zone,omitempty"`
+	Status         string     `json:"status"`
+	VisitCount     int        `json:"visit_count"`
+	LastVisitAt    *time.Time `json:"last_visit_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
+}
+
+// UserSummary - краткая информация о пользователе
+type UserSummary struct {
+	ID             string    `json:"id"`
+	Username       string    `json:"username"`
+	Email          string    `json:"email"`
+	Balance        float64   `json:"balance"`
+	Referrals      int       `json:"referrals"`
+	TasksCompleted int       `json:"tasks_completed"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// LeaderboardEntry - пользователь в таблице лидеров
+type LeaderboardEntry struct {
+	Rank int `json:"rank"`
+	User
+}
+
+// Task - задача
+type Task struct {
+	ID               string     `json:"id"`
+	Title            string     `json:"title"`
+	Description      string     `json:"description,omitempty"`
+	Status           string     `json:"status"`
+	Reward           float64    `json:"reward"`
+	DueDate          *time.Time `json:"due_date,omitempty"`
+	AssigneeID       *string    `json:"assignee_id,omitempty"`
+	CompletedBy      *string    `json:"completed_by,omitempty"`
+	AssignmentMode   string     `json:"assignment_mode"`
+	MaxClaims        *int       `json:"max_claims,omitempty"`
+	RequiresEvidence bool       `json:"requires_evidence"`
+	CampaignID       *string    `json:"campaign_id,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`
+}
+
+// UserTask - задача в списке задач пользователя вместе с его прогрессом
+type UserTask struct {
+	Task
+	Progress    string     `json:"progress"`
+	ClaimedAt   *time.Time `json:"claimed_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// TaskStatusChange - переход задачи между статусами
+type TaskStatusChange struct {
+	ID          int64     `json:"id"`
+	TaskID      string    `json:"task_id"`
+	FromStatus  string    `json:"from_status"`
+	ToStatus    string    `json:"to_status"`
+	Actor       string    `json:"actor"`
+	UserID      *string   `json:"user_id,omitempty"`
+	RewardDelta float64   `json:"reward_delta"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Referral - реферальный код
+type Referral struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Code      string    `json:"code"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Названия статусов в API /v2
+var (
+	userStatusNames = map[models.UserStatus]string{
+		models.Active:    "active",
+		models.Suspended: "suspended",
+		models.Banned:    "banned",
+		models.Pending:   "pending",
+	}
+	taskStatusNames = map[models.TaskStatus]string{
+		models.NotStarted: "not_started",
+		models.InProgress: "in_progress",
+		models.Completed:  "completed",
+		models.Canceled:   "canceled",
+		models.Expired:    "expired",
+	}
+)
+
+func userStatusName(s models.UserStatus) string {
+	if name, ok := userStatusNames[s]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+func taskStatusName(s models.TaskStatus) string {
+	if name, ok := taskStatusNames[s]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// parseUserStatus возвращает статус пользователя по названию
+func parseUserStatus(name string) (models.UserStatus, error) {
+	for status, statusName := range userStatusNames {
+		if statusName == name {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown user status: %s", name)
+}
+
+// parseTaskStatus возвращает статус задачи по названию
+func parseTaskStatus(name string) (models.TaskStatus, error) {
+	for status, statusName := range taskStatusNames {
+		if statusName == name {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown task status: %s", name)
+}
+
+func newUser(u *models.User) User {
+	user := User{
+		ID:             u.ID,
+		Username:       u.Username,
+		Email:          u.Email,
+		Balance:        u.Balance,
+		Referrals:      u.Referrals,
+		ReferralCode:   u.ReferralCode,
+		TasksCompleted: u.TasksCompleted,
+		Bio:            u.Bio,
+		TimeZone:       u.TimeZone,
+		Status:         userStatusName(u.Status),
+		VisitCount:     u.VisitCount,
+		CreatedAt:      u.CreatedAt,
+		UpdatedAt:      u.UpdatedAt,
+		DeletedAt:      u.DeletedAt,
+	}
+	if !u.LastVisit.IsZero() {
+		lastVisit := u.LastVisit
+		user.LastVisitAt = &lastVisit
+	}
+	return user
+}
+
+func newUserSummary(s *models.UserSummary) UserSummary {
+	return UserSummary{
+		ID:             s.ID,
+		Username:       s.Username,
+		Email:          s.Email,
+		Balance:        s.Balance,
+		Referrals:      s.Referrals,
+		TasksCompleted: s.TasksCompleted,
+		CreatedAt:      s.CreatedAt,
+	}
+}
+
+func newTask(t *models.Task) Task {
+	return Task{
+		ID:               t.TaskID,
+		Title:            t.Title,
+		Description:      t.Description,
+		Status:           taskStatusName(t.Status),
+		Reward:           t.Reward,
+		DueDate:          t.DueDate,
+		AssigneeID:       t.AssigneeID,
+		CompletedBy:      t.CompletedBy,
+		AssignmentMode:   string(t.AssignmentMode),
+		MaxClaims:        t.MaxClaims,
+		RequiresEvidence: t.RequiresEvidence,
+		CampaignID:       t.CampaignID,
+		CreatedAt:        t.CreatedAt,
+		UpdatedAt:        t.UpdatedAt,
+		DeletedAt:        t.DeletedAt,
+	}
+}
+
+func newUserTask(t *models.UserTask) UserTask {
+	return UserTask{
+		Task:        newTask(&t.Task),
+		Progress:    taskStatusName(t.Progress),
+		ClaimedAt:   t.ClaimedAt,
+		CompletedAt: t.CompletedAt,
+	}
+}
+
+func newTaskStatusChange(c *models.TaskStatusChange) TaskStatusChange {
+	return TaskStatusChange{
+		ID:          c.ID,
+		TaskID:      c.TaskID,
+		FromStatus:  taskStatusName(c.FromStatus),
+		ToStatus:    taskStatusName(c.ToStatus),
+		Actor:       c.Actor,
+		UserID:      c.UserID,
+		RewardDelta: c.RewardDelta,
+		CreatedAt:   c.CreatedAt,
+	}
+}
+
+func newReferral(r *models.Referral) Referral {
+	return Referral{
+		ID:        r.ReferralID,
+		UserID:    r.UserID,
+		Code:      r.Code,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}
+}
+
+// convert применяет преобразование к каждому элементу списка
+func convert[M any, R any](items []M, f func(*M) R) []R {
+	result := make([]R, 0, len(items))
+	for i := range items {
+		result = append(result, f(&items[i]))
+	}
+	return result
+}
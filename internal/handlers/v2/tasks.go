@@ -0,0 +1,300 @@
+package v2
+
+import (
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
+	"github.com/ZnNr/user-reward-controller/internal/service"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// CreateTaskRequest - тело запроса на создание задачи
+type CreateTaskRequest struct {
+	Title            string     `json:"title" validate:"required,max=255"`
+	Description      string     `json:"description,omitempty" validate:"max=4096"`
+	Status           string     `json:"status,omitempty" validate:"omitempty,oneof=not_started in_progress"` // По умолчанию not_started
+	Reward           float64    `json:"reward" validate:"gte=0"`
+	DueDate          *time.Time `json:"due_date,omitempty" validate:"omitempty,future"`
+	AssigneeID       *string    `json:"assignee_id,omitempty"`
+	AssigneeIDs      []string   `json:"assignee_ids,omitempty" validate:"dive,uuid"`
+	AssignmentMode   string     `json:"assignment_mode,omitempty" validate:"omitempty,oneof=assigned open capped"`
+	MaxClaims        *int       `json:"max_claims,omitempty" validate:"omitempty,gt=0"`
+	RequiresEvidence *bool      `json:"requires_evidence,omitempty"`
+	CampaignID       *string    `json:"campaign_id,omitempty"`
+}
+
+// UpdateTaskRequest - тело запроса на изменение задачи; переданные поля заменяют текущие значения.
+// Статус меняется только через PUT /v2/tasks/{task_id}/status.
+type UpdateTaskRequest struct {
+	Title            *string    `json:"title,omitempty" validate:"omitempty,min=1,max=255"`
+	Description      *string    `json:"description,omitempty" validate:"omitempty,max=4096"`
+	Reward           *float64   `json:"reward,omitempty" validate:"omitempty,gte=0"`
+	DueDate          *time.Time `json:"due_date,omitempty" validate:"omitempty,future"`
+	AssigneeID       *string    `json:"assignee_id,omitempty"`
+	AssignmentMode   *string    `json:"assignment_mode,omitempty" validate:"omitempty,oneof=assigned open capped"`
+	MaxClaims        *int       `json:"max_claims,omitempty" validate:"omitempty,gt=0"`
+	RequiresEvidence *bool      `json:"requires_evidence,omitempty"`
+	CampaignID       *string    `json:"campaign_id,omitempty"` // Пустая строка отвязывает задачу от кампании
+}
+
+// StatusChangeRequest - тело запроса на перевод задачи в новый статус
+type StatusChangeRequest struct {
+	Status string `json:"status" validate:"required,oneof=not_started in_progress completed canceled expired"`
+	UserID string `json:"user_id" validate:"required,uuid"` // Пользователь, от имени которого выполняется переход
+}
+
+// TaskHandler обслуживает задачи и задачи пользователей
+type TaskHandler struct {
+	baseHandler
+	service *service.TaskService
+}
+
+// NewTaskHandler создает обработчик задач API /v2
+func NewTaskHandler(service *service.TaskService, logger *zap.Logger) *TaskHandler {
+	return &TaskHandler{
+		baseHandler: baseHandler{logger: logger},
+		service:     service,
+	}
+}
+
+// GetTasks возвращает страницу задач, соответствующих фильтру
+func (h *TaskHandler) GetTasks(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTaskFilter(r)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	response, err := h.service.GetTasks(r.Context(), filter)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	tasks := convert(response.Tasks, newTask)
+	h.respondList(w, r, tasks, len(tasks), response.Info)
+}
+
+// parseTaskFilter читает фильтр и страницу списка задач из параметров запроса. В отличие от GET /tasks
+// параметры дат названы в snake_case, статусы перечисляются названиями v2, а страницы выбираются только по курсору.
+func parseTaskFilter(r *http.Request) (*models.TaskFilter, error) {
+	query := r.URL.Query()
+	filter := &models.TaskFilter{
+		Title:       query.Get("title"),
+		Description: query.Get("description"),
+		AssigneeID:  query.Get("assignee_id"),
+		CampaignID:  query.Get("campaign_id"),
+	}
+
+	if raw := query.Get("status"); raw != "" {
+		for _, name := range strings.Split(raw, ",") {
+			status, err := parseTaskStatus(strings.TrimSpace(name))
+			if err != nil {
+				return nil, errors.NewBadRequest("invalid status", err)
+			}
+			filter.Statuses = append(filter.Statuses, status)
+		}
+	}
+
+	dates := map[string]**time.Time{
+		"created_after":  &filter.CreatedAfter,
+		"created_before": &filter.CreatedBefore,
+		"due_after":      &filter.DueAfter,
+		"due_before":     &filter.DueBefore,
+	}
+	for param, target := range dates {
+		raw := query.Get(param)
+		if raw == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, errors.NewBadRequest(fmt.Sprintf("invalid %s value", param), err)
+		}
+		*target = &t
+	}
+
+	if raw := query.Get("include_deleted"); raw != "" {
+		includeDeleted, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, errors.NewBadRequest("invalid include_deleted value", err)
+		}
+		filter.IncludeDeleted = includeDeleted
+	}
+
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	filter.Pagination = &page
+	return filter, nil
+}
+
+func (h *TaskHandler) GetTask(w http.ResponseWriter, r *http.Request) {
+	task, err := h.service.GetTaskByID(r.Context(), mux.Vars(r)["task_id"])
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	h.respond(w, http.StatusOK, newTask(task))
+}
+
+func (h *TaskHandler) CreateTask(w http.ResponseWriter, r *http.Request) {
+	var body CreateTaskRequest
+	if err := decode(r, &body); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	req := &models.CreateTaskRequest{BaseTaskRequest: models.BaseTaskRequest{
+		Title:            body.Title,
+		Description:      body.Description,
+		DueDate:          body.DueDate,
+		AssigneeID:       body.AssigneeID,
+		Reward:           body.Reward,
+		AssignmentMode:   models.TaskAssignmentMode(body.AssignmentMode),
+		MaxClaims:        body.MaxClaims,
+		AssigneeIDs:      body.AssigneeIDs,
+		RequiresEvidence: body.RequiresEvidence,
+		CampaignID:       body.CampaignID,
+	}}
+	if body.Status != "" {
+		status, err := parseTaskStatus(body.Status)
+		if err != nil {
+			h.handleError(w, r, errors.NewValidation("invalid status", err))
+			return
+		}
+		req.Status = status
+	}
+
+	task, err := h.service.CreateTask(r.Context(), req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	h.respond(w, http.StatusCreated, newTask(task))
+}
+
+func (h *TaskHandler) UpdateTask(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["task_id"])
+	if err != nil {
+		h.handleError(w, r, errors.NewBadRequest("invalid task ID", err))
+		return
+	}
+
+	var body UpdateTaskRequest
+	if err := decode(r, &body); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	// Нулевые значения запроса сервиса означают, что поле не меняется
+	req := &models.UpdateTaskRequest{TaskID: id.String()}
+	req.DueDate = body.DueDate
+	req.AssigneeID = body.AssigneeID
+	req.MaxClaims = body.MaxClaims
+	req.RequiresEvidence = body.RequiresEvidence
+	req.CampaignID = body.CampaignID
+	if body.Title != nil {
+		req.Title = *body.Title
+	}
+	if body.Description != nil {
+		req.Description = *body.Description
+	}
+	if body.Reward != nil {
+		req.Reward = *body.Reward
+	}
+	if body.AssignmentMode != nil {
+		req.AssignmentMode = models.TaskAssignmentMode(*body.AssignmentMode)
+	}
+
+	task, err := h.service.UpdateTask(r.Context(), id, req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	h.respond(w, http.StatusOK, newTask(task))
+}
+
+func (h *TaskHandler) DeleteTask(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteTask(r.Context(), mux.Vars(r)["task_id"]); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TaskHandler) RestoreTask(w http.ResponseWriter, r *http.Request) {
+	task, err := h.service.RestoreTask(r.Context(), mux.Vars(r)["task_id"])
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	h.respond(w, http.StatusOK, newTask(task))
+}
+
+// ChangeStatus переводит задачу в новый статус; при выполнении начисляет вознаграждение,
+// при повторном открытии возвращает его
+func (h *TaskHandler) ChangeStatus(w http.ResponseWriter, r *http.Request) {
+	var body StatusChangeRequest
+	if err := decode(r, &body); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	status, err := parseTaskStatus(body.Status)
+	if err != nil {
+		h.handleError(w, r, errors.NewValidation("invalid status", err))
+		return
+	}
+	userID, err := uuid.Parse(body.UserID)
+	if err != nil {
+		h.handleError(w, r, errors.NewValidation("invalid user_id", err))
+		return
+	}
+
+	task, err := h.service.UpdateTaskStatus(r.Context(), mux.Vars(r)["task_id"], status, userID)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	h.respond(w, http.StatusOK, newTask(task))
+}
+
+func (h *TaskHandler) GetTaskHistory(w http.ResponseWriter, r *http.Request) {
+	history, err := h.service.GetTaskHistory(r.Context(), mux.Vars(r)["task_id"])
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	changes := convert(history, newTaskStatusChange)
+	h.respondList(w, r, changes, len(changes), pagination.Info{})
+}
+
+// GetUserTasks возвращает взятые, назначенные и выполненные задачи пользователя
+func (h *TaskHandler) GetUserTasks(w http.ResponseWriter, r *http.Request) {
+	var progress models.TaskStatus
+	if raw := r.URL.Query().Get("progress"); raw != "" {
+		var err error
+		if progress, err = parseTaskStatus(raw); err != nil {
+			h.handleError(w, r, errors.NewBadRequest("invalid progress", err))
+			return
+		}
+	}
+
+	tasks, err := h.service.GetUserTasks(r.Context(), mux.Vars(r)["user_id"], progress)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	userTasks := convert(tasks, newUserTask)
+	h.respondList(w, r, userTasks, len(userTasks), pagination.Info{})
+}
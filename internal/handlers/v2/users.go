@@ -0,0 +1,239 @@
+package v2
+
+import (
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/handlers"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
+	"github.com/ZnNr/user-reward-controller/internal/service"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// CreateUserRequest - тело запроса на создание пользователя
+type CreateUserRequest struct {
+	Username     string `json:"username" validate:"required,max=255"`
+	Email        string `json:"email" validate:"required,email,max=255"`
+	ReferralCode string `json:"referral_code,omitempty" validate:"max=255"`
+	Bio          string `json:"bio,omitempty" validate:"max=1024"`
+	TimeZone     string `json:"time_zone,omitempty" validate:"omitempty,iana_tz"`
+	Status       string `json:"status,omitempty" validate:"omitempty,oneof=pending"` // По умолчанию pending
+}
+
+// UpdateUserRequest - тело запроса на изменение пользователя; переданные поля заменяют текущие значения
+type UpdateUserRequest struct {
+	Username     *string `json:"username,omitempty" validate:"omitempty,min=1,max=255"`
+	Email        *string `json:"email,omitempty" validate:"omitempty,email,max=255"`
+	ReferralCode *string `json:"referral_code,omitempty" validate:"omitempty,max=255"`
+	Bio          *string `json:"bio,omitempty" validate:"omitempty,max=1024"`
+	TimeZone     *string `json:"time_zone,omitempty" validate:"omitempty,iana_tz"`
+	Status       *string `json:"status,omitempty" validate:"omitempty,oneof=active suspended banned pending"`
+}
+
+// BalanceAdjustment - тело запроса на изменение баланса
+type BalanceAdjustment struct {
+	Amount float64 `json:"amount"` // Начисление (>0) или списание (<0)
+}
+
+// InvitationRequest - тело запроса на приглашение пользователя
+type InvitationRequest struct {
+	InviterID    string `json:"inviter_id" validate:"required,uuid"`
+	InviteeEmail string `json:"invitee_email" validate:"required,email"`
+}
+
+// UserHandler обслуживает пользователей, таблицу лидеров и приглашения
+type UserHandler struct {
+	baseHandler
+	service *service.UserService
+}
+
+// NewUserHandler создает обработчик пользователей API /v2
+func NewUserHandler(service *service.UserService, logger *zap.Logger) *UserHandler {
+	return &UserHandler{
+		baseHandler: baseHandler{logger: logger},
+		service:     service,
+	}
+}
+
+// GetUsers возвращает страницу пользователей; параметры фильтра совпадают с GET /users
+func (h *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
+	filter, err := handlers.ParseUserFilter(r)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	response, err := h.service.GetUsers(r.Context(), filter)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	users := make([]User, 0, len(response.Users))
+	for _, user := range response.Users {
+		users = append(users, newUser(user))
+	}
+	h.respondList(w, r, users, response.Count, response.Info)
+}
+
+func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.service.GetUserByID(r.Context(), mux.Vars(r)["user_id"])
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	h.respond(w, http.StatusOK, newUser(user))
+}
+
+func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var body CreateUserRequest
+	if err := decode(r, &body); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	req := &models.CreateUserRequest{
+		Username:     body.Username,
+		Email:        body.Email,
+		ReferralCode: body.ReferralCode,
+		Bio:          body.Bio,
+		TimeZone:     body.TimeZone,
+	}
+	if body.Status != "" {
+		status, err := parseUserStatus(body.Status)
+		if err != nil {
+			h.handleError(w, r, errors.NewValidation("invalid status", err))
+			return
+		}
+		req.Status = status
+	}
+
+	user, err := h.service.CreateUser(r.Context(), req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	h.respond(w, http.StatusCreated, newUser(user))
+}
+
+func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	var body UpdateUserRequest
+	if err := decode(r, &body); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	req := &models.UpdateUserRequest{
+		UserID:       mux.Vars(r)["user_id"],
+		Username:     body.Username,
+		Email:        body.Email,
+		ReferralCode: body.ReferralCode,
+		Bio:          body.Bio,
+		TimeZone:     body.TimeZone,
+	}
+	if body.Status != nil {
+		status, err := parseUserStatus(*body.Status)
+		if err != nil {
+			h.handleError(w, r, errors.NewValidation("invalid status", err))
+			return
+		}
+		req.Status = &status
+	}
+
+	user, err := h.service.UpdateUser(r.Context(), req)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	h.respond(w, http.StatusOK, newUser(user))
+}
+
+func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteUser(r.Context(), mux.Vars(r)["user_id"]); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserHandler) RestoreUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.service.RestoreUser(r.Context(), mux.Vars(r)["user_id"])
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	h.respond(w, http.StatusOK, newUser(user))
+}
+
+func (h *UserHandler) GetUserSummary(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.service.GetUserSummary(r.Context(), mux.Vars(r)["user_id"])
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	h.respond(w, http.StatusOK, newUserSummary(summary))
+}
+
+// AdjustBalance изменяет баланс пользователя на сумму из тела запроса и возвращает пользователя
+func (h *UserHandler) AdjustBalance(w http.ResponseWriter, r *http.Request) {
+	var body BalanceAdjustment
+	if err := decode(r, &body); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	id := mux.Vars(r)["user_id"]
+	if err := h.service.UpdateBalance(r.Context(), id, body.Amount); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	user, err := h.service.GetUserByID(r.Context(), id)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	h.respond(w, http.StatusOK, newUser(user))
+}
+
+// GetLeaderboard возвращает страницу таблицы лидеров по балансу
+func (h *UserHandler) GetLeaderboard(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.FromRequest(r)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	top, err := h.service.GetTopUsersPage(r.Context(), page)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	entries := make([]LeaderboardEntry, 0, len(top.Users))
+	for i := range top.Users {
+		entries = append(entries, LeaderboardEntry{Rank: top.Users[i].Rank, User: newUser(&top.Users[i].User)})
+	}
+	h.respondList(w, r, entries, top.Count, top.Info)
+}
+
+// CreateInvitation приглашает пользователя по email и возвращает созданного пользователя
+func (h *UserHandler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	var body InvitationRequest
+	if err := decode(r, &body); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	if err := h.service.InviteUser(r.Context(), body.InviterID, body.InviteeEmail); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	invitee, err := h.service.GetUserByEmail(r.Context(), body.InviteeEmail)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+	h.respond(w, http.StatusCreated, newUser(invitee))
+}
@@ -0,0 +1,72 @@
+// Package v2 содержит обработчики API /v2. В отличие от первой версии, все ресурсы v2 названы
+// в одном стиле (snake_case), успешные ответы заворачиваются в конверт {"data": ..., "meta": ...},
+// а ошибки возвращаются в формате RFC 7807 (application/problem+json).
+package v2
+
+import (
+	"encoding/json"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
+	"github.com/ZnNr/user-reward-controller/internal/problem"
+	"github.com/ZnNr/user-reward-controller/internal/validation"
+
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// Envelope - тело успешного ответа
+type Envelope struct {
+	Data interface{} `json:"data"`           // Ресурс или список ресурсов
+	Meta *Meta       `json:"meta,omitempty"` // Сведения о списке; только для списков
+}
+
+// Meta - сведения о странице списка
+type Meta struct {
+	Count      int    `json:"count"`                 // Количество элементов на странице
+	NextCursor string `json:"next_cursor,omitempty"` // Курсор следующей страницы
+	PrevCursor string `json:"prev_cursor,omitempty"` // Курсор предыдущей страницы
+}
+
+// newMeta возвращает сведения о странице из count элементов
+func newMeta(count int, info pagination.Info) *Meta {
+	return &Meta{Count: count, NextCursor: info.NextCursor, PrevCursor: info.PrevCursor}
+}
+
+// baseHandler - общие методы обработчиков v2
+type baseHandler struct {
+	logger *zap.Logger
+}
+
+// handleError отвечает описанием ошибки в формате problem+json
+func (h *baseHandler) handleError(w http.ResponseWriter, r *http.Request, err error) {
+	h.logger.Error("Handling error", zap.Error(err))
+	problem.WriteError(w, r, err)
+}
+
+// respond отвечает ресурсом data в конверте
+func (h *baseHandler) respond(w http.ResponseWriter, status int, data interface{}) {
+	h.write(w, status, Envelope{Data: data})
+}
+
+// respondList отвечает страницей списка items в конверте и добавляет ссылки на соседние страницы в заголовок Link
+func (h *baseHandler) respondList(w http.ResponseWriter, r *http.Request, items interface{}, count int, info pagination.Info) {
+	pagination.SetLinkHeader(w, r, info)
+	h.write(w, http.StatusOK, Envelope{Data: items, Meta: newMeta(count, info)})
+}
+
+func (h *baseHandler) write(w http.ResponseWriter, status int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		h.logger.Error("Failed to write response", zap.Error(err))
+	}
+}
+
+// decode разбирает JSON-тело запроса в v и проверяет его по тегам validate, чтобы нарушения
+// назывались полями тела /v2, а не полями моделей сервиса
+func decode(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errors.NewBadRequest("invalid request body", err)
+	}
+	return validation.Struct(v)
+}
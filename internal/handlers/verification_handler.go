@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/service"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+// VerificationHandler обрабатывает подтверждение email пользователей
+type VerificationHandler struct {
+	BaseHandler
+	verifier *service.VerificationService
+	users    *service.UserService
+}
+
+// NewVerificationHandler returns a new instance of VerificationHandler
+func NewVerificationHandler(verifier *service.VerificationService, users *service.UserService, logger *zap.Logger) *VerificationHandler {
+	return &VerificationHandler{
+		BaseHandler: BaseHandler{logger: logger},
+		verifier:    verifier,
+		users:       users,
+	}
+}
+
+// verifyRequest - тело запроса подтверждения email
+type verifyRequest struct {
+	Token string `json:"token"`
+}
+
+// Verify handles POST /users/verify
+func (h *VerificationHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling VerifyEmail request")
+
+	var req verifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.handleError(w, r, errors.NewBadRequest("Invalid request body", err))
+		return
+	}
+
+	user, err := h.verifier.Verify(r.Context(), req.Token)
+	if err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, user)
+}
+
+// Resend handles POST /users/{user_id}/verification
+func (h *VerificationHandler) Resend(w http.ResponseWriter, r *http.Request) {
+	h.logger.Debug("Handling ResendVerification request")
+
+	if err := h.users.ResendVerification(r.Context(), mux.Vars(r)["user_id"]); err != nil {
+		h.handleError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
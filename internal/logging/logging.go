@@ -33,6 +33,7 @@ func LoggingMiddleware(logger *zap.Logger) func(http.Handler) http.Handler {
 // logRequest логирует входящий HTTP запрос
 func logRequest(logger *zap.Logger, r *http.Request) {
 	logger.Info("Incoming request",
+		zap.String("request_id", RequestID(r.Context())),
 		zap.String("method", r.Method),
 		zap.String("path", r.URL.Path),
 		zap.String("remote_addr", r.RemoteAddr),
@@ -43,6 +44,7 @@ func logRequest(logger *zap.Logger, r *http.Request) {
 // logResponse логирует результат исходящего HTTP ответа
 func logResponse(logger *zap.Logger, r *http.Request, rw *responseWriter, duration time.Duration) {
 	logger.Info("Request completed",
+		zap.String("request_id", RequestID(r.Context())),
 		zap.String("method", r.Method),
 		zap.String("path", r.URL.Path),
 		zap.Int("status", rw.status),
@@ -74,3 +76,8 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	}
 	return rw.ResponseWriter.Write(b)
 }
+
+// Unwrap возвращает исходный ResponseWriter, чтобы http.ResponseController мог использовать Flush и дедлайны
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
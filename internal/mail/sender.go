@@ -0,0 +1,93 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Поддерживаемые способы отправки писем
+const (
+	SenderLog  = "log"  // Письма записываются в лог приложения
+	SenderFile = "file" // Письма сохраняются в каталог в формате .eml
+)
+
+// Message представляет исходящее письмо
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender отправляет письма пользователям
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// NewSender создает отправителя указанного типа.
+func NewSender(kind, dir string, logger *zap.Logger) (Sender, error) {
+	switch kind {
+	case SenderLog:
+		return NewLogSender(logger), nil
+	case SenderFile:
+		return NewFileSender(dir)
+	default:
+		return nil, fmt.Errorf("unknown mail sender %q", kind)
+	}
+}
+
+// LogSender записывает письма в лог; предназначен для локальной разработки
+type LogSender struct {
+	logger *zap.Logger
+}
+
+// NewLogSender создает новый экземпляр LogSender
+func NewLogSender(logger *zap.Logger) *LogSender {
+	return &LogSender{logger: logger}
+}
+
+// Send записывает письмо в лог
+func (s *LogSender) Send(_ context.Context, msg Message) error {
+	s.logger.Info("Outgoing email",
+		zap.String("to", msg.To),
+		zap.String("subject", msg.Subject),
+		zap.String("body", msg.Body))
+	return nil
+}
+
+// FileSender сохраняет каждое письмо в отдельный .eml файл
+type FileSender struct {
+	dir string
+}
+
+// NewFileSender создает каталог для писем, если его нет, и возвращает FileSender
+func NewFileSender(dir string) (*FileSender, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	return &FileSender{dir: dir}, nil
+}
+
+// Send сохраняет письмо в файл вида <время>-<uuid>.eml
+func (s *FileSender) Send(_ context.Context, msg Message) error {
+	now := time.Now().UTC()
+	name := fmt.Sprintf("%s-%s.eml", now.Format("20060102T150405"), uuid.NewString())
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(msg.Body)
+
+	if err := os.WriteFile(filepath.Join(s.dir, name), []byte(b.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Действия администраторов, фиксируемые в журнале аудита
+const (
+	AuditUserBalanceUpdate = "user.balance_update" // Изменение баланса пользователя
+	AuditUserStatusChange  = "user.status_change"  // Перевод пользователя в статус Banned или Suspended
+	AuditTaskDelete        = "task.delete"         // Удаление задачи
+	AuditSubmissionApprove = "submission.approve"  // Одобрение заявки на выполнение задачи
+	AuditSubmissionReject  = "submission.reject"   // Отклонение заявки на выполнение задачи
+)
+
+// Типы объектов, над которыми выполняются действия
+const (
+	AuditTargetUser = "user"
+	AuditTargetTask = "task"
+
+	AuditTargetSubmission = "submission"
+)
+
+// AuditEntry представляет неизменяемую запись журнала аудита
+type AuditEntry struct {
+	ID          int64           `json:"id"`                    // Порядковый номер записи
+	Actor       string          `json:"actor"`                 // Инициатор действия
+	Action      string          `json:"action"`                // Действие
+	TargetType  string          `json:"target_type"`           // Тип объекта
+	TargetID    string          `json:"target_id"`             // Идентификатор объекта
+	Before      json.RawMessage `json:"before,omitempty"`      // Значения измененных полей до действия
+	After       json.RawMessage `json:"after,omitempty"`       // Значения измененных полей после действия
+	RequestID   string          `json:"request_id"`            // Идентификатор запроса
+	IP          string          `json:"ip"`                    // IP-адрес клиента
+	CreatedAt   time.Time       `json:"created_at"`            // Время действия
+	ContentHash string          `json:"content_hash"`          // Хеш Before, After и IP; входит в Hash вместо них, чтобы затирание не нарушало цепочку
+	RedactedAt  *time.Time      `json:"redacted_at,omitempty"` // Время затирания персональных данных
+	PrevHash    string          `json:"prev_hash"`             // Хеш предыдущей записи (пустой для первой)
+	Hash        string          `json:"hash"`                  // Хеш записи, включающий PrevHash
+}
+
+// AuditPersonalFields - поля снимков Before и After, которые затираются при анонимизации пользователя
+var AuditPersonalFields = []string{"Username", "Email", "Bio", "ReferralCode", "evidence", "reason"}
+
+// AuditRedacted - значение, которым заменяются затертые поля
+const AuditRedacted = "[anonymized]"
+
+// ComputeContentHash вычисляет SHA-256 от Before, After и IP записи.
+func (e *AuditEntry) ComputeContentHash() string {
+	return hashStrings(string(e.Before), string(e.After), e.IP)
+}
+
+// ComputeHash вычисляет SHA-256 от записи (с ContentHash вместо содержимого) и хеша предыдущей записи.
+// Изменение любой записи нарушает цепочку хешей всех последующих.
+func (e *AuditEntry) ComputeHash() string {
+	return hashStrings(
+		e.PrevHash,
+		e.Actor,
+		e.Action,
+		e.TargetType,
+		e.TargetID,
+		e.ContentHash,
+		e.RequestID,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	)
+}
+
+// hashStrings вычисляет SHA-256 от JSON-массива значений; JSON однозначно разделяет значения
+func hashStrings(values ...string) string {
+	payload, _ := json.Marshal(values)
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditFilter используется для выборки записей журнала аудита
+type AuditFilter struct {
+	Actor    string     `json:"actor,omitempty"`     // Инициатор действия
+	TargetID string     `json:"target_id,omitempty"` // Идентификатор объекта
+	From     *time.Time `json:"from,omitempty"`      // Начало интервала (включительно)
+	To       *time.Time `json:"to,omitempty"`        // Конец интервала (не включительно)
+	Limit    int        `json:"limit"`               // Максимальное количество записей
+}
+
+// AuditVerification содержит результат проверки цепочки хешей журнала аудита
+type AuditVerification struct {
+	Valid     bool   `json:"valid"`               // Цепочка не нарушена
+	Checked   int    `json:"checked"`             // Количество проверенных записей
+	BrokenAt  int64  `json:"broken_at,omitempty"` // ID первой записи с нарушенной цепочкой
+	LastHash  string `json:"last_hash,omitempty"` // Хеш последней проверенной записи
+	Violation string `json:"violation,omitempty"` // Описание нарушения
+}
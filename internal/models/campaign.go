@@ -0,0 +1,84 @@
+package models
+
+import "time"
+
+// CampaignStatus определяет состояние кампании относительно ее расписания
+type CampaignStatus string
+
+const (
+	CampaignScheduled CampaignStatus = "scheduled" // Кампания еще не началась
+	CampaignActive    CampaignStatus = "active"    // Кампания идет, вознаграждения выплачиваются
+	CampaignEnded     CampaignStatus = "ended"     // Кампания завершилась
+)
+
+// IsValid проверяет, что статус кампании известен
+func (s CampaignStatus) IsValid() bool {
+	switch s {
+	case CampaignScheduled, CampaignActive, CampaignEnded:
+		return true
+	default:
+		return false
+	}
+}
+
+// CampaignStatusAt возвращает статус кампании с расписанием [startsAt, endsAt) в момент now
+func CampaignStatusAt(startsAt, endsAt, now time.Time) CampaignStatus {
+	switch {
+	case now.Before(startsAt):
+		return CampaignScheduled
+	case now.Before(endsAt):
+		return CampaignActive
+	default:
+		return CampaignEnded
+	}
+}
+
+// Campaign представляет маркетинговую кампанию, объединяющую задачи с общим бюджетом вознаграждений
+type Campaign struct {
+	CampaignID  string         `json:"campaign_id"`           // Уникальный идентификатор кампании
+	Name        string         `json:"name"`                  // Название кампании
+	Description string         `json:"description,omitempty"` // Описание кампании
+	StartsAt    time.Time      `json:"starts_at"`             // Начало кампании
+	EndsAt      time.Time      `json:"ends_at"`               // Окончание кампании (не включительно)
+	Budget      float64        `json:"budget"`                // Общий бюджет вознаграждений в баллах
+	Spent       float64        `json:"spent"`                 // Выплачено вознаграждений
+	Status      CampaignStatus `json:"status"`                // Состояние по расписанию
+	CreatedAt   time.Time      `json:"created_at"`            // Дата создания
+	UpdatedAt   time.Time      `json:"updated_at"`            // Дата последнего обновления
+}
+
+// Remaining возвращает неизрасходованный остаток бюджета
+func (c *Campaign) Remaining() float64 {
+	if c.Spent >= c.Budget {
+		return 0
+	}
+	return c.Budget - c.Spent
+}
+
+// CampaignRequest представляет запрос на создание или обновление кампании
+type CampaignRequest struct {
+	Name        string     `json:"name" validate:"required,max=255"`             // Название кампании
+	Description string     `json:"description,omitempty"`                        // Описание кампании
+	StartsAt    *time.Time `json:"starts_at" validate:"required"`                // Начало кампании
+	EndsAt      *time.Time `json:"ends_at" validate:"required,gtfield=StartsAt"` // Окончание кампании
+	Budget      *float64   `json:"budget" validate:"required,gte=0"`             // Общий бюджет вознаграждений
+}
+
+// CampaignFilter используется для выборки кампаний
+type CampaignFilter struct {
+	Status CampaignStatus `json:"status,omitempty"` // Состояние кампаний
+	Limit  int            `json:"limit,omitempty"`  // Максимальное количество записей
+	Offset int            `json:"offset,omitempty"` // Смещение от начала выборки
+}
+
+// CampaignStats содержит сводные показатели кампании
+type CampaignStats struct {
+	CampaignID   string  `json:"campaign_id"`  // Идентификатор кампании
+	Tasks        int     `json:"tasks"`        // Количество задач кампании
+	Participants int     `json:"participants"` // Пользователи, взявшие, выполнившие задачи или отправившие заявки
+	Completions  int     `json:"completions"`  // Засчитанные выполнения задач
+	PointsSpent  float64 `json:"points_spent"` // Выплачено вознаграждений
+	Budget       float64 `json:"budget"`       // Общий бюджет
+	Remaining    float64 `json:"remaining"`    // Остаток бюджета
+	Exhausted    bool    `json:"exhausted"`    // Бюджет исчерпан, вознаграждения больше не выплачиваются
+}
@@ -0,0 +1,21 @@
+package models
+
+// BalanceUpdate описывает событие изменения баланса пользователя
+type BalanceUpdate struct {
+	UserID  string  `json:"user_id"` // Идентификатор пользователя
+	Balance float64 `json:"balance"` // Новый баланс
+	Delta   float64 `json:"delta"`   // Изменение баланса
+}
+
+// RankChange описывает изменение позиции пользователя в таблице лидеров
+type RankChange struct {
+	UserID  string `json:"user_id"`  // Идентификатор пользователя
+	OldRank int    `json:"old_rank"` // Прежний ранг (0 - пользователь не входил в топ)
+	NewRank int    `json:"new_rank"` // Новый ранг (0 - пользователь выбыл из топа)
+}
+
+// LeaderboardUpdate описывает событие изменения таблицы лидеров
+type LeaderboardUpdate struct {
+	Changes []RankChange `json:"changes"` // Изменения рангов
+	Top     []TopUser    `json:"top"`     // Актуальный топ пользователей
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// ExportKind определяет набор выгружаемых данных
+type ExportKind string
+
+const (
+	ExportUsers  ExportKind = "users"
+	ExportTasks  ExportKind = "tasks"
+	ExportLedger ExportKind = "ledger"
+)
+
+// ExportFormat определяет формат выгрузки
+type ExportFormat string
+
+const (
+	ExportFormatCSV    ExportFormat = "csv"
+	ExportFormatNDJSON ExportFormat = "ndjson"
+)
+
+// ExportFilter используется для фильтрации выгружаемых записей
+type ExportFilter struct {
+	From   *time.Time // Записи, созданные не раньше указанного момента
+	To     *time.Time // Записи, созданные раньше указанного момента
+	Status int        // Статус пользователя или задачи (0 - без фильтра)
+	UserID string     // Идентификатор пользователя (для журнала баланса)
+}
@@ -0,0 +1,37 @@
+package models
+
+// ImportFormat определяет формат входного потока массового импорта
+type ImportFormat string
+
+const (
+	ImportFormatCSV   ImportFormat = "csv"   // CSV с обязательной строкой заголовков
+	ImportFormatJSONL ImportFormat = "jsonl" // Один JSON-объект на строку
+)
+
+// ImportRowError описывает ошибку обработки одной строки импорта
+type ImportRowError struct {
+	Row   int    `json:"row"`   // Номер строки данных (начиная с 1, без учета заголовка CSV)
+	Error string `json:"error"` // Описание ошибки
+}
+
+// ImportReport представляет результат массового импорта
+type ImportReport struct {
+	DryRun   bool             `json:"dry_run"`  // Режим проверки без записи в базу данных
+	Total    int              `json:"total"`    // Количество обработанных строк
+	Valid    int              `json:"valid"`    // Количество строк, прошедших проверку
+	Imported int              `json:"imported"` // Количество записанных строк (0 в режиме dry-run)
+	Failed   int              `json:"failed"`   // Количество строк с ошибками
+	Errors   []ImportRowError `json:"errors"`   // Ошибки по строкам
+}
+
+// AddError регистрирует ошибку строки в отчете
+func (r *ImportReport) AddError(row int, message string) {
+	r.Failed++
+	r.Errors = append(r.Errors, ImportRowError{Row: row, Error: message})
+}
+
+// TaskKey идентифицирует задачу по заголовку и описанию, которые должны быть уникальны
+type TaskKey struct {
+	Title       string
+	Description string
+}
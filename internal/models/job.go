@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// JobRunStatus определяет состояние запуска фонового задания
+type JobRunStatus string
+
+const (
+	JobRunRunning   JobRunStatus = "running"   // Задание выполняется
+	JobRunSucceeded JobRunStatus = "succeeded" // Задание завершилось успешно
+	JobRunFailed    JobRunStatus = "failed"    // Задание завершилось ошибкой
+	JobRunCanceled  JobRunStatus = "canceled"  // Задание прервано остановкой приложения
+)
+
+// JobRun представляет запуск фонового задания по расписанию
+type JobRun struct {
+	ID          int64        `json:"id"`                    // Порядковый номер запуска
+	JobName     string       `json:"job_name"`              // Имя задания
+	ScheduledAt time.Time    `json:"scheduled_at"`          // Время запуска по расписанию
+	StartedAt   time.Time    `json:"started_at"`            // Фактическое время начала
+	FinishedAt  *time.Time   `json:"finished_at,omitempty"` // Время завершения
+	Status      JobRunStatus `json:"status"`                // Состояние запуска
+	Error       string       `json:"error,omitempty"`       // Текст ошибки для неуспешного запуска
+	Instance    string       `json:"instance"`              // Реплика, выполнившая задание
+}
+
+// JobRunFilter используется для выборки истории запусков
+type JobRunFilter struct {
+	JobName string       // Имя задания
+	Status  JobRunStatus // Состояние запуска
+	Limit   int          // Максимальное количество записей
+}
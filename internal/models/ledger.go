@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// Причины изменения баланса, записываемые в журнал
+const (
+	LedgerReasonBalanceUpdate = "balance_update" // Изменение баланса администратором или через API
+	LedgerReasonReferralBonus = "referral_bonus" // Бонус за приглашение пользователя
+	LedgerReasonTaskReward    = "task_reward"    // Вознаграждение за выполнение задачи
+	LedgerReasonTaskReversal  = "task_reversal"  // Возврат вознаграждения при повторном открытии задачи
+	LedgerReasonQuestBonus    = "quest_bonus"    // Бонус за выполнение всех задач квеста
+	LedgerReasonQuestReversal = "quest_reversal" // Возврат бонуса за квест при повторном открытии его задачи
+)
+
+// LedgerEntry представляет запись журнала изменений баланса
+type LedgerEntry struct {
+	ID           int64     `json:"id"`            // Идентификатор записи
+	UserID       string    `json:"user_id"`       // Идентификатор пользователя
+	Amount       float64   `json:"amount"`        // Изменение баланса
+	BalanceAfter float64   `json:"balance_after"` // Баланс после изменения
+	Reason       string    `json:"reason"`        // Причина изменения
+	CreatedAt    time.Time `json:"created_at"`    // Время изменения
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// DataExportFormat определяет формат выгрузки персональных данных пользователя
+type DataExportFormat string
+
+const (
+	DataExportJSON DataExportFormat = "json" // Один JSON-документ
+	DataExportZIP  DataExportFormat = "zip"  // ZIP-архив с отдельным JSON-файлом на каждый раздел
+)
+
+// UserDataExport содержит все данные, связанные с пользователем (запрос субъекта данных по GDPR)
+type UserDataExport struct {
+	GeneratedAt    time.Time     `json:"generated_at"`    // Время формирования выгрузки
+	Profile        *User         `json:"profile"`         // Профиль пользователя
+	Visits         []time.Time   `json:"visits"`          // Посещения
+	ActivityLog    []time.Time   `json:"activity_log"`    // Журнал активности
+	Tasks          []Task        `json:"tasks"`           // Задачи, назначенные пользователю
+	Referrals      []Referral    `json:"referrals"`       // Реферальные коды пользователя
+	Submissions    []Submission  `json:"submissions"`     // Заявки на выполнение задач с доказательствами
+	BalanceHistory []LedgerEntry `json:"balance_history"` // История изменений баланса
+}
@@ -0,0 +1,73 @@
+package models
+
+import "time"
+
+// DependencyRequest представляет запрос на добавление зависимостей задачи
+type DependencyRequest struct {
+	DependsOn []string `json:"depends_on" validate:"required,min=1,dive,uuid"` // Задачи, которые нужно выполнить раньше
+}
+
+// TaskAvailability описывает доступность задачи для пользователя с учетом зависимостей
+type TaskAvailability struct {
+	TaskID    string   `json:"task_id"`              // Идентификатор задачи
+	Available bool     `json:"available"`            // Все зависимости выполнены
+	Completed bool     `json:"completed"`            // Пользователь уже выполнил задачу
+	BlockedBy []string `json:"blocked_by,omitempty"` // Невыполненные зависимости
+}
+
+// QuestTask представляет задачу квеста
+type QuestTask struct {
+	TaskID   string `json:"task_id"`  // Идентификатор задачи
+	Title    string `json:"title"`    // Заголовок задачи
+	Position int    `json:"position"` // Порядковый номер задачи в квесте
+}
+
+// Quest представляет цепочку задач с бонусом за выполнение всех задач
+type Quest struct {
+	QuestID     string      `json:"quest_id"`              // Уникальный идентификатор квеста
+	Title       string      `json:"title"`                 // Название квеста
+	Description string      `json:"description,omitempty"` // Описание квеста
+	Bonus       float64     `json:"bonus"`                 // Бонус за выполнение всех задач
+	Tasks       []QuestTask `json:"tasks"`                 // Задачи квеста по порядку
+	CreatedAt   time.Time   `json:"created_at"`            // Дата создания
+	UpdatedAt   time.Time   `json:"updated_at"`            // Дата последнего обновления
+}
+
+// QuestRequest представляет запрос на создание квеста
+type QuestRequest struct {
+	Title       string   `json:"title" validate:"required,max=255"`            // Название квеста
+	Description string   `json:"description,omitempty"`                        // Описание квеста
+	Bonus       float64  `json:"bonus" validate:"gte=0"`                       // Бонус за выполнение всех задач
+	TaskIDs     []string `json:"task_ids" validate:"required,min=1,dive,uuid"` // Задачи квеста по порядку
+}
+
+// QuestCompletion представляет выплаченный пользователю бонус за квест
+type QuestCompletion struct {
+	QuestID     string    `json:"quest_id"`     // Идентификатор квеста
+	UserID      string    `json:"user_id"`      // Идентификатор пользователя
+	Bonus       float64   `json:"bonus"`        // Выплаченный бонус
+	CompletedAt time.Time `json:"completed_at"` // Время выполнения квеста
+}
+
+// QuestTaskProgress описывает прогресс пользователя по задаче квеста
+type QuestTaskProgress struct {
+	TaskID    string   `json:"task_id"`              // Идентификатор задачи
+	Title     string   `json:"title"`                // Заголовок задачи
+	Position  int      `json:"position"`             // Порядковый номер задачи в квесте
+	Completed bool     `json:"completed"`            // Пользователь выполнил задачу
+	Available bool     `json:"available"`            // Все зависимости задачи выполнены
+	BlockedBy []string `json:"blocked_by,omitempty"` // Невыполненные зависимости
+}
+
+// QuestProgress описывает прогресс пользователя по квесту
+type QuestProgress struct {
+	QuestID        string              `json:"quest_id"`               // Идентификатор квеста
+	UserID         string              `json:"user_id"`                // Идентификатор пользователя
+	Title          string              `json:"title"`                  // Название квеста
+	Bonus          float64             `json:"bonus"`                  // Бонус за выполнение всех задач
+	Tasks          []QuestTaskProgress `json:"tasks"`                  // Прогресс по задачам квеста
+	CompletedTasks int                 `json:"completed_tasks"`        // Выполнено задач
+	TotalTasks     int                 `json:"total_tasks"`            // Всего задач
+	Completed      bool                `json:"completed"`              // Квест выполнен и бонус выплачен
+	CompletedAt    *time.Time          `json:"completed_at,omitempty"` // Время выполнения квеста
+}
@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
+	"time"
+)
 
 // Referral представляет модель данных реферального кода
 type Referral struct {
@@ -11,14 +14,21 @@ type Referral struct {
 	UpdatedAt  time.Time `json:"updatedAt"`  // Дата последнего обновления информации о реферальном коде
 }
 
+// ReferralsResponse представляет страницу реферальных кодов пользователя
+type ReferralsResponse struct {
+	Referrals []Referral `json:"referrals"` // Реферальные коды на странице
+	Count     int        `json:"count"`     // Количество кодов на странице
+	pagination.Info
+}
+
 // CreateReferralRequest представляет запрос для создания нового реферального кода
 type CreateReferralRequest struct {
-	UserID string `json:"userId"` // Идентификатор пользователя, которому будет принадлежать код
-	Code   string `json:"code"`   // Сам реферальный код
+	UserID string `json:"userId" validate:"required,uuid"`  // Идентификатор пользователя, которому будет принадлежать код
+	Code   string `json:"code" validate:"required,max=255"` // Сам реферальный код
 }
 
 // UpdateReferralRequest представляет запрос для обновления существующего реферального кода
 type UpdateReferralRequest struct {
-	ReferralID string `json:"referralId"` // Уникальный идентификатор реферального кода
-	Code       string `json:"code"`       // Обновленный реферальный код
+	ReferralID string `json:"referralId"`                       // Уникальный идентификатор реферального кода
+	Code       string `json:"code" validate:"required,max=255"` // Обновленный реферальный код
 }
@@ -0,0 +1,46 @@
+package models
+
+// SearchType определяет, среди каких объектов выполняется поиск
+type SearchType string
+
+const (
+	SearchAll   SearchType = "all"   // Задачи и пользователи
+	SearchTasks SearchType = "tasks" // Только задачи
+	SearchUsers SearchType = "users" // Только пользователи
+)
+
+// IsValid проверяет, что тип поиска известен
+func (t SearchType) IsValid() bool {
+	switch t {
+	case SearchAll, SearchTasks, SearchUsers:
+		return true
+	default:
+		return false
+	}
+}
+
+// SearchFilter описывает поисковый запрос
+type SearchFilter struct {
+	Query  string     // Текст запроса; каждое слово ищется по префиксу
+	Type   SearchType // Тип искомых объектов
+	Limit  int        // Максимальное количество результатов
+	Offset int        // Смещение
+}
+
+// SearchResult представляет найденный объект
+type SearchResult struct {
+	Type    string  `json:"type"`    // Тип объекта: task или user
+	ID      string  `json:"id"`      // Идентификатор объекта
+	Title   string  `json:"title"`   // Заголовок задачи или имя пользователя с выделенными совпадениями
+	Snippet string  `json:"snippet"` // Фрагмент описания или биографии с выделенными совпадениями
+	Rank    float64 `json:"rank"`    // Релевантность
+}
+
+// SearchResponse представляет страницу результатов поиска
+type SearchResponse struct {
+	Query   string         `json:"query"`   // Исходный запрос
+	Type    SearchType     `json:"type"`    // Тип поиска
+	Results []SearchResult `json:"results"` // Результаты по убыванию релевантности
+	Limit   int            `json:"limit"`   // Размер страницы
+	Offset  int            `json:"offset"`  // Смещение
+}
@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// SubmissionStatus определяет состояние заявки на выполнение задачи
+type SubmissionStatus string
+
+const (
+	SubmissionPending  SubmissionStatus = "pending"  // Заявка ожидает модерации
+	SubmissionApproved SubmissionStatus = "approved" // Заявка одобрена, вознаграждение начислено
+	SubmissionRejected SubmissionStatus = "rejected" // Заявка отклонена модератором
+)
+
+// IsValid проверяет, что статус заявки известен
+func (s SubmissionStatus) IsValid() bool {
+	switch s {
+	case SubmissionPending, SubmissionApproved, SubmissionRejected:
+		return true
+	default:
+		return false
+	}
+}
+
+// EvidenceType определяет вид доказательства выполнения задачи
+type EvidenceType string
+
+const (
+	EvidenceURL  EvidenceType = "url"  // Ссылка на скриншот, публикацию и т.п.
+	EvidenceText EvidenceType = "text" // Произвольный текст
+)
+
+// Submission представляет заявку пользователя на выполнение задачи с доказательством
+type Submission struct {
+	ID           int64            `json:"id"`                    // Идентификатор заявки
+	TaskID       string           `json:"task_id"`               // Идентификатор задачи
+	UserID       string           `json:"user_id"`               // Пользователь, отправивший заявку
+	EvidenceType EvidenceType     `json:"evidence_type"`         // Вид доказательства
+	Evidence     string           `json:"evidence"`              // Ссылка или текст доказательства
+	Status       SubmissionStatus `json:"status"`                // Состояние модерации
+	Reason       string           `json:"reason,omitempty"`      // Причина решения модератора
+	ReviewedBy   *string          `json:"reviewed_by,omitempty"` // Модератор, принявший решение
+	ReviewedAt   *time.Time       `json:"reviewed_at,omitempty"` // Время решения
+	CreatedAt    time.Time        `json:"created_at"`            // Время отправки заявки
+}
+
+// CreateSubmissionRequest представляет запрос на отправку заявки
+type CreateSubmissionRequest struct {
+	UserID       string       `json:"user_id" validate:"required,uuid"`                 // Пользователь, выполнивший задачу
+	EvidenceType EvidenceType `json:"evidence_type" validate:"required,oneof=url text"` // Вид доказательства
+	Evidence     string       `json:"evidence" validate:"required,max=4096"`            // Ссылка или текст доказательства
+}
+
+// ReviewSubmissionRequest представляет решение модератора по заявке
+type ReviewSubmissionRequest struct {
+	Reason string `json:"reason,omitempty" validate:"max=1024"` // Причина решения (обязательна при отклонении)
+}
+
+// SubmissionFilter используется для выборки очереди модерации
+type SubmissionFilter struct {
+	Status SubmissionStatus `json:"status,omitempty"`  // Состояние заявок
+	TaskID string           `json:"task_id,omitempty"` // Заявки по задаче
+	UserID string           `json:"user_id,omitempty"` // Заявки пользователя
+	Limit  int              `json:"limit,omitempty"`   // Максимальное количество записей
+	Offset int              `json:"offset,omitempty"`  // Смещение от начала очереди
+}
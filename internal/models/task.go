@@ -1,6 +1,7 @@
 package models
 
 import (
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
 	"time"
 )
 
@@ -12,6 +13,7 @@ const (
 	InProgress
 	Completed
 	Canceled
+	Expired // Срок выполнения истек; выставляется автоматически
 )
 
 // Task представляет собой задание
@@ -24,15 +26,32 @@ type Task struct {
 	DueDate     *time.Time `json:"due_date,omitempty"`          // Дедлайн (необязательный)
 	Status      TaskStatus `json:"status"`                      // Статус задания
 	AssigneeID  *string    `json:"assignee_id,omitempty"`       // Уникальный идентификатор исполнителя (необязательный)
+	Reward      float64    `json:"reward"`                      // Вознаграждение за выполнение задания
+	CompletedBy *string    `json:"completed_by,omitempty"`      // Пользователь, получивший вознаграждение за выполнение
+	// Режим назначения задачи: assigned (только назначенные пользователи), open (любой пользователь), capped (первые MaxClaims пользователей)
+	AssignmentMode TaskAssignmentMode `json:"assignment_mode"`
+	MaxClaims      *int               `json:"max_claims,omitempty"` // Максимальное число взявших задачу пользователей для режима capped
+	// Выполнение засчитывается только после одобрения заявки с доказательством
+	RequiresEvidence bool       `json:"requires_evidence"`
+	CampaignID       *string    `json:"campaign_id,omitempty"` // Кампания, из бюджета которой выплачивается вознаграждение
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`  // Время мягкого удаления (nil для активных задач)
 }
 
 // BaseTaskRequest представляет собой базовую структуру для создания и обновления задания
 type BaseTaskRequest struct {
-	Title       string     `json:"title" validate:"required"`  // Заголовок задания
-	Description string     `json:"description,omitempty"`      // Описание задания
-	DueDate     *time.Time `json:"due_date,omitempty"`         // Дедлайн (необязательный)
-	Status      TaskStatus `json:"status" validate:"required"` // Статус задания, теперь обязательный
-	AssigneeID  *string    `json:"assignee_id,omitempty"`      // Уникальный идентификатор исполнителя (необязательный)
+	Title       string     `json:"title" validate:"required,max=255"`              // Заголовок задания
+	Description string     `json:"description,omitempty" validate:"max=4096"`      // Описание задания
+	DueDate     *time.Time `json:"due_date,omitempty" validate:"omitempty,future"` // Дедлайн (необязательный); новый дедлайн не в прошлом
+	Status      TaskStatus `json:"status" validate:"omitempty,oneof=1 2 3 4 5"`    // Статус задания; по умолчанию Not Started
+	AssigneeID  *string    `json:"assignee_id,omitempty"`                          // Уникальный идентификатор исполнителя (необязательный)
+	Reward      float64    `json:"reward" validate:"gte=0"`                        // Вознаграждение за выполнение задания
+	// Режим назначения задачи; по умолчанию assigned
+	AssignmentMode TaskAssignmentMode `json:"assignment_mode,omitempty" validate:"omitempty,oneof=assigned open capped"`
+	MaxClaims      *int               `json:"max_claims,omitempty" validate:"omitempty,gt=0"` // Лимит взявших задачу для режима capped
+	AssigneeIDs    []string           `json:"assignee_ids,omitempty" validate:"dive,uuid"`    // Явно назначенные пользователи с собственным прогрессом
+	// Требовать подтверждение выполнения заявкой с доказательством; nil при обновлении оставляет значение без изменений
+	RequiresEvidence *bool   `json:"requires_evidence,omitempty"`
+	CampaignID       *string `json:"campaign_id,omitempty"` // Кампания задачи; при обновлении пустая строка отвязывает задачу
 }
 
 // CreateTaskRequest представляет собой запрос на создание задания
@@ -42,23 +61,67 @@ type CreateTaskRequest struct {
 
 // UpdateTaskRequest представляет собой запрос на обновление задания
 type UpdateTaskRequest struct {
-	TaskID string `json:"task_id" validate:"required"` // Уникальный идентификатор задания
+	TaskID string `json:"task_id" validate:"required,uuid"` // Уникальный идентификатор задания
 	BaseTaskRequest
 	UpdatedAt time.Time `json:"updated_at"` // Дата и время последнего обновления записи
 }
 
 // TaskFilter используется для фильтрации задач
 type TaskFilter struct {
-	Title         string     `json:"title,omitempty"` // Фильтрация по заголовку
-	Description   string     `json:"description,omitempty"`
-	Status        TaskStatus `json:"status,omitempty"`         // Заменили string на TaskStatus для типизации
-	AssigneeID    string     `json:"assignee_id,omitempty"`    // Фильтрация по ID исполнителя
-	CreatedAfter  *time.Time `json:"created_after,omitempty"`  // Исправлено имя поля
-	CreatedBefore *time.Time `json:"created_before,omitempty"` // Исправлено имя поля
-	DueAfter      *time.Time `json:"due_after,omitempty"`      // Исправлено имя поля
-	DueBefore     *time.Time `json:"due_before,omitempty"`     // Исправлено имя поля
-	Page          int        `json:"page"`                     // Номер текущей страницы
-	PageSize      int        `json:"page_size"`                // Размер страницы (количество элементов на странице)
+	Title          string       `json:"title,omitempty"` // Фильтрация по заголовку
+	Description    string       `json:"description,omitempty"`
+	Status         TaskStatus   `json:"status,omitempty"`          // Заменили string на TaskStatus для типизации
+	Statuses       []TaskStatus `json:"statuses,omitempty"`        // Фильтрация по любому из статусов
+	AssigneeID     string       `json:"assignee_id,omitempty"`     // Фильтрация по ID исполнителя
+	CreatedAfter   *time.Time   `json:"created_after,omitempty"`   // Исправлено имя поля
+	CreatedBefore  *time.Time   `json:"created_before,omitempty"`  // Исправлено имя поля
+	DueAfter       *time.Time   `json:"due_after,omitempty"`       // Исправлено имя поля
+	DueBefore      *time.Time   `json:"due_before,omitempty"`      // Исправлено имя поля
+	Page           int          `json:"page"`                      // Номер текущей страницы
+	PageSize       int          `json:"page_size"`                 // Размер страницы (количество элементов на странице)
+	IncludeDeleted bool         `json:"include_deleted,omitempty"` // Включать мягко удаленные задачи (только для администраторов)
+	CampaignID     string       `json:"campaign_id,omitempty"`     // Фильтрация по кампании
+
+	// Pagination включает выборку по курсору вместо номера страницы; Page и PageSize при этом не используются
+	Pagination *pagination.Page `json:"-"`
+}
+
+// taskTransitions - граф допустимых переходов между статусами задачи.
+// Completed -> InProgress означает повторное открытие задачи с возвратом начисленного вознаграждения.
+// Expired -> NotStarted означает повторное открытие просроченной задачи после переноса срока.
+var taskTransitions = map[TaskStatus][]TaskStatus{
+	NotStarted: {InProgress, Completed, Canceled, Expired},
+	InProgress: {NotStarted, Completed, Canceled, Expired},
+	Completed:  {InProgress},
+	Canceled:   {NotStarted},
+	Expired:    {NotStarted},
+}
+
+// IsValid проверяет, что статус задачи известен
+func (s TaskStatus) IsValid() bool {
+	_, ok := taskTransitions[s]
+	return ok
+}
+
+// CanTransitionTo проверяет, разрешен ли переход из текущего статуса в статус to
+func (s TaskStatus) CanTransitionTo(to TaskStatus) bool {
+	for _, next := range taskTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsClosed проверяет, что задача в этом статусе больше не выполняется: выполнена, отменена или просрочена
+func (s TaskStatus) IsClosed() bool {
+	return s == Completed || s == Canceled || s == Expired
+}
+
+// PastDue проверяет, что срок выполнения dueDate раньше deadline. Для проверки к моменту now
+// с отсрочкой grace передается deadline = now - grace. Задача без срока не просрочивается.
+func PastDue(dueDate *time.Time, deadline time.Time) bool {
+	return dueDate != nil && dueDate.Before(deadline)
 }
 
 // String возвращает строковое представление статуса задачи
@@ -72,18 +135,22 @@ func (s TaskStatus) String() string {
 		return "Completed"
 	case Canceled:
 		return "Canceled"
+	case Expired:
+		return "Expired"
 	default:
 		return "Unknown"
 	}
 }
 
 // TaskResponse представляет структуру ответа со списком задач и информацией о пагинации.
+// При выборке по курсору номер страницы и общее количество не вычисляются, вместо них возвращаются курсоры.
 type TaskResponse struct {
-	Tasks      []Task `json:"tasks"`       // Список задач
-	Page       int    `json:"page"`        // Номер текущей страницы
-	TotalPages int    `json:"total_pages"` // Общее количество страниц
-	TotalItems int    `json:"total_items"` // Общее количество задач
-	PageSize   int    `json:"page_size"`   // Количество элементов на странице
+	Tasks      []Task `json:"tasks"`                 // Список задач
+	Page       int    `json:"page,omitempty"`        // Номер текущей страницы
+	TotalPages int    `json:"total_pages,omitempty"` // Общее количество страниц
+	TotalItems int    `json:"total_items,omitempty"` // Общее количество задач
+	PageSize   int    `json:"page_size"`             // Количество элементов на странице
+	pagination.Info
 }
 
 // DescriptionResponse представляет структуру ответа с текстом куплетов и информацией о пагинации.
@@ -93,3 +160,67 @@ type DescriptionResponse struct {
 	TotalPages  int    `json:"total_pages"`           // Общее количество страниц
 	PageSize    int    `json:"page_size"`             // Количество элементов на странице
 }
+
+// TaskStatusChange представляет запись истории переходов статуса задачи
+type TaskStatusChange struct {
+	ID          int64      `json:"id"`                // Идентификатор записи
+	TaskID      string     `json:"task_id"`           // Идентификатор задачи
+	FromStatus  TaskStatus `json:"from_status"`       // Предыдущий статус
+	ToStatus    TaskStatus `json:"to_status"`         // Новый статус
+	Actor       string     `json:"actor"`             // Инициатор перехода
+	UserID      *string    `json:"user_id,omitempty"` // Пользователь, от имени которого выполнен переход
+	RewardDelta float64    `json:"reward_delta"`      // Начисленное (>0) или возвращенное (<0) вознаграждение
+	CreatedAt   time.Time  `json:"created_at"`        // Время перехода
+}
+
+// TaskAssignmentMode определяет, кто может взять задачу
+type TaskAssignmentMode string
+
+const (
+	AssignmentAssigned TaskAssignmentMode = "assigned" // Задачу выполняют только назначенные пользователи
+	AssignmentOpen     TaskAssignmentMode = "open"     // Задачу может взять любой пользователь
+	AssignmentCapped   TaskAssignmentMode = "capped"   // Задачу могут взять первые MaxClaims пользователей
+)
+
+// IsValid проверяет, что режим назначения известен
+func (m TaskAssignmentMode) IsValid() bool {
+	switch m {
+	case AssignmentAssigned, AssignmentOpen, AssignmentCapped:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsShared сообщает, берут ли задачу пользователи самостоятельно
+func (m TaskAssignmentMode) IsShared() bool {
+	return m == AssignmentOpen || m == AssignmentCapped
+}
+
+// TaskAssignment представляет участие пользователя в задаче и его личный прогресс
+type TaskAssignment struct {
+	TaskID       string     `json:"task_id"`                // Идентификатор задачи
+	UserID       string     `json:"user_id"`                // Идентификатор пользователя
+	Progress     TaskStatus `json:"progress"`               // Статус выполнения задачи пользователем
+	ClaimedAt    time.Time  `json:"claimed_at"`             // Время, когда пользователь взял задачу или был назначен
+	ProgressedAt time.Time  `json:"progressed_at"`          // Время последнего изменения прогресса
+	CompletedAt  *time.Time `json:"completed_at,omitempty"` // Время выполнения задачи пользователем
+}
+
+// UserTask представляет задачу в списке задач пользователя вместе с его прогрессом
+type UserTask struct {
+	Task
+	Progress    TaskStatus `json:"progress"`               // Статус выполнения задачи пользователем
+	ClaimedAt   *time.Time `json:"claimed_at,omitempty"`   // Время, когда пользователь взял задачу (nil для задач с единственным исполнителем)
+	CompletedAt *time.Time `json:"completed_at,omitempty"` // Время выполнения задачи пользователем
+}
+
+// DueReminder описывает напоминание исполнителю о приближении срока выполнения задачи
+type DueReminder struct {
+	TaskID   string    `json:"task_id"`  // Идентификатор задачи
+	Title    string    `json:"title"`    // Заголовок задачи
+	DueDate  time.Time `json:"due_date"` // Срок выполнения
+	UserID   string    `json:"user_id"`  // Исполнитель
+	Username string    `json:"username"` // Имя исполнителя
+	Email    string    `json:"email"`    // Адрес для напоминания
+}
@@ -1,7 +1,8 @@
 package models
 
 import (
-	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
 	"time"
 )
 
@@ -48,6 +49,7 @@ type User struct {
 	Bio            string      `json:"Bio,omitempty"`
 	TimeZone       string      `json:"TimeZone,omitempty"`
 	Status         UserStatus  `json:"Status"`
+	DeletedAt      *time.Time  `json:"DeletedAt,omitempty"` // Время мягкого удаления (nil для активных записей)
 }
 
 // NewUser представляет модель для нового пользователя перед активацией
@@ -62,12 +64,12 @@ type NewUser struct {
 
 // CreateUserRequest представляет модель запроса на создание нового пользователя
 type CreateUserRequest struct {
-	Username     string     `json:"Username" validate:"required"`    // Имя пользователя, обязательное поле
-	Email        string     `json:"Email" validate:"required,email"` // Электронная почта, обязательное поле с валидацией на корректность
-	ReferralCode string     `json:"ReferralCode,omitempty"`          // Реферальный код, не обязательное поле
-	Bio          string     `json:"Bio,omitempty"`                   // Биография, не обязательное поле
-	TimeZone     string     `json:"TimeZone,omitempty"`              // Часовой пояс, не обязательное поле
-	Status       UserStatus `json:"Status"`
+	Username     string     `json:"Username" validate:"required,max=255"`            // Имя пользователя, обязательное поле
+	Email        string     `json:"Email" validate:"required,email,max=255"`         // Электронная почта, обязательное поле с валидацией на корректность
+	ReferralCode string     `json:"ReferralCode,omitempty" validate:"max=255"`       // Реферальный код, не обязательное поле
+	Bio          string     `json:"Bio,omitempty" validate:"max=1024"`               // Биография, не обязательное поле
+	TimeZone     string     `json:"TimeZone,omitempty" validate:"omitempty,iana_tz"` // Часовой пояс IANA, не обязательное поле
+	Status       UserStatus `json:"Status" validate:"omitempty,oneof=1 2 3 4"`       // Статус пользователя, по умолчанию Pending
 }
 
 // TopUser представляет пользователя с высшими показателями и использует User
@@ -80,28 +82,29 @@ type TopUser struct {
 type TopUsers struct {
 	Users []TopUser `json:"users"` // Список пользователей в топе
 	Count int       `json:"count"` // Общее количество пользователей в топе
+	pagination.Info
 }
 
 // UpdateUserRequest представляет модель запроса на обновление информации о пользователе.
 type UpdateUserRequest struct {
-	UserID       string      `json:"ID" validate:"required"`           // Идентификатор пользователя, обязательное поле
-	Username     *string     `json:"Username,omitempty"`               // Имя пользователя, может быть пустым
-	Email        *string     `json:"Email,omitempty" validate:"email"` // Электронная почта, может быть пустым, но если присутствует – должна соответствовать валидации email
-	Balance      *float64    `json:"Balance,omitempty"`                // Баланс, может быть пустым
-	ReferralCode *string     `json:"ReferralCode,omitempty"`           // Реферальный код, может быть пустым
-	Bio          *string     `json:"Bio,omitempty"`                    // Биография, может быть пустым
-	TimeZone     *string     `json:"TimeZone,omitempty"`               // Часовой пояс, может быть пустым
-	Status       *UserStatus `json:"Status,omitempty"`                 // Статус пользователя, может быть пустым
+	UserID       string      `json:"ID" validate:"required,uuid"`                           // Идентификатор пользователя, обязательное поле
+	Username     *string     `json:"Username,omitempty" validate:"omitempty,min=1,max=255"` // Имя пользователя, может быть пустым
+	Email        *string     `json:"Email,omitempty" validate:"omitempty,email,max=255"`    // Электронная почта, может быть пустым, но если присутствует – должна соответствовать валидации email
+	Balance      *float64    `json:"Balance,omitempty" validate:"omitempty,gte=0"`          // Баланс, может быть пустым
+	ReferralCode *string     `json:"ReferralCode,omitempty" validate:"omitempty,max=255"`   // Реферальный код, может быть пустым
+	Bio          *string     `json:"Bio,omitempty" validate:"omitempty,max=1024"`           // Биография, может быть пустым
+	TimeZone     *string     `json:"TimeZone,omitempty" validate:"omitempty,iana_tz"`       // Часовой пояс IANA, может быть пустым
+	Status       *UserStatus `json:"Status,omitempty" validate:"omitempty,oneof=1 2 3 4"`   // Статус пользователя, может быть пустым
 }
 
 // Структура краткой информации о пользователе
 type UserSummary struct {
-	ID             string  // Уникальный идентификатор
-	Username       string  // Имя пользователя
-	Email          string  // Адрес электронной почты
-	Balance        float64 // Баланс пользователя
-	Referrals      int
-	TasksCompleted int
+	ID             string    // Уникальный идентификатор
+	Username       string    // Имя пользователя
+	Email          string    // Адрес электронной почты
+	Balance        float64   // Баланс пользователя
+	Referrals      int       // Количество приглашенных пользователей
+	TasksCompleted int       // Количество выполненных задач
 	CreatedAt      time.Time // Дата создания аккаунта
 }
 
@@ -109,7 +112,7 @@ type UserSummary struct {
 func (u *User) UpdateBalance(amount float64) error {
 	newBalance := u.Balance + amount
 	if newBalance < 0 {
-		return fmt.Errorf("invalid balance: cannot go below zero")
+		return errors.NewValidation("invalid balance: cannot go below zero", nil).WithCode(errors.CodeInsufficientBalance)
 	}
 	now := time.Now()
 	u.Balance = newBalance
@@ -170,8 +173,59 @@ func BrandNewUser(email, username string, status UserStatus) *User {
 	}
 }
 
+// UserSortField - поле, по которому сортируется список пользователей
+type UserSortField string
+
+const (
+	UserSortCreatedAt      UserSortField = "created_at"
+	UserSortUsername       UserSortField = "username"
+	UserSortBalance        UserSortField = "balance"
+	UserSortReferrals      UserSortField = "referrals"
+	UserSortTasksCompleted UserSortField = "tasks_completed"
+	UserSortLastVisit      UserSortField = "last_visit"
+)
+
+// IsValid проверяет, поддерживается ли сортировка по полю
+func (f UserSortField) IsValid() bool {
+	switch f {
+	case UserSortCreatedAt, UserSortUsername, UserSortBalance, UserSortReferrals, UserSortTasksCompleted, UserSortLastVisit:
+		return true
+	}
+	return false
+}
+
+// UserSort - поле сортировки и ее направление
+type UserSort struct {
+	Field UserSortField `json:"field"`
+	Desc  bool          `json:"desc,omitempty"`
+}
+
+// UserFilter используется для фильтрации и сортировки списка пользователей.
+// Пустые поля не ограничивают выборку.
+type UserFilter struct {
+	Username        string     `json:"username,omitempty"`          // Часть имени пользователя без учета регистра
+	Status          UserStatus `json:"status,omitempty"`            // Статус пользователя
+	EmailDomain     string     `json:"email_domain,omitempty"`      // Домен электронной почты без учета регистра
+	MinBalance      *float64   `json:"min_balance,omitempty"`       // Баланс не меньше
+	MaxBalance      *float64   `json:"max_balance,omitempty"`       // Баланс не больше
+	CreatedAfter    *time.Time `json:"created_after,omitempty"`     // Зарегистрирован не раньше
+	CreatedBefore   *time.Time `json:"created_before,omitempty"`    // Зарегистрирован не позже
+	MinReferrals    *int       `json:"min_referrals,omitempty"`     // Приглашено не меньше
+	MaxReferrals    *int       `json:"max_referrals,omitempty"`     // Приглашено не больше
+	HasReferrer     *bool      `json:"has_referrer,omitempty"`      // Зарегистрирован (или нет) по реферальному коду
+	LastVisitBefore *time.Time `json:"last_visit_before,omitempty"` // Последнее посещение раньше (в том числе не посещавшие)
+	IncludeDeleted  bool       `json:"include_deleted,omitempty"`   // Включать мягко удаленных пользователей (только для администраторов)
+
+	// Sort - поля сортировки в порядке приоритета; по умолчанию - от новых пользователей к старым.
+	// При равенстве всех полей пользователи упорядочиваются по ID.
+	Sort []UserSort `json:"sort,omitempty"`
+
+	Pagination pagination.Page `json:"-"`
+}
+
 // UsersResponse представляет структуру ответа.
 type UsersResponse struct {
 	Users []*User `json:"users"`
 	Count int     `json:"count"` // total user count can be included
+	pagination.Info
 }
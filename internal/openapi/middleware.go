@@ -0,0 +1,167 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/problem"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+// Middleware проверяет запросы по операциям спецификации doc. Нарушения в параметрах пути, строки
+// запроса и заголовках, а также неразбираемое тело возвращаются с кодом 400, тело, нарушающее схему, -
+// с кодом 422 в /v2 и 400 в v1; все нарушения перечисляются в ответе application/problem+json.
+// Запросы к маршрутам, которых нет в спецификации, передаются обработчику без проверки.
+func Middleware(doc *Document) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			op := doc.routeOperation(r)
+			if op == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if errs := doc.validateParameters(op, r); len(errs) > 0 {
+				p := problem.New(http.StatusBadRequest, errors.CodeInvalidParameters, "invalid request parameters")
+				p.Errors = errs
+				problem.Write(w, r, p)
+				return
+			}
+			if p := doc.validateBody(op, r); p != nil {
+				if !strings.HasPrefix(r.URL.Path, "/v2/") {
+					p = problem.ForV1(p)
+				}
+				problem.Write(w, r, p)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// routeOperation возвращает операцию спецификации для маршрута, выбранного mux
+func (d *Document) routeOperation(r *http.Request) *Operation {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return nil
+	}
+	template, err := route.GetPathTemplate()
+	if err != nil {
+		return nil
+	}
+	return d.Operation(r.Method, PathTemplate(template))
+}
+
+// validateParameters проверяет параметры пути, строки запроса и заголовки
+func (d *Document) validateParameters(op *Operation, r *http.Request) []FieldError {
+	v := &validator{doc: d}
+	vars := mux.Vars(r)
+	query := r.URL.Query()
+	for _, p := range op.Parameters {
+		p = d.parameter(p)
+
+		var raw string
+		var present bool
+		switch p.In {
+		case "path":
+			raw, present = vars[p.Name]
+		case "query":
+			present = query.Has(p.Name) && query.Get(p.Name) != ""
+			raw = query.Get(p.Name)
+		case "header":
+			raw = r.Header.Get(p.Name)
+			present = raw != ""
+		}
+
+		v.in = p.In
+		if !present {
+			if p.Required {
+				v.fail(p.Name, "is required")
+			}
+			continue
+		}
+		v.validate(p.Schema, parameterValue(d.schema(p.Schema), raw), p.Name)
+	}
+	return v.errors
+}
+
+// parameterValue приводит строковое значение параметра к виду, в котором encoding/json с UseNumber
+// возвращает значения тела, чтобы параметры и тело проверялись одним кодом
+func parameterValue(s *Schema, raw string) interface{} {
+	if s == nil {
+		return raw
+	}
+	switch s.Type {
+	case "integer", "number":
+		return json.Number(raw)
+	case "boolean":
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b
+		}
+	}
+	return raw
+}
+
+// validateBody проверяет JSON-тело запроса и возвращает описание найденных нарушений или nil.
+// Прочитанное тело возвращается в запрос для обработчика.
+func (d *Document) validateBody(op *Operation, r *http.Request) *problem.Details {
+	if op.RequestBody == nil {
+		return nil
+	}
+	media, ok := op.RequestBody.Content["application/json"]
+	if !ok || media.Schema == nil {
+		return nil
+	}
+	bodyError := func(message string) *problem.Details {
+		p := problem.New(http.StatusBadRequest, errors.CodeInvalidBody, message)
+		p.Errors = []FieldError{{In: "body", Message: message}}
+		return p
+	}
+
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err != nil || (mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json")) {
+			return bodyError("Content-Type must be application/json")
+		}
+	}
+
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return bodyError("failed to read request body")
+		}
+		r.Body.Close()
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if len(bytes.TrimSpace(body)) == 0 {
+		if op.RequestBody.Required {
+			return bodyError("request body is required")
+		}
+		return nil
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return bodyError("request body is not valid JSON")
+	}
+
+	v := &validator{doc: d, in: "body"}
+	v.validate(media.Schema, value, "")
+	if len(v.errors) > 0 {
+		p := problem.New(http.StatusUnprocessableEntity, errors.CodeValidationFailed, "request body does not match the schema")
+		p.Errors = v.errors
+		return p
+	}
+	return nil
+}
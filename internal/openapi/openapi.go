@@ -0,0 +1,195 @@
+// Package openapi содержит спецификацию HTTP API в формате OpenAPI 3 (openapi.json), обработчики,
+// которые отдают ее клиентам вместе со страницей Swagger UI, и миддлвар, проверяющий входящие запросы
+// по этой спецификации до того, как они попадут в обработчики.
+//
+// Разбирается только та часть OpenAPI, которая используется в openapi.json: операции путей, параметры
+// пути, строки запроса и заголовков, JSON-тело запроса и ссылки $ref на components.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//go:embed openapi.json
+var spec []byte
+
+// Spec возвращает исходный текст спецификации
+func Spec() []byte {
+	return spec
+}
+
+// Document - разобранная спецификация
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// PathItem - операции пути по HTTP-методам в нижнем регистре
+type PathItem map[string]*Operation
+
+// Components - переиспользуемые схемы и параметры
+type Components struct {
+	Schemas    map[string]*Schema    `json:"schemas"`
+	Parameters map[string]*Parameter `json:"parameters"`
+}
+
+// Operation - операция над путем
+type Operation struct {
+	OperationID string       `json:"operationId"`
+	Parameters  []*Parameter `json:"parameters"`
+	RequestBody *RequestBody `json:"requestBody"`
+}
+
+// Parameter - параметр пути (path), строки запроса (query) или заголовок (header)
+type Parameter struct {
+	Ref      string  `json:"$ref"`
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody - тело запроса по типам содержимого
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// MediaType - схема тела для одного типа содержимого
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+const (
+	schemaRefPrefix    = "#/components/schemas/"
+	parameterRefPrefix = "#/components/parameters/"
+)
+
+// Load разбирает встроенную спецификацию и проверяет, что все ссылки $ref в ней разрешаются
+func Load() (*Document, error) {
+	var doc Document
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("parse openapi.json: %w", err)
+	}
+	if err := doc.check(); err != nil {
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// MustLoad - Load, завершающийся паникой при ошибке. Спецификация встроена в программу,
+// поэтому ошибка в ней - ошибка сборки, а не окружения.
+func MustLoad() *Document {
+	doc, err := Load()
+	if err != nil {
+		panic(err)
+	}
+	return doc
+}
+
+// Operation возвращает операцию для метода и пути в записи OpenAPI (/tasks/{task_id}) или nil
+func (d *Document) Operation(method, path string) *Operation {
+	item, ok := d.Paths[path]
+	if !ok {
+		return nil
+	}
+	return item[strings.ToLower(method)]
+}
+
+var muxVariable = regexp.MustCompile(`\{([^{}:]+):[^{}]*\}`)
+
+// PathTemplate переводит шаблон пути gorilla/mux в запись OpenAPI, отбрасывая регулярные выражения
+// переменных: /admin/export/{kind:users|tasks} -> /admin/export/{kind}
+func PathTemplate(muxTemplate string) string {
+	return muxVariable.ReplaceAllString(muxTemplate, "{$1}")
+}
+
+// parameter возвращает параметр с разрешенной ссылкой $ref
+func (d *Document) parameter(p *Parameter) *Parameter {
+	if p.Ref == "" {
+		return p
+	}
+	return d.Components.Parameters[strings.TrimPrefix(p.Ref, parameterRefPrefix)]
+}
+
+// schema возвращает схему с разрешенной ссылкой $ref
+func (d *Document) schema(s *Schema) *Schema {
+	for s != nil && s.Ref != "" {
+		s = d.Components.Schemas[strings.TrimPrefix(s.Ref, schemaRefPrefix)]
+	}
+	return s
+}
+
+// check проверяет ссылки $ref всех операций и компонентов
+func (d *Document) check() error {
+	for name, p := range d.Components.Parameters {
+		if err := d.checkParameter(p); err != nil {
+			return fmt.Errorf("parameter %s: %w", name, err)
+		}
+	}
+	for name, s := range d.Components.Schemas {
+		if err := d.checkSchema(s); err != nil {
+			return fmt.Errorf("schema %s: %w", name, err)
+		}
+	}
+	for path, item := range d.Paths {
+		for method, op := range item {
+			for _, p := range op.Parameters {
+				if err := d.checkParameter(p); err != nil {
+					return fmt.Errorf("%s %s: %w", strings.ToUpper(method), path, err)
+				}
+			}
+			if op.RequestBody == nil {
+				continue
+			}
+			for contentType, media := range op.RequestBody.Content {
+				if err := d.checkSchema(media.Schema); err != nil {
+					return fmt.Errorf("%s %s %s body: %w", strings.ToUpper(method), path, contentType, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (d *Document) checkParameter(p *Parameter) error {
+	if p.Ref != "" {
+		resolved := d.parameter(p)
+		if resolved == nil {
+			return fmt.Errorf("unresolved reference %s", p.Ref)
+		}
+		p = resolved
+	}
+	if p.Name == "" || (p.In != "path" && p.In != "query" && p.In != "header") {
+		return fmt.Errorf("parameter %q in %q is not supported", p.Name, p.In)
+	}
+	return d.checkSchema(p.Schema)
+}
+
+func (d *Document) checkSchema(s *Schema) error {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		if d.schema(s) == nil {
+			return fmt.Errorf("unresolved reference %s", s.Ref)
+		}
+		// Компоненты проверяются отдельно
+		return nil
+	}
+	children := append([]*Schema{s.Items}, s.AllOf...)
+	for _, property := range s.Properties {
+		children = append(children, property)
+	}
+	for _, child := range children {
+		if err := d.checkSchema(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
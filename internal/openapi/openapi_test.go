@@ -0,0 +1,229 @@
+package openapi
+
+import (
+	"encoding/json"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/problem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestLoad(t *testing.T) {
+	doc, err := Load()
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	if doc.Operation("GET", "/tasks/{task_id}") == nil {
+		t.Fatalf("GET /tasks/{task_id} is not documented")
+	}
+	if doc.Operation("PATCH", "/tasks/{task_id}") != nil {
+		t.Fatalf("unexpected PATCH /tasks/{task_id}")
+	}
+}
+
+func TestPathTemplate(t *testing.T) {
+	tests := map[string]string{
+		"/tasks/{task_id}":                   "/tasks/{task_id}",
+		"/admin/export/{kind:users|tasks}":   "/admin/export/{kind}",
+		"/moderation/{id:[0-9]+}/approve":    "/moderation/{id}/approve",
+		"/users/{user_id}/quests/{quest_id}": "/users/{user_id}/quests/{quest_id}",
+	}
+	for template, want := range tests {
+		if got := PathTemplate(template); got != want {
+			t.Errorf("PathTemplate(%q) = %q, want %q", template, got, want)
+		}
+	}
+}
+
+// newTestRouter возвращает маршрутизатор с проверкой запросов; обработчики возвращают полученное тело
+func newTestRouter(t *testing.T) *mux.Router {
+	t.Helper()
+	doc, err := Load()
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}
+	r := mux.NewRouter()
+	r.Use(Middleware(doc))
+	r.HandleFunc("/tasks", echo).Methods("POST")
+	r.HandleFunc("/tasks/{task_id}", echo).Methods("GET")
+	r.HandleFunc("/tasks/{task_id}/claim", echo).Methods("POST")
+	r.HandleFunc("/tasks/{task_id}/status/{user_id}", echo).Methods("PATCH")
+	r.HandleFunc("/users", echo).Methods("GET", "POST")
+	r.HandleFunc("/admin/export/{kind:users|tasks|ledger}", echo).Methods("GET")
+	r.HandleFunc("/v2/users", echo).Methods("POST")
+	r.HandleFunc("/undocumented", echo).Methods("POST")
+	return r
+}
+
+func TestMiddleware(t *testing.T) {
+	const taskID = "0b5d8f3e-8a2c-4f61-b7a4-3c9e1d2f6a71"
+	const userID = "6f1c1d36-3c1e-4b7a-9d55-0a7c2e9f4b10"
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		status      int
+		code        string
+		fields      []FieldError
+	}{
+		{
+			name:   "valid body reaches handler",
+			method: "POST", target: "/tasks",
+			body:   `{"title": "Write docs", "reward": 10, "due_date": "2030-01-02T15:04:05Z"}`,
+			status: http.StatusOK,
+		},
+		{
+			name:   "all body violations are reported",
+			method: "POST", target: "/tasks",
+			body:   `{"description": 5, "reward": -1, "status": 3, "assignee_ids": ["x"], "due_date": "tomorrow"}`,
+			status: http.StatusBadRequest,
+			code:   errors.CodeValidationFailed,
+			fields: []FieldError{
+				{In: "body", Field: "title", Message: "is required"},
+				{In: "body", Field: "assignee_ids[0]", Message: "must be a valid UUID"},
+				{In: "body", Field: "description", Message: "must be a string"},
+				{In: "body", Field: "due_date", Message: "must be an RFC 3339 date-time"},
+				{In: "body", Field: "reward", Message: "must be greater than or equal to 0"},
+				{In: "body", Field: "status", Message: "must be one of 1, 2"},
+			},
+		},
+		{
+			name:   "field in wrong case is suggested",
+			method: "POST", target: "/users",
+			body:   `{"username": "alice", "Email": "alice@example.com"}`,
+			status: http.StatusBadRequest,
+			code:   errors.CodeValidationFailed,
+			fields: []FieldError{
+				{In: "body", Field: "Username", Message: "is required"},
+				{In: "body", Field: "username", Message: `unknown field, did you mean "Username"?`},
+			},
+		},
+		{
+			name:   "v2 body violations are 422",
+			method: "POST", target: "/v2/users",
+			body:   `{"username": "alice"}`,
+			status: http.StatusUnprocessableEntity,
+			code:   errors.CodeValidationFailed,
+			fields: []FieldError{{In: "body", Field: "email", Message: "is required"}},
+		},
+		{
+			name:   "malformed JSON",
+			method: "POST", target: "/tasks",
+			body:   `{"title": `,
+			status: http.StatusBadRequest,
+			code:   errors.CodeInvalidBody,
+			fields: []FieldError{{In: "body", Message: "request body is not valid JSON"}},
+		},
+		{
+			name:   "missing required body",
+			method: "POST", target: "/tasks",
+			status: http.StatusBadRequest,
+			code:   errors.CodeInvalidBody,
+			fields: []FieldError{{In: "body", Message: "request body is required"}},
+		},
+		{
+			name:   "optional body may be omitted",
+			method: "POST", target: "/tasks/" + taskID + "/claim",
+			status: http.StatusOK,
+		},
+		{
+			name:   "wrong content type",
+			method: "POST", target: "/tasks", contentType: "text/plain",
+			body:   `{"title": "Write docs"}`,
+			status: http.StatusBadRequest,
+			code:   errors.CodeInvalidBody,
+			fields: []FieldError{{In: "body", Message: "Content-Type must be application/json"}},
+		},
+		{
+			name:   "invalid path parameter",
+			method: "GET", target: "/tasks/42",
+			status: http.StatusBadRequest,
+			code:   errors.CodeInvalidParameters,
+			fields: []FieldError{{In: "path", Field: "task_id", Message: "must be a valid UUID"}},
+		},
+		{
+			name:   "missing required query parameter",
+			method: "PATCH", target: "/tasks/" + taskID + "/status/" + userID,
+			status: http.StatusBadRequest,
+			code:   errors.CodeInvalidParameters,
+			fields: []FieldError{{In: "query", Field: "status", Message: "is required"}},
+		},
+		{
+			name:   "query parameter types",
+			method: "GET", target: "/users?min_balance=abc&has_referrer=maybe&limit=500&created_after=2024-01-01",
+			status: http.StatusBadRequest,
+			code:   errors.CodeInvalidParameters,
+			fields: []FieldError{
+				{In: "query", Field: "min_balance", Message: "must be a number"},
+				{In: "query", Field: "has_referrer", Message: "must be a boolean"},
+				{In: "query", Field: "created_after", Message: "must be an RFC 3339 date-time"},
+				{In: "query", Field: "limit", Message: "must be less than or equal to 100"},
+			},
+		},
+		{
+			name:   "valid query parameters",
+			method: "GET", target: "/users?min_balance=1.5&has_referrer=true&limit=5&status=Active",
+			status: http.StatusOK,
+		},
+		{
+			name:   "mux pattern variables",
+			method: "GET", target: "/admin/export/ledger?format=xml",
+			status: http.StatusBadRequest,
+			code:   errors.CodeInvalidParameters,
+			fields: []FieldError{{In: "query", Field: "format", Message: `must be one of "csv", "ndjson"`}},
+		},
+		{
+			name:   "undocumented route is not checked",
+			method: "POST", target: "/undocumented",
+			body:   `not json`,
+			status: http.StatusOK,
+		},
+	}
+
+	router := newTestRouter(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			} else if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d; body: %s", rec.Code, tt.status, rec.Body)
+			}
+			if tt.status == http.StatusOK {
+				if rec.Body.String() != tt.body {
+					t.Fatalf("handler got body %q, want %q", rec.Body, tt.body)
+				}
+				return
+			}
+			if contentType := rec.Header().Get("Content-Type"); contentType != problem.ContentType {
+				t.Fatalf("Content-Type %q, want %q", contentType, problem.ContentType)
+			}
+			var resp problem.Details
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v; body: %s", err, rec.Body)
+			}
+			if resp.Status != tt.status || resp.Code != tt.code || !reflect.DeepEqual(resp.Errors, tt.fields) {
+				t.Fatalf("problem:\n got: %d %s %+v\nwant: %d %s %+v", resp.Status, resp.Code, resp.Errors, tt.status, tt.code, tt.fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/problem"
+	"net/mail"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Schema - схема значения (подмножество JSON Schema из OpenAPI 3.0)
+type Schema struct {
+	Ref                  string             `json:"$ref"`
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Nullable             bool               `json:"nullable"`
+	Enum                 []interface{}      `json:"enum"`
+	Minimum              *float64           `json:"minimum"`
+	Maximum              *float64           `json:"maximum"`
+	MinLength            *int               `json:"minLength"`
+	MaxLength            *int               `json:"maxLength"`
+	MinItems             *int               `json:"minItems"`
+	Items                *Schema            `json:"items"`
+	Properties           map[string]*Schema `json:"properties"`
+	Required             []string           `json:"required"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	AllOf                []*Schema          `json:"allOf"`
+}
+
+// FieldError - нарушение схемы в одном поле запроса
+type FieldError = problem.FieldError
+
+// validator собирает нарушения схемы в одной части запроса
+type validator struct {
+	doc    *Document
+	in     string
+	errors []FieldError
+}
+
+func (v *validator) fail(field, format string, args ...interface{}) {
+	v.errors = append(v.errors, FieldError{In: v.in, Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// validate проверяет значение, разобранное encoding/json с UseNumber, по схеме s
+func (v *validator) validate(s *Schema, value interface{}, field string) {
+	s = v.doc.schema(s)
+	if s == nil {
+		return
+	}
+	for _, part := range s.AllOf {
+		v.validate(part, value, field)
+	}
+	if value == nil {
+		if s.Type != "" && !s.Nullable {
+			v.fail(field, "must not be null")
+		}
+		return
+	}
+
+	switch s.Type {
+	case "object":
+		v.validateObject(s, value, field)
+	case "array":
+		v.validateArray(s, value, field)
+	case "string":
+		v.validateString(s, value, field)
+	case "integer", "number":
+		v.validateNumber(s, value, field)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			v.fail(field, "must be a boolean")
+		}
+	}
+}
+
+func (v *validator) validateObject(s *Schema, value interface{}, field string) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		v.fail(field, "must be an object")
+		return
+	}
+	for _, name := range s.Required {
+		if _, ok := object[name]; !ok {
+			v.fail(joinField(field, name), "is required")
+		}
+	}
+
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if property, ok := s.Properties[name]; ok {
+			v.validate(property, object[name], joinField(field, name))
+		} else if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+			v.fail(joinField(field, name), "%s", unknownField(s, name))
+		}
+	}
+}
+
+// unknownField описывает неизвестное поле; поля разных ресурсов названы в разном регистре,
+// поэтому для поля, отличающегося от известного только регистром, подсказывается правильное написание
+func unknownField(s *Schema, name string) string {
+	normalized := strings.ReplaceAll(strings.ToLower(name), "_", "")
+	for property := range s.Properties {
+		if strings.ReplaceAll(strings.ToLower(property), "_", "") == normalized {
+			return fmt.Sprintf("unknown field, did you mean %q?", property)
+		}
+	}
+	return "unknown field"
+}
+
+func (v *validator) validateArray(s *Schema, value interface{}, field string) {
+	items, ok := value.([]interface{})
+	if !ok {
+		v.fail(field, "must be an array")
+		return
+	}
+	if s.MinItems != nil && len(items) < *s.MinItems {
+		v.fail(field, "must contain at least %d items", *s.MinItems)
+	}
+	for i, item := range items {
+		v.validate(s.Items, item, fmt.Sprintf("%s[%d]", field, i))
+	}
+}
+
+func (v *validator) validateString(s *Schema, value interface{}, field string) {
+	str, ok := value.(string)
+	if !ok {
+		v.fail(field, "must be a string")
+		return
+	}
+	length := utf8.RuneCountInString(str)
+	if s.MinLength != nil && length < *s.MinLength {
+		if *s.MinLength == 1 {
+			v.fail(field, "must not be empty")
+		} else {
+			v.fail(field, "must be at least %d characters long", *s.MinLength)
+		}
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		v.fail(field, "must be at most %d characters long", *s.MaxLength)
+	}
+	if message := checkFormat(s.Format, str); message != "" {
+		v.fail(field, "%s", message)
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, str) {
+		v.fail(field, "must be one of %s", formatEnum(s.Enum))
+	}
+}
+
+// checkFormat проверяет строку по формату схемы и возвращает описание нарушения
+func checkFormat(format, value string) string {
+	switch format {
+	case "uuid":
+		if _, err := uuid.Parse(value); err != nil {
+			return "must be a valid UUID"
+		}
+	case "email":
+		if address, err := mail.ParseAddress(value); err != nil || address.Address != value {
+			return "must be a valid email address"
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return "must be an RFC 3339 date-time"
+		}
+	}
+	return ""
+}
+
+func (v *validator) validateNumber(s *Schema, value interface{}, field string) {
+	number, ok := value.(json.Number)
+	if !ok {
+		v.fail(field, "must be %s", article(s.Type))
+		return
+	}
+	f, err := number.Float64()
+	if err == nil && s.Type == "integer" {
+		_, err = number.Int64()
+	}
+	if err != nil {
+		v.fail(field, "must be %s", article(s.Type))
+		return
+	}
+	if s.Minimum != nil && f < *s.Minimum {
+		v.fail(field, "must be greater than or equal to %s", formatNumber(*s.Minimum))
+	}
+	if s.Maximum != nil && f > *s.Maximum {
+		v.fail(field, "must be less than or equal to %s", formatNumber(*s.Maximum))
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, f) {
+		v.fail(field, "must be one of %s", formatEnum(s.Enum))
+	}
+}
+
+// inEnum сравнивает значение с перечислением; числа перечисления разобраны encoding/json как float64
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		if allowed == value {
+			return true
+		}
+	}
+	return false
+}
+
+func formatEnum(enum []interface{}) string {
+	values := make([]string, 0, len(enum))
+	for _, value := range enum {
+		if f, ok := value.(float64); ok {
+			values = append(values, formatNumber(f))
+		} else {
+			values = append(values, fmt.Sprintf("%q", value))
+		}
+	}
+	return strings.Join(values, ", ")
+}
+
+func formatNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func article(schemaType string) string {
+	if schemaType == "integer" {
+		return "an integer"
+	}
+	return "a number"
+}
+
+// joinField добавляет имя поля к пути через точку
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
@@ -0,0 +1,58 @@
+package pagination
+
+import (
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"strconv"
+	"strings"
+
+	"net/http"
+)
+
+// FromRequest читает из параметров запроса размер страницы (limit) и курсор (cursor)
+func FromRequest(r *http.Request) (Page, error) {
+	query := r.URL.Query()
+	page := Page{Limit: DefaultLimit}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > MaxLimit {
+			return Page{}, errors.NewBadRequest(fmt.Sprintf("limit must be between 1 and %d", MaxLimit), err)
+		}
+		page.Limit = limit
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := Decode(value)
+		if err != nil {
+			return Page{}, err
+		}
+		page.Cursor = cursor
+	}
+	return page, nil
+}
+
+// SetLinkHeader добавляет в ответ заголовок Link (RFC 8288) со ссылками на соседние страницы.
+// Ссылки повторяют запрос r, заменяя в нем курсор.
+func SetLinkHeader(w http.ResponseWriter, r *http.Request, info Info) {
+	var links []string
+	if info.NextCursor != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="next"`, pageURL(r, info.NextCursor)))
+	}
+	if info.PrevCursor != "" {
+		links = append(links, fmt.Sprintf(`<%s>; rel="prev"`, pageURL(r, info.PrevCursor)))
+	}
+	if len(links) > 0 {
+		w.Header().Set("Link", strings.Join(links, ", "))
+	}
+}
+
+// pageURL возвращает адрес запроса r с курсором cursor
+func pageURL(r *http.Request, cursor string) string {
+	u := *r.URL
+	query := u.Query()
+	query.Set("cursor", cursor)
+	u.RawQuery = query.Encode()
+	u.Scheme, u.Host = "", ""
+	return u.RequestURI()
+}
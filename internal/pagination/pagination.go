@@ -0,0 +1,165 @@
+// Package pagination реализует постраничную навигацию по ключу сортировки (keyset) с непрозрачными курсорами.
+// В отличие от OFFSET, стоимость запроса страницы не растет с ее номером: следующая страница начинается
+// сразу после ключа последней записи предыдущей.
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"slices"
+	"strconv"
+
+	"time"
+)
+
+const (
+	// DefaultLimit - размер страницы, если он не указан в запросе
+	DefaultLimit = 10
+	// MaxLimit - максимальный размер страницы
+	MaxLimit = 100
+)
+
+// Cursor указывает на запись, с которой начинается страница. Keys - значения ключей сортировки записи
+// в порядке сортировки; последний ключ должен быть уникальным, чтобы порядок был однозначным.
+type Cursor struct {
+	Keys     []string `json:"k"`
+	Backward bool     `json:"b,omitempty"` // Страница перед записью, а не после нее
+}
+
+// Encode кодирует курсор в строку для передачи клиенту
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode разбирает курсор, полученный от клиента
+func Decode(s string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errors.NewBadRequest("invalid cursor", err)
+	}
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, errors.NewBadRequest("invalid cursor", err)
+	}
+	if len(c.Keys) == 0 {
+		return nil, errors.NewBadRequest("invalid cursor", nil)
+	}
+	return &c, nil
+}
+
+// String возвращает i-й ключ курсора
+func (c *Cursor) String(i int) (string, error) {
+	if i < 0 || i >= len(c.Keys) {
+		return "", errors.NewBadRequest("invalid cursor", nil)
+	}
+	return c.Keys[i], nil
+}
+
+// Time возвращает i-й ключ курсора как время
+func (c *Cursor) Time(i int) (time.Time, error) {
+	key, err := c.String(i)
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.Parse(time.RFC3339Nano, key)
+	if err != nil {
+		return time.Time{}, errors.NewBadRequest("invalid cursor", err)
+	}
+	return t, nil
+}
+
+// Float возвращает i-й ключ курсора как число с плавающей точкой
+func (c *Cursor) Float(i int) (float64, error) {
+	key, err := c.String(i)
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.ParseFloat(key, 64)
+	if err != nil {
+		return 0, errors.NewBadRequest("invalid cursor", err)
+	}
+	return value, nil
+}
+
+// Int возвращает i-й ключ курсора как целое число
+func (c *Cursor) Int(i int) (int, error) {
+	key, err := c.String(i)
+	if err != nil {
+		return 0, err
+	}
+	value, err := strconv.Atoi(key)
+	if err != nil {
+		return 0, errors.NewBadRequest("invalid cursor", err)
+	}
+	return value, nil
+}
+
+// FormatTime, FormatFloat и FormatInt преобразуют значения ключей сортировки для курсора без потери точности
+func FormatTime(t time.Time) string { return t.Format(time.RFC3339Nano) }
+
+func FormatFloat(f float64) string { return strconv.FormatFloat(f, 'g', -1, 64) }
+
+func FormatInt(i int) string { return strconv.Itoa(i) }
+
+// Page - запрос страницы. Cursor равен nil для первой страницы; Limit <= 0 снимает ограничение размера.
+type Page struct {
+	Limit  int
+	Cursor *Cursor
+}
+
+// Backward сообщает, что запрашивается страница перед курсором. Такую страницу запрос выбирает
+// в обратном порядке сортировки, а Paginate возвращает записи в прямом.
+func (p Page) Backward() bool {
+	return p.Cursor != nil && p.Cursor.Backward
+}
+
+// QueryLimit возвращает значение для LIMIT: на одну запись больше размера страницы, чтобы узнать,
+// есть ли следующая страница, или nil (LIMIT NULL), если размер не ограничен.
+func (p Page) QueryLimit() interface{} {
+	if p.Limit <= 0 {
+		return nil
+	}
+	return p.Limit + 1
+}
+
+// Info содержит курсоры соседних страниц; пустой курсор означает, что страницы нет
+type Info struct {
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// Paginate формирует страницу из записей, выбранных запросом с лимитом QueryLimit в порядке запроса,
+// и вычисляет курсоры соседних страниц. keys возвращает ключи сортировки записи.
+func Paginate[T any](items []T, page Page, keys func(T) []string) ([]T, Info) {
+	hasMore := page.Limit > 0 && len(items) > page.Limit
+	if hasMore {
+		items = items[:page.Limit]
+	}
+	backward := page.Backward()
+	if backward {
+		slices.Reverse(items)
+	}
+
+	var info Info
+	if len(items) == 0 {
+		return items, info
+	}
+	first, last := keys(items[0]), keys(items[len(items)-1])
+	if backward {
+		// Страница перед курсором: следующая за ней страница заведомо есть
+		if hasMore {
+			info.PrevCursor = Cursor{Keys: first, Backward: true}.Encode()
+		}
+		info.NextCursor = Cursor{Keys: last}.Encode()
+	} else {
+		if hasMore {
+			info.NextCursor = Cursor{Keys: last}.Encode()
+		}
+		if page.Cursor != nil {
+			info.PrevCursor = Cursor{Keys: first, Backward: true}.Encode()
+		}
+	}
+	return items, info
+}
@@ -0,0 +1,137 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 30, 0, 123456789, time.FixedZone("MSK", 3*60*60))
+	tests := []Cursor{
+		{Keys: []string{"9a0e6a5e-2b9f-4c1d-8e2a-7d3b5c4f1e09"}},
+		{Keys: []string{FormatTime(at), FormatFloat(0.1 + 0.2), FormatInt(-7), "erin"}, Backward: true},
+		{Keys: []string{"", "ключ с пробелами и \"кавычками\""}},
+	}
+	for _, want := range tests {
+		got, err := Decode(want.Encode())
+		if err != nil {
+			t.Fatalf("decode %+v: %v", want, err)
+		}
+		if !reflect.DeepEqual(*got, want) {
+			t.Fatalf("got %+v, want %+v", *got, want)
+		}
+	}
+
+	c, _ := Decode(Cursor{Keys: []string{FormatTime(at), FormatFloat(0.1 + 0.2), FormatInt(-7)}}.Encode())
+	if v, err := c.Time(0); err != nil || !v.Equal(at) {
+		t.Fatalf("time key: %s, %v", v, err)
+	}
+	if v, err := c.Float(1); err != nil || v != 0.1+0.2 {
+		t.Fatalf("float key: %v, %v", v, err)
+	}
+	if v, err := c.Int(2); err != nil || v != -7 {
+		t.Fatalf("int key: %d, %v", v, err)
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	raw := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+	valid := Cursor{Keys: []string{"erin"}}.Encode()
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "!!!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte(`{"k":["a"]}`))},
+		{"truncated", valid[:len(valid)-3]},
+		{"tampered byte", "X" + valid[1:]},
+		{"not json", raw("erin")},
+		{"wrong key type", raw(`{"k":"erin"}`)},
+		{"no keys", raw(`{"b":true}`)},
+		{"empty keys", raw(`{"k":[]}`)},
+		{"null", raw("null")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if c, err := Decode(tt.cursor); !errors.IsErrorType(err, errors.BadRequest) {
+				t.Fatalf("got %+v, %v", c, err)
+			}
+			r := httptest.NewRequest("GET", "/users?cursor="+tt.cursor, nil)
+			if _, err := FromRequest(r); !errors.IsErrorType(err, errors.BadRequest) {
+				t.Fatalf("request: %v", err)
+			}
+		})
+	}
+}
+
+// Ключи подделанного, но корректно закодированного курсора проверяются при чтении
+func TestCursorKeysInvalid(t *testing.T) {
+	c := &Cursor{Keys: []string{"yesterday", "1e", "1.5"}}
+	if _, err := c.String(3); !errors.IsErrorType(err, errors.BadRequest) {
+		t.Errorf("missing key: %v", err)
+	}
+	if _, err := c.String(-1); !errors.IsErrorType(err, errors.BadRequest) {
+		t.Errorf("negative index: %v", err)
+	}
+	if _, err := c.Time(0); !errors.IsErrorType(err, errors.BadRequest) {
+		t.Errorf("time key: %v", err)
+	}
+	if _, err := c.Float(1); !errors.IsErrorType(err, errors.BadRequest) {
+		t.Errorf("float key: %v", err)
+	}
+	if _, err := c.Int(2); !errors.IsErrorType(err, errors.BadRequest) {
+		t.Errorf("int key: %v", err)
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	after := func(k int) *Cursor { return &Cursor{Keys: []string{strconv.Itoa(k)}} }
+	before := func(k int) *Cursor { return &Cursor{Keys: []string{strconv.Itoa(k)}, Backward: true} }
+	next := func(k int) string { return after(k).Encode() }
+	prev := func(k int) string { return before(k).Encode() }
+
+	// items - записи в порядке запроса: для страницы назад - в обратном порядке сортировки
+	tests := []struct {
+		name  string
+		page  Page
+		items []int
+		want  []int
+		info  Info
+	}{
+		{"empty", Page{Limit: 2}, nil, nil, Info{}},
+		{"first page with more", Page{Limit: 2}, []int{1, 2, 3}, []int{1, 2}, Info{NextCursor: next(2)}},
+		{"single first page", Page{Limit: 2}, []int{1, 2}, []int{1, 2}, Info{}},
+		{"unlimited", Page{}, []int{1, 2, 3}, []int{1, 2, 3}, Info{}},
+		{"middle page", Page{Limit: 2, Cursor: after(2)}, []int{3, 4, 5}, []int{3, 4}, Info{NextCursor: next(4), PrevCursor: prev(3)}},
+		{"last page", Page{Limit: 2, Cursor: after(4)}, []int{5}, []int{5}, Info{PrevCursor: prev(5)}},
+		{"empty page after cursor", Page{Limit: 2, Cursor: after(5)}, []int{}, []int{}, Info{}},
+		{"backward with more", Page{Limit: 2, Cursor: before(5)}, []int{4, 3, 2}, []int{3, 4}, Info{NextCursor: next(4), PrevCursor: prev(3)}},
+		{"backward to the first page", Page{Limit: 2, Cursor: before(3)}, []int{2, 1}, []int{1, 2}, Info{NextCursor: next(2)}},
+		{"backward partial page", Page{Limit: 3, Cursor: before(3)}, []int{2, 1}, []int{1, 2}, Info{NextCursor: next(2)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, info := Paginate(tt.items, tt.page, func(i int) []string { return []string{strconv.Itoa(i)} })
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("items %v, want %v", got, tt.want)
+			}
+			if info != tt.info {
+				t.Fatalf("info %+v, want %+v", info, tt.info)
+			}
+		})
+	}
+}
+
+func TestQueryLimit(t *testing.T) {
+	if got := (Page{Limit: 10}).QueryLimit(); got != 11 {
+		t.Fatalf("limit 10 queries %v", got)
+	}
+	if got := (Page{}).QueryLimit(); got != nil {
+		t.Fatalf("unlimited page queries %v", got)
+	}
+}
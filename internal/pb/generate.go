@@ -0,0 +1,4 @@
+// Package pb содержит код, сгенерированный из protobuf-описаний в api/proto.
+package pb
+
+//go:generate protoc -I ../../api/proto --go_out=../.. --go_opt=module=github.com/ZnNr/user-reward-controller --go-grpc_out=../.. --go-grpc_opt=module=github.com/ZnNr/user-reward-controller rewards/v1/user.proto rewards/v1/task.proto rewards/v1/referral.proto
@@ -0,0 +1,103 @@
+// Package problem формирует ответы об ошибках в формате RFC 7807 (application/problem+json).
+// Кроме полей RFC 7807 ответ содержит стабильный машиночитаемый код ошибки (code) и нарушения
+// по полям запроса (errors); случаем ошибки (instance) служит идентификатор запроса.
+package problem
+
+import (
+	"database/sql"
+	"encoding/json"
+	stderrors "errors"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/logging"
+
+	"net/http"
+)
+
+// ContentType - тип содержимого ответа об ошибке
+const ContentType = "application/problem+json"
+
+// Details - описание ошибки (problem details) по RFC 7807
+type Details struct {
+	Type     string       `json:"type"`               // URI типа ошибки; about:blank, если тип описывается кодом ответа
+	Title    string       `json:"title"`              // Краткое описание типа ошибки
+	Status   int          `json:"status"`             // HTTP-код ответа
+	Code     string       `json:"code"`               // Машиночитаемый код ошибки
+	Detail   string       `json:"detail,omitempty"`   // Описание конкретного случая
+	Instance string       `json:"instance,omitempty"` // Идентификатор запроса, в котором произошла ошибка
+	Errors   []FieldError `json:"errors,omitempty"`   // Нарушения по полям запроса
+}
+
+// FieldError - нарушение в одном поле запроса
+type FieldError = errors.FieldError
+
+// New создает описание ошибки с кодом ответа status и машиночитаемым кодом code
+func New(status int, code, detail string) *Details {
+	return &Details{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+		Detail: detail,
+	}
+}
+
+// FromError создает описание ошибки приложения. Код ответа, код ошибки, описание и нарушения по полям
+// берутся из первой *errors.Error в цепочке err; отсутствие строки (sql.ErrNoRows) означает 404.
+// Вложенные ошибки, текст остальных ошибок и ошибок с кодом 5xx клиенту не показываются.
+func FromError(err error) *Details {
+	e, ok := errors.As(err)
+	if !ok {
+		if stderrors.Is(err, sql.ErrNoRows) {
+			return New(http.StatusNotFound, errors.TypeCode(errors.NotFound), errors.ErrMsgNotFound)
+		}
+		return New(http.StatusInternalServerError, errors.TypeCode(errors.Internal), errors.ErrMsgInternal)
+	}
+	status := e.Status()
+	if status >= http.StatusInternalServerError {
+		return New(status, e.ErrorCode(), errors.ErrMsgInternal)
+	}
+	p := New(status, e.ErrorCode(), e.Message)
+	p.Errors = e.Fields
+	return p
+}
+
+// ForV1 приводит описание ошибки к соглашениям API v1: нарушения по полям запроса (validation_failed)
+// в v1 возвращаются с кодом 400, а 422 используется только в /v2. Остальные ошибки не меняются.
+func ForV1(p *Details) *Details {
+	if p.Status == http.StatusUnprocessableEntity && p.Code == errors.CodeValidationFailed && len(p.Errors) > 0 {
+		p.Status = http.StatusBadRequest
+		p.Title = http.StatusText(http.StatusBadRequest)
+	}
+	return p
+}
+
+// Write отправляет описание ошибки p в ответ на запрос r. Если случай ошибки не указан,
+// им становится идентификатор запроса.
+func Write(w http.ResponseWriter, r *http.Request, p *Details) {
+	if p.Instance == "" {
+		p.Instance = logging.RequestID(r.Context())
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// WriteError отправляет в ответ на запрос r описание ошибки приложения err
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	Write(w, r, FromError(err))
+}
+
+// NotFoundHandler отвечает 404 на запросы, для которых не найден маршрут. Такие запросы не проходят
+// миддлвары маршрутизатора, поэтому идентификатор запроса назначается здесь.
+func NotFoundHandler() http.Handler {
+	return logging.RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Write(w, r, New(http.StatusNotFound, errors.CodeRouteNotFound, "no route matches the request"))
+	}))
+}
+
+// MethodNotAllowedHandler отвечает 405 на запросы с методом, который маршрут не поддерживает
+func MethodNotAllowedHandler() http.Handler {
+	return logging.RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Write(w, r, New(http.StatusMethodNotAllowed, errors.CodeMethodNotAllowed, "the route does not support method "+r.Method))
+	}))
+}
@@ -0,0 +1,111 @@
+package problem
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/logging"
+	"net/http/httptest"
+	"testing"
+
+	"net/http"
+)
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		detail string
+	}{
+		{"not found hides cause", errors.NewNotFound("user not found", fmt.Errorf("sql: no rows in result set")), http.StatusNotFound, "not_found", "user not found"},
+		{"validation", errors.NewValidation("title is required", nil), http.StatusUnprocessableEntity, "validation_failed", "title is required"},
+		{"custom code", errors.NewValidation("illegal task status transition", nil).WithCode(errors.CodeInvalidStatusTransition), http.StatusUnprocessableEntity, "invalid_status_transition", "illegal task status transition"},
+		{"conflict", errors.NewConflict("task is closed", nil), http.StatusConflict, "conflict", "task is closed"},
+		{"too many requests", errors.NewTooManyRequests("retry later", nil), http.StatusTooManyRequests, "too_many_requests", "retry later"},
+		{"wrapped", fmt.Errorf("error updating balance: %w", errors.NewValidation("invalid balance", nil)), http.StatusUnprocessableEntity, "validation_failed", "invalid balance"},
+		{"no rows", fmt.Errorf("get user: %w", sql.ErrNoRows), http.StatusNotFound, "not_found", errors.ErrMsgNotFound},
+		{"internal hides cause", errors.NewInternal("query failed", fmt.Errorf("pq: connection refused")), http.StatusInternalServerError, "internal_error", errors.ErrMsgInternal},
+		{"plain error", fmt.Errorf("boom"), http.StatusInternalServerError, "internal_error", errors.ErrMsgInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := FromError(tt.err)
+			if p.Status != tt.status || p.Code != tt.code || p.Detail != tt.detail {
+				t.Fatalf("got %d %s %q, want %d %s %q", p.Status, p.Code, p.Detail, tt.status, tt.code, tt.detail)
+			}
+			if p.Title != http.StatusText(tt.status) {
+				t.Fatalf("title %q", p.Title)
+			}
+		})
+	}
+}
+
+func TestFromErrorFields(t *testing.T) {
+	fields := []FieldError{{In: "body", Field: "title", Message: "is required"}}
+	p := FromError(fmt.Errorf("create task: %w", errors.NewFieldsValidation("invalid task", fields)))
+	if p.Status != http.StatusUnprocessableEntity || len(p.Errors) != 1 || p.Errors[0] != fields[0] {
+		t.Fatalf("unexpected problem: %+v", p)
+	}
+}
+
+func TestForV1(t *testing.T) {
+	fields := []FieldError{{In: "body", Field: "email", Message: "must be a valid email"}}
+	tests := []struct {
+		name   string
+		err    error
+		status int
+	}{
+		{"field errors", errors.NewFieldsValidation("invalid user", fields), http.StatusBadRequest},
+		{"validation without fields", errors.NewValidation("user is already in active status", nil), http.StatusUnprocessableEntity},
+		{"custom code", errors.NewFieldsValidation("illegal task status transition", fields).WithCode(errors.CodeInvalidStatusTransition), http.StatusUnprocessableEntity},
+		{"not found", errors.NewNotFound("user not found", nil), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := ForV1(FromError(tt.err))
+			if p.Status != tt.status || p.Title != http.StatusText(tt.status) {
+				t.Fatalf("got %d %q, want %d", p.Status, p.Title, tt.status)
+			}
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	rec := httptest.NewRecorder()
+	p := New(http.StatusUnprocessableEntity, errors.CodeValidationFailed, "invalid body")
+	p.Errors = []FieldError{{In: "body", Field: "title", Message: "is required"}}
+	r := httptest.NewRequest("POST", "/v2/tasks", nil)
+	Write(rec, r.WithContext(logging.WithRequestID(r.Context(), "req-1")), p)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+		t.Fatalf("content type %q", ct)
+	}
+	var got Details
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Type != "about:blank" || got.Code != "validation_failed" || got.Instance != "req-1" || len(got.Errors) != 1 {
+		t.Fatalf("unexpected body: %s", rec.Body)
+	}
+}
+
+func TestNotFoundHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/nowhere", nil)
+	r.Header.Set(logging.RequestIDHeader, "req-2")
+	NotFoundHandler().ServeHTTP(rec, r)
+
+	var got Details
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rec.Code != http.StatusNotFound || got.Code != errors.CodeRouteNotFound || got.Instance != "req-2" {
+		t.Fatalf("unexpected response %d: %s", rec.Code, rec.Body)
+	}
+}
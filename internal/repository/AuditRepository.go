@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+)
+
+// AuditRepository хранит журнал аудита только в режиме добавления
+type AuditRepository interface {
+	// AppendEntry добавляет запись в конец журнала, заполняя ID, PrevHash и Hash
+	AppendEntry(ctx context.Context, entry *models.AuditEntry) error
+
+	// GetEntries возвращает записи, соответствующие фильтру, от новых к старым
+	GetEntries(ctx context.Context, filter *models.AuditFilter) ([]models.AuditEntry, error)
+
+	// ScanEntries последовательно передает в fn все записи журнала в порядке добавления
+	ScanEntries(ctx context.Context, fn func(*models.AuditEntry) error) error
+}
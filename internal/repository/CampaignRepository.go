@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"time"
+)
+
+// CampaignRepository хранит кампании и их бюджеты
+type CampaignRepository interface {
+	// CreateCampaign создает кампанию
+	CreateCampaign(ctx context.Context, campaign *models.Campaign) (*models.Campaign, error)
+
+	// GetCampaignByID возвращает кампанию по ID
+	GetCampaignByID(ctx context.Context, id string) (*models.Campaign, error)
+
+	// GetCampaigns возвращает кампании по фильтру, начиная с ближайших по дате начала
+	GetCampaigns(ctx context.Context, filter *models.CampaignFilter) ([]models.Campaign, error)
+
+	// UpdateCampaign обновляет название, описание, расписание и бюджет кампании
+	UpdateCampaign(ctx context.Context, campaign *models.Campaign) (*models.Campaign, error)
+
+	// GetCampaignStats возвращает сводные показатели кампании
+	GetCampaignStats(ctx context.Context, id string) (*models.CampaignStats, error)
+
+	// SyncCampaignStatuses приводит статусы кампаний в соответствие с расписанием на момент now
+	// и возвращает количество измененных кампаний
+	SyncCampaignStatuses(ctx context.Context, now time.Time) (int64, error)
+}
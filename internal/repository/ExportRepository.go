@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+)
+
+// ExportRepository последовательно читает большие наборы данных, не загружая их в память целиком.
+// Функция fn вызывается для каждой записи; ошибка fn прерывает чтение.
+type ExportRepository interface {
+	// ExportUsers выгружает пользователей, отсортированных по дате создания
+	ExportUsers(ctx context.Context, filter *models.ExportFilter, fn func(*models.User) error) error
+
+	// ExportTasks выгружает задачи, отсортированные по дате создания
+	ExportTasks(ctx context.Context, filter *models.ExportFilter, fn func(*models.Task) error) error
+
+	// ExportLedger выгружает журнал изменений баланса в хронологическом порядке
+	ExportLedger(ctx context.Context, filter *models.ExportFilter, fn func(*models.LedgerEntry) error) error
+}
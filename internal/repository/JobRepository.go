@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"time"
+)
+
+// JobRepository обеспечивает выбор единственной реплики для запуска задания и хранит историю запусков
+type JobRepository interface {
+	// TryLockJob пытается захватить блокировку задания без ожидания. Если блокировка захвачена,
+	// возвращается функция ее освобождения; если задание выполняется другой репликой, acquired = false.
+	TryLockJob(ctx context.Context, name string) (unlock func(), acquired bool, err error)
+
+	// StartJobRun записывает начало запуска задания. Возвращает false, если запуск
+	// с тем же временем по расписанию уже выполнен другой репликой.
+	StartJobRun(ctx context.Context, run *models.JobRun) (bool, error)
+
+	// FinishJobRun записывает результат запуска задания
+	FinishJobRun(ctx context.Context, run *models.JobRun) error
+
+	// GetJobRuns возвращает запуски заданий по фильтру, начиная с последних
+	GetJobRuns(ctx context.Context, filter *models.JobRunFilter) ([]models.JobRun, error)
+
+	// PurgeJobRuns удаляет записи о запусках, начатых раньше before
+	PurgeJobRuns(ctx context.Context, before time.Time) (int64, error)
+}
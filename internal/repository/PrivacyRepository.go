@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// PrivacyRepository обслуживает запросы субъектов персональных данных
+type PrivacyRepository interface {
+	// GetUserData собирает все данные, связанные с пользователем, включая мягко удаленного
+	GetUserData(ctx context.Context, id uuid.UUID) (*models.UserDataExport, error)
+
+	// AnonymizeUser затирает персональные данные пользователя, в том числе в журнале аудита,
+	// сохраняя баланс, счетчики и журнал баланса
+	AnonymizeUser(ctx context.Context, id uuid.UUID) (*models.User, error)
+}
@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+)
+
+// QuestRepository хранит квесты - цепочки задач с бонусом за выполнение всех задач
+type QuestRepository interface {
+	// CreateQuest создает квест вместе со списком его задач
+	CreateQuest(ctx context.Context, quest *models.Quest) (*models.Quest, error)
+
+	// GetQuestByID возвращает квест с задачами по порядку
+	GetQuestByID(ctx context.Context, id string) (*models.Quest, error)
+
+	// GetQuests возвращает страницу квестов, начиная с новых
+	GetQuests(ctx context.Context, limit, offset int) ([]models.Quest, error)
+
+	// GetQuestCompletion возвращает выплату бонуса за квест пользователю или nil, если квест еще не выполнен
+	GetQuestCompletion(ctx context.Context, questID, userID string) (*models.QuestCompletion, error)
+}
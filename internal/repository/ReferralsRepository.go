@@ -1,11 +1,14 @@
 package repository
 
-import "github.com/ZnNr/user-reward-controller/internal/models"
+import (
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
+)
 
 type ReferralRepository interface {
 	CreateReferral(userID string, code string) (*models.Referral, error)
 	GetReferral(referralID string) (*models.Referral, error)
-	GetReferralsByUserID(userID string) ([]models.Referral, error)
+	GetReferralsByUserID(userID string, page pagination.Page) ([]models.Referral, pagination.Info, error)
 	UpdateReferral(referralID string, code string) (*models.Referral, error)
 	DeleteReferral(referralID string) error
 }
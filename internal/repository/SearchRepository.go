@@ -0,0 +1,12 @@
+package repository
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+)
+
+// SearchRepository выполняет полнотекстовый поиск по задачам и пользователям
+type SearchRepository interface {
+	// Search возвращает найденные объекты по убыванию релевантности
+	Search(ctx context.Context, filter *models.SearchFilter) ([]models.SearchResult, error)
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"github.com/ZnNr/user-reward-controller/internal/models"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -15,15 +16,76 @@ type TaskRepository interface {
 	// GetTaskByID Получить задачу по ID
 	GetTaskByID(ctx context.Context, taskId uuid.UUID) (*models.Task, error)
 
-	// CreateTask Создать новую задачу
-	CreateTask(ctx context.Context, task *models.Task) (*models.Task, error)
+	// CreateTask Создать новую задачу и явно назначить на нее пользователей assignees
+	CreateTask(ctx context.Context, task *models.Task, assignees []string) (*models.Task, error)
 
 	// UpdateTask Обновить существующую задачу
 	UpdateTask(ctx context.Context, task *models.Task) (*models.Task, error)
 
-	// UpdateTaskStatus Обновить статус существующей задачи по ID
-	UpdateTaskStatus(ctx context.Context, taskID string, newStatus int, userID uuid.UUID) (*models.Task, error)
+	// UpdateTaskStatus Перевести задачу в новый статус по графу переходов с записью в историю;
+	// при выполнении начисляет вознаграждение пользователю, при повторном открытии возвращает его.
+	// Задачу со сроком раньше dueDeadline нельзя начать или выполнить (Conflict); срок проверяется
+	// под блокировкой задачи, нулевой dueDeadline отключает проверку.
+	// Возвращает изменение баланса пользователя или nil, если баланс не изменился
+	UpdateTaskStatus(ctx context.Context, taskID string, newStatus int, userID uuid.UUID, actor string, dueDeadline time.Time) (*models.Task, *models.BalanceUpdate, error)
 
-	// DeleteTask Удалить задачу по ID
+	// GetTaskStatusHistory Получить историю переходов статуса задачи
+	GetTaskStatusHistory(ctx context.Context, taskID uuid.UUID) ([]models.TaskStatusChange, error)
+
+	// ClaimTask Взять задачу пользователем (для открытых задач и задач с лимитом);
+	// задачу со сроком раньше dueDeadline взять нельзя (Conflict)
+	ClaimTask(ctx context.Context, taskID, userID string, dueDeadline time.Time) (*models.TaskAssignment, error)
+
+	// GetUserTasks Получить взятые, назначенные и выполненные пользователем задачи с его прогрессом
+	GetUserTasks(ctx context.Context, userID string, status models.TaskStatus) ([]models.UserTask, error)
+
+	// CreateSubmission Сохранить заявку пользователя на выполнение задачи с доказательством;
+	// по задаче со сроком раньше dueDeadline заявки не принимаются (Conflict)
+	CreateSubmission(ctx context.Context, submission *models.Submission, dueDeadline time.Time) (*models.Submission, error)
+
+	// GetSubmissions Получить заявки по фильтру в порядке поступления
+	GetSubmissions(ctx context.Context, filter *models.SubmissionFilter) ([]models.Submission, error)
+
+	// ApproveSubmission Одобрить заявку и засчитать выполнение задачи с начислением вознаграждения;
+	// возвращает изменение баланса пользователя или nil, если баланс не изменился
+	ApproveSubmission(ctx context.Context, id int64, reason, reviewer string) (*models.Submission, *models.BalanceUpdate, error)
+
+	// RejectSubmission Отклонить заявку с указанием причины
+	RejectSubmission(ctx context.Context, id int64, reason, reviewer string) (*models.Submission, error)
+
+	// AddTaskDependencies Добавить задаче зависимости; зависимость, замыкающая цикл, отклоняется
+	AddTaskDependencies(ctx context.Context, taskID string, dependsOn []string) error
+
+	// RemoveTaskDependency Удалить зависимость задачи
+	RemoveTaskDependency(ctx context.Context, taskID, dependsOnID string) error
+
+	// GetTaskDependencies Получить прямые зависимости указанных задач
+	GetTaskDependencies(ctx context.Context, taskIDs []string) (map[string][]string, error)
+
+	// GetCompletedTaskIDs Получить задачи из taskIDs, выполненные пользователем
+	GetCompletedTaskIDs(ctx context.Context, userID string, taskIDs []string) (map[string]bool, error)
+
+	// ExpireOverdueTasks Перевести в Expired незавершенные задачи и личный прогресс, срок которых истек раньше deadline
+	ExpireOverdueTasks(ctx context.Context, deadline time.Time, actor string) (int64, error)
+
+	// GetDueReminders Получить неотправленные напоминания исполнителям задач со сроком в интервале (from, to]
+	GetDueReminders(ctx context.Context, from, to time.Time, limit int) ([]models.DueReminder, error)
+
+	// MarkDueReminderSent Отметить напоминание исполнителю задачи отправленным
+	MarkDueReminderSent(ctx context.Context, taskID, userID string) error
+
+	// DeleteTask Пометить задачу удаленной (мягкое удаление)
 	DeleteTask(ctx context.Context, taskId uuid.UUID) error
+
+	// RestoreTask Восстановить мягко удаленную задачу
+	RestoreTask(ctx context.Context, taskId uuid.UUID) (*models.Task, error)
+
+	// PurgeDeletedTasks Окончательно удалить задачи, мягко удаленные раньше before
+	PurgeDeletedTasks(ctx context.Context, before time.Time) (int64, error)
+
+	// GetExistingTaskKeys возвращает пары заголовок/описание уже существующих задач с указанными заголовками
+	GetExistingTaskKeys(ctx context.Context, titles []string) (map[models.TaskKey]bool, error)
+
+	// CopyTasks вставляет задачи одной транзакцией с помощью COPY
+	CopyTasks(ctx context.Context, tasks []*models.Task) error
 }
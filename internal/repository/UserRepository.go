@@ -4,14 +4,17 @@ import (
 	"context"
 	"database/sql"
 	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 // UserRepository определяет методы для взаимодействия с данными пользователя в базе данных
 type UserRepository interface {
-	// GetUsers возвращает список пользователей, соответствующих заданному фильтру
-	GetUsers(ctx context.Context, filter *models.User) (*models.UsersResponse, error)
+	// GetUsers возвращает страницу пользователей, соответствующих заданному фильтру, в порядке его сортировки.
+	// Мягко удаленные пользователи возвращаются только при filter.IncludeDeleted
+	GetUsers(ctx context.Context, filter *models.UserFilter) (*models.UsersResponse, error)
 
 	// GetUserByID возвращает пользователя по его уникальному идентификатору
 	GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error)
@@ -22,9 +25,15 @@ type UserRepository interface {
 	// UpdateUser обновляет информацию о существующем пользователе
 	UpdateUser(ctx context.Context, user *models.User) (*models.User, error)
 
-	// DeleteUser удаляет пользователя из базы данных
+	// DeleteUser помечает пользователя удаленным (мягкое удаление)
 	DeleteUser(ctx context.Context, id uuid.UUID) error
 
+	// RestoreUser восстанавливает мягко удаленного пользователя
+	RestoreUser(ctx context.Context, id uuid.UUID) (*models.User, error)
+
+	// PurgeDeletedUsers окончательно удаляет пользователей, мягко удаленных раньше before
+	PurgeDeletedUsers(ctx context.Context, before time.Time) (int64, error)
+
 	// GetUsersByStatus возвращает пользователей по заданному статусу
 	GetUsersByStatus(ctx context.Context, status models.UserStatus) ([]*models.User, error)
 
@@ -55,10 +64,20 @@ type UserRepository interface {
 	// CreateUserTx создает нового пользователя в рамках транзакции
 	CreateUserTx(ctx context.Context, tx *sql.Tx, user *models.User) (*models.User, error)
 
-	// UpdateBalanceAndReferralsTx обновляет баланс и количество рефералов в рамках транзакции
-	UpdateBalanceAndReferralsTx(ctx context.Context, tx *sql.Tx, id uuid.UUID, balance float64, referrals int) error
+	// UpdateBalanceAndReferralsTx прибавляет к балансу и количеству рефералов переданные приращения в рамках транзакции
+	UpdateBalanceAndReferralsTx(ctx context.Context, tx *sql.Tx, id uuid.UUID, balanceDelta float64, referralsDelta int) error
 
 	GetTopUsers(ctx context.Context, limit int, offset int) ([]models.TopUser, error)
 
+	// GetTopUsersPage возвращает страницу топа пользователей по курсору и курсоры соседних страниц
+	GetTopUsersPage(ctx context.Context, page pagination.Page) ([]models.TopUser, pagination.Info, error)
+
 	GetLeaderByBalance(ctx context.Context) (*models.TopUser, error) // Новый метод
+
+	// GetExistingEmails возвращает множество адресов из списка, уже занятых пользователями.
+	// Адреса сравниваются без учета регистра; ключи результата - в нижнем регистре.
+	GetExistingEmails(ctx context.Context, emails []string) (map[string]bool, error)
+
+	// CopyUsers вставляет пользователей одной транзакцией с помощью COPY
+	CopyUsers(ctx context.Context, users []*models.User) error
 }
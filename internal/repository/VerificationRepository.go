@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// VerificationRepository хранит токены подтверждения email
+type VerificationRepository interface {
+	// CreateToken сохраняет хеш нового токена; ранее выданные неиспользованные токены пользователя аннулируются
+	CreateToken(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error
+
+	// ActivateByToken погашает действующий токен и переводит пользователя из Pending в Active
+	ActivateByToken(ctx context.Context, tokenHash string) (*models.User, error)
+}
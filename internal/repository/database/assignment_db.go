@@ -0,0 +1,251 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"time"
+)
+
+const (
+	assignmentColumns = `task_id, user_id, progress, claimed_at, progressed_at, completed_at`
+
+	lockTaskForClaimQuery = `SELECT status, assignment_mode, max_claims, due_date FROM tasks WHERE task_id = $1 AND deleted_at IS NULL FOR UPDATE`
+
+	checkActiveUserExistsQuery = `SELECT EXISTS (SELECT 1 FROM Users WHERE ID = $1 AND DeletedAt IS NULL)`
+
+	getAssignmentQuery = `SELECT ` + assignmentColumns + ` FROM task_assignments WHERE task_id = $1 AND user_id = $2`
+
+	lockAssignmentQuery = `SELECT progress FROM task_assignments WHERE task_id = $1 AND user_id = $2 FOR UPDATE`
+
+	countAssignmentsQuery = `SELECT COUNT(*) FROM task_assignments WHERE task_id = $1`
+
+	insertAssignmentQuery = `INSERT INTO task_assignments (task_id, user_id, progress)
+	VALUES ($1, $2, $3)
+	ON CONFLICT (task_id, user_id) DO NOTHING`
+
+	claimTaskQuery = `INSERT INTO task_assignments (task_id, user_id, progress)
+	VALUES ($1, $2, $3)
+	RETURNING ` + assignmentColumns
+
+	setAssignmentProgressQuery = `UPDATE task_assignments
+	SET progress = $1,
+	    progressed_at = CURRENT_TIMESTAMP,
+	    completed_at = CASE WHEN $1 = $4 THEN CURRENT_TIMESTAMP END
+	WHERE task_id = $2 AND user_id = $3`
+
+	// Задачи пользователя: взятые и назначенные явно с личным прогрессом, а также задачи
+	// с единственным исполнителем, где пользователь указан исполнителем или получил вознаграждение
+	getUserTasksByUserQuery = `SELECT ` + taskColumns + `, progress, claimed_at, completed_at
+	FROM (
+	    SELECT t.*, a.progress, a.claimed_at, a.completed_at
+	    FROM tasks t JOIN task_assignments a ON a.task_id = t.task_id
+	    WHERE a.user_id = $1
+	    UNION ALL
+	    SELECT t.*, t.status, NULL::timestamptz, CASE WHEN t.completed_by = $1 THEN t.updated_at::timestamptz END
+	    FROM tasks t
+	    WHERE (t.assignee_id = $1 OR t.completed_by = $1)
+	      AND NOT EXISTS (SELECT 1 FROM task_assignments a WHERE a.task_id = t.task_id AND a.user_id = $1)
+	) AS user_tasks
+	WHERE deleted_at IS NULL
+	  AND ($2::int IS NULL OR progress = $2)
+	ORDER BY created_at DESC, task_id`
+)
+
+// assignUsers явно назначает пользователей на задачу в рамках транзакции.
+func (r *PostgresTaskRepository) assignUsers(ctx context.Context, tx *sql.Tx, taskID string, userIDs []string) error {
+	for _, userID := range userIDs {
+		if err := r.checkActiveUser(ctx, tx, userID); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, insertAssignmentQuery, taskID, userID, models.NotStarted); err != nil {
+			return errors.NewInternal("failed to assign user to task", err)
+		}
+	}
+	return nil
+}
+
+// checkActiveUser проверяет, что пользователь существует и не удален.
+func (r *PostgresTaskRepository) checkActiveUser(ctx context.Context, tx *sql.Tx, userID string) error {
+	var exists bool
+	if err := tx.QueryRowContext(ctx, checkActiveUserExistsQuery, userID).Scan(&exists); err != nil {
+		return errors.NewInternal("failed to check user existence", err)
+	}
+	if !exists {
+		return errors.NewNotFound(fmt.Sprintf("user %s not found", userID), nil)
+	}
+	return nil
+}
+
+// ClaimTask закрепляет задачу за пользователем. Повторный вызов возвращает существующую запись.
+// Открытую задачу может взять любой пользователь, задачу с лимитом - только первые max_claims пользователей.
+func (r *PostgresTaskRepository) ClaimTask(ctx context.Context, taskID, userID string, dueDeadline time.Time) (*models.TaskAssignment, error) {
+	var assignment models.TaskAssignment
+
+	err := r.WithTransaction(ctx, func(tx *sql.Tx) error {
+		var status models.TaskStatus
+		var mode models.TaskAssignmentMode
+		var maxClaims sql.NullInt64
+		var dueDate *time.Time
+		// Блокировка строки задачи упорядочивает конкурирующие попытки взять задачу с лимитом
+		err := tx.QueryRowContext(ctx, lockTaskForClaimQuery, taskID).Scan(&status, &mode, &maxClaims, &dueDate)
+		if err == sql.ErrNoRows {
+			return errors.NewNotFound("task not found", nil)
+		} else if err != nil {
+			return errors.NewInternal("failed to get task", err)
+		}
+		if err := checkNotPastDue(dueDate, dueDeadline); err != nil {
+			return err
+		}
+
+		if err := r.checkActiveUser(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		err = scanAssignment(tx.QueryRowContext(ctx, getAssignmentQuery, taskID, userID), &assignment)
+		if err == nil {
+			return nil
+		} else if err != sql.ErrNoRows {
+			return errors.NewInternal("failed to get task assignment", err)
+		}
+
+		if !mode.IsShared() {
+			return errors.NewForbidden("task can be done only by assigned users", nil)
+		}
+		if status.IsClosed() {
+			return errors.NewConflict(fmt.Sprintf("task is %s and cannot be claimed", status), nil)
+		}
+		if mode == models.AssignmentCapped && maxClaims.Valid {
+			var claims int64
+			if err := tx.QueryRowContext(ctx, countAssignmentsQuery, taskID).Scan(&claims); err != nil {
+				return errors.NewInternal("failed to count task claims", err)
+			}
+			if claims >= maxClaims.Int64 {
+				return errors.NewConflict("task claim limit reached", nil)
+			}
+		}
+
+		if err := scanAssignment(tx.QueryRowContext(ctx, claimTaskQuery, taskID, userID, models.InProgress), &assignment); err != nil {
+			return errors.NewInternal("failed to claim task", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &assignment, nil
+}
+
+// lockUserProgress блокирует и возвращает личный прогресс пользователя по задаче. assigned равно false,
+// если пользователь не брал задачу и прогресс ведется по самой задаче. Пользователь, не взявший
+// открытую задачу или не назначенный на задачу с явными назначениями, получает Forbidden.
+func (r *PostgresTaskRepository) lockUserProgress(ctx context.Context, tx *sql.Tx, taskID, userID string,
+	mode models.TaskAssignmentMode) (models.TaskStatus, bool, error) {
+	var current models.TaskStatus
+	err := tx.QueryRowContext(ctx, lockAssignmentQuery, taskID, userID).Scan(&current)
+	if err == nil {
+		return current, true, nil
+	} else if err != sql.ErrNoRows {
+		return 0, false, errors.NewInternal("failed to get task assignment", err)
+	}
+
+	if mode.IsShared() {
+		return 0, false, errors.NewForbidden("task must be claimed first", nil)
+	}
+	// Задача с явными назначениями недоступна остальным пользователям
+	var assigned int64
+	if err := tx.QueryRowContext(ctx, countAssignmentsQuery, taskID).Scan(&assigned); err != nil {
+		return 0, false, errors.NewInternal("failed to count task assignments", err)
+	}
+	if assigned > 0 {
+		return 0, false, errors.NewForbidden("user is not assigned to the task", nil)
+	}
+	return 0, false, nil
+}
+
+// transitionAssignment применяет переход к личному прогрессу пользователя, если он взял задачу
+// или назначен на нее явно. Возвращает false, если переход относится к самой задаче,
+// и изменение баланса пользователя.
+func (r *PostgresTaskRepository) transitionAssignment(ctx context.Context, tx *sql.Tx, taskID, userID string,
+	mode models.TaskAssignmentMode, next models.TaskStatus, reward float64, actor string, canComplete bool) (bool, float64, error) {
+	current, assigned, err := r.lockUserProgress(ctx, tx, taskID, userID, mode)
+	if err != nil || !assigned {
+		return false, 0, err
+	}
+
+	if next == current {
+		return true, 0, nil
+	}
+	if !current.CanTransitionTo(next) {
+		return false, 0, errors.NewValidation(fmt.Sprintf("illegal task progress transition from %s to %s", current, next), nil).WithCode(errors.CodeInvalidStatusTransition)
+	}
+	if next == models.Completed && !canComplete {
+		return false, 0, errors.NewConflict("task requires an approved submission to be completed", nil)
+	}
+
+	var rewardDelta float64
+	switch {
+	case next == models.Completed:
+		paid, err := r.creditTaskReward(ctx, tx, taskID, userID, reward)
+		if err != nil {
+			return false, 0, err
+		}
+		rewardDelta = paid
+	case current == models.Completed:
+		reversed, err := r.reverseTaskReward(ctx, tx, taskID, userID)
+		if err != nil {
+			return false, 0, err
+		}
+		rewardDelta = -reversed
+	}
+
+	if _, err := tx.ExecContext(ctx, setAssignmentProgressQuery, next, taskID, userID, models.Completed); err != nil {
+		return false, 0, errors.NewInternal("failed to update task progress", err)
+	}
+	if _, err := tx.ExecContext(ctx, insertTaskStatusHistoryQuery, taskID, current, next, actor, userID, rewardDelta); err != nil {
+		return false, 0, errors.NewInternal("failed to record task status history", err)
+	}
+	bonus, err := r.syncQuestBonuses(ctx, tx, taskID, userID, current, next)
+	if err != nil {
+		return false, 0, err
+	}
+	return true, rewardDelta + bonus, nil
+}
+
+// GetUserTasks возвращает задачи пользователя с его прогрессом; status ограничивает выборку прогрессом.
+func (r *PostgresTaskRepository) GetUserTasks(ctx context.Context, userID string, status models.TaskStatus) ([]models.UserTask, error) {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, checkActiveUserExistsQuery, userID).Scan(&exists); err != nil {
+		return nil, errors.NewInternal("failed to check user existence", err)
+	} else if !exists {
+		return nil, errors.NewNotFound("user not found", nil)
+	}
+
+	rows, err := r.db.QueryContext(ctx, getUserTasksByUserQuery, userID, nullableStatus(int(status)))
+	if err != nil {
+		return nil, errors.NewInternal("failed to query user tasks", err)
+	}
+	defer rows.Close()
+
+	tasks := []models.UserTask{}
+	for rows.Next() {
+		var task models.UserTask
+		if err := scanTaskFields(rows, &task.Task, &task.Progress, &task.ClaimedAt, &task.CompletedAt); err != nil {
+			return nil, errors.NewInternal("failed to scan user task", err)
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewInternal("error occurred while iterating over user tasks", err)
+	}
+	return tasks, nil
+}
+
+// scanAssignment сканирует колонки assignmentColumns в запись назначения.
+func scanAssignment(row rowScanner, assignment *models.TaskAssignment) error {
+	return row.Scan(&assignment.TaskID, &assignment.UserID, &assignment.Progress,
+		&assignment.ClaimedAt, &assignment.ProgressedAt, &assignment.CompletedAt)
+}
@@ -0,0 +1,188 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+)
+
+// auditLockKey - ключ транзакционной advisory-блокировки, упорядочивающей добавление записей в цепочку
+const auditLockKey = 7364601
+
+const (
+	auditColumns = `id, actor, action, target_type, target_id, before, after, request_id, ip, created_at, content_hash, redacted_at, prev_hash, hash`
+
+	lockAuditLogQuery = `SELECT pg_advisory_xact_lock($1)`
+
+	getLastAuditHashQuery = `SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1`
+
+	insertAuditEntryQuery = `INSERT INTO audit_log (actor, action, target_type, target_id, before, after, request_id, ip, created_at, content_hash, prev_hash, hash)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	RETURNING id`
+
+	getAuditEntriesQuery = `SELECT ` + auditColumns + `
+	FROM audit_log
+	WHERE ($1::varchar IS NULL OR actor = $1)
+	  AND ($2::varchar IS NULL OR target_id = $2)
+	  AND ($3::timestamptz IS NULL OR created_at >= $3)
+	  AND ($4::timestamptz IS NULL OR created_at < $4)
+	ORDER BY id DESC
+	LIMIT $5`
+
+	scanAuditEntriesQuery = `SELECT ` + auditColumns + ` FROM audit_log ORDER BY id`
+)
+
+// PostgresAuditRepository реализует AuditRepository для PostgreSQL
+type PostgresAuditRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresAuditRepository создает новый репозиторий журнала аудита с указанным соединением с БД.
+func NewPostgresAuditRepository(db *sql.DB) repository.AuditRepository {
+	return &PostgresAuditRepository{db: db}
+}
+
+// AppendEntry связывает запись с последней записью журнала и сохраняет ее.
+// Advisory-блокировка гарантирует, что две записи не получат один и тот же PrevHash.
+func (r *PostgresAuditRepository) AppendEntry(ctx context.Context, entry *models.AuditEntry) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.NewInternal("failed to begin audit transaction", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, lockAuditLogQuery, auditLockKey); err != nil {
+		return errors.NewInternal("failed to lock audit log", err)
+	}
+
+	var prevHash string
+	if err := tx.QueryRowContext(ctx, getLastAuditHashQuery).Scan(&prevHash); err != nil && err != sql.ErrNoRows {
+		return errors.NewInternal("failed to read last audit hash", err)
+	}
+	entry.PrevHash = prevHash
+	entry.ContentHash = entry.ComputeContentHash()
+	entry.Hash = entry.ComputeHash()
+
+	if err := tx.QueryRowContext(ctx, insertAuditEntryQuery,
+		entry.Actor,
+		entry.Action,
+		entry.TargetType,
+		entry.TargetID,
+		nullableJSON(entry.Before),
+		nullableJSON(entry.After),
+		entry.RequestID,
+		entry.IP,
+		entry.CreatedAt,
+		entry.ContentHash,
+		entry.PrevHash,
+		entry.Hash,
+	).Scan(&entry.ID); err != nil {
+		return errors.NewInternal("failed to insert audit entry", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.NewInternal("failed to commit audit entry", err)
+	}
+	return nil
+}
+
+// GetEntries возвращает записи журнала по фильтру
+func (r *PostgresAuditRepository) GetEntries(ctx context.Context, filter *models.AuditFilter) ([]models.AuditEntry, error) {
+	rows, err := r.db.QueryContext(ctx, getAuditEntriesQuery,
+		nullableString(filter.Actor),
+		nullableString(filter.TargetID),
+		filter.From,
+		filter.To,
+		filter.Limit,
+	)
+	if err != nil {
+		return nil, errors.NewInternal("failed to query audit log", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var entry models.AuditEntry
+		if err := scanAuditEntry(rows, &entry); err != nil {
+			return nil, errors.NewInternal("failed to scan audit entry", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewInternal("error occurred while iterating over audit log", err)
+	}
+	return entries, nil
+}
+
+// ScanEntries читает журнал через серверный курсор, не загружая его в память целиком
+func (r *PostgresAuditRepository) ScanEntries(ctx context.Context, fn func(*models.AuditEntry) error) error {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return errors.NewInternal("failed to begin audit scan transaction", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DECLARE audit_cursor NO SCROLL CURSOR FOR "+scanAuditEntriesQuery); err != nil {
+		return errors.NewInternal("failed to declare audit cursor", err)
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM audit_cursor", exportFetchSize)
+	for {
+		rows, err := tx.QueryContext(ctx, fetch)
+		if err != nil {
+			return errors.NewInternal("failed to fetch from audit cursor", err)
+		}
+
+		count := 0
+		for rows.Next() {
+			count++
+			var entry models.AuditEntry
+			if err := scanAuditEntry(rows, &entry); err != nil {
+				rows.Close()
+				return errors.NewInternal("failed to scan audit entry", err)
+			}
+			if err := fn(&entry); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return errors.NewInternal("error occurred while iterating over audit cursor", err)
+		}
+		rows.Close()
+
+		if count < exportFetchSize {
+			return nil
+		}
+	}
+}
+
+// scanAuditEntry сканирует колонки auditColumns в запись журнала
+func scanAuditEntry(row rowScanner, entry *models.AuditEntry) error {
+	var before, after []byte
+	if err := row.Scan(&entry.ID, &entry.Actor, &entry.Action, &entry.TargetType, &entry.TargetID,
+		&before, &after, &entry.RequestID, &entry.IP, &entry.CreatedAt, &entry.ContentHash, &entry.RedactedAt,
+		&entry.PrevHash, &entry.Hash); err != nil {
+		return err
+	}
+	if before != nil {
+		entry.Before = json.RawMessage(before)
+	}
+	if after != nil {
+		entry.After = json.RawMessage(after)
+	}
+	return nil
+}
+
+// nullableJSON передает пустой JSON как NULL, а непустой - как текст, чтобы он хранился без изменений
+func nullableJSON(raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
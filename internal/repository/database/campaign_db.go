@@ -0,0 +1,206 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"time"
+)
+
+const (
+	campaignColumns = `campaign_id, name, COALESCE(description, ''), starts_at, ends_at, budget, spent, status, created_at, updated_at`
+
+	// campaignStatusExpr вычисляет статус кампании по расписанию на момент $1
+	campaignStatusExpr = `CASE WHEN $1 < starts_at THEN 'scheduled' WHEN $1 < ends_at THEN 'active' ELSE 'ended' END`
+
+	insertCampaignQuery = `INSERT INTO campaigns (campaign_id, name, description, starts_at, ends_at, budget, status)
+	VALUES ($1, $2, NULLIF($3, ''), $4, $5, $6, $7)
+	RETURNING ` + campaignColumns
+
+	getCampaignByIDQuery = `SELECT ` + campaignColumns + ` FROM campaigns WHERE campaign_id = $1`
+
+	lockCampaignQuery = `SELECT spent FROM campaigns WHERE campaign_id = $1 FOR UPDATE`
+
+	getCampaignsQuery = `SELECT ` + campaignColumns + `
+	FROM campaigns
+	WHERE ($1::varchar IS NULL OR status = $1)
+	ORDER BY starts_at DESC, campaign_id
+	LIMIT $2 OFFSET $3`
+
+	updateCampaignQuery = `UPDATE campaigns
+	SET name = $1,
+	    description = NULLIF($2, ''),
+	    starts_at = $3,
+	    ends_at = $4,
+	    budget = $5,
+	    status = $6,
+	    updated_at = NOW()
+	WHERE campaign_id = $7
+	RETURNING ` + campaignColumns
+
+	syncCampaignStatusesQuery = `UPDATE campaigns
+	SET status = ` + campaignStatusExpr + `, updated_at = NOW()
+	WHERE status <> ` + campaignStatusExpr
+
+	// Участники - пользователи, взявшие задачи кампании, получившие за них вознаграждение или отправившие заявки.
+	// Выполнения - засчитанный личный прогресс и выполненные задачи с единственным исполнителем.
+	getCampaignStatsQuery = `WITH campaign_tasks AS (
+	    SELECT task_id, status, completed_by FROM tasks WHERE campaign_id = $1 AND deleted_at IS NULL
+	), participants AS (
+	    SELECT a.user_id FROM task_assignments a JOIN campaign_tasks USING (task_id)
+	    UNION
+	    SELECT completed_by FROM campaign_tasks WHERE completed_by IS NOT NULL
+	    UNION
+	    SELECT s.user_id FROM task_submissions s JOIN campaign_tasks USING (task_id)
+	)
+	SELECT
+	    (SELECT COUNT(*) FROM campaign_tasks),
+	    (SELECT COUNT(*) FROM participants),
+	    (SELECT COUNT(*) FROM task_assignments a JOIN campaign_tasks USING (task_id) WHERE a.progress = $2)
+	        + (SELECT COUNT(*) FROM campaign_tasks WHERE status = $2 AND completed_by IS NOT NULL)`
+)
+
+// PostgresCampaignRepository реализует CampaignRepository для PostgreSQL
+type PostgresCampaignRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresCampaignRepository создает новый репозиторий кампаний с указанным соединением с БД.
+func NewPostgresCampaignRepository(db *sql.DB) repository.CampaignRepository {
+	return &PostgresCampaignRepository{db: db}
+}
+
+// CreateCampaign создает кампанию
+func (r *PostgresCampaignRepository) CreateCampaign(ctx context.Context, campaign *models.Campaign) (*models.Campaign, error) {
+	var created models.Campaign
+	err := scanCampaign(r.db.QueryRowContext(ctx, insertCampaignQuery,
+		campaign.CampaignID,
+		campaign.Name,
+		campaign.Description,
+		campaign.StartsAt,
+		campaign.EndsAt,
+		campaign.Budget,
+		campaign.Status,
+	), &created)
+	if isUniqueViolation(err) {
+		return nil, errors.NewAlreadyExists("campaign already exists", err)
+	} else if err != nil {
+		return nil, errors.NewInternal("failed to create campaign", err)
+	}
+	return &created, nil
+}
+
+// GetCampaignByID возвращает кампанию по ID
+func (r *PostgresCampaignRepository) GetCampaignByID(ctx context.Context, id string) (*models.Campaign, error) {
+	var campaign models.Campaign
+	err := scanCampaign(r.db.QueryRowContext(ctx, getCampaignByIDQuery, id), &campaign)
+	if err == sql.ErrNoRows {
+		return nil, errors.NewNotFound("campaign not found", nil)
+	} else if err != nil {
+		return nil, errors.NewInternal("failed to get campaign", err)
+	}
+	return &campaign, nil
+}
+
+// GetCampaigns возвращает кампании по фильтру
+func (r *PostgresCampaignRepository) GetCampaigns(ctx context.Context, filter *models.CampaignFilter) ([]models.Campaign, error) {
+	rows, err := r.db.QueryContext(ctx, getCampaignsQuery, nullableString(string(filter.Status)), filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, errors.NewInternal("failed to query campaigns", err)
+	}
+	defer rows.Close()
+
+	campaigns := []models.Campaign{}
+	for rows.Next() {
+		var campaign models.Campaign
+		if err := scanCampaign(rows, &campaign); err != nil {
+			return nil, errors.NewInternal("failed to scan campaign", err)
+		}
+		campaigns = append(campaigns, campaign)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewInternal("error occurred while iterating over campaigns", err)
+	}
+	return campaigns, nil
+}
+
+// UpdateCampaign обновляет кампанию. Бюджет нельзя уменьшить ниже уже выплаченной суммы;
+// строка кампании блокируется, чтобы проверка не разошлась с параллельными выплатами.
+func (r *PostgresCampaignRepository) UpdateCampaign(ctx context.Context, campaign *models.Campaign) (*models.Campaign, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.NewInternal("failed to begin campaign transaction", err)
+	}
+	defer tx.Rollback()
+
+	var spent float64
+	err = tx.QueryRowContext(ctx, lockCampaignQuery, campaign.CampaignID).Scan(&spent)
+	if err == sql.ErrNoRows {
+		return nil, errors.NewNotFound("campaign not found", nil)
+	} else if err != nil {
+		return nil, errors.NewInternal("failed to get campaign", err)
+	}
+	if campaign.Budget < spent {
+		return nil, errors.NewValidation("budget cannot be less than points already spent", nil)
+	}
+
+	var updated models.Campaign
+	if err := scanCampaign(tx.QueryRowContext(ctx, updateCampaignQuery,
+		campaign.Name,
+		campaign.Description,
+		campaign.StartsAt,
+		campaign.EndsAt,
+		campaign.Budget,
+		campaign.Status,
+		campaign.CampaignID,
+	), &updated); err != nil {
+		return nil, errors.NewInternal("failed to update campaign", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.NewInternal("failed to commit campaign update", err)
+	}
+	return &updated, nil
+}
+
+// GetCampaignStats возвращает сводные показатели кампании
+func (r *PostgresCampaignRepository) GetCampaignStats(ctx context.Context, id string) (*models.CampaignStats, error) {
+	campaign, err := r.GetCampaignByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &models.CampaignStats{
+		CampaignID:  campaign.CampaignID,
+		PointsSpent: campaign.Spent,
+		Budget:      campaign.Budget,
+		Remaining:   campaign.Remaining(),
+		Exhausted:   campaign.Remaining() == 0,
+	}
+	if err := r.db.QueryRowContext(ctx, getCampaignStatsQuery, id, models.Completed).Scan(
+		&stats.Tasks, &stats.Participants, &stats.Completions); err != nil {
+		return nil, errors.NewInternal("failed to get campaign stats", err)
+	}
+	return stats, nil
+}
+
+// SyncCampaignStatuses приводит статусы кампаний в соответствие с расписанием
+func (r *PostgresCampaignRepository) SyncCampaignStatuses(ctx context.Context, now time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, syncCampaignStatusesQuery, now)
+	if err != nil {
+		return 0, errors.NewInternal("failed to sync campaign statuses", err)
+	}
+	changed, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.NewInternal("failed to retrieve affected rows after campaign sync", err)
+	}
+	return changed, nil
+}
+
+// scanCampaign сканирует колонки campaignColumns в кампанию.
+func scanCampaign(row rowScanner, campaign *models.Campaign) error {
+	return row.Scan(&campaign.CampaignID, &campaign.Name, &campaign.Description, &campaign.StartsAt,
+		&campaign.EndsAt, &campaign.Budget, &campaign.Spent, &campaign.Status, &campaign.CreatedAt, &campaign.UpdatedAt)
+}
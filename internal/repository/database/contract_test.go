@@ -0,0 +1,20 @@
+package database
+
+import (
+	"github.com/ZnNr/user-reward-controller/internal/repository/repotest"
+	"github.com/ZnNr/user-reward-controller/internal/testdb"
+	"testing"
+)
+
+// TestRepositoryContract проверяет реализации PostgreSQL по общему контракту репозиториев.
+// Каждый тест получает отдельную схему; без TEST_DATABASE_URL тест пропускается.
+func TestRepositoryContract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		db := testdb.New(t)
+		return repotest.Repositories{
+			Users:     NewPostgresUserRepository(db),
+			Tasks:     NewPostgresTaskRepository(db),
+			Referrals: NewReferralRepository(db),
+		}
+	})
+}
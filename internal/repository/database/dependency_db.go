@@ -0,0 +1,153 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// dependencyLockKey - ключ транзакционной advisory-блокировки, сериализующей изменение графа зависимостей:
+// без нее две параллельные вставки встречных ребер могли бы обе пройти проверку на цикл
+const dependencyLockKey = 7364602
+
+const (
+	lockDependenciesQuery = `SELECT pg_advisory_xact_lock($1)`
+
+	countExistingTasksQuery = `SELECT COUNT(*) FROM tasks WHERE task_id = ANY($1) AND deleted_at IS NULL`
+
+	// Цикл возникает, если от новых зависимостей по ребрам depends_on достижима сама задача
+	dependencyCreatesCycleQuery = `WITH RECURSIVE reachable(task_id) AS (
+	    SELECT unnest($1::varchar[])
+	    UNION
+	    SELECT d.depends_on_id FROM task_dependencies d JOIN reachable r ON d.task_id = r.task_id
+	)
+	SELECT EXISTS (SELECT 1 FROM reachable WHERE task_id = $2)`
+
+	insertDependencyQuery = `INSERT INTO task_dependencies (task_id, depends_on_id)
+	VALUES ($1, $2)
+	ON CONFLICT (task_id, depends_on_id) DO NOTHING`
+
+	deleteDependencyQuery = `DELETE FROM task_dependencies WHERE task_id = $1 AND depends_on_id = $2`
+
+	// Зависимости от удаленных задач не блокируют выполнение
+	getTaskDependenciesQuery = `SELECT d.task_id, d.depends_on_id
+	FROM task_dependencies d
+	JOIN tasks t ON t.task_id = d.depends_on_id
+	WHERE d.task_id = ANY($1) AND t.deleted_at IS NULL
+	ORDER BY d.task_id, d.created_at, d.depends_on_id`
+
+	// taskDoneByUserExpr - задача t засчитана пользователю $2: выполнена лично или как единственным исполнителем
+	taskDoneByUserExpr = `(COALESCE(t.completed_by = $2, FALSE) OR EXISTS (
+	    SELECT 1 FROM task_assignments a WHERE a.task_id = t.task_id AND a.user_id = $2 AND a.progress = $3
+	))`
+
+	getCompletedTaskIDsQuery = `SELECT t.task_id FROM tasks t WHERE t.task_id = ANY($1) AND ` + taskDoneByUserExpr
+)
+
+// AddTaskDependencies добавляет задаче зависимости. Граф зависимостей остается ацикличным:
+// зависимость, замыкающая цикл, отклоняется с ошибкой валидации.
+func (r *PostgresTaskRepository) AddTaskDependencies(ctx context.Context, taskID string, dependsOn []string) error {
+	return r.WithTransaction(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, lockDependenciesQuery, dependencyLockKey); err != nil {
+			return errors.NewInternal("failed to lock task dependencies", err)
+		}
+
+		var found int
+		ids := append([]string{taskID}, dependsOn...)
+		if err := tx.QueryRowContext(ctx, countExistingTasksQuery, pq.Array(ids)).Scan(&found); err != nil {
+			return errors.NewInternal("failed to check tasks", err)
+		}
+		if found != len(uniqueStrings(ids)) {
+			return errors.NewNotFound("task not found", nil)
+		}
+
+		var cycle bool
+		if err := tx.QueryRowContext(ctx, dependencyCreatesCycleQuery, pq.Array(dependsOn), taskID).Scan(&cycle); err != nil {
+			return errors.NewInternal("failed to check dependency cycle", err)
+		}
+		if cycle {
+			return errors.NewValidation("dependency would create a cycle", nil)
+		}
+
+		for _, dependency := range dependsOn {
+			if _, err := tx.ExecContext(ctx, insertDependencyQuery, taskID, dependency); err != nil {
+				return errors.NewInternal("failed to add task dependency", err)
+			}
+		}
+		return nil
+	})
+}
+
+// RemoveTaskDependency удаляет зависимость задачи
+func (r *PostgresTaskRepository) RemoveTaskDependency(ctx context.Context, taskID, dependsOnID string) error {
+	result, err := r.db.ExecContext(ctx, deleteDependencyQuery, taskID, dependsOnID)
+	if err != nil {
+		return errors.NewInternal("failed to remove task dependency", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return errors.NewInternal("failed to retrieve affected rows after removing dependency", err)
+	} else if rowsAffected == 0 {
+		return errors.NewNotFound("task dependency not found", nil)
+	}
+	return nil
+}
+
+// GetTaskDependencies возвращает прямые зависимости указанных задач
+func (r *PostgresTaskRepository) GetTaskDependencies(ctx context.Context, taskIDs []string) (map[string][]string, error) {
+	rows, err := r.db.QueryContext(ctx, getTaskDependenciesQuery, pq.Array(taskIDs))
+	if err != nil {
+		return nil, errors.NewInternal("failed to query task dependencies", err)
+	}
+	defer rows.Close()
+
+	dependencies := make(map[string][]string, len(taskIDs))
+	for rows.Next() {
+		var taskID, dependsOnID string
+		if err := rows.Scan(&taskID, &dependsOnID); err != nil {
+			return nil, errors.NewInternal("failed to scan task dependency", err)
+		}
+		dependencies[taskID] = append(dependencies[taskID], dependsOnID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewInternal("error occurred while iterating over task dependencies", err)
+	}
+	return dependencies, nil
+}
+
+// GetCompletedTaskIDs возвращает задачи из taskIDs, выполненные пользователем
+func (r *PostgresTaskRepository) GetCompletedTaskIDs(ctx context.Context, userID string, taskIDs []string) (map[string]bool, error) {
+	rows, err := r.db.QueryContext(ctx, getCompletedTaskIDsQuery, pq.Array(taskIDs), userID, models.Completed)
+	if err != nil {
+		return nil, errors.NewInternal("failed to query completed tasks", err)
+	}
+	defer rows.Close()
+
+	completed := make(map[string]bool, len(taskIDs))
+	for rows.Next() {
+		var taskID string
+		if err := rows.Scan(&taskID); err != nil {
+			return nil, errors.NewInternal("failed to scan completed task", err)
+		}
+		completed[taskID] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewInternal("error occurred while iterating over completed tasks", err)
+	}
+	return completed, nil
+}
+
+// uniqueStrings возвращает значения без повторов с сохранением порядка
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
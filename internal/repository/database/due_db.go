@@ -0,0 +1,141 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"time"
+)
+
+const (
+	// Просрочка задач с истекшим сроком: задача или личный прогресс пользователя переводятся в Expired
+	// с записью в историю. Задачи и пользователи с заявкой на модерации ждут ее решения.
+	// Строки, заблокированные параллельным переходом, пропускаются до следующего запуска.
+	expireOverdueTasksQuery = `WITH overdue AS (
+	    SELECT t.task_id, t.status
+	    FROM tasks t
+	    WHERE t.deleted_at IS NULL
+	      AND t.due_date < $1
+	      AND t.status IN ($2, $3)
+	      AND NOT EXISTS (SELECT 1 FROM task_submissions s WHERE s.task_id = t.task_id AND s.status = $5)
+	    FOR UPDATE SKIP LOCKED
+	), expired AS (
+	    UPDATE tasks t SET status = $4, updated_at = NOW()
+	    FROM overdue o
+	    WHERE t.task_id = o.task_id
+	    RETURNING t.task_id
+	)
+	INSERT INTO task_status_history (task_id, from_status, to_status, actor, user_id, reward_delta)
+	SELECT o.task_id, o.status, $4, $6, NULL, 0
+	FROM overdue o JOIN expired e ON e.task_id = o.task_id`
+
+	expireOverdueAssignmentsQuery = `WITH overdue AS (
+	    SELECT a.task_id, a.user_id, a.progress
+	    FROM task_assignments a JOIN tasks t ON t.task_id = a.task_id
+	    WHERE t.deleted_at IS NULL
+	      AND t.due_date < $1
+	      AND a.progress IN ($2, $3)
+	      AND NOT EXISTS (
+	          SELECT 1 FROM task_submissions s
+	          WHERE s.task_id = a.task_id AND s.user_id = a.user_id AND s.status = $5
+	      )
+	    FOR UPDATE OF a SKIP LOCKED
+	), expired AS (
+	    UPDATE task_assignments a SET progress = $4, progressed_at = CURRENT_TIMESTAMP
+	    FROM overdue o
+	    WHERE a.task_id = o.task_id AND a.user_id = o.user_id
+	    RETURNING a.task_id, a.user_id
+	)
+	INSERT INTO task_status_history (task_id, from_status, to_status, actor, user_id, reward_delta)
+	SELECT o.task_id, o.progress, $4, $6, o.user_id, 0
+	FROM overdue o JOIN expired e ON e.task_id = o.task_id AND e.user_id = o.user_id`
+
+	// Исполнители задач со сроком в интервале ($1, $2], которым еще не отправлено напоминание:
+	// пользователи с незавершенным личным прогрессом и единственный исполнитель незавершенной задачи
+	getDueRemindersQuery = `SELECT t.task_id, t.title, t.due_date, u.ID, u.Username, u.Email
+	FROM tasks t
+	JOIN LATERAL (
+	    SELECT a.user_id FROM task_assignments a WHERE a.task_id = t.task_id AND a.progress IN ($3, $4)
+	    UNION
+	    SELECT t.assignee_id
+	    WHERE t.assignee_id IS NOT NULL
+	      AND t.status IN ($3, $4)
+	      AND NOT EXISTS (SELECT 1 FROM task_assignments a WHERE a.task_id = t.task_id AND a.user_id = t.assignee_id)
+	) recipients ON TRUE
+	JOIN Users u ON u.ID = recipients.user_id AND u.DeletedAt IS NULL
+	WHERE t.deleted_at IS NULL
+	  AND t.due_date > $1 AND t.due_date <= $2
+	  AND NOT EXISTS (SELECT 1 FROM task_due_reminders r WHERE r.task_id = t.task_id AND r.user_id = u.ID)
+	ORDER BY t.due_date, t.task_id, u.ID
+	LIMIT $5`
+
+	insertDueReminderQuery = `INSERT INTO task_due_reminders (task_id, user_id)
+	VALUES ($1, $2)
+	ON CONFLICT (task_id, user_id) DO NOTHING`
+)
+
+// checkNotPastDue возвращает Conflict, если срок задачи раньше deadline. Вызывается после блокировки
+// строки задачи, чтобы изменение срока или просрочка не вклинились между проверкой и переходом.
+func checkNotPastDue(dueDate *time.Time, deadline time.Time) error {
+	if models.PastDue(dueDate, deadline) {
+		return errors.NewConflict("task is past its due date", nil)
+	}
+	return nil
+}
+
+// ExpireOverdueTasks переводит в Expired незавершенные задачи и личный прогресс пользователей,
+// срок которых истек раньше deadline, и возвращает количество просроченных записей.
+func (r *PostgresTaskRepository) ExpireOverdueTasks(ctx context.Context, deadline time.Time, actor string) (int64, error) {
+	var expired int64
+	err := r.WithTransaction(ctx, func(tx *sql.Tx) error {
+		for _, query := range []string{expireOverdueTasksQuery, expireOverdueAssignmentsQuery} {
+			result, err := tx.ExecContext(ctx, query, deadline, models.NotStarted, models.InProgress, models.Expired,
+				models.SubmissionPending, actor)
+			if err != nil {
+				return errors.NewInternal("failed to expire overdue tasks", err)
+			}
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return errors.NewInternal("failed to retrieve affected rows after expiring tasks", err)
+			}
+			expired += rowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return expired, nil
+}
+
+// GetDueReminders возвращает не более limit напоминаний исполнителям задач со сроком в интервале (from, to]
+func (r *PostgresTaskRepository) GetDueReminders(ctx context.Context, from, to time.Time, limit int) ([]models.DueReminder, error) {
+	rows, err := r.db.QueryContext(ctx, getDueRemindersQuery, from, to, models.NotStarted, models.InProgress, limit)
+	if err != nil {
+		return nil, errors.NewInternal("failed to query due reminders", err)
+	}
+	defer rows.Close()
+
+	reminders := []models.DueReminder{}
+	for rows.Next() {
+		var reminder models.DueReminder
+		if err := rows.Scan(&reminder.TaskID, &reminder.Title, &reminder.DueDate, &reminder.UserID,
+			&reminder.Username, &reminder.Email); err != nil {
+			return nil, errors.NewInternal("failed to scan due reminder", err)
+		}
+		reminders = append(reminders, reminder)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewInternal("error occurred while iterating over due reminders", err)
+	}
+	return reminders, nil
+}
+
+// MarkDueReminderSent отмечает напоминание исполнителю задачи отправленным
+func (r *PostgresTaskRepository) MarkDueReminderSent(ctx context.Context, taskID, userID string) error {
+	if _, err := r.db.ExecContext(ctx, insertDueReminderQuery, taskID, userID); err != nil {
+		return errors.NewInternal("failed to mark due reminder as sent", err)
+	}
+	return nil
+}
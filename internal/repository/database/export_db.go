@@ -0,0 +1,153 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+)
+
+// exportFetchSize - количество строк, получаемых из курсора за один FETCH
+const exportFetchSize = 500
+
+const (
+	exportUsersQuery = `SELECT ID, Username, Email, COALESCE(Balance, 0), COALESCE(Referrals, 0), COALESCE(ReferralCode, ''),
+	       COALESCE(TasksCompleted, 0), CreatedAt, UpdatedAt, LastVisit, COALESCE(VisitCount, 0), COALESCE(Bio, ''),
+	       COALESCE(TimeZone, ''), Status
+	FROM Users
+	WHERE ($1::timestamp IS NULL OR CreatedAt >= $1)
+	  AND ($2::timestamp IS NULL OR CreatedAt < $2)
+	  AND ($3::int IS NULL OR Status = $3)
+	  AND DeletedAt IS NULL
+	ORDER BY CreatedAt, ID`
+
+	exportTasksQuery = `SELECT ` + taskColumns + `
+	FROM tasks
+	WHERE ($1::timestamptz IS NULL OR created_at >= $1)
+	  AND ($2::timestamptz IS NULL OR created_at < $2)
+	  AND ($3::int IS NULL OR status = $3)
+	  AND deleted_at IS NULL
+	ORDER BY created_at, task_id`
+
+	exportLedgerQuery = `SELECT id, user_id, amount, balance_after, reason, created_at
+	FROM balance_ledger
+	WHERE ($1::timestamptz IS NULL OR created_at >= $1)
+	  AND ($2::timestamptz IS NULL OR created_at < $2)
+	  AND ($3::varchar IS NULL OR user_id = $3)
+	ORDER BY id`
+)
+
+// PostgresExportRepository читает данные для выгрузки через серверный курсор
+type PostgresExportRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresExportRepository создает новый репозиторий выгрузки с указанным соединением с БД.
+func NewPostgresExportRepository(db *sql.DB) repository.ExportRepository {
+	return &PostgresExportRepository{db: db}
+}
+
+// withCursor открывает серверный курсор для запроса в read-only транзакции и передает
+// в scan полученные порциями строки, пока курсор не будет исчерпан.
+func (r *PostgresExportRepository) withCursor(ctx context.Context, query string, args []interface{}, scan func(*sql.Rows) error) error {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return errors.NewInternal("failed to begin export transaction", err)
+	}
+	// Транзакция только читает данные, поэтому откат безопасен в любом случае
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DECLARE export_cursor NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		return errors.NewInternal("failed to declare export cursor", err)
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM export_cursor", exportFetchSize)
+	for {
+		rows, err := tx.QueryContext(ctx, fetch)
+		if err != nil {
+			return errors.NewInternal("failed to fetch from export cursor", err)
+		}
+
+		count := 0
+		for rows.Next() {
+			count++
+			if err := scan(rows); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return errors.NewInternal("error occurred while iterating over export cursor", err)
+		}
+		rows.Close()
+
+		if count < exportFetchSize {
+			return nil
+		}
+	}
+}
+
+// exportArgs формирует параметры запросов выгрузки; нулевые значения фильтра передаются как NULL.
+func exportArgs(filter *models.ExportFilter, third interface{}) []interface{} {
+	return []interface{}{filter.From, filter.To, third}
+}
+
+// nullableString возвращает nil для пустой строки.
+func nullableString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+// nullableStatus возвращает nil для незаданного статуса.
+func nullableStatus(status int) interface{} {
+	if status == 0 {
+		return nil
+	}
+	return status
+}
+
+// ExportUsers выгружает пользователей, отсортированных по дате создания
+func (r *PostgresExportRepository) ExportUsers(ctx context.Context, filter *models.ExportFilter, fn func(*models.User) error) error {
+	return r.withCursor(ctx, exportUsersQuery, exportArgs(filter, nullableStatus(filter.Status)), func(rows *sql.Rows) error {
+		var user models.User
+		var lastVisit sql.NullTime
+		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.Balance, &user.Referrals,
+			&user.ReferralCode, &user.TasksCompleted, &user.CreatedAt, &user.UpdatedAt,
+			&lastVisit, &user.VisitCount, &user.Bio, &user.TimeZone, &user.Status); err != nil {
+			return errors.NewInternal("failed to scan user", err)
+		}
+		user.LastVisit = lastVisit.Time
+		return fn(&user)
+	})
+}
+
+// ExportTasks выгружает задачи, отсортированные по дате создания
+func (r *PostgresExportRepository) ExportTasks(ctx context.Context, filter *models.ExportFilter, fn func(*models.Task) error) error {
+	return r.withCursor(ctx, exportTasksQuery, exportArgs(filter, nullableStatus(filter.Status)), func(rows *sql.Rows) error {
+		var task models.Task
+		if err := scanTaskFields(rows, &task); err != nil {
+			return errors.NewInternal("failed to scan task", err)
+		}
+		return fn(&task)
+	})
+}
+
+// ExportLedger выгружает журнал изменений баланса в хронологическом порядке
+func (r *PostgresExportRepository) ExportLedger(ctx context.Context, filter *models.ExportFilter, fn func(*models.LedgerEntry) error) error {
+	var userID interface{}
+	if filter.UserID != "" {
+		userID = filter.UserID
+	}
+	return r.withCursor(ctx, exportLedgerQuery, exportArgs(filter, userID), func(rows *sql.Rows) error {
+		var entry models.LedgerEntry
+		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.Amount, &entry.BalanceAfter, &entry.Reason, &entry.CreatedAt); err != nil {
+			return errors.NewInternal("failed to scan ledger entry", err)
+		}
+		return fn(&entry)
+	})
+}
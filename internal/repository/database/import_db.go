@@ -0,0 +1,127 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+const (
+	getExistingEmailsQuery = `SELECT LOWER(Email) FROM Users WHERE LOWER(Email) = ANY($1) AND DeletedAt IS NULL`
+
+	getExistingTaskKeysQuery = `SELECT title, COALESCE(description, '') FROM tasks WHERE title = ANY($1) AND deleted_at IS NULL`
+)
+
+// copyInTx выполняет COPY в указанную таблицу в рамках транзакции; rows возвращает значения колонок по индексу.
+func copyInTx(ctx context.Context, db *sql.DB, table string, columns []string, count int, row func(i int) []interface{}) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if err := copyRows(ctx, tx, table, columns, count, row); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("rollback failed: %v, original error: %v", rbErr, err)
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// copyRows передает строки в открытый оператор COPY и завершает его.
+func copyRows(ctx context.Context, tx *sql.Tx, table string, columns []string, count int, row func(i int) []interface{}) error {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, columns...))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i := 0; i < count; i++ {
+		if _, err := stmt.ExecContext(ctx, row(i)...); err != nil {
+			return err
+		}
+	}
+	// Пустой Exec сбрасывает буфер COPY и возвращает ошибки ограничений
+	_, err = stmt.ExecContext(ctx)
+	return err
+}
+
+// GetExistingEmails возвращает множество адресов из списка, уже занятых пользователями.
+// Адреса сравниваются без учета регистра; ключи результата - в нижнем регистре.
+func (r *PostgresUserRepository) GetExistingEmails(ctx context.Context, emails []string) (map[string]bool, error) {
+	lowered := make([]string, len(emails))
+	for i, email := range emails {
+		lowered[i] = strings.ToLower(email)
+	}
+	rows, err := r.db.QueryContext(ctx, getExistingEmailsQuery, pq.Array(lowered))
+	if err != nil {
+		return nil, errors.NewInternal("failed to check existing emails", err)
+	}
+	defer rows.Close()
+
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, errors.NewInternal("failed to scan email", err)
+		}
+		existing[email] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewInternal("error occurred while iterating over emails", err)
+	}
+	return existing, nil
+}
+
+// CopyUsers вставляет пользователей одной транзакцией с помощью COPY
+func (r *PostgresUserRepository) CopyUsers(ctx context.Context, users []*models.User) error {
+	columns := []string{"id", "username", "email", "balance", "referralcode", "bio", "timezone", "status", "createdat", "updatedat"}
+	err := copyInTx(ctx, r.db, "users", columns, len(users), func(i int) []interface{} {
+		u := users[i]
+		return []interface{}{u.ID, u.Username, u.Email, u.Balance, u.ReferralCode, u.Bio, u.TimeZone, int(u.Status), u.CreatedAt, u.UpdatedAt}
+	})
+	if err != nil {
+		return errors.NewInternal("failed to copy users", err)
+	}
+	return nil
+}
+
+// GetExistingTaskKeys возвращает пары заголовок/описание уже существующих задач с указанными заголовками
+func (r *PostgresTaskRepository) GetExistingTaskKeys(ctx context.Context, titles []string) (map[models.TaskKey]bool, error) {
+	rows, err := r.db.QueryContext(ctx, getExistingTaskKeysQuery, pq.Array(titles))
+	if err != nil {
+		return nil, errors.NewInternal("failed to check existing tasks", err)
+	}
+	defer rows.Close()
+
+	existing := make(map[models.TaskKey]bool)
+	for rows.Next() {
+		var key models.TaskKey
+		if err := rows.Scan(&key.Title, &key.Description); err != nil {
+			return nil, errors.NewInternal("failed to scan task key", err)
+		}
+		existing[key] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewInternal("error occurred while iterating over tasks", err)
+	}
+	return existing, nil
+}
+
+// CopyTasks вставляет задачи одной транзакцией с помощью COPY
+func (r *PostgresTaskRepository) CopyTasks(ctx context.Context, tasks []*models.Task) error {
+	columns := []string{"task_id", "title", "description", "due_date", "status", "assignee_id", "reward", "created_at", "updated_at"}
+	err := copyInTx(ctx, r.db, "tasks", columns, len(tasks), func(i int) []interface{} {
+		t := tasks[i]
+		return []interface{}{t.TaskID, t.Title, t.Description, t.DueDate, int(t.Status), t.AssigneeID, t.Reward, t.CreatedAt, t.UpdatedAt}
+	})
+	if err != nil {
+		return errors.NewInternal("failed to copy tasks", err)
+	}
+	return nil
+}
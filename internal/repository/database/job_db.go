@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"hash/fnv"
+	"time"
+)
+
+// jobLockNamespace - первый ключ сессионной advisory-блокировки заданий; второй ключ - хеш имени задания
+const jobLockNamespace = 7364603
+
+const (
+	jobRunColumns = `id, job_name, scheduled_at, started_at, finished_at, status, COALESCE(error, ''), instance`
+
+	tryLockJobQuery = `SELECT pg_try_advisory_lock($1, $2)`
+
+	unlockJobQuery = `SELECT pg_advisory_unlock($1, $2)`
+
+	startJobRunQuery = `INSERT INTO job_runs (job_name, scheduled_at, status, instance)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (job_name, scheduled_at) DO NOTHING
+	RETURNING id, started_at`
+
+	finishJobRunQuery = `UPDATE job_runs SET status = $1, error = NULLIF($2, ''), finished_at = $3 WHERE id = $4`
+
+	getJobRunsQuery = `SELECT ` + jobRunColumns + `
+	FROM job_runs
+	WHERE ($1::varchar IS NULL OR job_name = $1)
+	  AND ($2::varchar IS NULL OR status = $2)
+	ORDER BY id DESC
+	LIMIT $3`
+
+	purgeJobRunsQuery = `DELETE FROM job_runs WHERE started_at < $1`
+)
+
+// PostgresJobRepository реализует JobRepository для PostgreSQL
+type PostgresJobRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresJobRepository создает новый репозиторий заданий с указанным соединением с БД.
+func NewPostgresJobRepository(db *sql.DB) repository.JobRepository {
+	return &PostgresJobRepository{db: db}
+}
+
+// TryLockJob захватывает сессионную advisory-блокировку задания на выделенном соединении.
+// Блокировка держится, пока соединение не вернется в пул, и снимается сервером при обрыве соединения.
+func (r *PostgresJobRepository) TryLockJob(ctx context.Context, name string) (func(), bool, error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, false, errors.NewInternal("failed to get database connection", err)
+	}
+
+	key := jobLockKey(name)
+	var acquired bool
+	if err := conn.QueryRowContext(ctx, tryLockJobQuery, jobLockNamespace, key).Scan(&acquired); err != nil {
+		conn.Close()
+		return nil, false, errors.NewInternal("failed to lock job", err)
+	}
+	if !acquired {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		// Контекст задания к этому моменту может быть отменен, а блокировку нужно снять в любом случае
+		_, _ = conn.ExecContext(context.Background(), unlockJobQuery, jobLockNamespace, key)
+		conn.Close()
+	}
+	return unlock, true, nil
+}
+
+// StartJobRun записывает начало запуска задания
+func (r *PostgresJobRepository) StartJobRun(ctx context.Context, run *models.JobRun) (bool, error) {
+	err := r.db.QueryRowContext(ctx, startJobRunQuery, run.JobName, run.ScheduledAt, run.Status, run.Instance).
+		Scan(&run.ID, &run.StartedAt)
+	if err == sql.ErrNoRows {
+		return false, nil
+	} else if err != nil {
+		return false, errors.NewInternal("failed to record job run", err)
+	}
+	return true, nil
+}
+
+// FinishJobRun записывает результат запуска задания
+func (r *PostgresJobRepository) FinishJobRun(ctx context.Context, run *models.JobRun) error {
+	if _, err := r.db.ExecContext(ctx, finishJobRunQuery, run.Status, run.Error, run.FinishedAt, run.ID); err != nil {
+		return errors.NewInternal("failed to record job run result", err)
+	}
+	return nil
+}
+
+// GetJobRuns возвращает запуски заданий по фильтру, начиная с последних
+func (r *PostgresJobRepository) GetJobRuns(ctx context.Context, filter *models.JobRunFilter) ([]models.JobRun, error) {
+	rows, err := r.db.QueryContext(ctx, getJobRunsQuery,
+		nullableString(filter.JobName), nullableString(string(filter.Status)), filter.Limit)
+	if err != nil {
+		return nil, errors.NewInternal("failed to query job runs", err)
+	}
+	defer rows.Close()
+
+	runs := []models.JobRun{}
+	for rows.Next() {
+		var run models.JobRun
+		if err := rows.Scan(&run.ID, &run.JobName, &run.ScheduledAt, &run.StartedAt, &run.FinishedAt,
+			&run.Status, &run.Error, &run.Instance); err != nil {
+			return nil, errors.NewInternal("failed to scan job run", err)
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewInternal("error occurred while iterating over job runs", err)
+	}
+	return runs, nil
+}
+
+// PurgeJobRuns удаляет записи о запусках, начатых раньше before
+func (r *PostgresJobRepository) PurgeJobRuns(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, purgeJobRunsQuery, before)
+	if err != nil {
+		return 0, errors.NewInternal("failed to purge job runs", err)
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, errors.NewInternal("failed to retrieve affected rows after purging job runs", err)
+	}
+	return purged, nil
+}
+
+// jobLockKey возвращает ключ блокировки задания, одинаковый на всех репликах
+func jobLockKey(name string) int32 {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return int32(h.Sum32())
+}
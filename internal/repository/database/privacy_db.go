@@ -0,0 +1,218 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const (
+	// Профиль пользователя вне зависимости от мягкого удаления
+	getUserProfileQuery = `SELECT ` + userColumns + ` FROM Users WHERE ID = $1`
+
+	getUserVisitsQuery = `SELECT VisitDate FROM UserVisits WHERE UserID = $1 ORDER BY VisitDate`
+
+	getUserActivityQuery = `SELECT ActivityTime FROM UserActivityLog WHERE UserID = $1 ORDER BY ActivityTime`
+
+	getUserTasksQuery = `SELECT ` + taskColumns + `
+	FROM tasks
+	WHERE assignee_id = $1 OR completed_by = $1
+	   OR task_id IN (SELECT task_id FROM task_assignments WHERE user_id = $1)
+	ORDER BY created_at`
+
+	getUserReferralsQuery = `SELECT referral_id, user_id, code, created_at, updated_at
+	FROM referral WHERE user_id = $1 ORDER BY created_at`
+
+	getUserSubmissionsQuery = `SELECT ` + submissionColumns + `
+	FROM task_submissions WHERE user_id = $1 ORDER BY created_at, id`
+
+	getUserLedgerQuery = `SELECT id, user_id, amount, balance_after, reason, created_at
+	FROM balance_ledger WHERE user_id = $1 ORDER BY id`
+
+	// Имя и адрес заменяются детерминированными значениями на основе ID, чтобы сохранить уникальность Email.
+	// Реферальный код мог быть составлен из имени, поэтому удаляется.
+	// Доказательства в заявках на выполнение задач также могут содержать персональные данные и затираются.
+	// Баланс не изменяется, поэтому триггер журнала баланса не срабатывает.
+	anonymizeUserQuery = `WITH redacted AS (
+	    UPDATE task_submissions SET evidence = '[anonymized]' WHERE user_id = $1
+	)
+	UPDATE Users
+	SET Username = 'anonymized-' || LEFT(ID, 8),
+	    Email = 'anonymized-' || ID || '@anonymized.invalid',
+	    Bio = NULL,
+	    ReferralCode = NULL,
+	    UpdatedAt = CURRENT_TIMESTAMP
+	WHERE ID = $1
+	RETURNING ` + userColumns
+
+	// В записях журнала аудита о пользователе, его заявках и его собственных действиях персональные поля
+	// снимков ($2) заменяются на $3, а IP запросов самого пользователя удаляется. Хеши записей не меняются.
+	redactUserAuditQuery = `UPDATE audit_log
+	SET before = COALESCE((SELECT json_object_agg(f.key, CASE WHEN f.key = ANY($2) THEN to_json($3::text) ELSE f.value END)
+	                       FROM json_each(before) f), before),
+	    after = COALESCE((SELECT json_object_agg(f.key, CASE WHEN f.key = ANY($2) THEN to_json($3::text) ELSE f.value END)
+	                      FROM json_each(after) f), after),
+	    ip = CASE WHEN actor = $1 THEN '' ELSE ip END,
+	    redacted_at = CURRENT_TIMESTAMP
+	WHERE redacted_at IS NULL
+	  AND (actor = $1
+	       OR (target_type = 'user' AND target_id = $1)
+	       OR (target_type = 'submission' AND target_id IN (SELECT id::text FROM task_submissions WHERE user_id = $1)))`
+)
+
+// PostgresPrivacyRepository реализует PrivacyRepository для PostgreSQL
+type PostgresPrivacyRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresPrivacyRepository создает новый репозиторий персональных данных с указанным соединением с БД.
+func NewPostgresPrivacyRepository(db *sql.DB) repository.PrivacyRepository {
+	return &PostgresPrivacyRepository{db: db}
+}
+
+// GetUserData собирает данные пользователя в одной read-only транзакции, чтобы разделы были согласованы между собой
+func (r *PostgresPrivacyRepository) GetUserData(ctx context.Context, id uuid.UUID) (*models.UserDataExport, error) {
+	tx, err := r.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true, Isolation: sql.LevelRepeatableRead})
+	if err != nil {
+		return nil, errors.NewInternal("failed to begin data export transaction", err)
+	}
+	// Транзакция только читает данные, поэтому откат безопасен в любом случае
+	defer tx.Rollback()
+
+	var profile models.User
+	if err := scanUserFields(tx.QueryRowContext(ctx, getUserProfileQuery, id), &profile); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.NewNotFound("user not found", nil)
+		}
+		return nil, errors.NewInternal("failed to get user profile", err)
+	}
+
+	data := &models.UserDataExport{
+		GeneratedAt:    time.Now().UTC(),
+		Profile:        &profile,
+		Visits:         []time.Time{},
+		ActivityLog:    []time.Time{},
+		Tasks:          []models.Task{},
+		Referrals:      []models.Referral{},
+		Submissions:    []models.Submission{},
+		BalanceHistory: []models.LedgerEntry{},
+	}
+
+	if err := queryRows(ctx, tx, getUserVisitsQuery, id, func(rows *sql.Rows) error {
+		var visit time.Time
+		if err := rows.Scan(&visit); err != nil {
+			return err
+		}
+		data.Visits = append(data.Visits, visit)
+		return nil
+	}); err != nil {
+		return nil, errors.NewInternal("failed to get user visits", err)
+	}
+
+	if err := queryRows(ctx, tx, getUserActivityQuery, id, func(rows *sql.Rows) error {
+		var activity time.Time
+		if err := rows.Scan(&activity); err != nil {
+			return err
+		}
+		data.ActivityLog = append(data.ActivityLog, activity)
+		return nil
+	}); err != nil {
+		return nil, errors.NewInternal("failed to get user activity log", err)
+	}
+
+	if err := queryRows(ctx, tx, getUserTasksQuery, id, func(rows *sql.Rows) error {
+		var task models.Task
+		if err := scanTaskFields(rows, &task); err != nil {
+			return err
+		}
+		data.Tasks = append(data.Tasks, task)
+		return nil
+	}); err != nil {
+		return nil, errors.NewInternal("failed to get user tasks", err)
+	}
+
+	if err := queryRows(ctx, tx, getUserReferralsQuery, id, func(rows *sql.Rows) error {
+		var referral models.Referral
+		if err := rows.Scan(&referral.ReferralID, &referral.UserID, &referral.Code,
+			&referral.CreatedAt, &referral.UpdatedAt); err != nil {
+			return err
+		}
+		data.Referrals = append(data.Referrals, referral)
+		return nil
+	}); err != nil {
+		return nil, errors.NewInternal("failed to get user referrals", err)
+	}
+
+	if err := queryRows(ctx, tx, getUserSubmissionsQuery, id, func(rows *sql.Rows) error {
+		var submission models.Submission
+		if err := scanSubmission(rows, &submission); err != nil {
+			return err
+		}
+		data.Submissions = append(data.Submissions, submission)
+		return nil
+	}); err != nil {
+		return nil, errors.NewInternal("failed to get user submissions", err)
+	}
+
+	if err := queryRows(ctx, tx, getUserLedgerQuery, id, func(rows *sql.Rows) error {
+		var entry models.LedgerEntry
+		if err := rows.Scan(&entry.ID, &entry.UserID, &entry.Amount, &entry.BalanceAfter,
+			&entry.Reason, &entry.CreatedAt); err != nil {
+			return err
+		}
+		data.BalanceHistory = append(data.BalanceHistory, entry)
+		return nil
+	}); err != nil {
+		return nil, errors.NewInternal("failed to get user balance history", err)
+	}
+
+	return data, nil
+}
+
+// queryRows выполняет запрос с единственным параметром и вызывает scan для каждой строки
+func queryRows(ctx context.Context, tx *sql.Tx, query string, arg interface{}, scan func(*sql.Rows) error) error {
+	rows, err := tx.QueryContext(ctx, query, arg)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// AnonymizeUser затирает Email, Username, Bio и реферальный код пользователя и его персональные данные
+// в журнале аудита одной транзакцией
+func (r *PostgresPrivacyRepository) AnonymizeUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.NewInternal("failed to begin anonymization transaction", err)
+	}
+	defer tx.Rollback()
+
+	var user models.User
+	if err := scanUserFields(tx.QueryRowContext(ctx, anonymizeUserQuery, id.String()), &user); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errors.NewNotFound("user not found", nil)
+		}
+		return nil, errors.NewInternal("failed to anonymize user", err)
+	}
+	if _, err := tx.ExecContext(ctx, redactUserAuditQuery, id.String(), pq.Array(models.AuditPersonalFields), models.AuditRedacted); err != nil {
+		return nil, errors.NewInternal("failed to redact audit log", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.NewInternal("failed to commit anonymization", err)
+	}
+	return &user, nil
+}
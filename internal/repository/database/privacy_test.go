@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/testdb"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestAnonymizeUserRedactsAuditLog проверяет, что после анонимизации в журнале аудита не остается
+// персональных данных пользователя, а цепочка хешей по-прежнему проверяется.
+func TestAnonymizeUserRedactsAuditLog(t *testing.T) {
+	db := testdb.New(t)
+	ctx := context.Background()
+	users := NewPostgresUserRepository(db)
+	audit := NewPostgresAuditRepository(db)
+	privacy := NewPostgresPrivacyRepository(db)
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	erin := &models.User{ID: uuid.NewString(), Username: "erin", Email: "erin@example.com", ReferralCode: "ERIN2024",
+		Status: models.Active, CreatedAt: now, UpdatedAt: now}
+	other := &models.User{ID: uuid.NewString(), Username: "frank", Email: "frank@example.com", Status: models.Active,
+		CreatedAt: now, UpdatedAt: now}
+	if err := users.CopyUsers(ctx, []*models.User{erin, other}); err != nil {
+		t.Fatalf("create users: %v", err)
+	}
+
+	entries := []*models.AuditEntry{
+		// Действие администратора над пользователем: снимки содержат имя и адрес
+		{Actor: "admin", Action: models.AuditUserStatusChange, TargetType: models.AuditTargetUser, TargetID: erin.ID,
+			Before: json.RawMessage(`{"Email":"erin@example.com","Status":1,"Username":"erin"}`),
+			After:  json.RawMessage(`{"Email":"erin@example.com","Status":3,"Username":"erin"}`), IP: "10.0.0.1"},
+		// Действие самого пользователя: его IP - персональные данные
+		{Actor: erin.ID, Action: models.AuditTaskDelete, TargetType: models.AuditTargetTask, TargetID: uuid.NewString(),
+			Before: json.RawMessage(`{"title":"Review"}`), IP: "203.0.113.7"},
+		// Запись о другом пользователе не меняется
+		{Actor: "admin", Action: models.AuditUserStatusChange, TargetType: models.AuditTargetUser, TargetID: other.ID,
+			Before: json.RawMessage(`{"Email":"frank@example.com"}`), IP: "10.0.0.1"},
+	}
+	for _, entry := range entries {
+		entry.CreatedAt = now
+		if err := audit.AppendEntry(ctx, entry); err != nil {
+			t.Fatalf("append audit entry: %v", err)
+		}
+	}
+
+	anonymized, err := privacy.AnonymizeUser(ctx, uuid.MustParse(erin.ID))
+	if err != nil {
+		t.Fatalf("anonymize user: %v", err)
+	}
+	if anonymized.ReferralCode != "" || strings.Contains(anonymized.Email, "erin") {
+		t.Fatalf("user keeps personal data: %+v", anonymized)
+	}
+
+	var stored []models.AuditEntry
+	if err := audit.ScanEntries(ctx, func(entry *models.AuditEntry) error {
+		stored = append(stored, *entry)
+		return nil
+	}); err != nil {
+		t.Fatalf("scan audit log: %v", err)
+	}
+	if len(stored) != len(entries) {
+		t.Fatalf("audit log has %d entries, want %d", len(stored), len(entries))
+	}
+
+	for i, entry := range stored[:2] {
+		content := string(entry.Before) + string(entry.After) + entry.IP
+		for _, leaked := range []string{"erin@example.com", `"erin"`, "203.0.113.7"} {
+			if strings.Contains(content, leaked) {
+				t.Errorf("entry %d keeps %s: %s", i+1, leaked, content)
+			}
+		}
+		if entry.RedactedAt == nil {
+			t.Errorf("entry %d is not marked as redacted", i+1)
+		}
+	}
+	if want := `{"Email":"[anonymized]","Status":1,"Username":"[anonymized]"}`; !jsonEqual(t, stored[0].Before, want) {
+		t.Errorf("redacted snapshot: %s, want %s", stored[0].Before, want)
+	}
+	if stored[0].IP != "10.0.0.1" {
+		t.Errorf("administrator IP was removed: %q", stored[0].IP)
+	}
+	if stored[2].RedactedAt != nil || !jsonEqual(t, stored[2].Before, `{"Email":"frank@example.com"}`) {
+		t.Errorf("entry about another user changed: %+v", stored[2])
+	}
+
+	// Цепочка хешей не нарушена затиранием
+	prevHash := ""
+	for i, entry := range stored {
+		if entry.PrevHash != prevHash || entry.ComputeHash() != entry.Hash {
+			t.Fatalf("hash chain is broken at entry %d", i+1)
+		}
+		if entry.RedactedAt == nil && entry.ComputeContentHash() != entry.ContentHash {
+			t.Fatalf("content of entry %d does not match its hash", i+1)
+		}
+		prevHash = entry.Hash
+	}
+
+	// Кроме затирания, записи журнала по-прежнему нельзя изменять
+	if _, err := db.ExecContext(ctx, `UPDATE audit_log SET actor = 'someone' WHERE id = $1`, stored[0].ID); err == nil {
+		t.Fatal("audit entry was modified")
+	}
+}
+
+// jsonEqual сравнивает JSON без учета порядка ключей и пробелов
+func jsonEqual(t *testing.T, got json.RawMessage, want string) bool {
+	t.Helper()
+	var g, w interface{}
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("parse %s: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("parse %s: %v", want, err)
+	}
+	gotJSON, _ := json.Marshal(g)
+	wantJSON, _ := json.Marshal(w)
+	return string(gotJSON) == string(wantJSON)
+}
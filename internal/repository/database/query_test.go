@@ -0,0 +1,146 @@
+package database
+
+import (
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// whereClause возвращает часть запроса между WHERE и ORDER BY
+func whereClause(sql string) string {
+	_, where, found := strings.Cut(sql, " WHERE ")
+	if !found {
+		return ""
+	}
+	where, _, _ = strings.Cut(where, " ORDER BY ")
+	return where
+}
+
+func TestTaskListQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    models.TaskFilter
+		wantWhere string
+		wantArgs  []interface{}
+	}{
+		{
+			name:      "only soft-delete condition by default",
+			filter:    models.TaskFilter{},
+			wantWhere: "deleted_at IS NULL",
+			wantArgs:  []interface{}{},
+		},
+		{
+			name:      "description filter is applied",
+			filter:    models.TaskFilter{Title: "report", Description: "weekly"},
+			wantWhere: "title ILIKE '%' || $1 || '%' AND description ILIKE '%' || $2 || '%' AND deleted_at IS NULL",
+			wantArgs:  []interface{}{"report", "weekly"},
+		},
+		{
+			name: "statuses and assignee",
+			filter: models.TaskFilter{
+				Status:         models.Expired,
+				Statuses:       []models.TaskStatus{models.Completed},
+				AssigneeID:     "u1",
+				IncludeDeleted: true,
+			},
+			wantWhere: "status IN ($1, $2) AND assignee_id = $3",
+			wantArgs:  []interface{}{models.Completed, models.Expired, "u1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := taskListQuery(&tt.filter).Build()
+			if where := whereClause(sql); where != tt.wantWhere {
+				t.Errorf("WHERE:\n got: %s\nwant: %s", where, tt.wantWhere)
+			}
+			if len(args) == 0 {
+				args = []interface{}{}
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args: got %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestBuildUsersQuery(t *testing.T) {
+	minBalance := 10.0
+	hasReferrer := true
+	filter := &models.UserFilter{
+		EmailDomain: "example.com",
+		MinBalance:  &minBalance,
+		HasReferrer: &hasReferrer,
+		Sort:        []models.UserSort{{Field: models.UserSortBalance, Desc: true}, {Field: models.UserSortUsername}},
+		Pagination: pagination.Page{
+			Limit:  5,
+			Cursor: &pagination.Cursor{Keys: []string{"20", "bob", "u1"}},
+		},
+	}
+
+	sql, args, _, err := buildUsersQuery(filter)
+	if err != nil {
+		t.Fatalf("buildUsersQuery: %v", err)
+	}
+	wantWhere := "LOWER(SPLIT_PART(Email, '@', 2)) = LOWER($1) AND COALESCE(Balance, 0) >= $2 AND DeletedAt IS NULL" +
+		" AND COALESCE(ReferralCode, '') <> ''" +
+		" AND ((COALESCE(Balance, 0) < $3) OR (COALESCE(Balance, 0) = $3 AND Username > $4)" +
+		" OR (COALESCE(Balance, 0) = $3 AND Username = $4 AND ID > $5))"
+	if where := whereClause(sql); where != wantWhere {
+		t.Errorf("WHERE:\n got: %s\nwant: %s", where, wantWhere)
+	}
+	if want := " ORDER BY COALESCE(Balance, 0) DESC, Username ASC, ID ASC LIMIT $6"; !strings.HasSuffix(sql, want) {
+		t.Errorf("SQL %q does not end with %q", sql, want)
+	}
+	if want := []interface{}{"example.com", &minBalance, 20.0, "bob", "u1", 6}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args: got %v, want %v", args, want)
+	}
+
+	// Курсор другой сортировки отклоняется
+	filter.Sort = filter.Sort[:1]
+	if _, _, _, err := buildUsersQuery(filter); err == nil {
+		t.Error("expected error for cursor that does not match the sort")
+	}
+}
+
+// Любой ввод превращается в корректный запрос to_tsquery из слов с поиском по префиксу
+// или в пустую строку, при которой поиск не выполняется
+func TestPrefixTSQuery(t *testing.T) {
+	valid := regexp.MustCompile(`^[\pL\pN]+:\*( & [\pL\pN]+:\*)*$`)
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"report", "report:*"},
+		{"Weekly  Report", "weekly:* & report:*"},
+		{"a & b", "a:* & b:*"},
+		{"a | b & !c", "a:* & b:* & c:*"},
+		{"foo:*", "foo:*"},
+		{"foo:* & bar:A", "foo:* & bar:* & a:*"},
+		{"(foo <-> bar)", "foo:* & bar:*"},
+		{"O'Brien", "o:* & brien:*"},
+		{`"quoted" phrase`, "quoted:* & phrase:*"},
+		{"отчёт 2024", "отчёт:* & 2024:*"},
+		{"e-mail_address", "e:* & mail:* & address:*"},
+		{"'", ""},
+		{"!", ""},
+		{"&", ""},
+		{":*", ""},
+		{"''", ""},
+		{"", ""},
+		{" \t\n ", ""},
+		{"!!! ||| &&&", ""},
+	}
+	for _, tt := range tests {
+		got := prefixTSQuery(tt.text)
+		if got != tt.want {
+			t.Errorf("prefixTSQuery(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+		if got != "" && !valid.MatchString(got) {
+			t.Errorf("prefixTSQuery(%q) = %q is not a prefix query", tt.text, got)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+
+	"github.com/lib/pq"
+)
+
+const (
+	insertQuestQuery = `INSERT INTO quests (quest_id, title, description, bonus)
+	VALUES ($1, $2, NULLIF($3, ''), $4)`
+
+	insertQuestTaskQuery = `INSERT INTO quest_tasks (quest_id, task_id, position) VALUES ($1, $2, $3)`
+
+	getQuestByIDQuery = `SELECT quest_id, title, COALESCE(description, ''), bonus, created_at, updated_at
+	FROM quests WHERE quest_id = $1`
+
+	getQuestsQuery = `SELECT quest_id, title, COALESCE(description, ''), bonus, created_at, updated_at
+	FROM quests
+	ORDER BY created_at DESC, quest_id
+	LIMIT $1 OFFSET $2`
+
+	// Удаленные задачи не входят в квест и не требуются для его выполнения
+	getQuestTasksQuery = `SELECT qt.quest_id, qt.task_id, t.title, qt.position
+	FROM quest_tasks qt JOIN tasks t ON t.task_id = qt.task_id
+	WHERE qt.quest_id = ANY($1) AND t.deleted_at IS NULL
+	ORDER BY qt.quest_id, qt.position`
+
+	getQuestCompletionQuery = `SELECT quest_id, user_id, bonus, completed_at
+	FROM quest_completions WHERE quest_id = $1 AND user_id = $2`
+
+	// Бонус начисляется по квестам с задачей $1, все задачи которых засчитаны пользователю $2;
+	// первичный ключ quest_completions не дает выплатить бонус дважды
+	awardQuestBonusesQuery = `INSERT INTO quest_completions (quest_id, user_id, bonus)
+	SELECT q.quest_id, $2, q.bonus
+	FROM quests q JOIN quest_tasks qt ON qt.quest_id = q.quest_id
+	WHERE qt.task_id = $1
+	  AND NOT EXISTS (
+	      SELECT 1 FROM quest_tasks qt2 JOIN tasks t ON t.task_id = qt2.task_id
+	      WHERE qt2.quest_id = q.quest_id AND t.deleted_at IS NULL AND NOT ` + taskDoneByUserExpr + `
+	  )
+	ON CONFLICT (quest_id, user_id) DO NOTHING
+	RETURNING bonus`
+
+	revokeQuestBonusesQuery = `DELETE FROM quest_completions c
+	USING quest_tasks qt
+	WHERE qt.quest_id = c.quest_id AND qt.task_id = $1 AND c.user_id = $2
+	RETURNING c.bonus`
+
+	// Запросы изменения баланса на сумму бонусов возвращают фактическое изменение:
+	// при возврате баланс не опускается ниже нуля
+	creditQuestBonusQuery = `WITH prev AS (
+	    SELECT ID, COALESCE(Balance, 0) AS balance FROM Users WHERE ID = $2 FOR UPDATE
+	)
+	UPDATE Users u
+	SET Balance = prev.balance + $1,
+	    UpdatedAt = CURRENT_TIMESTAMP
+	FROM prev
+	WHERE u.ID = prev.ID
+	RETURNING u.Balance - prev.balance`
+
+	debitQuestBonusQuery = `WITH prev AS (
+	    SELECT ID, COALESCE(Balance, 0) AS balance FROM Users WHERE ID = $2 FOR UPDATE
+	)
+	UPDATE Users u
+	SET Balance = GREATEST(prev.balance - $1, 0),
+	    UpdatedAt = CURRENT_TIMESTAMP
+	FROM prev
+	WHERE u.ID = prev.ID
+	RETURNING u.Balance - prev.balance`
+)
+
+// PostgresQuestRepository реализует QuestRepository для PostgreSQL
+type PostgresQuestRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresQuestRepository создает новый репозиторий квестов с указанным соединением с БД.
+func NewPostgresQuestRepository(db *sql.DB) repository.QuestRepository {
+	return &PostgresQuestRepository{db: db}
+}
+
+// CreateQuest создает квест вместе со списком его задач
+func (r *PostgresQuestRepository) CreateQuest(ctx context.Context, quest *models.Quest) (*models.Quest, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.NewInternal("failed to begin quest transaction", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, insertQuestQuery, quest.QuestID, quest.Title, quest.Description, quest.Bonus)
+	if isUniqueViolation(err) {
+		return nil, errors.NewAlreadyExists("quest already exists", err)
+	} else if err != nil {
+		return nil, errors.NewInternal("failed to create quest", err)
+	}
+
+	for _, task := range quest.Tasks {
+		_, err := tx.ExecContext(ctx, insertQuestTaskQuery, quest.QuestID, task.TaskID, task.Position)
+		if isForeignKeyViolation(err) {
+			return nil, errors.NewNotFound("task not found", err)
+		} else if isUniqueViolation(err) {
+			return nil, errors.NewValidation("quest task ids must be unique", err)
+		} else if err != nil {
+			return nil, errors.NewInternal("failed to add quest task", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.NewInternal("failed to commit quest", err)
+	}
+	return r.GetQuestByID(ctx, quest.QuestID)
+}
+
+// GetQuestByID возвращает квест с задачами по порядку
+func (r *PostgresQuestRepository) GetQuestByID(ctx context.Context, id string) (*models.Quest, error) {
+	var quest models.Quest
+	err := scanQuest(r.db.QueryRowContext(ctx, getQuestByIDQuery, id), &quest)
+	if err == sql.ErrNoRows {
+		return nil, errors.NewNotFound("quest not found", nil)
+	} else if err != nil {
+		return nil, errors.NewInternal("failed to get quest", err)
+	}
+
+	quests := []models.Quest{quest}
+	if err := r.loadQuestTasks(ctx, quests); err != nil {
+		return nil, err
+	}
+	return &quests[0], nil
+}
+
+// GetQuests возвращает страницу квестов, начиная с новых
+func (r *PostgresQuestRepository) GetQuests(ctx context.Context, limit, offset int) ([]models.Quest, error) {
+	rows, err := r.db.QueryContext(ctx, getQuestsQuery, limit, offset)
+	if err != nil {
+		return nil, errors.NewInternal("failed to query quests", err)
+	}
+	defer rows.Close()
+
+	quests := []models.Quest{}
+	for rows.Next() {
+		var quest models.Quest
+		if err := scanQuest(rows, &quest); err != nil {
+			return nil, errors.NewInternal("failed to scan quest", err)
+		}
+		quests = append(quests, quest)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewInternal("error occurred while iterating over quests", err)
+	}
+
+	if err := r.loadQuestTasks(ctx, quests); err != nil {
+		return nil, err
+	}
+	return quests, nil
+}
+
+// GetQuestCompletion возвращает выплату бонуса за квест пользователю или nil, если квест еще не выполнен
+func (r *PostgresQuestRepository) GetQuestCompletion(ctx context.Context, questID, userID string) (*models.QuestCompletion, error) {
+	var completion models.QuestCompletion
+	err := r.db.QueryRowContext(ctx, getQuestCompletionQuery, questID, userID).Scan(
+		&completion.QuestID, &completion.UserID, &completion.Bonus, &completion.CompletedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.NewInternal("failed to get quest completion", err)
+	}
+	return &completion, nil
+}
+
+// loadQuestTasks заполняет задачи квестов одним запросом
+func (r *PostgresQuestRepository) loadQuestTasks(ctx context.Context, quests []models.Quest) error {
+	if len(quests) == 0 {
+		return nil
+	}
+	ids := make([]string, len(quests))
+	index := make(map[string]int, len(quests))
+	for i := range quests {
+		ids[i] = quests[i].QuestID
+		index[quests[i].QuestID] = i
+		quests[i].Tasks = []models.QuestTask{}
+	}
+
+	rows, err := r.db.QueryContext(ctx, getQuestTasksQuery, pq.Array(ids))
+	if err != nil {
+		return errors.NewInternal("failed to query quest tasks", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var questID string
+		var task models.QuestTask
+		if err := rows.Scan(&questID, &task.TaskID, &task.Title, &task.Position); err != nil {
+			return errors.NewInternal("failed to scan quest task", err)
+		}
+		i := index[questID]
+		quests[i].Tasks = append(quests[i].Tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return errors.NewInternal("error occurred while iterating over quest tasks", err)
+	}
+	return nil
+}
+
+// scanQuest сканирует основные колонки квеста
+func scanQuest(row rowScanner, quest *models.Quest) error {
+	return row.Scan(&quest.QuestID, &quest.Title, &quest.Description, &quest.Bonus, &quest.CreatedAt, &quest.UpdatedAt)
+}
+
+// awardQuestBonuses начисляет пользователю бонусы за квесты, которые он завершил выполнением задачи taskID.
+// Вызывается в транзакции перехода после обновления статуса или личного прогресса.
+// Возвращает изменение баланса пользователя.
+func (r *PostgresTaskRepository) awardQuestBonuses(ctx context.Context, tx *sql.Tx, taskID, userID string) (float64, error) {
+	bonus, err := sumBonuses(tx.QueryContext(ctx, awardQuestBonusesQuery, taskID, userID, models.Completed))
+	if err != nil {
+		return 0, errors.NewInternal("failed to award quest bonuses", err)
+	}
+	return applyQuestBonus(ctx, tx, models.LedgerReasonQuestBonus, creditQuestBonusQuery, bonus, userID)
+}
+
+// revokeQuestBonuses возвращает бонусы за квесты с задачей taskID, если задача перестала быть выполненной.
+// Возвращает изменение баланса пользователя.
+func (r *PostgresTaskRepository) revokeQuestBonuses(ctx context.Context, tx *sql.Tx, taskID, userID string) (float64, error) {
+	bonus, err := sumBonuses(tx.QueryContext(ctx, revokeQuestBonusesQuery, taskID, userID))
+	if err != nil {
+		return 0, errors.NewInternal("failed to revoke quest bonuses", err)
+	}
+	return applyQuestBonus(ctx, tx, models.LedgerReasonQuestReversal, debitQuestBonusQuery, bonus, userID)
+}
+
+// applyQuestBonus изменяет баланс пользователя на сумму бонусов с указанной причиной в журнале
+// и возвращает фактическое изменение баланса
+func applyQuestBonus(ctx context.Context, tx *sql.Tx, reason, query string, bonus float64, userID string) (float64, error) {
+	if bonus == 0 {
+		return 0, nil
+	}
+	if _, err := tx.ExecContext(ctx, SetLedgerReasonQuery, reason); err != nil {
+		return 0, errors.NewInternal("failed to set ledger reason", err)
+	}
+	var delta float64
+	err := tx.QueryRowContext(ctx, query, bonus, userID).Scan(&delta)
+	if err == sql.ErrNoRows {
+		// Пользователь уже окончательно удален
+		return 0, nil
+	} else if err != nil {
+		return 0, errors.NewInternal("failed to update balance with quest bonus", err)
+	}
+	return delta, nil
+}
+
+// sumBonuses суммирует бонусы, возвращенные запросом
+func sumBonuses(rows *sql.Rows, err error) (float64, error) {
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var total float64
+	for rows.Next() {
+		var bonus float64
+		if err := rows.Scan(&bonus); err != nil {
+			return 0, err
+		}
+		total += bonus
+	}
+	return total, rows.Err()
+}
@@ -2,16 +2,30 @@ package database
 
 import (
 	"database/sql"
-	"errors"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
 	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
+	"strconv"
 )
 
+// referralColumns - список колонок реферального кода в порядке, ожидаемом scanReferral
+const referralColumns = `referral_id, user_id, code, created_at, updated_at`
+
 const (
-	CreateReferralQuery       = `INSERT INTO referral (UserID, Code, CreatedAt, UpdatedAt) VALUES (?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP) RETURNING ID, UserID, Code, CreatedAt, UpdatedAt`
-	GetReferralQuery          = `SELECT ID, UserID, Code, CreatedAt, UpdatedAt FROM referral WHERE ID = ?`
-	GetReferralsByUserIDQuery = `SELECT ID, UserID, Code, CreatedAt, UpdatedAt FROM referral WHERE UserID = ?`
-	UpdateReferralQuery       = `UPDATE referral SET Code = ?, UpdatedAt = CURRENT_TIMESTAMP WHERE ID = ? RETURNING ID, UserID, Code, CreatedAt, UpdatedAt`
-	DeleteReferralQuery       = `DELETE FROM referral WHERE ID = ?`
+	CreateReferralQuery = `INSERT INTO referral (user_id, code) VALUES ($1, $2) RETURNING ` + referralColumns
+	GetReferralQuery    = `SELECT ` + referralColumns + ` FROM referral WHERE referral_id = $1`
+	// Страница рефералов пользователя после курсора ($2, $3), начиная с новых
+	GetReferralsByUserIDQuery = `SELECT ` + referralColumns + ` FROM referral
+	WHERE user_id = $1 AND ($2::timestamptz IS NULL OR (created_at, referral_id) < ($2, $3::int))
+	ORDER BY created_at DESC, referral_id DESC
+	LIMIT $4`
+	// Страница рефералов пользователя перед курсором ($2, $3): выбирается в обратном порядке
+	GetReferralsByUserIDBeforeQuery = `SELECT ` + referralColumns + ` FROM referral
+	WHERE user_id = $1 AND (created_at, referral_id) > ($2, $3::int)
+	ORDER BY created_at, referral_id
+	LIMIT $4`
+	UpdateReferralQuery = `UPDATE referral SET code = $1, updated_at = CURRENT_TIMESTAMP WHERE referral_id = $2 RETURNING ` + referralColumns
+	DeleteReferralQuery = `DELETE FROM referral WHERE referral_id = $1`
 )
 
 type ReferralRepository struct {
@@ -26,73 +40,105 @@ func NewReferralRepository(db *sql.DB) *ReferralRepository {
 // CreateReferral создает новый реферальный код для пользователя
 func (r *ReferralRepository) CreateReferral(userID string, code string) (*models.Referral, error) {
 	referral := &models.Referral{}
-
-	err := r.db.QueryRow(CreateReferralQuery, userID, code).Scan(&referral.UserID, &referral.UserID, &referral.Code, &referral.CreatedAt, &referral.UpdatedAt)
-
-	if err != nil {
+	err := scanReferral(r.db.QueryRow(CreateReferralQuery, userID, code), referral)
+	if isUniqueViolation(err) {
+		return nil, errors.NewAlreadyExists("referral code already exists", err)
+	} else if err != nil {
 		return nil, err
 	}
-
 	return referral, nil
 }
 
 // GetReferral возвращает реферал по его ID
 func (r *ReferralRepository) GetReferral(referralID string) (*models.Referral, error) {
-	referral := &models.Referral{}
-
-	row := r.db.QueryRow(GetReferralQuery, referralID)
+	// Идентификаторы кодов - числа; другой идентификатор не может принадлежать существующему коду
+	if _, err := strconv.Atoi(referralID); err != nil {
+		return nil, errors.NewNotFound("referral not found", nil)
+	}
 
-	err := row.Scan(&referral.UserID, &referral.UserID, &referral.Code, &referral.CreatedAt, &referral.UpdatedAt)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errors.New("referral not found")
-		}
+	referral := &models.Referral{}
+	err := scanReferral(r.db.QueryRow(GetReferralQuery, referralID), referral)
+	if err == sql.ErrNoRows {
+		return nil, errors.NewNotFound("referral not found", nil)
+	} else if err != nil {
 		return nil, err
 	}
-
 	return referral, nil
 }
 
-// GetReferralsByUserID возвращает список рефералов для указанного пользователя
-func (r *ReferralRepository) GetReferralsByUserID(userID string) ([]models.Referral, error) {
+// scanReferral сканирует колонки referralColumns в реферальный код
+func scanReferral(row rowScanner, referral *models.Referral) error {
+	return row.Scan(&referral.ReferralID, &referral.UserID, &referral.Code, &referral.CreatedAt, &referral.UpdatedAt)
+}
 
-	rows, err := r.db.Query(GetReferralsByUserIDQuery, userID)
+// GetReferralsByUserID возвращает страницу рефералов для указанного пользователя, начиная с новых
+func (r *ReferralRepository) GetReferralsByUserID(userID string, page pagination.Page) ([]models.Referral, pagination.Info, error) {
+	query := GetReferralsByUserIDQuery
+	var createdAt interface{}
+	var referralID interface{}
+	if page.Cursor != nil {
+		if page.Cursor.Backward {
+			query = GetReferralsByUserIDBeforeQuery
+		}
+		t, err := page.Cursor.Time(0)
+		if err != nil {
+			return nil, pagination.Info{}, err
+		}
+		id, err := page.Cursor.Int(1)
+		if err != nil {
+			return nil, pagination.Info{}, err
+		}
+		createdAt, referralID = t, id
+	}
+
+	rows, err := r.db.Query(query, userID, createdAt, referralID, page.QueryLimit())
 	if err != nil {
-		return nil, err
+		return nil, pagination.Info{}, err
 	}
 	defer rows.Close()
 
 	referrals := make([]models.Referral, 0)
 	for rows.Next() {
 		referral := models.Referral{}
-		err := rows.Scan(&referral.UserID, &referral.UserID, &referral.Code, &referral.CreatedAt, &referral.UpdatedAt)
-		if err != nil {
-			return nil, err
+		if err := scanReferral(rows, &referral); err != nil {
+			return nil, pagination.Info{}, err
 		}
 		referrals = append(referrals, referral)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, pagination.Info{}, err
+	}
 
-	return referrals, nil
+	referrals, info := pagination.Paginate(referrals, page, func(referral models.Referral) []string {
+		return []string{pagination.FormatTime(referral.CreatedAt), referral.ReferralID}
+	})
+	return referrals, info, nil
 }
 
 // UpdateReferral обновляет указанный реферальный код
 func (r *ReferralRepository) UpdateReferral(referralID string, code string) (*models.Referral, error) {
+	if _, err := strconv.Atoi(referralID); err != nil {
+		return nil, errors.NewNotFound("referral not found", nil)
+	}
 
 	referral := &models.Referral{}
-
-	err := r.db.QueryRow(UpdateReferralQuery, code, referralID).Scan(&referral.UserID, &referral.UserID, &referral.Code, &referral.CreatedAt, &referral.UpdatedAt)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, errors.New("referral not found")
-		}
+	err := scanReferral(r.db.QueryRow(UpdateReferralQuery, code, referralID), referral)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, errors.NewNotFound("referral not found", nil)
+	case isUniqueViolation(err):
+		return nil, errors.NewAlreadyExists("referral code already exists", err)
+	case err != nil:
 		return nil, err
 	}
-
 	return referral, nil
 }
 
 // DeleteReferral удаляет реферальный код по его ID
 func (r *ReferralRepository) DeleteReferral(referralID string) error {
+	if _, err := strconv.Atoi(referralID); err != nil {
+		return errors.NewNotFound("referral not found", nil)
+	}
 
 	result, err := r.db.Exec(DeleteReferralQuery, referralID)
 	if err != nil {
@@ -105,7 +151,7 @@ func (r *ReferralRepository) DeleteReferral(referralID string) error {
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("referral not found")
+		return errors.NewNotFound("referral not found", nil)
 	}
 
 	return nil
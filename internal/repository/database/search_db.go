@@ -0,0 +1,87 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"strings"
+	"unicode"
+)
+
+const (
+	// Совпадения ранжируются по ts_rank_cd; фрагменты с выделением строятся только для страницы результатов.
+	// Заголовок выделяется целиком, из описания и биографии берется фрагмент вокруг совпадений.
+	searchQuery = `WITH q AS (
+	    SELECT to_tsquery('simple', $1) AS query
+	), hits AS (
+	    SELECT 'task' AS type, t.task_id AS id, t.title AS title, COALESCE(t.description, '') AS body,
+	           ts_rank_cd(t.search_vector, q.query) AS rank
+	    FROM tasks t, q
+	    WHERE $4 AND t.deleted_at IS NULL AND t.search_vector @@ q.query
+	    UNION ALL
+	    SELECT 'user', u.ID, u.Username, COALESCE(u.Bio, ''), ts_rank_cd(u.SearchVector, q.query)
+	    FROM Users u, q
+	    WHERE $5 AND u.DeletedAt IS NULL AND u.SearchVector @@ q.query
+	), page AS (
+	    SELECT * FROM hits ORDER BY rank DESC, type, id LIMIT $2 OFFSET $3
+	)
+	SELECT p.type, p.id,
+	       ts_headline('simple', p.title, q.query, 'HighlightAll=true'),
+	       ts_headline('simple', p.body, q.query, 'MaxWords=35, MinWords=15, MaxFragments=2'),
+	       p.rank
+	FROM page p, q
+	ORDER BY p.rank DESC, p.type, p.id`
+)
+
+// PostgresSearchRepository реализует SearchRepository для PostgreSQL
+type PostgresSearchRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresSearchRepository создает новый репозиторий поиска с указанным соединением с БД.
+func NewPostgresSearchRepository(db *sql.DB) repository.SearchRepository {
+	return &PostgresSearchRepository{db: db}
+}
+
+// Search ищет задачи и пользователей, содержащие все слова запроса (каждое - по префиксу)
+func (r *PostgresSearchRepository) Search(ctx context.Context, filter *models.SearchFilter) ([]models.SearchResult, error) {
+	results := []models.SearchResult{}
+	tsQuery := prefixTSQuery(filter.Query)
+	if tsQuery == "" {
+		return results, nil
+	}
+
+	includeTasks := filter.Type == models.SearchAll || filter.Type == models.SearchTasks
+	includeUsers := filter.Type == models.SearchAll || filter.Type == models.SearchUsers
+	rows, err := r.db.QueryContext(ctx, searchQuery, tsQuery, filter.Limit, filter.Offset, includeTasks, includeUsers)
+	if err != nil {
+		return nil, errors.NewInternal("failed to search", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var result models.SearchResult
+		if err := rows.Scan(&result.Type, &result.ID, &result.Title, &result.Snippet, &result.Rank); err != nil {
+			return nil, errors.NewInternal("failed to scan search result", err)
+		}
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewInternal("error occurred while iterating over search results", err)
+	}
+	return results, nil
+}
+
+// prefixTSQuery строит запрос to_tsquery из слов текста: слова объединяются через & и ищутся по префиксу.
+// Знаки препинания и операторы tsquery отбрасываются, поэтому произвольный ввод не приводит к синтаксической ошибке.
+func prefixTSQuery(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+	return strings.Join(words, " & ")
+}
@@ -0,0 +1,171 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"time"
+)
+
+const (
+	submissionColumns = `id, task_id, user_id, evidence_type, evidence, status, COALESCE(reason, ''), reviewed_by, reviewed_at, created_at`
+
+	insertSubmissionQuery = `INSERT INTO task_submissions (task_id, user_id, evidence_type, evidence)
+	VALUES ($1, $2, $3, $4)
+	RETURNING ` + submissionColumns
+
+	lockSubmissionQuery = `SELECT ` + submissionColumns + ` FROM task_submissions WHERE id = $1 FOR UPDATE`
+
+	reviewSubmissionQuery = `UPDATE task_submissions
+	SET status = $1, reason = NULLIF($2, ''), reviewed_by = $3, reviewed_at = CURRENT_TIMESTAMP
+	WHERE id = $4
+	RETURNING ` + submissionColumns
+
+	getSubmissionsQuery = `SELECT ` + submissionColumns + `
+	FROM task_submissions s
+	WHERE ($1::varchar IS NULL OR s.status = $1)
+	  AND ($2::varchar IS NULL OR s.task_id = $2)
+	  AND ($3::varchar IS NULL OR s.user_id = $3)
+	  AND EXISTS (SELECT 1 FROM tasks t WHERE t.task_id = s.task_id AND t.deleted_at IS NULL)
+	ORDER BY s.created_at, s.id
+	LIMIT $4 OFFSET $5`
+)
+
+// CreateSubmission сохраняет заявку пользователя на выполнение задачи. Заявку может отправить
+// только пользователь, которому доступна задача и который еще не выполнил ее.
+func (r *PostgresTaskRepository) CreateSubmission(ctx context.Context, submission *models.Submission, dueDeadline time.Time) (*models.Submission, error) {
+	var created models.Submission
+
+	err := r.WithTransaction(ctx, func(tx *sql.Tx) error {
+		var status models.TaskStatus
+		var mode models.TaskAssignmentMode
+		var maxClaims sql.NullInt64
+		var dueDate *time.Time
+		err := tx.QueryRowContext(ctx, lockTaskForClaimQuery, submission.TaskID).Scan(&status, &mode, &maxClaims, &dueDate)
+		if err == sql.ErrNoRows {
+			return errors.NewNotFound("task not found", nil)
+		} else if err != nil {
+			return errors.NewInternal("failed to get task", err)
+		}
+		if err := checkNotPastDue(dueDate, dueDeadline); err != nil {
+			return err
+		}
+
+		if err := r.checkActiveUser(ctx, tx, submission.UserID); err != nil {
+			return err
+		}
+
+		progress, assigned, err := r.lockUserProgress(ctx, tx, submission.TaskID, submission.UserID, mode)
+		if err != nil {
+			return err
+		}
+		if !assigned {
+			progress = status
+		}
+		if progress.IsClosed() {
+			return errors.NewConflict(fmt.Sprintf("task is already %s", progress), nil)
+		}
+
+		err = scanSubmission(tx.QueryRowContext(ctx, insertSubmissionQuery, submission.TaskID, submission.UserID,
+			submission.EvidenceType, submission.Evidence), &created)
+		if isUniqueViolation(err) {
+			return errors.NewAlreadyExists("a pending submission for this task already exists", err)
+		} else if err != nil {
+			return errors.NewInternal("failed to create submission", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &created, nil
+}
+
+// GetSubmissions возвращает заявки по фильтру в порядке поступления
+func (r *PostgresTaskRepository) GetSubmissions(ctx context.Context, filter *models.SubmissionFilter) ([]models.Submission, error) {
+	rows, err := r.db.QueryContext(ctx, getSubmissionsQuery, nullableString(string(filter.Status)),
+		nullableString(filter.TaskID), nullableString(filter.UserID), filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, errors.NewInternal("failed to query submissions", err)
+	}
+	defer rows.Close()
+
+	submissions := []models.Submission{}
+	for rows.Next() {
+		var submission models.Submission
+		if err := scanSubmission(rows, &submission); err != nil {
+			return nil, errors.NewInternal("failed to scan submission", err)
+		}
+		submissions = append(submissions, submission)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewInternal("error occurred while iterating over submissions", err)
+	}
+	return submissions, nil
+}
+
+// ApproveSubmission одобряет заявку и засчитывает выполнение задачи пользователю через общий
+// путь смены статуса: вознаграждение начисляется в той же транзакции.
+func (r *PostgresTaskRepository) ApproveSubmission(ctx context.Context, id int64, reason, reviewer string) (*models.Submission, *models.BalanceUpdate, error) {
+	var change *models.BalanceUpdate
+	approved, err := r.reviewSubmission(ctx, id, models.SubmissionApproved, reason, reviewer, func(tx *sql.Tx, submission *models.Submission) error {
+		var err error
+		change, err = r.transitionTask(ctx, tx, submission.TaskID, models.Completed, submission.UserID, reviewer, true, time.Time{})
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return approved, change, nil
+}
+
+// RejectSubmission отклоняет заявку с указанной причиной
+func (r *PostgresTaskRepository) RejectSubmission(ctx context.Context, id int64, reason, reviewer string) (*models.Submission, error) {
+	return r.reviewSubmission(ctx, id, models.SubmissionRejected, reason, reviewer, nil)
+}
+
+// reviewSubmission фиксирует решение модератора по ожидающей заявке; apply выполняется
+// в той же транзакции до сохранения решения.
+func (r *PostgresTaskRepository) reviewSubmission(ctx context.Context, id int64, status models.SubmissionStatus, reason, reviewer string,
+	apply func(tx *sql.Tx, submission *models.Submission) error) (*models.Submission, error) {
+	var reviewed models.Submission
+
+	err := r.WithTransaction(ctx, func(tx *sql.Tx) error {
+		var submission models.Submission
+		err := scanSubmission(tx.QueryRowContext(ctx, lockSubmissionQuery, id), &submission)
+		if err == sql.ErrNoRows {
+			return errors.NewNotFound("submission not found", nil)
+		} else if err != nil {
+			return errors.NewInternal("failed to get submission", err)
+		}
+		if submission.Status != models.SubmissionPending {
+			return errors.NewConflict(fmt.Sprintf("submission is already %s", submission.Status), nil)
+		}
+
+		if apply != nil {
+			if err := apply(tx, &submission); err != nil {
+				return err
+			}
+		}
+
+		if err := scanSubmission(tx.QueryRowContext(ctx, reviewSubmissionQuery, status, reason, reviewer, id), &reviewed); err != nil {
+			return errors.NewInternal("failed to review submission", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &reviewed, nil
+}
+
+// scanSubmission сканирует колонки submissionColumns в заявку.
+func scanSubmission(row rowScanner, submission *models.Submission) error {
+	return row.Scan(&submission.ID, &submission.TaskID, &submission.UserID, &submission.EvidenceType,
+		&submission.Evidence, &submission.Status, &submission.Reason, &submission.ReviewedBy,
+		&submission.ReviewedAt, &submission.CreatedAt)
+}
@@ -6,59 +6,123 @@ import (
 	"fmt"
 	"github.com/ZnNr/user-reward-controller/internal/errors"
 	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
 	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"github.com/ZnNr/user-reward-controller/internal/sqlbuilder"
 	"github.com/google/uuid"
+	"math"
+	"time"
 )
 
+// taskColumns - список колонок задачи в порядке, ожидаемом scanTaskFields
+const taskColumns = `task_id, title, COALESCE(description, ''), created_at, updated_at, due_date, status, assignee_id,
+	reward, completed_by, assignment_mode, max_claims, requires_evidence, campaign_id, deleted_at`
+
 // SQL Queries
 const (
 	addTaskQuery = `
-	INSERT INTO tasks (task_id, title, description, due_date, status, assignee_id)
-	VALUES ($1, $2, $3, $4, $5, $6)
-	RETURNING task_id, title, description, created_at, due_date, status, assignee_id`
+	INSERT INTO tasks (task_id, title, description, due_date, status, assignee_id, reward, assignment_mode, max_claims,
+	                   requires_evidence, campaign_id)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	RETURNING ` + taskColumns
 
 	getTaskByIDQuery = `
-	SELECT task_id, title, description, created_at, updated_at, due_date, status, assignee_id
+	SELECT ` + taskColumns + `
 	FROM tasks
-	WHERE task_id = $1`
+	WHERE task_id = $1 AND deleted_at IS NULL`
 
 	updateTaskQuery = `
 	UPDATE tasks
 	SET title = $1, 
 		description = $2, 
 		due_date = $3,
-		status = $4,
-		assignee_id = $5,
+		assignee_id = $4,
+		reward = $5,
+		assignment_mode = $6,
+		max_claims = $7,
+		requires_evidence = $8,
+		campaign_id = $9,
 		updated_at = NOW()
-	WHERE task_id = $6
-	RETURNING task_id, title, description, created_at, updated_at, due_date, status, assignee_id`
+	WHERE task_id = $10 AND deleted_at IS NULL
+	RETURNING ` + taskColumns
+
+	// Мягкое удаление: задача скрывается из выборок до восстановления или очистки по сроку хранения
+	deleteTaskQuery = `UPDATE tasks SET deleted_at = NOW(), updated_at = NOW() WHERE task_id = $1 AND deleted_at IS NULL`
 
-	deleteTaskQuery = `DELETE FROM tasks WHERE task_id = $1`
+	restoreTaskQuery = `
+	UPDATE tasks
+	SET deleted_at = NULL, updated_at = NOW()
+	WHERE task_id = $1 AND deleted_at IS NOT NULL
+	RETURNING ` + taskColumns
+
+	purgeDeletedTasksQuery = `DELETE FROM tasks WHERE deleted_at IS NOT NULL AND deleted_at < $1`
 
 	checkTaskExistsQuery = `
 	SELECT EXISTS (
 	    SELECT 1 
 	    FROM tasks 
-	    WHERE task_id = $1
+	    WHERE task_id = $1 AND deleted_at IS NULL
 	);`
 
-	checkTaskDuplicateQuery = `SELECT COUNT(*) FROM tasks WHERE title = $1 AND description = $2 AND task_id <> $3`
-
-	countTasksQuery = `SELECT COUNT(*) FROM tasks WHERE (title ILIKE COALESCE($1, title) OR $1 IS NULL) AND (status = COALESCE($2, status) OR $2 IS NULL) AND (assignee_id = COALESCE($3, assignee_id) OR $3 IS NULL) AND (created_at >= COALESCE($4, created_at) OR $4 IS NULL) AND (created_at <= COALESCE($5, created_at) OR $5 IS NULL) AND (due_date >= COALESCE($6, due_date) OR $6 IS NULL) AND (due_date <= COALESCE($7, due_date) OR $7 IS NULL);`
-
-	getAllTasksQuery = `
-	SELECT task_id, title, description, created_at, updated_at, due_date, status, assignee_id 
-	FROM tasks 
-	WHERE ($1 IS NULL OR title ILIKE '%' || $1 || '%') 
-	  AND ($2::timestamp IS NULL OR created_at >= $2) 
-	  AND ($3::timestamp IS NULL OR created_at <= $3) 
-	  AND ($4::timestamp IS NULL OR due_date >= $4) 
-	  AND ($5::timestamp IS NULL OR due_date <= $5) 
-	  AND ($6 IS NULL OR status = $6) 
-	  AND ($7 IS NULL OR assignee_id = $7)
-	ORDER BY created_at DESC;`
-
-	userTaskStatusChangeQuery = `UPDATE users SET TasksCompleted = TasksCompleted + 1 WHERE id = $1`
+	checkTaskDuplicateQuery = `SELECT COUNT(*) FROM tasks WHERE title = $1 AND description = $2 AND task_id <> $3 AND deleted_at IS NULL`
+
+	// Блокировка задачи на время смены статуса
+	lockTaskForStatusQuery = `SELECT status, reward, completed_by, assignment_mode, requires_evidence, due_date
+	FROM tasks WHERE task_id = $1 AND deleted_at IS NULL FOR UPDATE`
+
+	// Начисление вознаграждения и увеличение счетчика выполненных задач
+	creditTaskRewardQuery = `UPDATE Users
+	SET TasksCompleted = COALESCE(TasksCompleted, 0) + 1,
+	    Balance = COALESCE(Balance, 0) + $1,
+	    UpdatedAt = CURRENT_TIMESTAMP
+	WHERE ID = $2 AND DeletedAt IS NULL`
+
+	// Блокировка кампании задачи на время выплаты вознаграждения из ее бюджета
+	lockTaskCampaignQuery = `SELECT c.campaign_id, c.budget - c.spent, c.starts_at <= NOW() AND NOW() < c.ends_at
+	FROM tasks t JOIN campaigns c ON c.campaign_id = t.campaign_id
+	WHERE t.task_id = $1
+	FOR UPDATE OF c`
+
+	spendCampaignBudgetQuery = `UPDATE campaigns SET spent = spent + $1, updated_at = NOW() WHERE campaign_id = $2`
+
+	refundCampaignBudgetQuery = `UPDATE campaigns c
+	SET spent = GREATEST(c.spent - $1, 0), updated_at = NOW()
+	FROM tasks t
+	WHERE t.task_id = $2 AND c.campaign_id = t.campaign_id`
+
+	// Последнее начисление пользователю за задачу - сумма, которая возвращается при повторном открытии
+	lastTaskRewardQuery = `SELECT COALESCE((
+	    SELECT reward_delta FROM task_status_history
+	    WHERE task_id = $1 AND user_id = $2 AND to_status = $3
+	    ORDER BY id DESC
+	    LIMIT 1
+	), 0)`
+
+	// Возврат вознаграждения при повторном открытии задачи; баланс не опускается ниже нуля.
+	// Возвращает фактически списанную сумму.
+	reverseTaskRewardQuery = `WITH prev AS (
+	    SELECT ID, COALESCE(Balance, 0) AS balance FROM Users WHERE ID = $2 FOR UPDATE
+	)
+	UPDATE Users u
+	SET TasksCompleted = GREATEST(COALESCE(u.TasksCompleted, 0) - 1, 0),
+	    Balance = GREATEST(prev.balance - $1, 0),
+	    UpdatedAt = CURRENT_TIMESTAMP
+	FROM prev
+	WHERE u.ID = prev.ID
+	RETURNING prev.balance - u.Balance`
+
+	// Баланс пользователя после изменений в транзакции перехода
+	userBalanceQuery = `SELECT COALESCE(Balance, 0) FROM Users WHERE ID = $1`
+
+	setTaskStatusQuery = `UPDATE tasks SET status = $1, completed_by = $2, updated_at = NOW() WHERE task_id = $3`
+
+	insertTaskStatusHistoryQuery = `INSERT INTO task_status_history (task_id, from_status, to_status, actor, user_id, reward_delta)
+	VALUES ($1, $2, $3, $4, $5, $6)`
+
+	getTaskStatusHistoryQuery = `SELECT id, task_id, from_status, to_status, actor, user_id, reward_delta, created_at
+	FROM task_status_history
+	WHERE task_id = $1
+	ORDER BY id`
 )
 
 type PostgresTaskRepository struct {
@@ -71,7 +135,7 @@ func NewPostgresTaskRepository(db *sql.DB) repository.TaskRepository {
 }
 
 // CreateTask сохраняет новую задачу в базу данных
-func (r *PostgresTaskRepository) CreateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+func (r *PostgresTaskRepository) CreateTask(ctx context.Context, task *models.Task, assignees []string) (*models.Task, error) {
 	// проверяем на дубликаты
 	if isDuplicate, err := r.checkForDuplicateTask(ctx, task, ""); err != nil {
 		return nil, err
@@ -79,15 +143,22 @@ func (r *PostgresTaskRepository) CreateTask(ctx context.Context, task *models.Ta
 		return nil, errors.NewAlreadyExists("a task with the same title and description already exists", nil)
 	}
 
-	if err := r.insertTask(ctx, task); err != nil {
+	// Задача и назначения пользователей создаются атомарно
+	err := r.WithTransaction(ctx, func(tx *sql.Tx) error {
+		if err := r.insertTask(ctx, tx, task); err != nil {
+			return err
+		}
+		return r.assignUsers(ctx, tx, task.TaskID, assignees)
+	})
+	if err != nil {
 		return nil, err
 	}
 
 	return task, nil
 }
 
-func (r *PostgresTaskRepository) insertTask(ctx context.Context, task *models.Task) error {
-	err := r.db.QueryRowContext(
+func (r *PostgresTaskRepository) insertTask(ctx context.Context, tx *sql.Tx, task *models.Task) error {
+	err := scanTaskFields(tx.QueryRowContext(
 		ctx,
 		addTaskQuery,
 		task.TaskID,
@@ -96,25 +167,70 @@ func (r *PostgresTaskRepository) insertTask(ctx context.Context, task *models.Ta
 		task.DueDate,
 		task.Status,
 		task.AssigneeID,
-	).Scan(
+		task.Reward,
+		task.AssignmentMode,
+		task.MaxClaims,
+		task.RequiresEvidence,
+		task.CampaignID,
+	), task)
+	if isForeignKeyViolation(err) {
+		return errors.NewNotFound("campaign not found", err)
+	} else if err != nil {
+		return errors.NewInternal("failed to insert task", err)
+	}
+	return nil
+}
+
+// scanTaskFields сканирует колонки taskColumns в задачу; extra получают дополнительные колонки, следующие за ними.
+func scanTaskFields(row rowScanner, task *models.Task, extra ...interface{}) error {
+	dest := []interface{}{
 		&task.TaskID,
 		&task.Title,
 		&task.Description,
 		&task.CreatedAt,
+		&task.UpdatedAt,
 		&task.DueDate,
 		&task.Status,
 		&task.AssigneeID,
-	)
-	if err != nil {
-		return errors.NewInternal("failed to insert task", err)
+		&task.Reward,
+		&task.CompletedBy,
+		&task.AssignmentMode,
+		&task.MaxClaims,
+		&task.RequiresEvidence,
+		&task.CampaignID,
+		&task.DeletedAt,
 	}
-	return nil
+	return row.Scan(append(dest, extra...)...)
+}
+
+// taskSortKeys - порядок списка задач: от новых к старым, task_id делает порядок однозначным
+var taskSortKeys = []sqlbuilder.SortKey{{Expr: "created_at", Desc: true}, {Expr: "task_id", Desc: true}}
+
+// taskListQuery строит запрос списка задач с условиями только для заданных полей фильтра
+func taskListQuery(filter *models.TaskFilter) *sqlbuilder.Builder {
+	statuses := filter.Statuses
+	if filter.Status != 0 {
+		statuses = append(statuses, filter.Status)
+	}
+	return sqlbuilder.Select(taskColumns).From("tasks").
+		Contains("title", filter.Title).
+		Contains("description", filter.Description).
+		Range("created_at", filter.CreatedAfter, filter.CreatedBefore).
+		Range("due_date", filter.DueAfter, filter.DueBefore).
+		In("status", sqlbuilder.Values(statuses)...).
+		Eq("assignee_id", filter.AssigneeID).
+		Eq("campaign_id", filter.CampaignID).
+		WhereIf(!filter.IncludeDeleted, "deleted_at IS NULL")
 }
 
 func (r *PostgresTaskRepository) GetTasks(ctx context.Context, filter *models.TaskFilter) (*models.TaskResponse, error) {
 	// Apply default filter values
 	setDefaultFilterValues(filter)
 
+	if filter.Pagination != nil {
+		return r.getTasksByCursor(ctx, filter, *filter.Pagination)
+	}
+
 	// Count the total number of tasks
 	totalItems, err := r.countTotalTasks(ctx, filter)
 	if err != nil {
@@ -130,7 +246,8 @@ func (r *PostgresTaskRepository) GetTasks(ctx context.Context, filter *models.Ta
 
 	// Fetch tasks for the requested page
 	offset := (filter.Page - 1) * filter.PageSize
-	tasks, err := r.getTasksByPage(ctx, filter, offset)
+	query, args := taskListQuery(filter).OrderByKeys(taskSortKeys, false).Limit(filter.PageSize).Offset(offset).Build()
+	tasks, err := r.queryTasks(ctx, query, args)
 	if err != nil {
 		return nil, err
 	}
@@ -144,39 +261,50 @@ func (r *PostgresTaskRepository) GetTasks(ctx context.Context, filter *models.Ta
 	}, nil
 }
 
-// getTasksByPage retrieves tasks for a specific page.
-func (r *PostgresTaskRepository) getTasksByPage(ctx context.Context, filter *models.TaskFilter, offset int) ([]models.Task, error) {
-	rows, err := r.db.QueryContext(ctx, getAllTasksQuery,
-		filter.Title,
-
-		filter.CreatedAfter,
-		filter.CreatedBefore,
-		filter.DueAfter,
-		filter.DueBefore,
-		filter.Status,
-		filter.AssigneeID,
-		filter.PageSize,
-		offset,
-	)
+// getTasksByCursor retrieves the page of tasks adjacent to the cursor without counting the total.
+func (r *PostgresTaskRepository) getTasksByCursor(ctx context.Context, filter *models.TaskFilter, page pagination.Page) (*models.TaskResponse, error) {
+	q := taskListQuery(filter)
+	if page.Cursor != nil {
+		createdAt, err := page.Cursor.Time(0)
+		if err != nil {
+			return nil, err
+		}
+		taskID, err := page.Cursor.String(1)
+		if err != nil {
+			return nil, err
+		}
+		q.After(taskSortKeys, []interface{}{createdAt, taskID}, page.Cursor.Backward)
+	}
+	// Страница перед курсором выбирается в обратном порядке
+	query, args := q.OrderByKeys(taskSortKeys, page.Backward()).Limit(page.QueryLimit()).Build()
+
+	tasks, err := r.queryTasks(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, info := pagination.Paginate(tasks, page, func(task models.Task) []string {
+		return []string{pagination.FormatTime(task.CreatedAt), task.TaskID}
+	})
+	return &models.TaskResponse{
+		Tasks:    tasks,
+		PageSize: page.Limit,
+		Info:     info,
+	}, nil
+}
 
+// queryTasks executes a task list query.
+func (r *PostgresTaskRepository) queryTasks(ctx context.Context, query string, args []interface{}) ([]models.Task, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, errors.NewInternal("failed to query tasks", err)
 	}
 	defer rows.Close()
 
-	var tasks []models.Task
+	tasks := []models.Task{}
 	for rows.Next() {
 		var task models.Task
-		if err := rows.Scan(
-			&task.TaskID,
-			&task.Title,
-			&task.Description,
-			&task.DueDate,
-			&task.Status,
-			&task.AssigneeID,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-		); err != nil {
+		if err := scanTaskFields(rows, &task); err != nil {
 			return nil, errors.NewInternal("failed to scan task", err)
 		}
 		tasks = append(tasks, task)
@@ -197,28 +325,13 @@ func setDefaultFilterValues(filter *models.TaskFilter) {
 	if filter.Page <= 0 {
 		filter.Page = 1 // Default to the first page
 	}
-	// Optional: Provide defaults for time-based filters (e.g., CreatedAfter/DueAfter)
-	if filter.Title == "" {
-		filter.Title = "%" // Default match-all title
-	}
 }
 
 // countTotalTasks counts the total number of tasks matching the filter.
 func (r *PostgresTaskRepository) countTotalTasks(ctx context.Context, filter *models.TaskFilter) (int, error) {
 	var totalItems int
-	err := r.db.QueryRowContext(
-		ctx,
-		countTasksQuery,
-		filter.Title,
-		filter.Status,
-		filter.AssigneeID,
-		filter.CreatedAfter,
-		filter.CreatedBefore,
-		filter.DueAfter,
-		filter.DueBefore,
-	).Scan(&totalItems)
-
-	if err != nil {
+	query, args := taskListQuery(filter).BuildCount()
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&totalItems); err != nil {
 		return 0, errors.NewInternal("failed to count tasks", err)
 	}
 	return totalItems, nil
@@ -227,14 +340,7 @@ func (r *PostgresTaskRepository) countTotalTasks(ctx context.Context, filter *mo
 // GetTaskByID retrieves task information by its ID from PostgreSQL.
 func (r *PostgresTaskRepository) GetTaskByID(ctx context.Context, id uuid.UUID) (*models.Task, error) {
 	var task models.Task
-	err := r.db.QueryRowContext(ctx, getTaskByIDQuery, id).Scan(&task.TaskID,
-		&task.Title,
-		&task.Description,
-		&task.CreatedAt,
-		&task.UpdatedAt,
-		&task.DueDate,
-		&task.Status,
-		&task.AssigneeID)
+	err := scanTaskFields(r.db.QueryRowContext(ctx, getTaskByIDQuery, id), &task)
 	if err == sql.ErrNoRows {
 		return nil, errors.NewNotFound("task not found", nil)
 	} else if err != nil {
@@ -258,31 +364,28 @@ func (r *PostgresTaskRepository) UpdateTask(ctx context.Context, task *models.Ta
 	}
 
 	if err := r.updateTask(ctx, task); err != nil {
-		return nil, errors.NewInternal("failed to update task", err)
+		return nil, err
 	}
 	return task, nil
 }
 
 // updateTask обновляет существующую задачу в базе данных.
 func (r *PostgresTaskRepository) updateTask(ctx context.Context, task *models.Task) error {
-	err := r.db.QueryRowContext(ctx, updateTaskQuery,
+	err := scanTaskFields(r.db.QueryRowContext(ctx, updateTaskQuery,
 		task.Title,
 		task.Description,
 		task.DueDate,
-		task.Status,
 		task.AssigneeID,
+		task.Reward,
+		task.AssignmentMode,
+		task.MaxClaims,
+		task.RequiresEvidence,
+		task.CampaignID,
 		task.TaskID,
-	).Scan(
-		&task.TaskID,
-		&task.Title,
-		&task.Description,
-		&task.CreatedAt,
-		&task.UpdatedAt,
-		&task.DueDate,
-		&task.Status,
-		&task.AssigneeID,
-	)
-	if err != nil {
+	), task)
+	if isForeignKeyViolation(err) {
+		return errors.NewNotFound("campaign not found", err)
+	} else if err != nil {
 		return errors.NewInternal("failed to update task", err)
 	}
 	return nil
@@ -312,7 +415,7 @@ func (r *PostgresTaskRepository) checkForDuplicateTask(ctx context.Context, task
 	return count > 0, nil
 }
 
-// DeleteTask deletes a task by its ID.
+// DeleteTask soft-deletes a task by its ID.
 func (r *PostgresTaskRepository) DeleteTask(ctx context.Context, taskId uuid.UUID) error {
 	result, err := r.db.ExecContext(ctx, deleteTaskQuery, taskId)
 	if err != nil {
@@ -328,6 +431,27 @@ func (r *PostgresTaskRepository) DeleteTask(ctx context.Context, taskId uuid.UUI
 	return nil
 }
 
+// RestoreTask restores a soft-deleted task by its ID.
+func (r *PostgresTaskRepository) RestoreTask(ctx context.Context, taskId uuid.UUID) (*models.Task, error) {
+	var task models.Task
+	err := scanTaskFields(r.db.QueryRowContext(ctx, restoreTaskQuery, taskId), &task)
+	if err == sql.ErrNoRows {
+		return nil, errors.NewNotFound("deleted task not found", nil)
+	} else if err != nil {
+		return nil, errors.NewInternal("failed to restore task", err)
+	}
+	return &task, nil
+}
+
+// PurgeDeletedTasks permanently removes tasks soft-deleted before the given moment.
+func (r *PostgresTaskRepository) PurgeDeletedTasks(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, purgeDeletedTasksQuery, before)
+	if err != nil {
+		return 0, errors.NewInternal("failed to purge deleted tasks", err)
+	}
+	return result.RowsAffected()
+}
+
 // Выполнение функции в рамках транзакции
 func (r *PostgresTaskRepository) WithTransaction(ctx context.Context, f func(tx *sql.Tx) error) error {
 	tx, err := r.db.BeginTx(ctx, nil)
@@ -343,92 +467,226 @@ func (r *PostgresTaskRepository) WithTransaction(ctx context.Context, f func(tx
 	return tx.Commit()
 }
 
-// UpdateTaskStatus updates the status of a task and handles user task completion count.
-func (r *PostgresTaskRepository) UpdateTaskStatus(ctx context.Context, taskID string, newStatus int, userID uuid.UUID) (*models.Task, error) {
+// UpdateTaskStatus переводит задачу в новый статус по графу переходов и записывает переход в историю.
+// При переходе в Completed пользователю начисляется вознаграждение и увеличивается счетчик выполненных задач;
+// при повторном открытии выполненной задачи начисление возвращается у получившего его пользователя.
+// Если пользователь взял задачу или назначен на нее явно, переход применяется к его личному прогрессу.
+// Задачу, требующую подтверждения, можно выполнить только одобрением заявки с доказательством.
+func (r *PostgresTaskRepository) UpdateTaskStatus(ctx context.Context, taskID string, newStatus int, userID uuid.UUID, actor string, dueDeadline time.Time) (*models.Task, *models.BalanceUpdate, error) {
 	var updatedTask models.Task
+	var change *models.BalanceUpdate
 
 	err := r.WithTransaction(ctx, func(tx *sql.Tx) error {
-		if exists, err := r.checkTaskExistsTx(ctx, tx, taskID); err != nil || !exists {
-			if err != nil {
-				return err
-			}
-			return errors.NewNotFound("task not found", nil)
-		}
-
-		currentStatus, err := r.getCurrentTaskStatus(ctx, tx, taskID)
-		if err != nil {
+		var err error
+		if change, err = r.transitionTask(ctx, tx, taskID, models.TaskStatus(newStatus), userID.String(), actor, false, dueDeadline); err != nil {
 			return err
 		}
+		return scanTaskFields(tx.QueryRowContext(ctx, getTaskByIDQuery, taskID), &updatedTask)
+	})
 
-		if err := r.validateNewStatus(ctx, tx, newStatus); err != nil {
-			return err
-		}
+	if err != nil {
+		return nil, nil, err
+	}
 
-		if newStatus == 3 && currentStatus != 3 {
-			if err := r.incrementUserTaskCount(ctx, tx, userID); err != nil {
-				return err
-			}
-		}
+	return &updatedTask, change, nil
+}
 
-		if err := r.updateTaskStatusInDB(ctx, tx, newStatus, taskID); err != nil {
-			return err
+// transitionTask выполняет переход статуса задачи (или личного прогресса пользователя) в рамках транзакции.
+// approved означает, что выполнение подтверждено одобренной заявкой: заявка отправлена до срока, поэтому
+// dueDeadline для нее не проверяется. Переход изменяет баланс не больше чем одного пользователя;
+// возвращает это изменение или nil.
+func (r *PostgresTaskRepository) transitionTask(ctx context.Context, tx *sql.Tx, taskID string, next models.TaskStatus,
+	userID, actor string, approved bool, dueDeadline time.Time) (*models.BalanceUpdate, error) {
+	var current models.TaskStatus
+	var reward float64
+	var completedBy *string
+	var mode models.TaskAssignmentMode
+	var requiresEvidence bool
+	var dueDate *time.Time
+	err := tx.QueryRowContext(ctx, lockTaskForStatusQuery, taskID).Scan(&current, &reward, &completedBy, &mode, &requiresEvidence, &dueDate)
+	if err == sql.ErrNoRows {
+		return nil, errors.NewNotFound("task not found", nil)
+	} else if err != nil {
+		return nil, errors.NewInternal("failed to get task status", err)
+	}
+	if !approved && (next == models.InProgress || next == models.Completed) {
+		if err := checkNotPastDue(dueDate, dueDeadline); err != nil {
+			return nil, err
 		}
+	}
 
-		return r.fetchUpdatedTask(ctx, tx, taskID, &updatedTask)
-	})
-
+	canComplete := approved || !requiresEvidence
+	handled, delta, err := r.transitionAssignment(ctx, tx, taskID, userID, mode, next, reward, actor, canComplete)
 	if err != nil {
 		return nil, err
+	} else if handled {
+		return balanceUpdate(ctx, tx, userID, delta)
 	}
 
-	return &updatedTask, nil
+	if next == current {
+		// Повторная установка того же статуса не является переходом
+		return nil, nil
+	}
+	if !current.CanTransitionTo(next) {
+		return nil, errors.NewValidation(fmt.Sprintf("illegal task status transition from %s to %s", current, next), nil).WithCode(errors.CodeInvalidStatusTransition)
+	}
+	if next == models.Completed && !canComplete {
+		return nil, errors.NewConflict("task requires an approved submission to be completed", nil)
+	}
+
+	historyUser := userID
+	var rewardDelta float64
+	switch {
+	case next == models.Completed:
+		paid, err := r.creditTaskReward(ctx, tx, taskID, userID, reward)
+		if err != nil {
+			return nil, err
+		}
+		rewardDelta = paid
+		completedBy = &historyUser
+	case current == models.Completed:
+		if completedBy != nil {
+			reversed, err := r.reverseTaskReward(ctx, tx, taskID, *completedBy)
+			if err != nil {
+				return nil, err
+			}
+			rewardDelta = -reversed
+			historyUser = *completedBy
+		}
+		completedBy = nil
+	}
+
+	if _, err := tx.ExecContext(ctx, setTaskStatusQuery, next, completedBy, taskID); err != nil {
+		return nil, errors.NewInternal("failed to update task status", err)
+	}
+	if _, err := tx.ExecContext(ctx, insertTaskStatusHistoryQuery, taskID, current, next, actor, historyUser, rewardDelta); err != nil {
+		return nil, errors.NewInternal("failed to record task status history", err)
+	}
+	bonus, err := r.syncQuestBonuses(ctx, tx, taskID, historyUser, current, next)
+	if err != nil {
+		return nil, err
+	}
+	return balanceUpdate(ctx, tx, historyUser, rewardDelta+bonus)
 }
 
-func (r *PostgresTaskRepository) getCurrentTaskStatus(ctx context.Context, tx *sql.Tx, taskID string) (int, error) {
-	var currentStatus int
-	if err := tx.QueryRowContext(ctx, `SELECT status FROM tasks WHERE task_id = $1`, taskID).Scan(&currentStatus); err != nil {
-		return 0, errors.NewInternal("failed to get task status", err)
+// syncQuestBonuses начисляет бонусы за завершенные квесты при выполнении задачи
+// и возвращает их при повторном открытии выполненной задачи. Возвращает изменение баланса.
+func (r *PostgresTaskRepository) syncQuestBonuses(ctx context.Context, tx *sql.Tx, taskID, userID string, current, next models.TaskStatus) (float64, error) {
+	switch {
+	case next == models.Completed:
+		return r.awardQuestBonuses(ctx, tx, taskID, userID)
+	case current == models.Completed:
+		return r.revokeQuestBonuses(ctx, tx, taskID, userID)
 	}
-	return currentStatus, nil
+	return 0, nil
 }
 
-func (r *PostgresTaskRepository) validateNewStatus(ctx context.Context, tx *sql.Tx, newStatus int) error {
-	var statusExists bool
-	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM task_status WHERE id = $1)`, newStatus).Scan(&statusExists); err != nil {
-		return errors.NewValidation("failed to check if status exists", err)
+// balanceUpdate возвращает изменение баланса пользователя на delta с его новым балансом
+// или nil, если баланс не изменился.
+func balanceUpdate(ctx context.Context, tx *sql.Tx, userID string, delta float64) (*models.BalanceUpdate, error) {
+	if delta == 0 {
+		return nil, nil
 	}
-	if !statusExists {
-		return fmt.Errorf("status ID %d does not exist", newStatus)
+	change := &models.BalanceUpdate{UserID: userID, Delta: delta}
+	if err := tx.QueryRowContext(ctx, userBalanceQuery, userID).Scan(&change.Balance); err != nil {
+		return nil, errors.NewInternal("failed to get user balance", err)
 	}
-	return nil
+	return change, nil
 }
 
-func (r *PostgresTaskRepository) incrementUserTaskCount(ctx context.Context, tx *sql.Tx, userID uuid.UUID) error {
-	if _, err := tx.ExecContext(ctx, userTaskStatusChangeQuery, userID); err != nil {
-		return errors.NewInternal("failed to update user's completed tasks count", err)
+// creditTaskReward начисляет пользователю вознаграждение за задачу в рамках транзакции и возвращает
+// выплаченную сумму. Вознаграждение за задачу кампании выплачивается только во время кампании
+// и не превышает остаток ее бюджета: после исчерпания бюджета выполнение засчитывается без выплаты.
+func (r *PostgresTaskRepository) creditTaskReward(ctx context.Context, tx *sql.Tx, taskID, userID string, reward float64) (float64, error) {
+	paid := reward
+	var campaignID string
+	var remaining float64
+	var active bool
+	err := tx.QueryRowContext(ctx, lockTaskCampaignQuery, taskID).Scan(&campaignID, &remaining, &active)
+	switch {
+	case err == sql.ErrNoRows:
+		// Задача вне кампании
+	case err != nil:
+		return 0, errors.NewInternal("failed to get task campaign", err)
+	case !active:
+		return 0, errors.NewConflict("task campaign is not active", nil)
+	default:
+		if paid > remaining {
+			paid = math.Max(remaining, 0)
+		}
+		if _, err := tx.ExecContext(ctx, spendCampaignBudgetQuery, paid, campaignID); err != nil {
+			return 0, errors.NewInternal("failed to spend campaign budget", err)
+		}
 	}
-	return nil
-}
 
-func (r *PostgresTaskRepository) updateTaskStatusInDB(ctx context.Context, tx *sql.Tx, newStatus int, taskID string) error {
-	if _, err := tx.ExecContext(ctx, `UPDATE tasks SET status = $1, updated_at = CURRENT_TIMESTAMP WHERE task_id = $2`, newStatus, taskID); err != nil {
-		return errors.NewInternal("failed to update task status", err)
+	if _, err := tx.ExecContext(ctx, SetLedgerReasonQuery, models.LedgerReasonTaskReward); err != nil {
+		return 0, errors.NewInternal("failed to set ledger reason", err)
 	}
-	return nil
+
+	result, err := tx.ExecContext(ctx, creditTaskRewardQuery, paid, userID)
+	if err != nil {
+		return 0, errors.NewInternal("failed to credit task reward", err)
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return 0, errors.NewInternal("failed to retrieve affected rows after reward", err)
+	} else if rowsAffected == 0 {
+		return 0, errors.NewNotFound("user not found", nil)
+	}
+	return paid, nil
 }
 
-func (r *PostgresTaskRepository) fetchUpdatedTask(ctx context.Context, tx *sql.Tx, taskID string, updatedTask *models.Task) error {
-	return tx.QueryRowContext(ctx, `SELECT task_id, status, title, description, created_at, updated_at, due_date, assignee_id 
-	FROM tasks WHERE task_id = $1`, taskID).Scan(&updatedTask.TaskID,
-		&updatedTask.Status, &updatedTask.Title, &updatedTask.Description,
-		&updatedTask.CreatedAt, &updatedTask.UpdatedAt, &updatedTask.DueDate,
-		&updatedTask.AssigneeID)
+// reverseTaskReward списывает последнее начисленное пользователю за задачу вознаграждение, возвращает
+// его в бюджет кампании и возвращает фактически списанную сумму.
+func (r *PostgresTaskRepository) reverseTaskReward(ctx context.Context, tx *sql.Tx, taskID, userID string) (float64, error) {
+	var paid float64
+	if err := tx.QueryRowContext(ctx, lastTaskRewardQuery, taskID, userID, models.Completed).Scan(&paid); err != nil {
+		return 0, errors.NewInternal("failed to get paid task reward", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, SetLedgerReasonQuery, models.LedgerReasonTaskReversal); err != nil {
+		return 0, errors.NewInternal("failed to set ledger reason", err)
+	}
+
+	var reversed float64
+	err := tx.QueryRowContext(ctx, reverseTaskRewardQuery, paid, userID).Scan(&reversed)
+	if err == sql.ErrNoRows {
+		// Пользователь уже окончательно удален - списывать не с кого
+		return 0, nil
+	} else if err != nil {
+		return 0, errors.NewInternal("failed to reverse task reward", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, refundCampaignBudgetQuery, reversed, taskID); err != nil {
+		return 0, errors.NewInternal("failed to refund campaign budget", err)
+	}
+	return reversed, nil
 }
 
-func (r *PostgresTaskRepository) checkTaskExistsTx(ctx context.Context, tx *sql.Tx, taskID string) (bool, error) {
-	var exists bool
-	if err := tx.QueryRowContext(ctx, checkTaskExistsQuery, taskID).Scan(&exists); err != nil {
-		return false, errors.NewInternal("failed to check task existence", err)
+// GetTaskStatusHistory возвращает историю переходов статуса задачи в хронологическом порядке.
+func (r *PostgresTaskRepository) GetTaskStatusHistory(ctx context.Context, taskID uuid.UUID) ([]models.TaskStatusChange, error) {
+	if exists, err := r.checkTaskExists(ctx, taskID.String()); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, errors.NewNotFound("task not found", nil)
 	}
-	return exists, nil
+
+	rows, err := r.db.QueryContext(ctx, getTaskStatusHistoryQuery, taskID)
+	if err != nil {
+		return nil, errors.NewInternal("failed to query task status history", err)
+	}
+	defer rows.Close()
+
+	history := []models.TaskStatusChange{}
+	for rows.Next() {
+		var change models.TaskStatusChange
+		if err := rows.Scan(&change.ID, &change.TaskID, &change.FromStatus, &change.ToStatus,
+			&change.Actor, &change.UserID, &change.RewardDelta, &change.CreatedAt); err != nil {
+			return nil, errors.NewInternal("failed to scan task status change", err)
+		}
+		history = append(history, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.NewInternal("error occurred while iterating over task status history", err)
+	}
+	return history, nil
 }
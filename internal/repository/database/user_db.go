@@ -3,25 +3,29 @@ package database
 import (
 	"context"
 	"database/sql"
+	stderrors "errors"
 	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
 	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
 	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+// userColumns - список колонок пользователя в порядке, ожидаемом scanUser и scanUsers.
+// Необязательные колонки приводятся к нулевым значениям, чтобы их можно было сканировать в поля модели.
+const userColumns = `ID, Username, Email, COALESCE(Balance, 0), COALESCE(Referrals, 0), COALESCE(ReferralCode, ''), COALESCE(TasksCompleted, 0),
+	CreatedAt, UpdatedAt, LastVisit, COALESCE(VisitCount, 0), COALESCE(Bio, ''), COALESCE(TimeZone, ''), Status, DeletedAt`
+
 // SQL Queries
 const (
-	// Получение пользователей с фильтрацией
-	GetUsersQuery = `SELECT ID, Username, Email, Balance, Referrals, ReferralCode, TasksCompleted, CreatedAt, UpdatedAt, LastVisit, VisitCount, Bio, TimeZone, Status 
-	FROM Users
-	WHERE (Username ILIKE COALESCE($1, Username) OR $1 IS NULL) 
-	  AND (Status = COALESCE($2, Status) OR $2 IS NULL);`
-
 	// Получение пользователя по ID
-	GetUserByIDQuery = `SELECT ID, Username, Email, Balance, Referrals, ReferralCode, TasksCompleted, CreatedAt, UpdatedAt, LastVisit, VisitCount, Bio, TimeZone, Status 
+	GetUserByIDQuery = `SELECT ` + userColumns + `
 	FROM Users 
-	WHERE ID = $1;`
+	WHERE ID = $1 AND DeletedAt IS NULL;`
 
 	// Создание нового пользователя
 	CreateUserQuery = `INSERT INTO Users (ID, Username, Email, Status) VALUES ($1, $2, $3, $4) RETURNING ID, Username, Email, Status, CreatedAt;`
@@ -38,12 +42,20 @@ const (
 	    TimeZone = COALESCE($8, TimeZone),
 	    Status = COALESCE($9, Status),
 	    UpdatedAt = CURRENT_TIMESTAMP
-	WHERE ID = $10
-	RETURNING ID, Username, Email, Balance, Referrals, ReferralCode, TasksCompleted, CreatedAt, UpdatedAt;`
+	WHERE ID = $10 AND DeletedAt IS NULL
+	RETURNING ` + userColumns + `;`
 
-	// Удаление пользователя
-	DeleteUserQuery = `DELETE FROM Users 
-	WHERE ID = $1;`
+	// Мягкое удаление пользователя: запись и связанная история сохраняются до очистки по сроку хранения
+	DeleteUserQuery = `UPDATE Users SET DeletedAt = CURRENT_TIMESTAMP, UpdatedAt = CURRENT_TIMESTAMP
+	WHERE ID = $1 AND DeletedAt IS NULL;`
+
+	// Восстановление мягко удаленного пользователя
+	RestoreUserQuery = `UPDATE Users SET DeletedAt = NULL, UpdatedAt = CURRENT_TIMESTAMP
+	WHERE ID = $1 AND DeletedAt IS NOT NULL
+	RETURNING ` + userColumns + `;`
+
+	// Окончательное удаление пользователей, удаленных раньше указанного момента
+	PurgeDeletedUsersQuery = `DELETE FROM Users WHERE DeletedAt IS NOT NULL AND DeletedAt < $1;`
 
 	// Добавление записи в журнал активности пользователя
 	AddUserActivityLogQuery = `INSERT INTO UserActivityLog (UserID, ActivityTime)
@@ -55,24 +67,61 @@ const (
 	ON CONFLICT (UserID, VisitDate) DO NOTHING;`
 
 	// Получение пользователей по статусу
-	GetUsersByStatusQuery = `SELECT ID, Username, Email, Balance, Referrals, ReferralCode, TasksCompleted, CreatedAt, UpdatedAt, LastVisit, VisitCount, Bio, TimeZone, Status 
+	GetUsersByStatusQuery = `SELECT ` + userColumns + `
 	FROM Users 
-	WHERE Status = $1;`
+	WHERE Status = $1 AND DeletedAt IS NULL;`
+
+	// Установка причины изменения баланса для журнала (действует до конца транзакции)
+	SetLedgerReasonQuery = `SELECT set_config('app.ledger_reason', $1, true)`
 
-	UpdateBalanceAndReferralsTxQuery = `UPDATE Users SET Balance = Balance + $1, Referrals = Referrals + $2 WHERE ID = $3`
+	UpdateBalanceAndReferralsTxQuery = `UPDATE Users SET Balance = COALESCE(Balance, 0) + $1, Referrals = COALESCE(Referrals, 0) + $2 WHERE ID = $3 AND DeletedAt IS NULL`
 
-	GetUserByEmailTxQuery = `SELECT ID, Username, Email, Balance, Referrals, ReferralCode, TasksCompleted, CreatedAt, UpdatedAt, LastVisit, VisitCount, Bio, TimeZone, Status FROM Users WHERE Email = $1`
+	GetUserByEmailTxQuery = `SELECT ` + userColumns + ` FROM Users WHERE Email = $1 AND DeletedAt IS NULL`
 
-	GetUserByEmailQuery = `SELECT ID, Username, Email, Balance, Referrals, ReferralCode, TasksCompleted, CreatedAt, UpdatedAt, LastVisit, VisitCount, Bio, TimeZone, Status FROM Users WHERE Email = $1`
+	GetUserByEmailQuery = `SELECT ` + userColumns + ` FROM Users WHERE Email = $1 AND DeletedAt IS NULL`
 
-	UpdateBalanceQuery = `UPDATE Users SET Balance = Balance + $1 WHERE ID = $2`
+	UpdateBalanceQuery = `UPDATE Users SET Balance = COALESCE(Balance, 0) + $1 WHERE ID = $2 AND DeletedAt IS NULL`
+
+	// Порядок таблицы лидеров: баланс по убыванию (пользователи без баланса - ниже нулевого),
+	// количество выполненных задач по убыванию, ID. Ключи вычисляются так же, как в индексе idx_users_leaderboard.
+	leaderboardOrder = `COALESCE(Balance, -1) DESC, COALESCE(TasksCompleted, 0) DESC, ID`
 
 	// Получение лидера по балансу
-	GetLeaderByBalanceQuery = `SELECT ID, Username, Email, Balance, Referrals, ReferralCode, TasksCompleted, CreatedAt, UpdatedAt, LastVisit, VisitCount, Bio, TimeZone, Status 
+	GetLeaderByBalanceQuery = `SELECT ` + userColumns + `, 1
     FROM Users 
-    ORDER BY Balance DESC 
+    WHERE DeletedAt IS NULL
+    ORDER BY ` + leaderboardOrder + `
     LIMIT 1;`
 
+	// Получение топа пользователей с рангом, вычисленным в порядке убывания баланса
+	GetTopUsersQuery = `SELECT ` + userColumns + `,
+	       ROW_NUMBER() OVER (ORDER BY ` + leaderboardOrder + `)
+	FROM Users
+	WHERE DeletedAt IS NULL
+	ORDER BY ` + leaderboardOrder + `
+	LIMIT $1 OFFSET $2;`
+
+	// Получение страницы топа после курсора ($1, $2, $3) - ключей порядка последнего пользователя предыдущей страницы
+	GetTopUsersAfterQuery = `SELECT ` + userColumns + `, COALESCE(Balance, -1)
+	FROM Users
+	WHERE DeletedAt IS NULL
+	  AND ($1::numeric IS NULL
+	       OR COALESCE(Balance, -1) < $1
+	       OR (COALESCE(Balance, -1) = $1 AND (COALESCE(TasksCompleted, 0) < $2
+	           OR (COALESCE(TasksCompleted, 0) = $2 AND ID > $3))))
+	ORDER BY ` + leaderboardOrder + `
+	LIMIT $4;`
+
+	// Получение страницы топа перед курсором ($1, $2, $3): выбирается в обратном порядке
+	GetTopUsersBeforeQuery = `SELECT ` + userColumns + `, COALESCE(Balance, -1)
+	FROM Users
+	WHERE DeletedAt IS NULL
+	  AND (COALESCE(Balance, -1) > $1
+	       OR (COALESCE(Balance, -1) = $1 AND (COALESCE(TasksCompleted, 0) > $2
+	           OR (COALESCE(TasksCompleted, 0) = $2 AND ID < $3))))
+	ORDER BY COALESCE(Balance, -1), COALESCE(TasksCompleted, 0), ID DESC
+	LIMIT $4;`
+
 	GetUserRankQuery = `
         SELECT COUNT(*) + 1 
         FROM users
@@ -90,13 +139,40 @@ func NewPostgresUserRepository(db *sql.DB) repository.UserRepository {
 	return &PostgresUserRepository{db: db}
 }
 
+// rowScanner - общий интерфейс *sql.Row и *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// isUniqueViolation проверяет, нарушено ли ограничение уникальности
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return stderrors.As(err, &pqErr) && pqErr.Code == "23505"
+}
+
+// isForeignKeyViolation проверяет, нарушено ли ограничение внешнего ключа
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return stderrors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+// scanUserFields сканирует колонки userColumns и дополнительные колонки extra в пользователя.
+func scanUserFields(row rowScanner, user *models.User, extra ...interface{}) error {
+	var lastVisit sql.NullTime
+	dest := append([]interface{}{&user.ID, &user.Username, &user.Email, &user.Balance, &user.Referrals,
+		&user.ReferralCode, &user.TasksCompleted, &user.CreatedAt, &user.UpdatedAt,
+		&lastVisit, &user.VisitCount, &user.Bio, &user.TimeZone, &user.Status, &user.DeletedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return err
+	}
+	user.LastVisit = lastVisit.Time
+	return nil
+}
+
 // scanUser сканирует пользователя из строки и возвращает его.
 func scanUser(row *sql.Row) (*models.User, error) {
 	var user models.User
-	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.Balance, &user.Referrals,
-		&user.ReferralCode, &user.TasksCompleted, &user.CreatedAt, &user.UpdatedAt,
-		&user.LastVisit, &user.VisitCount, &user.Bio, &user.TimeZone, &user.Status)
-	if err != nil {
+	if err := scanUserFields(row, &user); err != nil {
 		return nil, err
 	}
 	return &user, nil
@@ -105,12 +181,7 @@ func scanUser(row *sql.Row) (*models.User, error) {
 // scanTopUser сканирует данные о пользователе в структуру TopUser.
 func scanTopUser(row *sql.Row) (*models.TopUser, error) {
 	var topUser models.TopUser
-	err := row.Scan(&topUser.ID, &topUser.Username, &topUser.Email, &topUser.Balance,
-		&topUser.Referrals, &topUser.ReferralCode, &topUser.TasksCompleted,
-		&topUser.CreatedAt, &topUser.UpdatedAt, &topUser.LastVisit,
-		&topUser.VisitCount, &topUser.Bio, &topUser.TimeZone,
-		&topUser.Status, &topUser.Rank) // Добавляем поле Rank
-	if err != nil {
+	if err := scanUserFields(row, &topUser.User, &topUser.Rank); err != nil { // Добавляем поле Rank
 		return nil, err
 	}
 	return &topUser, nil
@@ -121,19 +192,22 @@ func scanUsers(rows *sql.Rows) ([]*models.User, error) {
 	var users []*models.User
 	for rows.Next() {
 		var user models.User
-		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.Balance, &user.Referrals,
-			&user.ReferralCode, &user.TasksCompleted, &user.CreatedAt, &user.UpdatedAt,
-			&user.LastVisit, &user.VisitCount, &user.Bio, &user.TimeZone, &user.Status); err != nil {
+		if err := scanUserFields(rows, &user); err != nil {
 			return nil, err
 		}
 		users = append(users, &user)
 	}
-	return users, nil
+	return users, rows.Err()
 }
 
-// Загрузить всех пользователей по фильтру
-func (r *PostgresUserRepository) GetUsers(ctx context.Context, filter *models.User) (*models.UsersResponse, error) {
-	rows, err := r.db.QueryContext(ctx, GetUsersQuery, filter.Username, filter.Status)
+// Загрузить страницу пользователей по фильтру в заданном порядке
+func (r *PostgresUserRepository) GetUsers(ctx context.Context, filter *models.UserFilter) (*models.UsersResponse, error) {
+	query, args, keys, err := buildUsersQuery(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -144,9 +218,11 @@ func (r *PostgresUserRepository) GetUsers(ctx context.Context, filter *models.Us
 		return nil, err
 	}
 
+	users, info := pagination.Paginate(users, filter.Pagination, keys)
 	response := &models.UsersResponse{
 		Users: users,
 		Count: len(users), //populate Count based on row count
+		Info:  info,
 	}
 	return response, nil
 }
@@ -184,10 +260,44 @@ func (r *PostgresUserRepository) UpdateUser(ctx context.Context, user *models.Us
 	return scanUser(row)
 }
 
-// Удалить пользователя
+// Удалить пользователя (мягкое удаление)
 func (r *PostgresUserRepository) DeleteUser(ctx context.Context, id uuid.UUID) error {
-	_, err := r.db.ExecContext(ctx, DeleteUserQuery, id)
-	return err
+	result, err := r.db.ExecContext(ctx, DeleteUserQuery, id)
+	if err != nil {
+		return errors.NewInternal("failed to delete user", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return errors.NewInternal("failed to retrieve affected rows after delete", err)
+	} else if rowsAffected == 0 {
+		return errors.NewNotFound("user not found", nil)
+	}
+	return nil
+}
+
+// RestoreUser восстанавливает мягко удаленного пользователя
+func (r *PostgresUserRepository) RestoreUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	user, err := scanUser(r.db.QueryRowContext(ctx, RestoreUserQuery, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.NewNotFound("deleted user not found", nil)
+	}
+	if err != nil {
+		if isUniqueViolation(err) {
+			return nil, errors.NewAlreadyExists("email is already taken by another user", err)
+		}
+		return nil, errors.NewInternal("failed to restore user", err)
+	}
+	return user, nil
+}
+
+// PurgeDeletedUsers окончательно удаляет пользователей, мягко удаленных раньше указанного момента
+func (r *PostgresUserRepository) PurgeDeletedUsers(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, PurgeDeletedUsersQuery, before)
+	if err != nil {
+		return 0, errors.NewInternal("failed to purge deleted users", err)
+	}
+	return result.RowsAffected()
 }
 
 // Получить пользователей по статусу
@@ -220,7 +330,7 @@ func (r *PostgresUserRepository) GetUserFullInfo(ctx context.Context, id uuid.UU
 		return "", err
 	}
 	if user == nil {
-		return "", fmt.Errorf("user not found")
+		return "", errors.NewNotFound("user not found", nil)
 	}
 	return fmt.Sprintf("User Info: %+v", user), nil
 }
@@ -232,7 +342,7 @@ func (r *PostgresUserRepository) GetUserSummary(ctx context.Context, id uuid.UUI
 		return nil, err
 	}
 	if user == nil {
-		return nil, fmt.Errorf("user not found")
+		return nil, errors.NewNotFound("user not found", nil)
 	}
 	return &models.UserSummary{
 		ID:             user.ID,
@@ -244,9 +354,13 @@ func (r *PostgresUserRepository) GetUserSummary(ctx context.Context, id uuid.UUI
 	}, nil
 }
 
-// Обновление баланса и рефералов в рамках транзакции
-func (r *PostgresUserRepository) UpdateBalanceAndReferralsTx(ctx context.Context, tx *sql.Tx, id uuid.UUID, balance float64, referrals int) error {
-	_, err := tx.ExecContext(ctx, UpdateBalanceAndReferralsTxQuery, balance, referrals, id)
+// Приращение баланса и рефералов в рамках транзакции
+func (r *PostgresUserRepository) UpdateBalanceAndReferralsTx(ctx context.Context, tx *sql.Tx, id uuid.UUID, balanceDelta float64, referralsDelta int) error {
+	// Помечаем изменение баланса в журнале как реферальный бонус
+	if _, err := tx.ExecContext(ctx, SetLedgerReasonQuery, models.LedgerReasonReferralBonus); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, UpdateBalanceAndReferralsTxQuery, balanceDelta, referralsDelta, id)
 	return err
 }
 
@@ -279,10 +393,8 @@ func (r *PostgresUserRepository) GetUserByEmailTx(ctx context.Context, tx *sql.T
 
 // Создание нового пользователя в рамках транзакции
 func (r *PostgresUserRepository) CreateUserTx(ctx context.Context, tx *sql.Tx, user *models.User) (*models.User, error) {
-	err := tx.QueryRowContext(ctx, CreateUserQuery, user.ID, user.Username, user.Email, user.Balance,
-		user.Referrals, user.ReferralCode, user.TasksCompleted, user.Bio, user.TimeZone, user.Status).
-		Scan(&user.ID, &user.Username, &user.Email, &user.Balance, &user.Referrals,
-			&user.ReferralCode, &user.TasksCompleted, &user.CreatedAt, &user.UpdatedAt)
+	err := tx.QueryRowContext(ctx, CreateUserQuery, user.ID, user.Username, user.Email, user.Status).
+		Scan(&user.ID, &user.Username, &user.Email, &user.Status, &user.CreatedAt)
 	if err != nil {
 		return nil, err
 	}
@@ -299,33 +411,9 @@ func (r *PostgresUserRepository) GetLeaderByBalance(ctx context.Context) (*model
 	return topUser, nil
 }
 
-func getUserRank(db *sql.DB, username string) (int, error) {
-	// Получаем количество завершённых задач для указанного пользователя
-	var tasksCompleted int
-	err := db.QueryRow("SELECT tasks_completed FROM users WHERE username = ?", username).Scan(&tasksCompleted)
-	if err != nil {
-		return 0, err
-	}
-
-	// Теперь вычисляем ранг, подсчитывая количество пользователей с задачами >= tasksCompleted
-	var rank int
-	err = db.QueryRow("SELECT COUNT(*) FROM users WHERE tasks_completed >= ?", tasksCompleted).Scan(&rank)
-	if err != nil {
-		return 0, err
-	}
-
-	// Возвращаем ранг пользователя
-	return rank, nil
-}
-
 // / Получить топ пользователей с лимитом по количеству
 func (r *PostgresUserRepository) GetTopUsers(ctx context.Context, limit int, offset int) ([]models.TopUser, error) {
-	rows, err := r.db.QueryContext(ctx, `
-        SELECT ID, Username, Email, Balance, Referrals, ReferralCode, TasksCompleted, CreatedAt, UpdatedAt, LastVisit, VisitCount, Bio, TimeZone, Status
-        FROM Users
-        ORDER BY Balance DESC, TasksCompleted DESC
-        LIMIT $1;
-    `, limit)
+	rows, err := r.db.QueryContext(ctx, GetTopUsersQuery, limit, offset)
 
 	if err != nil {
 		return nil, err
@@ -336,32 +424,11 @@ func (r *PostgresUserRepository) GetTopUsers(ctx context.Context, limit int, off
 
 	for rows.Next() {
 		var user models.User
-		err = rows.Scan(
-			&user.ID,
-			&user.Username,
-			&user.Email,
-			&user.Balance,
-			&user.Referrals,
-			&user.ReferralCode,
-			&user.TasksCompleted,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-			&user.LastVisit,
-			&user.VisitCount,
-			&user.Bio,
-			&user.TimeZone,
-			&user.Status,
-		)
-		if err != nil {
+		var rank int
+		if err := scanUserFields(rows, &user, &rank); err != nil {
 			return nil, err
 		}
 
-		// Получаем ранг пользователя
-		rank, err := getUserRank(r.db, user.Username)
-		if err != nil {
-			return nil, err // Возвращаем ошибку, если не удалось получить ранг
-		}
-
 		// Создаем объект TopUser и добавляем в срез
 		topUser := models.TopUser{
 			User: user, // Используем user как User
@@ -376,3 +443,71 @@ func (r *PostgresUserRepository) GetTopUsers(ctx context.Context, limit int, off
 
 	return topUsers, nil
 }
+
+// GetTopUsersPage возвращает страницу топа пользователей после или перед курсором.
+// Курсор хранит ключи порядка и ранг пользователя, поэтому ранги страницы вычисляются без подсчета
+// пользователей выше нее.
+func (r *PostgresUserRepository) GetTopUsersPage(ctx context.Context, page pagination.Page) ([]models.TopUser, pagination.Info, error) {
+	query := GetTopUsersAfterQuery
+	var balance interface{}
+	var tasksCompleted, rank int
+	var id string
+	if page.Cursor != nil {
+		if page.Cursor.Backward {
+			query = GetTopUsersBeforeQuery
+		}
+		b, err := page.Cursor.Float(0)
+		if err != nil {
+			return nil, pagination.Info{}, err
+		}
+		if tasksCompleted, err = page.Cursor.Int(1); err != nil {
+			return nil, pagination.Info{}, err
+		}
+		if id, err = page.Cursor.String(2); err != nil {
+			return nil, pagination.Info{}, err
+		}
+		if rank, err = page.Cursor.Int(3); err != nil {
+			return nil, pagination.Info{}, err
+		}
+		balance = b
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, balance, tasksCompleted, id, page.QueryLimit())
+	if err != nil {
+		return nil, pagination.Info{}, err
+	}
+	defer rows.Close()
+
+	// Ключ баланса отличается от Balance пользователя, у которого баланс не задан
+	type leaderboardRow struct {
+		models.TopUser
+		balanceKey float64
+	}
+	var leaders []leaderboardRow
+	for rows.Next() {
+		var leader leaderboardRow
+		if err := scanUserFields(rows, &leader.User, &leader.balanceKey); err != nil {
+			return nil, pagination.Info{}, err
+		}
+		// Страница перед курсором выбирается от курсора к началу топа
+		if page.Backward() {
+			leader.Rank = rank - len(leaders) - 1
+		} else {
+			leader.Rank = rank + len(leaders) + 1
+		}
+		leaders = append(leaders, leader)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, pagination.Info{}, err
+	}
+
+	leaders, info := pagination.Paginate(leaders, page, func(leader leaderboardRow) []string {
+		return []string{pagination.FormatFloat(leader.balanceKey), pagination.FormatInt(leader.TasksCompleted),
+			leader.ID, pagination.FormatInt(leader.Rank)}
+	})
+	topUsers := make([]models.TopUser, len(leaders))
+	for i, leader := range leaders {
+		topUsers[i] = leader.TopUser
+	}
+	return topUsers, info, nil
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
+	"github.com/ZnNr/user-reward-controller/internal/sqlbuilder"
+)
+
+// userSortKey описывает выражение сортировки списка пользователей. Необязательные колонки приводятся
+// к тем же значениям, что и в userColumns, поэтому ключ курсора совпадает со значением поля модели.
+type userSortKey struct {
+	expr  string
+	value func(user *models.User) string                              // Значение ключа для курсора
+	parse func(cursor *pagination.Cursor, i int) (interface{}, error) // Значение ключа из курсора
+}
+
+// userSortKeys - допустимые поля сортировки. В запрос попадают только выражения из этого списка.
+var userSortKeys = map[models.UserSortField]userSortKey{
+	models.UserSortCreatedAt: {
+		expr:  `CreatedAt`,
+		value: func(user *models.User) string { return pagination.FormatTime(user.CreatedAt) },
+		parse: cursorTime,
+	},
+	models.UserSortUsername: {
+		expr:  `Username`,
+		value: func(user *models.User) string { return user.Username },
+		parse: cursorString,
+	},
+	models.UserSortBalance: {
+		expr:  `COALESCE(Balance, 0)`,
+		value: func(user *models.User) string { return pagination.FormatFloat(user.Balance) },
+		parse: cursorFloat,
+	},
+	models.UserSortReferrals: {
+		expr:  `COALESCE(Referrals, 0)`,
+		value: func(user *models.User) string { return pagination.FormatInt(user.Referrals) },
+		parse: cursorInt,
+	},
+	models.UserSortTasksCompleted: {
+		expr:  `COALESCE(TasksCompleted, 0)`,
+		value: func(user *models.User) string { return pagination.FormatInt(user.TasksCompleted) },
+		parse: cursorInt,
+	},
+	// Не посещавшие пользователи получают нулевое время, как и поле LastVisit модели
+	models.UserSortLastVisit: {
+		expr:  `COALESCE(LastVisit, '0001-01-01 00:00:00'::timestamp)`,
+		value: func(user *models.User) string { return pagination.FormatTime(user.LastVisit) },
+		parse: cursorTime,
+	},
+}
+
+// userIDSortKey упорядочивает пользователей с одинаковыми значениями полей сортировки
+var userIDSortKey = userSortKey{
+	expr:  `ID`,
+	value: func(user *models.User) string { return user.ID },
+	parse: cursorString,
+}
+
+func cursorTime(cursor *pagination.Cursor, i int) (interface{}, error)   { return cursor.Time(i) }
+func cursorString(cursor *pagination.Cursor, i int) (interface{}, error) { return cursor.String(i) }
+func cursorFloat(cursor *pagination.Cursor, i int) (interface{}, error)  { return cursor.Float(i) }
+func cursorInt(cursor *pagination.Cursor, i int) (interface{}, error)    { return cursor.Int(i) }
+
+// buildUsersQuery строит запрос страницы пользователей по фильтру. Возвращает также функцию,
+// вычисляющую ключи курсора пользователя в порядке сортировки.
+func buildUsersQuery(filter *models.UserFilter) (string, []interface{}, func(*models.User) []string, error) {
+	sorts := filter.Sort
+	if len(sorts) == 0 {
+		sorts = []models.UserSort{{Field: models.UserSortCreatedAt, Desc: true}}
+	}
+	keys := make([]userSortKey, 0, len(sorts)+1)
+	order := make([]sqlbuilder.SortKey, 0, len(sorts)+1)
+	for _, sort := range sorts {
+		key, ok := userSortKeys[sort.Field]
+		if !ok {
+			return "", nil, nil, errors.NewValidation(fmt.Sprintf("unsupported sort field %q", sort.Field), nil)
+		}
+		keys = append(keys, key)
+		order = append(order, sqlbuilder.SortKey{Expr: key.expr, Desc: sort.Desc})
+	}
+	// ID делает порядок однозначным и сортируется в направлении последнего поля
+	keys = append(keys, userIDSortKey)
+	order = append(order, sqlbuilder.SortKey{Expr: userIDSortKey.expr, Desc: order[len(order)-1].Desc})
+
+	q := sqlbuilder.Select(userColumns).From(`Users`).
+		Contains(`Username`, filter.Username).
+		Eq(`Status`, filter.Status).
+		WhereIf(filter.EmailDomain != "", `LOWER(SPLIT_PART(Email, '@', 2)) = LOWER(?)`, filter.EmailDomain).
+		Range(`COALESCE(Balance, 0)`, filter.MinBalance, filter.MaxBalance).
+		Range(`CreatedAt`, filter.CreatedAfter, filter.CreatedBefore).
+		Range(`COALESCE(Referrals, 0)`, filter.MinReferrals, filter.MaxReferrals).
+		WhereIf(filter.LastVisitBefore != nil, `(LastVisit IS NULL OR LastVisit < ?)`, filter.LastVisitBefore).
+		WhereIf(!filter.IncludeDeleted, `DeletedAt IS NULL`)
+	if filter.HasReferrer != nil {
+		// Код пригласившего сохраняется в ReferralCode при регистрации
+		if *filter.HasReferrer {
+			q.Where(`COALESCE(ReferralCode, '') <> ''`)
+		} else {
+			q.Where(`COALESCE(ReferralCode, '') = ''`)
+		}
+	}
+
+	page := filter.Pagination
+	if page.Cursor != nil {
+		if len(page.Cursor.Keys) != len(keys) {
+			return "", nil, nil, errors.NewBadRequest("cursor does not match the requested sort", nil)
+		}
+		values := make([]interface{}, len(keys))
+		for i, key := range keys {
+			value, err := key.parse(page.Cursor, i)
+			if err != nil {
+				return "", nil, nil, err
+			}
+			values[i] = value
+		}
+		q.After(order, values, page.Cursor.Backward)
+	}
+	// Страница перед курсором выбирается в обратном порядке
+	q.OrderByKeys(order, page.Backward()).Limit(page.QueryLimit())
+
+	cursorKeys := func(user *models.User) []string {
+		values := make([]string, len(keys))
+		for i, key := range keys {
+			values[i] = key.value(user)
+		}
+		return values
+	}
+	query, args := q.Build()
+	return query, args, cursorKeys, nil
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	revokeVerificationTokensQuery = `UPDATE user_verification_tokens SET used_at = NOW()
+	WHERE user_id = $1 AND used_at IS NULL`
+
+	createVerificationTokenQuery = `INSERT INTO user_verification_tokens (token_hash, user_id, expires_at)
+	VALUES ($1, $2, $3)`
+
+	consumeVerificationTokenQuery = `UPDATE user_verification_tokens SET used_at = NOW()
+	WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+	RETURNING user_id`
+
+	activatePendingUserQuery = `UPDATE Users SET Status = $2, UpdatedAt = CURRENT_TIMESTAMP
+	WHERE ID = $1 AND Status = $3 AND DeletedAt IS NULL
+	RETURNING ` + userColumns
+)
+
+// PostgresVerificationRepository реализует VerificationRepository для PostgreSQL
+type PostgresVerificationRepository struct {
+	db *sql.DB
+}
+
+// NewPostgresVerificationRepository создает новый репозиторий токенов подтверждения с указанным соединением с БД.
+func NewPostgresVerificationRepository(db *sql.DB) repository.VerificationRepository {
+	return &PostgresVerificationRepository{db: db}
+}
+
+// CreateToken сохраняет токен, аннулируя предыдущие токены пользователя
+func (r *PostgresVerificationRepository) CreateToken(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.NewInternal("failed to begin transaction", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, revokeVerificationTokensQuery, userID); err != nil {
+		return errors.NewInternal("failed to revoke previous verification tokens", err)
+	}
+	if _, err := tx.ExecContext(ctx, createVerificationTokenQuery, tokenHash, userID, expiresAt); err != nil {
+		return errors.NewInternal("failed to create verification token", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.NewInternal("failed to commit verification token", err)
+	}
+	return nil
+}
+
+// ActivateByToken погашает токен и активирует пользователя в одной транзакции
+func (r *PostgresVerificationRepository) ActivateByToken(ctx context.Context, tokenHash string) (*models.User, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.NewInternal("failed to begin transaction", err)
+	}
+	defer tx.Rollback()
+
+	var userID string
+	err = tx.QueryRowContext(ctx, consumeVerificationTokenQuery, tokenHash).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return nil, errors.NewValidation("verification token is invalid or expired", nil)
+	} else if err != nil {
+		return nil, errors.NewInternal("failed to consume verification token", err)
+	}
+
+	var user models.User
+	err = scanUserFields(tx.QueryRowContext(ctx, activatePendingUserQuery, userID, models.Active, models.Pending), &user)
+	if err == sql.ErrNoRows {
+		return nil, errors.NewValidation("user is not pending verification", nil)
+	} else if err != nil {
+		return nil, errors.NewInternal("failed to activate user", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, errors.NewInternal("failed to commit user activation", err)
+	}
+	return &user, nil
+}
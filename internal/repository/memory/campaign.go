@@ -0,0 +1,217 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"math"
+	"slices"
+	"time"
+)
+
+// CampaignRepository - репозиторий кампаний в памяти. Бюджет расходуется при начислении
+// вознаграждений за задачи кампании репозиторием задач того же хранилища.
+type CampaignRepository struct {
+	store *Store
+}
+
+// NewCampaignRepository создает репозиторий кампаний над хранилищем store
+func NewCampaignRepository(store *Store) repository.CampaignRepository {
+	return &CampaignRepository{store: store}
+}
+
+// storeCampaign приводит время кампании к точности PostgreSQL
+func storeCampaign(campaign *models.Campaign) {
+	campaign.StartsAt = campaign.StartsAt.Truncate(time.Microsecond)
+	campaign.EndsAt = campaign.EndsAt.Truncate(time.Microsecond)
+	campaign.Budget = roundMoney(campaign.Budget)
+}
+
+// CreateCampaign создает кампанию
+func (r *CampaignRepository) CreateCampaign(ctx context.Context, campaign *models.Campaign) (*models.Campaign, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	s := r.store.state
+	if _, ok := s.campaigns[campaign.CampaignID]; ok {
+		return nil, errors.NewAlreadyExists("campaign already exists", violation("campaigns_pkey"))
+	}
+	created := *campaign
+	storeCampaign(&created)
+	created.Spent = 0
+	created.CreatedAt = now()
+	created.UpdatedAt = created.CreatedAt
+	s.campaigns[created.CampaignID] = &created
+	copied := created
+	return &copied, nil
+}
+
+// GetCampaignByID возвращает кампанию по ID
+func (r *CampaignRepository) GetCampaignByID(ctx context.Context, id string) (*models.Campaign, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	campaign, ok := r.store.state.campaigns[id]
+	if !ok {
+		return nil, errors.NewNotFound("campaign not found", nil)
+	}
+	copied := *campaign
+	return &copied, nil
+}
+
+// GetCampaigns возвращает кампании по фильтру, начиная с ближайших по дате начала
+func (r *CampaignRepository) GetCampaigns(ctx context.Context, filter *models.CampaignFilter) ([]models.Campaign, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var matched []models.Campaign
+	for _, campaign := range r.store.state.campaigns {
+		if filter.Status == "" || campaign.Status == filter.Status {
+			matched = append(matched, *campaign)
+		}
+	}
+	slices.SortFunc(matched, func(a, b models.Campaign) int {
+		if c := b.StartsAt.Compare(a.StartsAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.CampaignID, b.CampaignID)
+	})
+
+	campaigns := []models.Campaign{}
+	if filter.Offset < len(matched) {
+		campaigns = append(campaigns, matched[filter.Offset:min(filter.Offset+max(filter.Limit, 0), len(matched))]...)
+	}
+	return campaigns, nil
+}
+
+// UpdateCampaign обновляет кампанию. Бюджет нельзя уменьшить ниже уже выплаченной суммы.
+func (r *CampaignRepository) UpdateCampaign(ctx context.Context, campaign *models.Campaign) (*models.Campaign, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	stored, ok := r.store.state.campaigns[campaign.CampaignID]
+	if !ok {
+		return nil, errors.NewNotFound("campaign not found", nil)
+	}
+	if campaign.Budget < stored.Spent {
+		return nil, errors.NewValidation("budget cannot be less than points already spent", nil)
+	}
+	updated := *stored
+	updated.Name = campaign.Name
+	updated.Description = campaign.Description
+	updated.StartsAt = campaign.StartsAt
+	updated.EndsAt = campaign.EndsAt
+	updated.Budget = campaign.Budget
+	updated.Status = campaign.Status
+	updated.UpdatedAt = now()
+	storeCampaign(&updated)
+	*stored = updated
+	return &updated, nil
+}
+
+// GetCampaignStats возвращает сводные показатели кампании
+func (r *CampaignRepository) GetCampaignStats(ctx context.Context, id string) (*models.CampaignStats, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	s := r.store.state
+	campaign, ok := s.campaigns[id]
+	if !ok {
+		return nil, errors.NewNotFound("campaign not found", nil)
+	}
+	stats := &models.CampaignStats{
+		CampaignID:  campaign.CampaignID,
+		PointsSpent: campaign.Spent,
+		Budget:      campaign.Budget,
+		Remaining:   campaign.Remaining(),
+		Exhausted:   campaign.Remaining() == 0,
+	}
+
+	participants := make(map[string]bool)
+	for taskID, task := range s.tasks {
+		if task.DeletedAt != nil || task.CampaignID == nil || *task.CampaignID != id {
+			continue
+		}
+		stats.Tasks++
+		for userID, assignment := range s.assignments[taskID] {
+			participants[userID] = true
+			if assignment.Progress == models.Completed {
+				stats.Completions++
+			}
+		}
+		if task.CompletedBy != nil {
+			participants[*task.CompletedBy] = true
+			if task.Status == models.Completed {
+				stats.Completions++
+			}
+		}
+		for _, submission := range s.submissions {
+			if submission.TaskID == taskID {
+				participants[submission.UserID] = true
+			}
+		}
+	}
+	stats.Participants = len(participants)
+	return stats, nil
+}
+
+// SyncCampaignStatuses приводит статусы кампаний в соответствие с расписанием на момент now
+func (r *CampaignRepository) SyncCampaignStatuses(ctx context.Context, now time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var changed int64
+	for _, campaign := range r.store.state.campaigns {
+		if status := models.CampaignStatusAt(campaign.StartsAt, campaign.EndsAt, now); campaign.Status != status {
+			campaign.Status = status
+			campaign.UpdatedAt = now.UTC().Truncate(time.Microsecond)
+			changed++
+		}
+	}
+	return changed, nil
+}
+
+// checkCampaignRef проверяет ссылку задачи на кампанию, как внешний ключ tasks.campaign_id
+func (s *state) checkCampaignRef(task *models.Task) error {
+	if task.CampaignID == nil {
+		return nil
+	}
+	if _, ok := s.campaigns[*task.CampaignID]; !ok {
+		return errors.NewNotFound("campaign not found", violation("tasks_campaign_id_fkey"))
+	}
+	return nil
+}
+
+// spendCampaignBudget выплачивает вознаграждение за задачу кампании из ее бюджета и возвращает
+// выплаченную сумму: вознаграждение выплачивается только во время кампании и не превышает
+// остаток бюджета. Вознаграждение за задачу вне кампании выплачивается полностью.
+func (s *state) spendCampaignBudget(task *models.Task, reward float64) (float64, error) {
+	if task.CampaignID == nil {
+		return reward, nil
+	}
+	campaign := s.campaigns[*task.CampaignID]
+	if campaign == nil {
+		return reward, nil
+	}
+	if models.CampaignStatusAt(campaign.StartsAt, campaign.EndsAt, time.Now()) != models.CampaignActive {
+		return 0, errors.NewConflict("task campaign is not active", nil)
+	}
+	paid := roundMoney(math.Min(reward, math.Max(campaign.Budget-campaign.Spent, 0)))
+	campaign.Spent = roundMoney(campaign.Spent + paid)
+	campaign.UpdatedAt = now()
+	return paid, nil
+}
+
+// refundCampaignBudget возвращает списанное за задачу вознаграждение в бюджет ее кампании
+func (s *state) refundCampaignBudget(taskID string, amount float64) {
+	task, ok := s.tasks[taskID]
+	if !ok || task.CampaignID == nil {
+		return
+	}
+	if campaign := s.campaigns[*task.CampaignID]; campaign != nil {
+		campaign.Spent = roundMoney(math.Max(campaign.Spent-amount, 0))
+		campaign.UpdatedAt = now()
+	}
+}
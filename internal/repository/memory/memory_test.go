@@ -0,0 +1,18 @@
+package memory
+
+import (
+	"github.com/ZnNr/user-reward-controller/internal/repository/repotest"
+	"testing"
+)
+
+// TestRepositoryContract проверяет фейки по общему контракту репозиториев
+func TestRepositoryContract(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		store := NewStore()
+		return repotest.Repositories{
+			Users:     NewUserRepository(store),
+			Tasks:     NewTaskRepository(store),
+			Referrals: NewReferralRepository(store),
+		}
+	})
+}
@@ -0,0 +1,186 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"slices"
+)
+
+// QuestRepository - репозиторий квестов в памяти. Бонусы за квесты начисляет репозиторий задач
+// того же хранилища при выполнении задач.
+type QuestRepository struct {
+	store *Store
+}
+
+// NewQuestRepository создает репозиторий квестов над хранилищем store
+func NewQuestRepository(store *Store) repository.QuestRepository {
+	return &QuestRepository{store: store}
+}
+
+// CreateQuest создает квест вместе со списком его задач
+func (r *QuestRepository) CreateQuest(ctx context.Context, quest *models.Quest) (*models.Quest, error) {
+	var created models.Quest
+	err := r.store.update(func(s *state) error {
+		if _, ok := s.quests[quest.QuestID]; ok {
+			return errors.NewAlreadyExists("quest already exists", violation("quests_pkey"))
+		}
+		stored := *quest
+		stored.Bonus = roundMoney(quest.Bonus)
+		stored.Tasks = nil
+		stored.CreatedAt = now()
+		stored.UpdatedAt = stored.CreatedAt
+		s.quests[stored.QuestID] = &stored
+
+		for _, task := range quest.Tasks {
+			if _, ok := s.tasks[task.TaskID]; !ok {
+				return errors.NewNotFound("task not found", violation("quest_tasks_task_id_fkey"))
+			}
+			if slices.ContainsFunc(s.questTasks, func(qt questTask) bool {
+				return qt.questID == quest.QuestID && qt.taskID == task.TaskID
+			}) {
+				return errors.NewValidation("quest task ids must be unique", violation("quest_tasks_pkey"))
+			}
+			s.questTasks = append(s.questTasks, questTask{questID: quest.QuestID, taskID: task.TaskID, position: task.Position})
+		}
+		created = s.loadQuest(&stored)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetQuestByID возвращает квест с задачами по порядку
+func (r *QuestRepository) GetQuestByID(ctx context.Context, id string) (*models.Quest, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	quest, ok := r.store.state.quests[id]
+	if !ok {
+		return nil, errors.NewNotFound("quest not found", nil)
+	}
+	loaded := r.store.state.loadQuest(quest)
+	return &loaded, nil
+}
+
+// GetQuests возвращает страницу квестов, начиная с новых
+func (r *QuestRepository) GetQuests(ctx context.Context, limit, offset int) ([]models.Quest, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	s := r.store.state
+	sorted := sortedValues(s.quests, func(a, b *models.Quest) bool {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c < 0
+		}
+		return a.QuestID < b.QuestID
+	})
+	quests := []models.Quest{}
+	if offset < len(sorted) {
+		for _, quest := range sorted[offset:min(offset+max(limit, 0), len(sorted))] {
+			quests = append(quests, s.loadQuest(quest))
+		}
+	}
+	return quests, nil
+}
+
+// GetQuestCompletion возвращает выплату бонуса за квест пользователю или nil, если квест еще не выполнен
+func (r *QuestRepository) GetQuestCompletion(ctx context.Context, questID, userID string) (*models.QuestCompletion, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	completion, ok := r.store.state.completions[questCompletionKey{questID: questID, userID: userID}]
+	if !ok {
+		return nil, nil
+	}
+	copied := *completion
+	return &copied, nil
+}
+
+// loadQuest возвращает копию квеста с неудаленными задачами по порядку
+func (s *state) loadQuest(quest *models.Quest) models.Quest {
+	loaded := *quest
+	loaded.Tasks = []models.QuestTask{}
+	for _, qt := range s.questTasks {
+		if task := s.activeTask(qt.taskID); qt.questID == quest.QuestID && task != nil {
+			loaded.Tasks = append(loaded.Tasks, models.QuestTask{TaskID: qt.taskID, Title: task.Title, Position: qt.position})
+		}
+	}
+	slices.SortFunc(loaded.Tasks, func(a, b models.QuestTask) int { return cmp.Compare(a.Position, b.Position) })
+	return loaded
+}
+
+// taskDoneBy проверяет, выполнил ли пользователь задачу лично или как единственный исполнитель
+func (s *state) taskDoneBy(task *models.Task, userID string) bool {
+	if task.CompletedBy != nil && *task.CompletedBy == userID {
+		return true
+	}
+	assignment := s.assignment(task.TaskID, userID)
+	return assignment != nil && assignment.Progress == models.Completed
+}
+
+// syncQuestBonuses начисляет бонусы за завершенные квесты при выполнении задачи
+// и возвращает их при повторном открытии выполненной задачи. Возвращает изменение баланса.
+func (s *state) syncQuestBonuses(taskID, userID string, current, next models.TaskStatus) float64 {
+	switch {
+	case next == models.Completed:
+		return s.awardQuestBonuses(taskID, userID)
+	case current == models.Completed:
+		return s.revokeQuestBonuses(taskID, userID)
+	}
+	return 0
+}
+
+// awardQuestBonuses начисляет пользователю бонусы за квесты с задачей taskID, все неудаленные задачи
+// которых он выполнил; бонус за квест выплачивается один раз. Возвращает изменение баланса.
+func (s *state) awardQuestBonuses(taskID, userID string) float64 {
+	var bonus float64
+	for _, qt := range s.questTasks {
+		key := questCompletionKey{questID: qt.questID, userID: userID}
+		if qt.taskID != taskID || s.completions[key] != nil {
+			continue
+		}
+		done := !slices.ContainsFunc(s.questTasks, func(other questTask) bool {
+			task := s.activeTask(other.taskID)
+			return other.questID == qt.questID && task != nil && !s.taskDoneBy(task, userID)
+		})
+		if done {
+			quest := s.quests[qt.questID]
+			s.completions[key] = &models.QuestCompletion{QuestID: quest.QuestID, UserID: userID, Bonus: quest.Bonus, CompletedAt: now()}
+			bonus += quest.Bonus
+		}
+	}
+	return s.applyQuestBonus(userID, bonus)
+}
+
+// revokeQuestBonuses возвращает бонусы за квесты с задачей taskID, если задача перестала быть выполненной.
+// Возвращает изменение баланса.
+func (s *state) revokeQuestBonuses(taskID, userID string) float64 {
+	var bonus float64
+	for _, qt := range s.questTasks {
+		key := questCompletionKey{questID: qt.questID, userID: userID}
+		if completion := s.completions[key]; qt.taskID == taskID && completion != nil {
+			bonus += completion.Bonus
+			delete(s.completions, key)
+		}
+	}
+	return s.applyQuestBonus(userID, -bonus)
+}
+
+// applyQuestBonus изменяет баланс пользователя на сумму бонусов, не опуская его ниже нуля,
+// и возвращает фактическое изменение баланса
+func (s *state) applyQuestBonus(userID string, bonus float64) float64 {
+	record, ok := s.users[userID]
+	if bonus == 0 || !ok {
+		return 0
+	}
+	previous := record.user.Balance
+	record.user.Balance = roundMoney(max(previous+bonus, 0))
+	record.user.UpdatedAt = now()
+	record.hasBalance = true
+	return roundMoney(record.user.Balance - previous)
+}
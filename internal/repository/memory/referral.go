@@ -0,0 +1,155 @@
+package memory
+
+import (
+	"cmp"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"slices"
+	"strconv"
+)
+
+// ReferralRepository - репозиторий реферальных кодов в памяти
+type ReferralRepository struct {
+	store *Store
+}
+
+// NewReferralRepository создает репозиторий реферальных кодов над хранилищем store
+func NewReferralRepository(store *Store) repository.ReferralRepository {
+	return &ReferralRepository{store: store}
+}
+
+// referralByID возвращает реферальный код по строковому идентификатору или nil
+func (s *state) referralByID(referralID string) *models.Referral {
+	id, err := strconv.Atoi(referralID)
+	if err != nil {
+		return nil
+	}
+	return s.referrals[id]
+}
+
+// codeTaken проверяет, занят ли код другим реферальным кодом
+func (s *state) codeTaken(code, excludeID string) bool {
+	for _, referral := range s.referrals {
+		if referral.Code == code && referral.ReferralID != excludeID {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateReferral создает новый реферальный код для пользователя
+func (r *ReferralRepository) CreateReferral(userID string, code string) (*models.Referral, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	s := r.store.state
+	if s.codeTaken(code, "") {
+		return nil, errors.NewAlreadyExists("referral code already exists", nil)
+	}
+	s.lastReferralID++
+	createdAt := now()
+	referral := &models.Referral{
+		ReferralID: strconv.Itoa(s.lastReferralID),
+		UserID:     userID,
+		Code:       code,
+		CreatedAt:  createdAt,
+		UpdatedAt:  createdAt,
+	}
+	s.referrals[s.lastReferralID] = referral
+	copied := *referral
+	return &copied, nil
+}
+
+// GetReferral возвращает реферал по его ID
+func (r *ReferralRepository) GetReferral(referralID string) (*models.Referral, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	referral := r.store.state.referralByID(referralID)
+	if referral == nil {
+		return nil, errors.NewNotFound("referral not found", nil)
+	}
+	copied := *referral
+	return &copied, nil
+}
+
+// compareReferrals сравнивает реферальные коды в порядке выборки: от новых к старым
+func compareReferrals(a, b *models.Referral) int {
+	if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+		return c
+	}
+	ai, _ := strconv.Atoi(a.ReferralID)
+	bi, _ := strconv.Atoi(b.ReferralID)
+	return cmp.Compare(bi, ai)
+}
+
+// GetReferralsByUserID возвращает страницу рефералов для указанного пользователя, начиная с новых
+func (r *ReferralRepository) GetReferralsByUserID(userID string, page pagination.Page) ([]models.Referral, pagination.Info, error) {
+	var cursor models.Referral
+	if page.Cursor != nil {
+		createdAt, err := page.Cursor.Time(0)
+		if err != nil {
+			return nil, pagination.Info{}, err
+		}
+		id, err := page.Cursor.Int(1)
+		if err != nil {
+			return nil, pagination.Info{}, err
+		}
+		cursor = models.Referral{ReferralID: strconv.Itoa(id), CreatedAt: createdAt}
+	}
+
+	r.store.mu.Lock()
+	var matched []*models.Referral
+	for _, referral := range r.store.state.referrals {
+		if referral.UserID == userID {
+			copied := *referral
+			matched = append(matched, &copied)
+		}
+	}
+	r.store.mu.Unlock()
+	slices.SortFunc(matched, compareReferrals)
+
+	referrals := make([]models.Referral, 0)
+	for _, referral := range keysetPage(matched, page, func(referral *models.Referral) int { return compareReferrals(referral, &cursor) }) {
+		referrals = append(referrals, *referral)
+	}
+	referrals, info := pagination.Paginate(referrals, page, func(referral models.Referral) []string {
+		return []string{pagination.FormatTime(referral.CreatedAt), referral.ReferralID}
+	})
+	return referrals, info, nil
+}
+
+// UpdateReferral обновляет указанный реферальный код
+func (r *ReferralRepository) UpdateReferral(referralID string, code string) (*models.Referral, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	s := r.store.state
+	referral := s.referralByID(referralID)
+	if referral == nil {
+		return nil, errors.NewNotFound("referral not found", nil)
+	}
+	if s.codeTaken(code, referral.ReferralID) {
+		return nil, errors.NewAlreadyExists("referral code already exists", nil)
+	}
+	referral.Code = code
+	referral.UpdatedAt = now()
+	copied := *referral
+	return &copied, nil
+}
+
+// DeleteReferral удаляет реферальный код по его ID
+func (r *ReferralRepository) DeleteReferral(referralID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	referral := r.store.state.referralByID(referralID)
+	if referral == nil {
+		return errors.NewNotFound("referral not found", nil)
+	}
+	id, _ := strconv.Atoi(referral.ReferralID)
+	delete(r.store.state.referrals, id)
+	return nil
+}
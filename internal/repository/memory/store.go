@@ -0,0 +1,237 @@
+// Package memory содержит реализации репозиториев в памяти для тестов сервисного слоя.
+// Поведение фейков совпадает с реализациями PostgreSQL: те же ошибки, порядок выборок и побочные
+// эффекты (начисление вознаграждений, бюджеты кампаний, бонусы за квесты, история переходов).
+// Совпадение проверяет общий контракт из пакета repotest, который выполняется и для фейков, и для PostgreSQL.
+package memory
+
+import (
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
+	"slices"
+	"sort"
+	"sync"
+
+	"time"
+)
+
+// Store - общее состояние фейковых репозиториев. Репозитории задач начисляют вознаграждения
+// пользователям, поэтому фейки одного теста должны создаваться над одним хранилищем.
+type Store struct {
+	mu    sync.Mutex
+	state *state
+}
+
+// NewStore создает пустое хранилище
+func NewStore() *Store {
+	return &Store{state: newState()}
+}
+
+// userRecord - пользователь и признак заданного баланса: пользователь, созданный без баланса,
+// в таблице лидеров располагается ниже пользователей с нулевым балансом, как в PostgreSQL
+type userRecord struct {
+	user       models.User
+	hasBalance bool
+}
+
+// dependency - ребро графа зависимостей задач
+type dependency struct {
+	taskID      string
+	dependsOnID string
+	createdAt   time.Time
+}
+
+// questTask - задача квеста с ее позицией
+type questTask struct {
+	questID  string
+	taskID   string
+	position int
+}
+
+// questCompletionKey идентифицирует выплату бонуса за квест пользователю
+type questCompletionKey struct {
+	questID string
+	userID  string
+}
+
+// reminderKey идентифицирует отправленное напоминание исполнителю задачи
+type reminderKey struct {
+	taskID string
+	userID string
+}
+
+type state struct {
+	users        map[string]*userRecord
+	tasks        map[string]*models.Task
+	assignments  map[string]map[string]*models.TaskAssignment // Задача -> пользователь -> прогресс
+	history      []models.TaskStatusChange
+	submissions  []*models.Submission
+	dependencies []dependency
+	reminders    map[reminderKey]bool
+	referrals    map[int]*models.Referral
+	campaigns    map[string]*models.Campaign
+	quests       map[string]*models.Quest // Задачи квестов хранятся в questTasks
+	questTasks   []questTask
+	completions  map[questCompletionKey]*models.QuestCompletion
+
+	lastHistoryID    int64
+	lastSubmissionID int64
+	lastReferralID   int
+}
+
+func newState() *state {
+	return &state{
+		users:       make(map[string]*userRecord),
+		tasks:       make(map[string]*models.Task),
+		assignments: make(map[string]map[string]*models.TaskAssignment),
+		reminders:   make(map[reminderKey]bool),
+		referrals:   make(map[int]*models.Referral),
+		campaigns:   make(map[string]*models.Campaign),
+		quests:      make(map[string]*models.Quest),
+		completions: make(map[questCompletionKey]*models.QuestCompletion),
+	}
+}
+
+// clone возвращает копию состояния. Записи копируются по значению: фейки не изменяют значения
+// по указателям внутри записей, а заменяют их, поэтому указатели можно разделять.
+func (s *state) clone() *state {
+	c := newState()
+	for id, record := range s.users {
+		copied := *record
+		c.users[id] = &copied
+	}
+	for id, task := range s.tasks {
+		copied := *task
+		c.tasks[id] = &copied
+	}
+	for taskID, users := range s.assignments {
+		c.assignments[taskID] = make(map[string]*models.TaskAssignment, len(users))
+		for userID, assignment := range users {
+			copied := *assignment
+			c.assignments[taskID][userID] = &copied
+		}
+	}
+	c.history = slices.Clone(s.history)
+	for _, submission := range s.submissions {
+		copied := *submission
+		c.submissions = append(c.submissions, &copied)
+	}
+	c.dependencies = slices.Clone(s.dependencies)
+	for key := range s.reminders {
+		c.reminders[key] = true
+	}
+	for id, referral := range s.referrals {
+		copied := *referral
+		c.referrals[id] = &copied
+	}
+	for id, campaign := range s.campaigns {
+		copied := *campaign
+		c.campaigns[id] = &copied
+	}
+	for id, quest := range s.quests {
+		copied := *quest
+		c.quests[id] = &copied
+	}
+	c.questTasks = slices.Clone(s.questTasks)
+	for key, completion := range s.completions {
+		copied := *completion
+		c.completions[key] = &copied
+	}
+	c.lastHistoryID, c.lastSubmissionID, c.lastReferralID = s.lastHistoryID, s.lastSubmissionID, s.lastReferralID
+	return c
+}
+
+// withTransaction выполняет f и откатывает изменения хранилища, если f вернула ошибку.
+// Транзакции не изолированы: при откате теряются и изменения, сделанные параллельно с f.
+func (s *Store) withTransaction(f func() error) error {
+	s.mu.Lock()
+	snapshot := s.state.clone()
+	s.mu.Unlock()
+
+	if err := f(); err != nil {
+		s.mu.Lock()
+		s.state = snapshot
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// update выполняет f над копией состояния и сохраняет ее, только если f завершилась без ошибки.
+// Так изменения нескольких записей применяются атомарно, как в транзакции PostgreSQL.
+func (s *Store) update(f func(s *state) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.state.clone()
+	if err := f(c); err != nil {
+		return err
+	}
+	s.state = c
+	return nil
+}
+
+// activeUser возвращает неудаленного пользователя или nil
+func (s *state) activeUser(id string) *userRecord {
+	record, ok := s.users[id]
+	if !ok || record.user.DeletedAt != nil {
+		return nil
+	}
+	return record
+}
+
+// activeTask возвращает неудаленную задачу или nil
+func (s *state) activeTask(id string) *models.Task {
+	task, ok := s.tasks[id]
+	if !ok || task.DeletedAt != nil {
+		return nil
+	}
+	return task
+}
+
+// now возвращает текущее время с точностью PostgreSQL
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// storedTime возвращает копию времени с точностью PostgreSQL или nil
+func storedTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	stored := t.Truncate(time.Microsecond)
+	return &stored
+}
+
+// sortedValues возвращает значения отображения, упорядоченные less
+func sortedValues[K comparable, V any](m map[K]V, less func(a, b V) bool) []V {
+	values := make([]V, 0, len(m))
+	for _, value := range m {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool { return less(values[i], values[j]) })
+	return values
+}
+
+// keysetPage выбирает из упорядоченных записей sorted записи после курсора (или перед ним в обратном
+// порядке, если курсор указывает назад) с лимитом page.QueryLimit - так же, как запрос к PostgreSQL.
+// compareToCursor сравнивает запись с записью курсора в порядке сортировки.
+func keysetPage[T any](sorted []T, page pagination.Page, compareToCursor func(T) int) []T {
+	var selected []T
+	if page.Backward() {
+		for i := len(sorted) - 1; i >= 0; i-- {
+			if compareToCursor(sorted[i]) < 0 {
+				selected = append(selected, sorted[i])
+			}
+		}
+	} else {
+		for _, item := range sorted {
+			if page.Cursor == nil || compareToCursor(item) > 0 {
+				selected = append(selected, item)
+			}
+		}
+	}
+	if page.Limit > 0 && len(selected) > page.Limit+1 {
+		selected = selected[:page.Limit+1]
+	}
+	return selected
+}
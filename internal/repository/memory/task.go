@@ -0,0 +1,1096 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TaskRepository - репозиторий задач в памяти. Вознаграждения начисляются пользователям того же хранилища.
+type TaskRepository struct {
+	store *Store
+}
+
+// NewTaskRepository создает репозиторий задач над хранилищем store
+func NewTaskRepository(store *Store) repository.TaskRepository {
+	return &TaskRepository{store: store}
+}
+
+// copyTask возвращает копию задачи, которую вызывающий код может изменять
+func copyTask(task *models.Task) *models.Task {
+	copied := *task
+	return &copied
+}
+
+// checkTask проверяет ограничения таблицы задач
+func checkTask(task *models.Task) error {
+	switch {
+	case !task.Status.IsValid():
+		return violation("tasks_status_fkey")
+	case task.Reward < 0:
+		return violation("tasks_reward_check")
+	case !task.AssignmentMode.IsValid():
+		return violation("tasks_assignment_mode_check")
+	case task.MaxClaims != nil && *task.MaxClaims <= 0:
+		return violation("tasks_max_claims_check")
+	case task.AssignmentMode == models.AssignmentCapped && task.MaxClaims == nil:
+		return violation("tasks_capped_max_claims")
+	}
+	return nil
+}
+
+// hasDuplicateTask проверяет, есть ли другая неудаленная задача с тем же заголовком и описанием
+func (s *state) hasDuplicateTask(task *models.Task, excludeID string) bool {
+	for id, other := range s.tasks {
+		if id != excludeID && other.DeletedAt == nil && other.Title == task.Title && other.Description == task.Description {
+			return true
+		}
+	}
+	return false
+}
+
+// checkActiveUser проверяет, что пользователь существует и не удален
+func (s *state) checkActiveUser(userID string) error {
+	if s.activeUser(userID) == nil {
+		return errors.NewNotFound(fmt.Sprintf("user %s not found", userID), nil)
+	}
+	return nil
+}
+
+// CreateTask сохраняет новую задачу и явно назначает на нее пользователей
+func (r *TaskRepository) CreateTask(ctx context.Context, task *models.Task, assignees []string) (*models.Task, error) {
+	var created models.Task
+	err := r.store.update(func(s *state) error {
+		if s.hasDuplicateTask(task, "") {
+			return errors.NewAlreadyExists("a task with the same title and description already exists", nil)
+		}
+
+		created = *task
+		created.Reward = roundMoney(task.Reward)
+		created.DueDate = storedTime(task.DueDate)
+		created.CreatedAt = now()
+		created.UpdatedAt = created.CreatedAt
+		created.CompletedBy = nil
+		created.DeletedAt = nil
+		if _, ok := s.tasks[task.TaskID]; ok {
+			return errors.NewInternal("failed to insert task", violation("tasks_pkey"))
+		}
+		if err := checkTask(&created); err != nil {
+			return errors.NewInternal("failed to insert task", err)
+		}
+		if err := s.checkCampaignRef(&created); err != nil {
+			return err
+		}
+		s.tasks[created.TaskID] = copyTask(&created)
+
+		for _, userID := range assignees {
+			if err := s.checkActiveUser(userID); err != nil {
+				return err
+			}
+			if s.assignment(created.TaskID, userID) == nil {
+				s.setAssignment(&models.TaskAssignment{
+					TaskID:       created.TaskID,
+					UserID:       userID,
+					Progress:     models.NotStarted,
+					ClaimedAt:    created.CreatedAt,
+					ProgressedAt: created.CreatedAt,
+				})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	*task = created
+	return task, nil
+}
+
+// assignment возвращает участие пользователя в задаче или nil
+func (s *state) assignment(taskID, userID string) *models.TaskAssignment {
+	return s.assignments[taskID][userID]
+}
+
+// setAssignment сохраняет участие пользователя в задаче
+func (s *state) setAssignment(assignment *models.TaskAssignment) {
+	users, ok := s.assignments[assignment.TaskID]
+	if !ok {
+		users = make(map[string]*models.TaskAssignment)
+		s.assignments[assignment.TaskID] = users
+	}
+	users[assignment.UserID] = assignment
+}
+
+// matchTask проверяет, что задача соответствует фильтру
+func matchTask(task *models.Task, filter *models.TaskFilter) bool {
+	statuses := filter.Statuses
+	if filter.Status != 0 {
+		statuses = append(slices.Clone(statuses), filter.Status)
+	}
+	switch {
+	case filter.Title != "" && !containsFold(task.Title, filter.Title),
+		filter.Description != "" && !containsFold(task.Description, filter.Description):
+		return false
+	case filter.CreatedAfter != nil && task.CreatedAt.Before(*filter.CreatedAfter),
+		filter.CreatedBefore != nil && task.CreatedAt.After(*filter.CreatedBefore):
+		return false
+	// Задачи без срока не попадают в выборку с ограничением срока
+	case filter.DueAfter != nil && (task.DueDate == nil || task.DueDate.Before(*filter.DueAfter)),
+		filter.DueBefore != nil && (task.DueDate == nil || task.DueDate.After(*filter.DueBefore)):
+		return false
+	case len(statuses) > 0 && !slices.Contains(statuses, task.Status):
+		return false
+	case filter.AssigneeID != "" && (task.AssigneeID == nil || *task.AssigneeID != filter.AssigneeID),
+		filter.CampaignID != "" && (task.CampaignID == nil || *task.CampaignID != filter.CampaignID):
+		return false
+	case !filter.IncludeDeleted && task.DeletedAt != nil:
+		return false
+	}
+	return true
+}
+
+// containsFold проверяет вхождение подстроки без учета регистра, как ILIKE
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// compareTasks сравнивает задачи в порядке списка: от новых к старым, затем по task_id по убыванию
+func compareTasks(a, b *models.Task) int {
+	if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+		return c
+	}
+	return strings.Compare(b.TaskID, a.TaskID)
+}
+
+func (r *TaskRepository) GetTasks(ctx context.Context, filter *models.TaskFilter) (*models.TaskResponse, error) {
+	if filter.PageSize <= 0 {
+		filter.PageSize = 10
+	}
+	if filter.Page <= 0 {
+		filter.Page = 1
+	}
+
+	r.store.mu.Lock()
+	var matched []*models.Task
+	for _, task := range r.store.state.tasks {
+		if matchTask(task, filter) {
+			matched = append(matched, copyTask(task))
+		}
+	}
+	r.store.mu.Unlock()
+	slices.SortFunc(matched, compareTasks)
+
+	if filter.Pagination != nil {
+		return getTasksByCursor(matched, *filter.Pagination)
+	}
+
+	totalItems := len(matched)
+	totalPages := (totalItems + filter.PageSize - 1) / filter.PageSize
+	if filter.Page > totalPages {
+		return nil, errors.NewNotFound(fmt.Sprintf("page %d does not exist, total pages: %d", filter.Page, totalPages), nil)
+	}
+
+	offset := (filter.Page - 1) * filter.PageSize
+	tasks := []models.Task{}
+	for _, task := range matched[offset:min(offset+filter.PageSize, totalItems)] {
+		tasks = append(tasks, *task)
+	}
+	return &models.TaskResponse{
+		TotalItems: totalItems,
+		TotalPages: totalPages,
+		Page:       filter.Page,
+		PageSize:   filter.PageSize,
+		Tasks:      tasks,
+	}, nil
+}
+
+// getTasksByCursor возвращает страницу упорядоченных задач, соседнюю с курсором
+func getTasksByCursor(sorted []*models.Task, page pagination.Page) (*models.TaskResponse, error) {
+	var cursor models.Task
+	if page.Cursor != nil {
+		var err error
+		if cursor.CreatedAt, err = page.Cursor.Time(0); err != nil {
+			return nil, err
+		}
+		if cursor.TaskID, err = page.Cursor.String(1); err != nil {
+			return nil, err
+		}
+	}
+
+	tasks := []models.Task{}
+	for _, task := range keysetPage(sorted, page, func(task *models.Task) int { return compareTasks(task, &cursor) }) {
+		tasks = append(tasks, *task)
+	}
+	tasks, info := pagination.Paginate(tasks, page, func(task models.Task) []string {
+		return []string{pagination.FormatTime(task.CreatedAt), task.TaskID}
+	})
+	return &models.TaskResponse{
+		Tasks:    tasks,
+		PageSize: page.Limit,
+		Info:     info,
+	}, nil
+}
+
+// GetTaskByID возвращает неудаленную задачу по ID
+func (r *TaskRepository) GetTaskByID(ctx context.Context, id uuid.UUID) (*models.Task, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	task := r.store.state.activeTask(id.String())
+	if task == nil {
+		return nil, errors.NewNotFound("task not found", nil)
+	}
+	return copyTask(task), nil
+}
+
+// UpdateTask обновляет изменяемые поля задачи и возвращает ее сохраненное состояние
+func (r *TaskRepository) UpdateTask(ctx context.Context, task *models.Task) (*models.Task, error) {
+	var updated models.Task
+	err := r.store.update(func(s *state) error {
+		stored := s.activeTask(task.TaskID)
+		if stored == nil {
+			return errors.NewNotFound("task not found", nil)
+		}
+		if s.hasDuplicateTask(task, task.TaskID) {
+			return errors.NewAlreadyExists("a task with the same title and description already exists", nil)
+		}
+
+		updated = *stored
+		updated.Title = task.Title
+		updated.Description = task.Description
+		updated.DueDate = storedTime(task.DueDate)
+		updated.AssigneeID = task.AssigneeID
+		updated.Reward = roundMoney(task.Reward)
+		updated.AssignmentMode = task.AssignmentMode
+		updated.MaxClaims = task.MaxClaims
+		updated.RequiresEvidence = task.RequiresEvidence
+		updated.CampaignID = task.CampaignID
+		updated.UpdatedAt = now()
+		if err := checkTask(&updated); err != nil {
+			return errors.NewInternal("failed to update task", err)
+		}
+		if err := s.checkCampaignRef(&updated); err != nil {
+			return err
+		}
+		*stored = updated
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	*task = updated
+	return task, nil
+}
+
+// DeleteTask помечает задачу удаленной
+func (r *TaskRepository) DeleteTask(ctx context.Context, taskId uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	task := r.store.state.activeTask(taskId.String())
+	if task == nil {
+		return errors.NewNotFound("task not found", nil)
+	}
+	deletedAt := now()
+	task.DeletedAt = &deletedAt
+	task.UpdatedAt = deletedAt
+	return nil
+}
+
+// RestoreTask восстанавливает мягко удаленную задачу
+func (r *TaskRepository) RestoreTask(ctx context.Context, taskId uuid.UUID) (*models.Task, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	task, ok := r.store.state.tasks[taskId.String()]
+	if !ok || task.DeletedAt == nil {
+		return nil, errors.NewNotFound("deleted task not found", nil)
+	}
+	task.DeletedAt = nil
+	task.UpdatedAt = now()
+	return copyTask(task), nil
+}
+
+// PurgeDeletedTasks окончательно удаляет задачи, мягко удаленные раньше before, вместе со связанными записями
+func (r *TaskRepository) PurgeDeletedTasks(ctx context.Context, before time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	s := r.store.state
+	purged := make(map[string]bool)
+	for id, task := range s.tasks {
+		if task.DeletedAt != nil && task.DeletedAt.Before(before) {
+			purged[id] = true
+			delete(s.tasks, id)
+			delete(s.assignments, id)
+		}
+	}
+	s.history = slices.DeleteFunc(s.history, func(change models.TaskStatusChange) bool { return purged[change.TaskID] })
+	s.submissions = slices.DeleteFunc(s.submissions, func(submission *models.Submission) bool { return purged[submission.TaskID] })
+	s.dependencies = slices.DeleteFunc(s.dependencies, func(d dependency) bool { return purged[d.taskID] || purged[d.dependsOnID] })
+	for key := range s.reminders {
+		if purged[key.taskID] {
+			delete(s.reminders, key)
+		}
+	}
+	return int64(len(purged)), nil
+}
+
+// UpdateTaskStatus переводит задачу (или личный прогресс пользователя) в новый статус по тем же правилам,
+// что и реализация PostgreSQL, с начислением или возвратом вознаграждения и записью в историю
+func (r *TaskRepository) UpdateTaskStatus(ctx context.Context, taskID string, newStatus int, userID uuid.UUID, actor string, dueDeadline time.Time) (*models.Task, *models.BalanceUpdate, error) {
+	var updated *models.Task
+	var change *models.BalanceUpdate
+	err := r.store.update(func(s *state) error {
+		var err error
+		if change, err = s.transitionTask(taskID, models.TaskStatus(newStatus), userID.String(), actor, false, dueDeadline); err != nil {
+			return err
+		}
+		updated = copyTask(s.tasks[taskID])
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return updated, change, nil
+}
+
+// transitionTask выполняет переход статуса задачи или личного прогресса пользователя.
+// approved означает, что выполнение подтверждено одобренной заявкой, и срок dueDeadline не проверяется.
+// Возвращает изменение баланса или nil.
+func (s *state) transitionTask(taskID string, next models.TaskStatus, userID, actor string, approved bool, dueDeadline time.Time) (*models.BalanceUpdate, error) {
+	task := s.activeTask(taskID)
+	if task == nil {
+		return nil, errors.NewNotFound("task not found", nil)
+	}
+	if !approved && (next == models.InProgress || next == models.Completed) {
+		if err := checkNotPastDue(task, dueDeadline); err != nil {
+			return nil, err
+		}
+	}
+
+	canComplete := approved || !task.RequiresEvidence
+	handled, delta, err := s.transitionAssignment(task, userID, next, actor, canComplete)
+	if err != nil {
+		return nil, err
+	} else if handled {
+		return s.balanceUpdate(userID, delta), nil
+	}
+
+	current := task.Status
+	if next == current {
+		return nil, nil
+	}
+	if !current.CanTransitionTo(next) {
+		return nil, errors.NewValidation(fmt.Sprintf("illegal task status transition from %s to %s", current, next), nil).WithCode(errors.CodeInvalidStatusTransition)
+	}
+	if next == models.Completed && !canComplete {
+		return nil, errors.NewConflict("task requires an approved submission to be completed", nil)
+	}
+
+	historyUser := userID
+	completedBy := task.CompletedBy
+	var rewardDelta float64
+	switch {
+	case next == models.Completed:
+		paid, err := s.creditTaskReward(task, userID)
+		if err != nil {
+			return nil, err
+		}
+		rewardDelta = paid
+		completedBy = &historyUser
+	case current == models.Completed:
+		if completedBy != nil {
+			historyUser = *completedBy
+			rewardDelta = -s.reverseTaskReward(taskID, historyUser)
+		}
+		completedBy = nil
+	}
+
+	task.Status = next
+	task.CompletedBy = completedBy
+	task.UpdatedAt = now()
+	s.addHistory(taskID, current, next, actor, &historyUser, rewardDelta)
+	bonus := s.syncQuestBonuses(taskID, historyUser, current, next)
+	return s.balanceUpdate(historyUser, rewardDelta+bonus), nil
+}
+
+// checkNotPastDue возвращает Conflict, если срок задачи раньше dueDeadline
+func checkNotPastDue(task *models.Task, dueDeadline time.Time) error {
+	if models.PastDue(task.DueDate, dueDeadline) {
+		return errors.NewConflict("task is past its due date", nil)
+	}
+	return nil
+}
+
+// balanceUpdate возвращает изменение баланса пользователя на delta с его новым балансом
+// или nil, если баланс не изменился
+func (s *state) balanceUpdate(userID string, delta float64) *models.BalanceUpdate {
+	record, ok := s.users[userID]
+	if delta == 0 || !ok {
+		return nil
+	}
+	return &models.BalanceUpdate{UserID: userID, Balance: record.user.Balance, Delta: roundMoney(delta)}
+}
+
+// lockUserProgress возвращает личный прогресс пользователя по задаче. assigned равно false, если
+// пользователь не брал задачу и прогресс ведется по самой задаче.
+func (s *state) lockUserProgress(task *models.Task, userID string) (models.TaskStatus, bool, error) {
+	if assignment := s.assignment(task.TaskID, userID); assignment != nil {
+		return assignment.Progress, true, nil
+	}
+	if task.AssignmentMode.IsShared() {
+		return 0, false, errors.NewForbidden("task must be claimed first", nil)
+	}
+	if len(s.assignments[task.TaskID]) > 0 {
+		return 0, false, errors.NewForbidden("user is not assigned to the task", nil)
+	}
+	return 0, false, nil
+}
+
+// transitionAssignment применяет переход к личному прогрессу пользователя, если он взял задачу
+// или назначен на нее явно. Возвращает false, если переход относится к самой задаче,
+// и изменение баланса пользователя.
+func (s *state) transitionAssignment(task *models.Task, userID string, next models.TaskStatus, actor string, canComplete bool) (bool, float64, error) {
+	current, assigned, err := s.lockUserProgress(task, userID)
+	if err != nil || !assigned {
+		return false, 0, err
+	}
+
+	if next == current {
+		return true, 0, nil
+	}
+	if !current.CanTransitionTo(next) {
+		return false, 0, errors.NewValidation(fmt.Sprintf("illegal task progress transition from %s to %s", current, next), nil).WithCode(errors.CodeInvalidStatusTransition)
+	}
+	if next == models.Completed && !canComplete {
+		return false, 0, errors.NewConflict("task requires an approved submission to be completed", nil)
+	}
+
+	var rewardDelta float64
+	switch {
+	case next == models.Completed:
+		paid, err := s.creditTaskReward(task, userID)
+		if err != nil {
+			return false, 0, err
+		}
+		rewardDelta = paid
+	case current == models.Completed:
+		rewardDelta = -s.reverseTaskReward(task.TaskID, userID)
+	}
+
+	assignment := *s.assignment(task.TaskID, userID)
+	assignment.Progress = next
+	assignment.ProgressedAt = now()
+	assignment.CompletedAt = nil
+	if next == models.Completed {
+		completedAt := assignment.ProgressedAt
+		assignment.CompletedAt = &completedAt
+	}
+	s.setAssignment(&assignment)
+	s.addHistory(task.TaskID, current, next, actor, &userID, rewardDelta)
+	bonus := s.syncQuestBonuses(task.TaskID, userID, current, next)
+	return true, rewardDelta + bonus, nil
+}
+
+// creditTaskReward начисляет неудаленному пользователю вознаграждение за задачу и увеличивает счетчик
+// выполненных задач. Вознаграждение за задачу кампании ограничено остатком ее бюджета.
+// Возвращает выплаченную сумму.
+func (s *state) creditTaskReward(task *models.Task, userID string) (float64, error) {
+	reward, err := s.spendCampaignBudget(task, task.Reward)
+	if err != nil {
+		return 0, err
+	}
+	record := s.activeUser(userID)
+	if record == nil {
+		return 0, errors.NewNotFound("user not found", nil)
+	}
+	record.user.TasksCompleted++
+	record.user.Balance = roundMoney(record.user.Balance + reward)
+	record.user.UpdatedAt = now()
+	record.hasBalance = true
+	return reward, nil
+}
+
+// reverseTaskReward списывает последнее начисленное пользователю за задачу вознаграждение,
+// не опуская баланс ниже нуля, возвращает его в бюджет кампании и возвращает фактически списанную сумму
+func (s *state) reverseTaskReward(taskID, userID string) float64 {
+	var paid float64
+	for i := len(s.history) - 1; i >= 0; i-- {
+		change := s.history[i]
+		if change.TaskID == taskID && change.ToStatus == models.Completed && change.UserID != nil && *change.UserID == userID {
+			paid = change.RewardDelta
+			break
+		}
+	}
+
+	// Возврат выполняется и у мягко удаленного пользователя
+	record, ok := s.users[userID]
+	if !ok {
+		return 0
+	}
+	previous := record.user.Balance
+	record.user.Balance = roundMoney(math.Max(previous-paid, 0))
+	record.user.TasksCompleted = max(record.user.TasksCompleted-1, 0)
+	record.user.UpdatedAt = now()
+	record.hasBalance = true
+	reversed := roundMoney(previous - record.user.Balance)
+	s.refundCampaignBudget(taskID, reversed)
+	return reversed
+}
+
+// addHistory записывает переход статуса задачи в историю
+func (s *state) addHistory(taskID string, from, to models.TaskStatus, actor string, userID *string, rewardDelta float64) {
+	s.lastHistoryID++
+	s.history = append(s.history, models.TaskStatusChange{
+		ID:          s.lastHistoryID,
+		TaskID:      taskID,
+		FromStatus:  from,
+		ToStatus:    to,
+		Actor:       actor,
+		UserID:      userID,
+		RewardDelta: roundMoney(rewardDelta),
+		CreatedAt:   now(),
+	})
+}
+
+// GetTaskStatusHistory возвращает историю переходов статуса задачи в хронологическом порядке
+func (r *TaskRepository) GetTaskStatusHistory(ctx context.Context, taskID uuid.UUID) ([]models.TaskStatusChange, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	id := taskID.String()
+	if r.store.state.activeTask(id) == nil {
+		return nil, errors.NewNotFound("task not found", nil)
+	}
+	history := []models.TaskStatusChange{}
+	for _, change := range r.store.state.history {
+		if change.TaskID == id {
+			history = append(history, change)
+		}
+	}
+	return history, nil
+}
+
+// ClaimTask закрепляет задачу за пользователем; повторный вызов возвращает существующую запись
+func (r *TaskRepository) ClaimTask(ctx context.Context, taskID, userID string, dueDeadline time.Time) (*models.TaskAssignment, error) {
+	var claimed models.TaskAssignment
+	err := r.store.update(func(s *state) error {
+		task := s.activeTask(taskID)
+		if task == nil {
+			return errors.NewNotFound("task not found", nil)
+		}
+		if err := checkNotPastDue(task, dueDeadline); err != nil {
+			return err
+		}
+		if err := s.checkActiveUser(userID); err != nil {
+			return err
+		}
+		if assignment := s.assignment(taskID, userID); assignment != nil {
+			claimed = *assignment
+			return nil
+		}
+
+		if !task.AssignmentMode.IsShared() {
+			return errors.NewForbidden("task can be done only by assigned users", nil)
+		}
+		if task.Status.IsClosed() {
+			return errors.NewConflict(fmt.Sprintf("task is %s and cannot be claimed", task.Status), nil)
+		}
+		if task.AssignmentMode == models.AssignmentCapped && task.MaxClaims != nil && len(s.assignments[taskID]) >= *task.MaxClaims {
+			return errors.NewConflict("task claim limit reached", nil)
+		}
+
+		claimedAt := now()
+		claimed = models.TaskAssignment{
+			TaskID:       taskID,
+			UserID:       userID,
+			Progress:     models.InProgress,
+			ClaimedAt:    claimedAt,
+			ProgressedAt: claimedAt,
+		}
+		assignment := claimed
+		s.setAssignment(&assignment)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &claimed, nil
+}
+
+// GetUserTasks возвращает задачи пользователя с его прогрессом; status ограничивает выборку прогрессом
+func (r *TaskRepository) GetUserTasks(ctx context.Context, userID string, status models.TaskStatus) ([]models.UserTask, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	s := r.store.state
+	if s.activeUser(userID) == nil {
+		return nil, errors.NewNotFound("user not found", nil)
+	}
+
+	tasks := []models.UserTask{}
+	for _, task := range s.tasks {
+		if task.DeletedAt != nil {
+			continue
+		}
+		userTask := models.UserTask{Task: *task}
+		if assignment := s.assignment(task.TaskID, userID); assignment != nil {
+			claimedAt := assignment.ClaimedAt
+			userTask.Progress, userTask.ClaimedAt, userTask.CompletedAt = assignment.Progress, &claimedAt, assignment.CompletedAt
+		} else {
+			assignee := task.AssigneeID != nil && *task.AssigneeID == userID
+			completer := task.CompletedBy != nil && *task.CompletedBy == userID
+			if !assignee && !completer {
+				continue
+			}
+			userTask.Progress = task.Status
+			if completer {
+				completedAt := task.UpdatedAt
+				userTask.CompletedAt = &completedAt
+			}
+		}
+		if status == 0 || userTask.Progress == status {
+			tasks = append(tasks, userTask)
+		}
+	}
+	// От новых задач к старым, затем по task_id
+	slices.SortFunc(tasks, func(a, b models.UserTask) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.TaskID, b.TaskID)
+	})
+	return tasks, nil
+}
+
+// CreateSubmission сохраняет заявку пользователя на выполнение задачи
+func (r *TaskRepository) CreateSubmission(ctx context.Context, submission *models.Submission, dueDeadline time.Time) (*models.Submission, error) {
+	var created models.Submission
+	err := r.store.update(func(s *state) error {
+		task := s.activeTask(submission.TaskID)
+		if task == nil {
+			return errors.NewNotFound("task not found", nil)
+		}
+		if err := checkNotPastDue(task, dueDeadline); err != nil {
+			return err
+		}
+		if err := s.checkActiveUser(submission.UserID); err != nil {
+			return err
+		}
+
+		progress, assigned, err := s.lockUserProgress(task, submission.UserID)
+		if err != nil {
+			return err
+		}
+		if !assigned {
+			progress = task.Status
+		}
+		if progress.IsClosed() {
+			return errors.NewConflict(fmt.Sprintf("task is already %s", progress), nil)
+		}
+
+		for _, other := range s.submissions {
+			if other.TaskID == submission.TaskID && other.UserID == submission.UserID && other.Status == models.SubmissionPending {
+				return errors.NewAlreadyExists("a pending submission for this task already exists", nil)
+			}
+		}
+		if submission.EvidenceType != models.EvidenceURL && submission.EvidenceType != models.EvidenceText {
+			return errors.NewInternal("failed to create submission", violation("task_submissions_evidence_type_check"))
+		}
+
+		s.lastSubmissionID++
+		created = models.Submission{
+			ID:           s.lastSubmissionID,
+			TaskID:       submission.TaskID,
+			UserID:       submission.UserID,
+			EvidenceType: submission.EvidenceType,
+			Evidence:     submission.Evidence,
+			Status:       models.SubmissionPending,
+			CreatedAt:    now(),
+		}
+		stored := created
+		s.submissions = append(s.submissions, &stored)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetSubmissions возвращает заявки по неудаленным задачам в порядке поступления
+func (r *TaskRepository) GetSubmissions(ctx context.Context, filter *models.SubmissionFilter) ([]models.Submission, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	s := r.store.state
+	var matched []models.Submission
+	for _, submission := range s.submissions {
+		switch {
+		case filter.Status != "" && submission.Status != filter.Status,
+			filter.TaskID != "" && submission.TaskID != filter.TaskID,
+			filter.UserID != "" && submission.UserID != filter.UserID,
+			s.activeTask(submission.TaskID) == nil:
+			continue
+		}
+		matched = append(matched, *submission)
+	}
+	slices.SortFunc(matched, func(a, b models.Submission) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	submissions := []models.Submission{}
+	if filter.Offset < len(matched) {
+		submissions = append(submissions, matched[filter.Offset:min(filter.Offset+max(filter.Limit, 0), len(matched))]...)
+	}
+	return submissions, nil
+}
+
+// ApproveSubmission одобряет заявку и засчитывает выполнение задачи с начислением вознаграждения
+func (r *TaskRepository) ApproveSubmission(ctx context.Context, id int64, reason, reviewer string) (*models.Submission, *models.BalanceUpdate, error) {
+	var change *models.BalanceUpdate
+	approved, err := r.reviewSubmission(id, models.SubmissionApproved, reason, reviewer, func(s *state, submission *models.Submission) error {
+		var err error
+		change, err = s.transitionTask(submission.TaskID, models.Completed, submission.UserID, reviewer, true, time.Time{})
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return approved, change, nil
+}
+
+// RejectSubmission отклоняет заявку с указанной причиной
+func (r *TaskRepository) RejectSubmission(ctx context.Context, id int64, reason, reviewer string) (*models.Submission, error) {
+	return r.reviewSubmission(id, models.SubmissionRejected, reason, reviewer, nil)
+}
+
+// reviewSubmission фиксирует решение модератора по ожидающей заявке; apply выполняется атомарно с решением
+func (r *TaskRepository) reviewSubmission(id int64, status models.SubmissionStatus, reason, reviewer string,
+	apply func(s *state, submission *models.Submission) error) (*models.Submission, error) {
+	var reviewed models.Submission
+	err := r.store.update(func(s *state) error {
+		index := slices.IndexFunc(s.submissions, func(submission *models.Submission) bool { return submission.ID == id })
+		if index < 0 {
+			return errors.NewNotFound("submission not found", nil)
+		}
+		submission := s.submissions[index]
+		if submission.Status != models.SubmissionPending {
+			return errors.NewConflict(fmt.Sprintf("submission is already %s", submission.Status), nil)
+		}
+
+		if apply != nil {
+			if err := apply(s, submission); err != nil {
+				return err
+			}
+		}
+
+		reviewedAt := now()
+		reviewed = *submission
+		reviewed.Status = status
+		reviewed.Reason = reason
+		reviewed.ReviewedBy = &reviewer
+		reviewed.ReviewedAt = &reviewedAt
+		stored := reviewed
+		s.submissions[index] = &stored
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &reviewed, nil
+}
+
+// AddTaskDependencies добавляет задаче зависимости; зависимость, замыкающая цикл, отклоняется
+func (r *TaskRepository) AddTaskDependencies(ctx context.Context, taskID string, dependsOn []string) error {
+	return r.store.update(func(s *state) error {
+		for _, id := range append([]string{taskID}, dependsOn...) {
+			if s.activeTask(id) == nil {
+				return errors.NewNotFound("task not found", nil)
+			}
+		}
+		if s.reachable(dependsOn)[taskID] {
+			return errors.NewValidation("dependency would create a cycle", nil)
+		}
+
+		createdAt := now()
+		for _, dependsOnID := range dependsOn {
+			exists := slices.ContainsFunc(s.dependencies, func(d dependency) bool {
+				return d.taskID == taskID && d.dependsOnID == dependsOnID
+			})
+			if !exists {
+				s.dependencies = append(s.dependencies, dependency{taskID: taskID, dependsOnID: dependsOnID, createdAt: createdAt})
+			}
+		}
+		return nil
+	})
+}
+
+// reachable возвращает задачи, достижимые из from по ребрам зависимостей, включая сами задачи from
+func (s *state) reachable(from []string) map[string]bool {
+	visited := make(map[string]bool)
+	queue := slices.Clone(from)
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if visited[id] {
+			continue
+		}
+		visited[id] = true
+		for _, d := range s.dependencies {
+			if d.taskID == id {
+				queue = append(queue, d.dependsOnID)
+			}
+		}
+	}
+	return visited
+}
+
+// RemoveTaskDependency удаляет зависимость задачи
+func (r *TaskRepository) RemoveTaskDependency(ctx context.Context, taskID, dependsOnID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	s := r.store.state
+	index := slices.IndexFunc(s.dependencies, func(d dependency) bool {
+		return d.taskID == taskID && d.dependsOnID == dependsOnID
+	})
+	if index < 0 {
+		return errors.NewNotFound("task dependency not found", nil)
+	}
+	s.dependencies = slices.Delete(s.dependencies, index, index+1)
+	return nil
+}
+
+// GetTaskDependencies возвращает прямые зависимости указанных задач от неудаленных задач
+func (r *TaskRepository) GetTaskDependencies(ctx context.Context, taskIDs []string) (map[string][]string, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	s := r.store.state
+	var edges []dependency
+	for _, d := range s.dependencies {
+		if slices.Contains(taskIDs, d.taskID) && s.activeTask(d.dependsOnID) != nil {
+			edges = append(edges, d)
+		}
+	}
+	slices.SortStableFunc(edges, func(a, b dependency) int {
+		if c := a.createdAt.Compare(b.createdAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.dependsOnID, b.dependsOnID)
+	})
+
+	dependencies := make(map[string][]string, len(taskIDs))
+	for _, d := range edges {
+		dependencies[d.taskID] = append(dependencies[d.taskID], d.dependsOnID)
+	}
+	return dependencies, nil
+}
+
+// GetCompletedTaskIDs возвращает задачи из taskIDs, выполненные пользователем лично или как единственным исполнителем
+func (r *TaskRepository) GetCompletedTaskIDs(ctx context.Context, userID string, taskIDs []string) (map[string]bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	s := r.store.state
+	completed := make(map[string]bool, len(taskIDs))
+	for _, id := range taskIDs {
+		task, ok := s.tasks[id]
+		if !ok {
+			continue
+		}
+		if s.taskDoneBy(task, userID) {
+			completed[id] = true
+		}
+	}
+	return completed, nil
+}
+
+// hasPendingSubmission проверяет, есть ли по задаче заявка на модерации; userID ограничивает проверку пользователем
+func (s *state) hasPendingSubmission(taskID, userID string) bool {
+	return slices.ContainsFunc(s.submissions, func(submission *models.Submission) bool {
+		return submission.TaskID == taskID && submission.Status == models.SubmissionPending &&
+			(userID == "" || submission.UserID == userID)
+	})
+}
+
+// ExpireOverdueTasks переводит в Expired незавершенные задачи и личный прогресс пользователей,
+// срок которых истек раньше deadline
+func (r *TaskRepository) ExpireOverdueTasks(ctx context.Context, deadline time.Time, actor string) (int64, error) {
+	var expired int64
+	err := r.store.update(func(s *state) error {
+		open := func(status models.TaskStatus) bool { return status == models.NotStarted || status == models.InProgress }
+		tasks := sortedValues(s.tasks, func(a, b *models.Task) bool { return a.TaskID < b.TaskID })
+		overdue := func(task *models.Task) bool {
+			return task.DeletedAt == nil && task.DueDate != nil && task.DueDate.Before(deadline)
+		}
+
+		for _, task := range tasks {
+			if overdue(task) && open(task.Status) && !s.hasPendingSubmission(task.TaskID, "") {
+				s.addHistory(task.TaskID, task.Status, models.Expired, actor, nil, 0)
+				task.Status = models.Expired
+				task.UpdatedAt = now()
+				expired++
+			}
+		}
+		for _, task := range tasks {
+			if !overdue(task) {
+				continue
+			}
+			users := sortedValues(s.assignments[task.TaskID], func(a, b *models.TaskAssignment) bool { return a.UserID < b.UserID })
+			for _, assignment := range users {
+				if open(assignment.Progress) && !s.hasPendingSubmission(task.TaskID, assignment.UserID) {
+					userID := assignment.UserID
+					s.addHistory(task.TaskID, assignment.Progress, models.Expired, actor, &userID, 0)
+					assignment.Progress = models.Expired
+					assignment.ProgressedAt = now()
+					expired++
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return expired, nil
+}
+
+// GetDueReminders возвращает не более limit напоминаний исполнителям задач со сроком в интервале (from, to]
+func (r *TaskRepository) GetDueReminders(ctx context.Context, from, to time.Time, limit int) ([]models.DueReminder, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	s := r.store.state
+	open := func(status models.TaskStatus) bool { return status == models.NotStarted || status == models.InProgress }
+	var reminders []models.DueReminder
+	for _, task := range s.tasks {
+		if task.DeletedAt != nil || task.DueDate == nil || !task.DueDate.After(from) || task.DueDate.After(to) {
+			continue
+		}
+		// Пользователи с незавершенным личным прогрессом и единственный исполнитель незавершенной задачи
+		recipients := make(map[string]bool)
+		for userID, assignment := range s.assignments[task.TaskID] {
+			if open(assignment.Progress) {
+				recipients[userID] = true
+			}
+		}
+		if task.AssigneeID != nil && open(task.Status) && s.assignment(task.TaskID, *task.AssigneeID) == nil {
+			recipients[*task.AssigneeID] = true
+		}
+
+		for userID := range recipients {
+			record := s.activeUser(userID)
+			if record == nil || s.reminders[reminderKey{taskID: task.TaskID, userID: userID}] {
+				continue
+			}
+			reminders = append(reminders, models.DueReminder{
+				TaskID:   task.TaskID,
+				Title:    task.Title,
+				DueDate:  *task.DueDate,
+				UserID:   userID,
+				Username: record.user.Username,
+				Email:    record.user.Email,
+			})
+		}
+	}
+	slices.SortFunc(reminders, func(a, b models.DueReminder) int {
+		if c := a.DueDate.Compare(b.DueDate); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.TaskID, b.TaskID); c != 0 {
+			return c
+		}
+		return strings.Compare(a.UserID, b.UserID)
+	})
+
+	if limit >= 0 && len(reminders) > limit {
+		reminders = reminders[:limit]
+	}
+	return append([]models.DueReminder{}, reminders...), nil
+}
+
+// MarkDueReminderSent отмечает напоминание исполнителю задачи отправленным
+func (r *TaskRepository) MarkDueReminderSent(ctx context.Context, taskID, userID string) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	s := r.store.state
+	if _, ok := s.tasks[taskID]; !ok {
+		return errors.NewInternal("failed to mark due reminder as sent", violation("task_due_reminders_task_id_fkey"))
+	}
+	if _, ok := s.users[userID]; !ok {
+		return errors.NewInternal("failed to mark due reminder as sent", violation("task_due_reminders_user_id_fkey"))
+	}
+	s.reminders[reminderKey{taskID: taskID, userID: userID}] = true
+	return nil
+}
+
+// GetExistingTaskKeys возвращает пары заголовок/описание существующих задач с указанными заголовками
+func (r *TaskRepository) GetExistingTaskKeys(ctx context.Context, titles []string) (map[models.TaskKey]bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing := make(map[models.TaskKey]bool)
+	for _, task := range r.store.state.tasks {
+		if task.DeletedAt == nil && slices.Contains(titles, task.Title) {
+			existing[models.TaskKey{Title: task.Title, Description: task.Description}] = true
+		}
+	}
+	return existing, nil
+}
+
+// CopyTasks вставляет задачи атомарно: при ошибке не сохраняется ни одна
+func (r *TaskRepository) CopyTasks(ctx context.Context, tasks []*models.Task) error {
+	err := r.store.update(func(s *state) error {
+		for _, t := range tasks {
+			task := &models.Task{
+				TaskID:         t.TaskID,
+				Title:          t.Title,
+				Description:    t.Description,
+				DueDate:        storedTime(t.DueDate),
+				Status:         t.Status,
+				AssigneeID:     t.AssigneeID,
+				Reward:         roundMoney(t.Reward),
+				CreatedAt:      t.CreatedAt.UTC().Truncate(time.Microsecond),
+				UpdatedAt:      t.UpdatedAt.UTC().Truncate(time.Microsecond),
+				AssignmentMode: models.AssignmentAssigned,
+			}
+			if _, ok := s.tasks[task.TaskID]; ok {
+				return violation("tasks_pkey")
+			}
+			if err := checkTask(task); err != nil {
+				return err
+			}
+			s.tasks[task.TaskID] = task
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.NewInternal("failed to copy tasks", err)
+	}
+	return nil
+}
+
+// WithTransaction выполняет функцию атомарно; f получает nil вместо транзакции
+func (r *TaskRepository) WithTransaction(ctx context.Context, f func(tx *sql.Tx) error) error {
+	return r.store.withTransaction(func() error { return f(nil) })
+}
@@ -0,0 +1,649 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserRepository - репозиторий пользователей в памяти
+type UserRepository struct {
+	store *Store
+}
+
+// NewUserRepository создает репозиторий пользователей над хранилищем store
+func NewUserRepository(store *Store) repository.UserRepository {
+	return &UserRepository{store: store}
+}
+
+// violation возвращает ошибку нарушения ограничения таблицы. PostgreSQL возвращает в таких случаях
+// ошибку драйвера, которую репозиторий не классифицирует, поэтому фейк тоже не задает ей тип.
+func violation(constraint string) error {
+	return fmt.Errorf("memory: constraint %q violated", constraint)
+}
+
+// roundMoney округляет сумму до точности денежных колонок DECIMAL(15, 2)
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// checkUser проверяет ограничения таблицы пользователей для записи record
+func (s *state) checkUser(record *userRecord) error {
+	user := &record.user
+	if user.Status < models.Active || user.Status > models.Pending {
+		return violation("users_status_fkey")
+	}
+	if user.Balance < 0 {
+		return violation("users_balance_check")
+	}
+	if user.Referrals < 0 {
+		return violation("users_referrals_check")
+	}
+	if user.TasksCompleted < 0 {
+		return violation("users_taskscompleted_check")
+	}
+	// Адрес уникален только среди неудаленных пользователей
+	if user.DeletedAt == nil {
+		for id, other := range s.users {
+			if id != user.ID && other.user.DeletedAt == nil && other.user.Email == user.Email {
+				return violation("idx_users_email_active")
+			}
+		}
+	}
+	return nil
+}
+
+// insertUser добавляет пользователя после проверки ограничений
+func (s *state) insertUser(record *userRecord) error {
+	if _, ok := s.users[record.user.ID]; ok {
+		return violation("users_pkey")
+	}
+	if err := s.checkUser(record); err != nil {
+		return err
+	}
+	s.users[record.user.ID] = record
+	return nil
+}
+
+// copyUser возвращает копию пользователя, которую вызывающий код может изменять
+func copyUser(record *userRecord) *models.User {
+	user := record.user
+	return &user
+}
+
+// Загрузить страницу пользователей по фильтру в заданном порядке
+func (r *UserRepository) GetUsers(ctx context.Context, filter *models.UserFilter) (*models.UsersResponse, error) {
+	order, err := userOrder(filter.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	page := filter.Pagination
+	var cursor models.User
+	if page.Cursor != nil {
+		if len(page.Cursor.Keys) != len(order) {
+			return nil, errors.NewBadRequest("cursor does not match the requested sort", nil)
+		}
+		for i, key := range order {
+			if err := key.set(&cursor, page.Cursor, i); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	r.store.mu.Lock()
+	var matched []*models.User
+	for _, record := range r.store.state.users {
+		if matchUser(&record.user, filter) {
+			matched = append(matched, copyUser(record))
+		}
+	}
+	r.store.mu.Unlock()
+
+	compare := func(a, b *models.User) int {
+		for _, key := range order {
+			if c := key.compare(a, b); c != 0 {
+				return c
+			}
+		}
+		return 0
+	}
+	slices.SortFunc(matched, compare)
+	users := keysetPage(matched, page, func(user *models.User) int { return compare(user, &cursor) })
+
+	users, info := pagination.Paginate(users, page, func(user *models.User) []string {
+		keys := make([]string, len(order))
+		for i, key := range order {
+			keys[i] = key.value(user)
+		}
+		return keys
+	})
+	return &models.UsersResponse{
+		Users: users,
+		Count: len(users),
+		Info:  info,
+	}, nil
+}
+
+// matchUser проверяет, что пользователь соответствует фильтру
+func matchUser(user *models.User, filter *models.UserFilter) bool {
+	switch {
+	case filter.Username != "" && !strings.Contains(strings.ToLower(user.Username), strings.ToLower(filter.Username)):
+		return false
+	case filter.Status != 0 && user.Status != filter.Status:
+		return false
+	case filter.EmailDomain != "" && !strings.EqualFold(emailDomain(user.Email), filter.EmailDomain):
+		return false
+	case filter.MinBalance != nil && user.Balance < *filter.MinBalance,
+		filter.MaxBalance != nil && user.Balance > *filter.MaxBalance:
+		return false
+	case filter.CreatedAfter != nil && user.CreatedAt.Before(*filter.CreatedAfter),
+		filter.CreatedBefore != nil && user.CreatedAt.After(*filter.CreatedBefore):
+		return false
+	case filter.MinReferrals != nil && user.Referrals < *filter.MinReferrals,
+		filter.MaxReferrals != nil && user.Referrals > *filter.MaxReferrals:
+		return false
+	case filter.LastVisitBefore != nil && !user.LastVisit.IsZero() && !user.LastVisit.Before(*filter.LastVisitBefore):
+		return false
+	case !filter.IncludeDeleted && user.DeletedAt != nil:
+		return false
+	case filter.HasReferrer != nil && *filter.HasReferrer != (user.ReferralCode != ""):
+		return false
+	}
+	return true
+}
+
+// emailDomain возвращает часть адреса после первого "@", как SPLIT_PART(Email, '@', 2)
+func emailDomain(email string) string {
+	parts := strings.SplitN(email, "@", 3)
+	if len(parts) < 2 {
+		return ""
+	}
+	return parts[1]
+}
+
+// userSortKey описывает поле сортировки списка пользователей: сравнение, значение ключа курсора
+// и установку значения из курсора в пользователя, с которым сравниваются записи
+type userSortKey struct {
+	compare func(a, b *models.User) int
+	value   func(user *models.User) string
+	set     func(user *models.User, cursor *pagination.Cursor, i int) error
+}
+
+var userSortKeys = map[models.UserSortField]userSortKey{
+	models.UserSortCreatedAt: {
+		compare: func(a, b *models.User) int { return a.CreatedAt.Compare(b.CreatedAt) },
+		value:   func(user *models.User) string { return pagination.FormatTime(user.CreatedAt) },
+		set: func(user *models.User, cursor *pagination.Cursor, i int) (err error) {
+			user.CreatedAt, err = cursor.Time(i)
+			return err
+		},
+	},
+	models.UserSortUsername: {
+		compare: func(a, b *models.User) int { return strings.Compare(a.Username, b.Username) },
+		value:   func(user *models.User) string { return user.Username },
+		set: func(user *models.User, cursor *pagination.Cursor, i int) (err error) {
+			user.Username, err = cursor.String(i)
+			return err
+		},
+	},
+	models.UserSortBalance: {
+		compare: func(a, b *models.User) int { return cmp.Compare(a.Balance, b.Balance) },
+		value:   func(user *models.User) string { return pagination.FormatFloat(user.Balance) },
+		set: func(user *models.User, cursor *pagination.Cursor, i int) (err error) {
+			user.Balance, err = cursor.Float(i)
+			return err
+		},
+	},
+	models.UserSortReferrals: {
+		compare: func(a, b *models.User) int { return cmp.Compare(a.Referrals, b.Referrals) },
+		value:   func(user *models.User) string { return pagination.FormatInt(user.Referrals) },
+		set: func(user *models.User, cursor *pagination.Cursor, i int) (err error) {
+			user.Referrals, err = cursor.Int(i)
+			return err
+		},
+	},
+	models.UserSortTasksCompleted: {
+		compare: func(a, b *models.User) int { return cmp.Compare(a.TasksCompleted, b.TasksCompleted) },
+		value:   func(user *models.User) string { return pagination.FormatInt(user.TasksCompleted) },
+		set: func(user *models.User, cursor *pagination.Cursor, i int) (err error) {
+			user.TasksCompleted, err = cursor.Int(i)
+			return err
+		},
+	},
+	models.UserSortLastVisit: {
+		compare: func(a, b *models.User) int { return a.LastVisit.Compare(b.LastVisit) },
+		value:   func(user *models.User) string { return pagination.FormatTime(user.LastVisit) },
+		set: func(user *models.User, cursor *pagination.Cursor, i int) (err error) {
+			user.LastVisit, err = cursor.Time(i)
+			return err
+		},
+	},
+}
+
+// userIDSortKey упорядочивает пользователей с одинаковыми значениями полей сортировки
+var userIDSortKey = userSortKey{
+	compare: func(a, b *models.User) int { return strings.Compare(a.ID, b.ID) },
+	value:   func(user *models.User) string { return user.ID },
+	set: func(user *models.User, cursor *pagination.Cursor, i int) (err error) {
+		user.ID, err = cursor.String(i)
+		return err
+	},
+}
+
+// userOrder возвращает ключи сортировки списка пользователей с учетом направлений.
+// ID сортируется в направлении последнего поля, как в запросе к PostgreSQL.
+func userOrder(sorts []models.UserSort) ([]userSortKey, error) {
+	if len(sorts) == 0 {
+		sorts = []models.UserSort{{Field: models.UserSortCreatedAt, Desc: true}}
+	}
+	order := make([]userSortKey, 0, len(sorts)+1)
+	for _, sort := range sorts {
+		key, ok := userSortKeys[sort.Field]
+		if !ok {
+			return nil, errors.NewValidation(fmt.Sprintf("unsupported sort field %q", sort.Field), nil)
+		}
+		order = append(order, directed(key, sort.Desc))
+	}
+	return append(order, directed(userIDSortKey, sorts[len(sorts)-1].Desc)), nil
+}
+
+// directed возвращает ключ с обратным сравнением при сортировке по убыванию
+func directed(key userSortKey, desc bool) userSortKey {
+	if desc {
+		compare := key.compare
+		key.compare = func(a, b *models.User) int { return compare(b, a) }
+	}
+	return key
+}
+
+// Получить пользователя по ID
+func (r *UserRepository) GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record := r.store.state.activeUser(id.String())
+	if record == nil {
+		return nil, sql.ErrNoRows
+	}
+	return copyUser(record), nil
+}
+
+// Создать нового пользователя
+func (r *UserRepository) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	createdAt := now()
+	record := &userRecord{user: models.User{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Status:    user.Status,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}}
+	if err := r.store.state.insertUser(record); err != nil {
+		return nil, err
+	}
+	// Как и INSERT ... RETURNING, заполняются только сохраненные колонки
+	user.CreatedAt = createdAt
+	return user, nil
+}
+
+// Обновить пользователя
+func (r *UserRepository) UpdateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record := r.store.state.activeUser(user.ID)
+	if record == nil {
+		return nil, sql.ErrNoRows
+	}
+	updated := *record
+	updated.user.Username = user.Username
+	updated.user.Email = user.Email
+	updated.user.Balance = roundMoney(user.Balance)
+	updated.user.Referrals = user.Referrals
+	updated.user.ReferralCode = user.ReferralCode
+	updated.user.TasksCompleted = user.TasksCompleted
+	updated.user.Bio = user.Bio
+	updated.user.TimeZone = user.TimeZone
+	updated.user.Status = user.Status
+	updated.user.UpdatedAt = now()
+	updated.hasBalance = true
+	if err := r.store.state.checkUser(&updated); err != nil {
+		return nil, err
+	}
+	*record = updated
+	return copyUser(record), nil
+}
+
+// Удалить пользователя (мягкое удаление)
+func (r *UserRepository) DeleteUser(ctx context.Context, id uuid.UUID) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record := r.store.state.activeUser(id.String())
+	if record == nil {
+		return errors.NewNotFound("user not found", nil)
+	}
+	deletedAt := now()
+	record.user.DeletedAt = &deletedAt
+	record.user.UpdatedAt = deletedAt
+	return nil
+}
+
+// RestoreUser восстанавливает мягко удаленного пользователя
+func (r *UserRepository) RestoreUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record, ok := r.store.state.users[id.String()]
+	if !ok || record.user.DeletedAt == nil {
+		return nil, errors.NewNotFound("deleted user not found", nil)
+	}
+	restored := *record
+	restored.user.DeletedAt = nil
+	restored.user.UpdatedAt = now()
+	if err := r.store.state.checkUser(&restored); err != nil {
+		return nil, errors.NewAlreadyExists("email is already taken by another user", err)
+	}
+	*record = restored
+	return copyUser(record), nil
+}
+
+// PurgeDeletedUsers окончательно удаляет пользователей, мягко удаленных раньше указанного момента,
+// вместе с их участием в задачах и заявками
+func (r *UserRepository) PurgeDeletedUsers(ctx context.Context, before time.Time) (int64, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	s := r.store.state
+	var purged int64
+	for id, record := range s.users {
+		if record.user.DeletedAt != nil && record.user.DeletedAt.Before(before) {
+			delete(s.users, id)
+			for _, users := range s.assignments {
+				delete(users, id)
+			}
+			s.submissions = slices.DeleteFunc(s.submissions, func(submission *models.Submission) bool {
+				return submission.UserID == id
+			})
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// Получить пользователей по статусу
+func (r *UserRepository) GetUsersByStatus(ctx context.Context, status models.UserStatus) ([]*models.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	var users []*models.User
+	for _, record := range sortedUsers(r.store.state) {
+		if record.user.DeletedAt == nil && record.user.Status == status {
+			users = append(users, copyUser(record))
+		}
+	}
+	return users, nil
+}
+
+// sortedUsers возвращает пользователей в порядке регистрации
+func sortedUsers(s *state) []*userRecord {
+	return sortedValues(s.users, func(a, b *userRecord) bool {
+		if !a.user.CreatedAt.Equal(b.user.CreatedAt) {
+			return a.user.CreatedAt.Before(b.user.CreatedAt)
+		}
+		return a.user.ID < b.user.ID
+	})
+}
+
+// Получить пользователя по электронной почте
+func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	for _, record := range r.store.state.users {
+		if record.user.DeletedAt == nil && record.user.Email == email {
+			return copyUser(record), nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// Обновить баланс пользователя
+func (r *UserRepository) UpdateBalance(ctx context.Context, id uuid.UUID, amount float64) error {
+	return r.addBalanceAndReferrals(id.String(), amount, 0)
+}
+
+// addBalanceAndReferrals изменяет баланс и количество рефералов неудаленного пользователя.
+// Отсутствие пользователя, как и в UPDATE без совпавших строк, не является ошибкой.
+func (r *UserRepository) addBalanceAndReferrals(id string, amount float64, referrals int) error {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	record := r.store.state.activeUser(id)
+	if record == nil {
+		return nil
+	}
+	updated := *record
+	updated.user.Balance = roundMoney(updated.user.Balance + amount)
+	updated.user.Referrals += referrals
+	updated.hasBalance = true
+	if err := r.store.state.checkUser(&updated); err != nil {
+		return err
+	}
+	*record = updated
+	return nil
+}
+
+// Получить полную информацию о пользователе
+func (r *UserRepository) GetUserFullInfo(ctx context.Context, id uuid.UUID) (string, error) {
+	user, err := r.GetUserByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("User Info: %+v", user), nil
+}
+
+// Получить сводную информацию о пользователе
+func (r *UserRepository) GetUserSummary(ctx context.Context, id uuid.UUID) (*models.UserSummary, error) {
+	user, err := r.GetUserByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &models.UserSummary{
+		ID:             user.ID,
+		Username:       user.Username,
+		Email:          user.Email,
+		Balance:        user.Balance,
+		Referrals:      user.Referrals,
+		TasksCompleted: user.TasksCompleted,
+	}, nil
+}
+
+// Выполнение функции в рамках транзакции; f получает nil вместо транзакции
+func (r *UserRepository) WithTransaction(ctx context.Context, f func(tx *sql.Tx) error) error {
+	return r.store.withTransaction(func() error { return f(nil) })
+}
+
+// Получение пользователя по ID в рамках транзакции
+func (r *UserRepository) GetUserByIDTx(ctx context.Context, tx *sql.Tx, id uuid.UUID) (*models.User, error) {
+	return r.GetUserByID(ctx, id)
+}
+
+// Получение пользователя по электронной почте в рамках транзакции
+func (r *UserRepository) GetUserByEmailTx(ctx context.Context, tx *sql.Tx, email string) (*models.User, error) {
+	return r.GetUserByEmail(ctx, email)
+}
+
+// Создание нового пользователя в рамках транзакции
+func (r *UserRepository) CreateUserTx(ctx context.Context, tx *sql.Tx, user *models.User) (*models.User, error) {
+	return r.CreateUser(ctx, user)
+}
+
+// Приращение баланса и рефералов в рамках транзакции
+func (r *UserRepository) UpdateBalanceAndReferralsTx(ctx context.Context, tx *sql.Tx, id uuid.UUID, balanceDelta float64, referralsDelta int) error {
+	return r.addBalanceAndReferrals(id.String(), balanceDelta, referralsDelta)
+}
+
+// leaderboardRow - пользователь в таблице лидеров и ключ его баланса: пользователи без баланса
+// получают ключ -1, как в выражении COALESCE(Balance, -1)
+type leaderboardRow struct {
+	models.TopUser
+	balanceKey float64
+}
+
+// compareLeaders сравнивает пользователей в порядке таблицы лидеров: баланс и количество выполненных
+// задач по убыванию, затем ID
+func compareLeaders(a, b *leaderboardRow) int {
+	if c := cmp.Compare(b.balanceKey, a.balanceKey); c != 0 {
+		return c
+	}
+	if c := cmp.Compare(b.TasksCompleted, a.TasksCompleted); c != 0 {
+		return c
+	}
+	return strings.Compare(a.ID, b.ID)
+}
+
+// leaderboard возвращает неудаленных пользователей в порядке таблицы лидеров с рангами
+func (r *UserRepository) leaderboard() []*leaderboardRow {
+	r.store.mu.Lock()
+	var rows []*leaderboardRow
+	for _, record := range r.store.state.users {
+		if record.user.DeletedAt != nil {
+			continue
+		}
+		row := &leaderboardRow{TopUser: models.TopUser{User: record.user}, balanceKey: record.user.Balance}
+		if !record.hasBalance {
+			row.balanceKey = -1
+		}
+		rows = append(rows, row)
+	}
+	r.store.mu.Unlock()
+
+	slices.SortFunc(rows, compareLeaders)
+	for i, row := range rows {
+		row.Rank = i + 1
+	}
+	return rows
+}
+
+// Получить лидера по балансу
+func (r *UserRepository) GetLeaderByBalance(ctx context.Context) (*models.TopUser, error) {
+	rows := r.leaderboard()
+	if len(rows) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &rows[0].TopUser, nil
+}
+
+// Получить топ пользователей с лимитом по количеству
+func (r *UserRepository) GetTopUsers(ctx context.Context, limit int, offset int) ([]models.TopUser, error) {
+	var topUsers []models.TopUser
+	for _, row := range r.leaderboard() {
+		if row.Rank > offset && len(topUsers) < limit {
+			topUsers = append(topUsers, row.TopUser)
+		}
+	}
+	return topUsers, nil
+}
+
+// GetTopUsersPage возвращает страницу топа пользователей после или перед курсором.
+// Ранги, как и в PostgreSQL, отсчитываются от ранга из курсора.
+func (r *UserRepository) GetTopUsersPage(ctx context.Context, page pagination.Page) ([]models.TopUser, pagination.Info, error) {
+	var cursor leaderboardRow
+	rank := 0
+	if page.Cursor != nil {
+		var err error
+		if cursor.balanceKey, err = page.Cursor.Float(0); err != nil {
+			return nil, pagination.Info{}, err
+		}
+		if cursor.TasksCompleted, err = page.Cursor.Int(1); err != nil {
+			return nil, pagination.Info{}, err
+		}
+		if cursor.ID, err = page.Cursor.String(2); err != nil {
+			return nil, pagination.Info{}, err
+		}
+		if rank, err = page.Cursor.Int(3); err != nil {
+			return nil, pagination.Info{}, err
+		}
+	}
+
+	rows := keysetPage(r.leaderboard(), page, func(row *leaderboardRow) int { return compareLeaders(row, &cursor) })
+	for i, row := range rows {
+		if page.Backward() {
+			row.Rank = rank - i - 1
+		} else {
+			row.Rank = rank + i + 1
+		}
+	}
+
+	rows, info := pagination.Paginate(rows, page, func(row *leaderboardRow) []string {
+		return []string{pagination.FormatFloat(row.balanceKey), pagination.FormatInt(row.TasksCompleted),
+			row.ID, pagination.FormatInt(row.Rank)}
+	})
+	topUsers := make([]models.TopUser, len(rows))
+	for i, row := range rows {
+		topUsers[i] = row.TopUser
+	}
+	return topUsers, info, nil
+}
+
+// GetExistingEmails возвращает множество адресов из списка, уже занятых пользователями.
+// Адреса сравниваются без учета регистра; ключи результата - в нижнем регистре.
+func (r *UserRepository) GetExistingEmails(ctx context.Context, emails []string) (map[string]bool, error) {
+	r.store.mu.Lock()
+	defer r.store.mu.Unlock()
+
+	existing := make(map[string]bool)
+	for _, email := range emails {
+		for _, record := range r.store.state.users {
+			if record.user.DeletedAt == nil && strings.EqualFold(record.user.Email, email) {
+				existing[strings.ToLower(email)] = true
+			}
+		}
+	}
+	return existing, nil
+}
+
+// CopyUsers вставляет пользователей атомарно: при ошибке не сохраняется ни один
+func (r *UserRepository) CopyUsers(ctx context.Context, users []*models.User) error {
+	err := r.store.update(func(s *state) error {
+		for _, u := range users {
+			record := &userRecord{user: models.User{
+				ID:           u.ID,
+				Username:     u.Username,
+				Email:        u.Email,
+				Balance:      roundMoney(u.Balance),
+				ReferralCode: u.ReferralCode,
+				Bio:          u.Bio,
+				TimeZone:     u.TimeZone,
+				Status:       u.Status,
+				CreatedAt:    u.CreatedAt.UTC().Truncate(time.Microsecond),
+				UpdatedAt:    u.UpdatedAt.UTC().Truncate(time.Microsecond),
+			}, hasBalance: true}
+			if err := s.insertUser(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.NewInternal("failed to copy users", err)
+	}
+	return nil
+}
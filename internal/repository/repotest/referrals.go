@@ -0,0 +1,91 @@
+package repotest
+
+import (
+	"cmp"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+var referralCases = []contractCase{
+	{"CRUD", testReferralCRUD},
+	{"PagesByUser", testReferralsByUser},
+}
+
+func testReferralCRUD(t *testing.T, r Repositories) {
+	user := createUser(t, r, "alice")
+	created, err := r.Referrals.CreateReferral(user.ID, "CODE1")
+	requireNoError(t, err, "create referral")
+	if created.ReferralID == "" || created.UserID != user.ID || created.Code != "CODE1" || created.CreatedAt.IsZero() {
+		t.Fatalf("unexpected referral: %+v", created)
+	}
+
+	referral, err := r.Referrals.GetReferral(created.ReferralID)
+	requireNoError(t, err, "get referral")
+	if referral.Code != "CODE1" || !referral.CreatedAt.Equal(created.CreatedAt) {
+		t.Fatalf("unexpected stored referral: %+v", referral)
+	}
+
+	_, err = r.Referrals.CreateReferral(user.ID, "CODE1")
+	requireErrorType(t, err, errors.AlreadyExists)
+	other, err := r.Referrals.CreateReferral(user.ID, "CODE2")
+	requireNoError(t, err, "create referral")
+
+	updated, err := r.Referrals.UpdateReferral(created.ReferralID, "CODE3")
+	requireNoError(t, err, "update referral")
+	if updated.Code != "CODE3" || updated.ReferralID != created.ReferralID {
+		t.Fatalf("unexpected updated referral: %+v", updated)
+	}
+	_, err = r.Referrals.UpdateReferral(created.ReferralID, other.Code)
+	requireErrorType(t, err, errors.AlreadyExists)
+
+	requireNoError(t, r.Referrals.DeleteReferral(created.ReferralID), "delete referral")
+	_, err = r.Referrals.GetReferral(created.ReferralID)
+	requireErrorType(t, err, errors.NotFound)
+	requireErrorType(t, r.Referrals.DeleteReferral(created.ReferralID), errors.NotFound)
+	_, err = r.Referrals.UpdateReferral(created.ReferralID, "CODE4")
+	requireErrorType(t, err, errors.NotFound)
+	_, err = r.Referrals.GetReferral("not-a-number")
+	requireErrorType(t, err, errors.NotFound)
+}
+
+func testReferralsByUser(t *testing.T, r Repositories) {
+	alice := createUser(t, r, "alice")
+	bob := createUser(t, r, "bob")
+
+	var created []*models.Referral
+	for i := range 5 {
+		referral, err := r.Referrals.CreateReferral(alice.ID, "ALICE"+strconv.Itoa(i))
+		requireNoError(t, err, "create referral")
+		created = append(created, referral)
+	}
+	_, err := r.Referrals.CreateReferral(bob.ID, "BOB")
+	requireNoError(t, err, "create referral")
+
+	// От новых кодов к старым
+	slices.SortFunc(created, func(a, b *models.Referral) int {
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		ai, _ := strconv.Atoi(a.ReferralID)
+		bi, _ := strconv.Atoi(b.ReferralID)
+		return cmp.Compare(bi, ai)
+	})
+	want := make([]string, 0, len(created))
+	for _, referral := range created {
+		want = append(want, referral.ReferralID)
+	}
+
+	checkPages(t, want, 2, func(t *testing.T, page pagination.Page) ([]string, pagination.Info) {
+		referrals, info, err := r.Referrals.GetReferralsByUserID(alice.ID, page)
+		requireNoError(t, err, "get referrals page")
+		ids := make([]string, 0, len(referrals))
+		for _, referral := range referrals {
+			ids = append(ids, referral.ReferralID)
+		}
+		return ids, info
+	})
+}
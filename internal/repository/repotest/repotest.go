@@ -0,0 +1,172 @@
+// Package repotest содержит контракт репозиториев пользователей, задач и реферальных кодов - общий набор
+// тестов, который выполняется для каждой реализации: PostgreSQL (пакет database) и фейков в памяти
+// (пакет memory). Совпадение результатов гарантирует, что сервисы, проверенные на фейках, ведут себя так же
+// с настоящей базой.
+package repotest
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// Repositories - проверяемые репозитории. Они должны работать с общими данными: задачи начисляют
+// вознаграждения пользователям того же хранилища.
+type Repositories struct {
+	Users     repository.UserRepository
+	Tasks     repository.TaskRepository
+	Referrals repository.ReferralRepository
+}
+
+// Factory создает репозитории над пустым хранилищем; вызывается для каждого теста контракта
+type Factory func(t *testing.T) Repositories
+
+// contractCase - отдельная проверка контракта
+type contractCase struct {
+	name string
+	run  func(t *testing.T, r Repositories)
+}
+
+// Run выполняет контракт для репозиториев, создаваемых factory
+func Run(t *testing.T, factory Factory) {
+	groups := []struct {
+		name  string
+		cases []contractCase
+	}{
+		{"Users", userCases},
+		{"Tasks", taskCases},
+		{"Referrals", referralCases},
+	}
+	for _, group := range groups {
+		t.Run(group.name, func(t *testing.T) {
+			for _, c := range group.cases {
+				t.Run(c.name, func(t *testing.T) {
+					c.run(t, factory(t))
+				})
+			}
+		})
+	}
+}
+
+var ctx = context.Background()
+
+// requireErrorType проверяет, что err - ошибка приложения указанного типа
+func requireErrorType(t *testing.T, err error, errorType errors.ErrorType) {
+	t.Helper()
+	if !errors.IsErrorType(err, errorType) {
+		t.Fatalf("expected %s error, got %v", errorType, err)
+	}
+}
+
+// requireNoError прерывает тест при ошибке
+func requireNoError(t *testing.T, err error, action string) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: %v", action, err)
+	}
+}
+
+// createUser создает активного пользователя с уникальным адресом на основе имени
+func createUser(t *testing.T, r Repositories, username string) *models.User {
+	t.Helper()
+	user, err := r.Users.CreateUser(ctx, &models.User{
+		ID:       uuid.NewString(),
+		Username: username,
+		Email:    username + "@example.com",
+		Status:   models.Active,
+	})
+	requireNoError(t, err, "create user")
+	return user
+}
+
+// getUser возвращает сохраненное состояние пользователя
+func getUser(t *testing.T, r Repositories, id string) *models.User {
+	t.Helper()
+	user, err := r.Users.GetUserByID(ctx, uuid.MustParse(id))
+	requireNoError(t, err, "get user")
+	return user
+}
+
+// createTask создает задачу с единственным исполнителем; configure может изменить задачу перед сохранением
+func createTask(t *testing.T, r Repositories, title string, configure func(task *models.Task), assignees ...string) *models.Task {
+	t.Helper()
+	task := &models.Task{
+		TaskID:         uuid.NewString(),
+		Title:          title,
+		Description:    "description of " + title,
+		Status:         models.NotStarted,
+		Reward:         10,
+		AssignmentMode: models.AssignmentAssigned,
+	}
+	if configure != nil {
+		configure(task)
+	}
+	created, err := r.Tasks.CreateTask(ctx, task, assignees)
+	requireNoError(t, err, "create task")
+	return created
+}
+
+// getTask возвращает сохраненное состояние задачи
+func getTask(t *testing.T, r Repositories, id string) *models.Task {
+	t.Helper()
+	task, err := r.Tasks.GetTaskByID(ctx, uuid.MustParse(id))
+	requireNoError(t, err, "get task")
+	return task
+}
+
+// pageFetcher возвращает идентификаторы записей страницы и курсоры соседних страниц
+type pageFetcher func(t *testing.T, page pagination.Page) ([]string, pagination.Info)
+
+// checkPages проходит страницы размером limit вперед от первой, а затем назад от последней,
+// и проверяет, что записи идут в порядке want и страницы в обе стороны совпадают
+func checkPages(t *testing.T, want []string, limit int, fetch pageFetcher) {
+	t.Helper()
+
+	var pages [][]string
+	var got []string
+	page := pagination.Page{Limit: limit}
+	for {
+		ids, info := fetch(t, page)
+		pages = append(pages, ids)
+		got = append(got, ids...)
+		if info.NextCursor == "" {
+			break
+		}
+		if len(pages) > len(want)+1 {
+			t.Fatalf("pagination does not terminate: got %v", got)
+		}
+		page.Cursor = decodeCursor(t, info.NextCursor)
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("forward pages:\n got: %v\nwant: %v", got, want)
+	}
+
+	// Назад от последней страницы по курсорам prev
+	_, info := fetch(t, page)
+	for i := len(pages) - 2; i >= 0; i-- {
+		if info.PrevCursor == "" {
+			t.Fatalf("page %d has no previous cursor", i+1)
+		}
+		var ids []string
+		ids, info = fetch(t, pagination.Page{Limit: limit, Cursor: decodeCursor(t, info.PrevCursor)})
+		if !slices.Equal(ids, pages[i]) {
+			t.Fatalf("backward page %d:\n got: %v\nwant: %v", i, ids, pages[i])
+		}
+	}
+	if info.PrevCursor != "" && len(pages) > 1 {
+		t.Fatalf("first page reached backward has a previous cursor")
+	}
+}
+
+func decodeCursor(t *testing.T, encoded string) *pagination.Cursor {
+	t.Helper()
+	cursor, err := pagination.Decode(encoded)
+	requireNoError(t, err, "decode cursor")
+	return cursor
+}
@@ -0,0 +1,533 @@
+package repotest
+
+import (
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var taskCases = []contractCase{
+	{"CreateAndGet", testCreateAndGetTask},
+	{"Update", testUpdateTask},
+	{"ListFilterAndPages", testGetTasks},
+	{"StatusTransitionsAndRewards", testTaskStatusTransitions},
+	{"Submissions", testSubmissions},
+	{"Claims", testClaims},
+	{"Dependencies", testDependencies},
+	{"ExpiryAndReminders", testExpiryAndReminders},
+	{"DueDeadline", testDueDeadline},
+	{"SoftDeleteRestoreAndPurge", testSoftDeleteTask},
+	{"ExistingKeysAndCopy", testCopyTasks},
+}
+
+func testCreateAndGetTask(t *testing.T, r Repositories) {
+	created := createTask(t, r, "write report", nil)
+	if created.CreatedAt.IsZero() || created.DeletedAt != nil {
+		t.Fatalf("unexpected created task: %+v", created)
+	}
+
+	task := getTask(t, r, created.TaskID)
+	if task.Title != "write report" || task.Reward != 10 || task.Status != models.NotStarted ||
+		task.AssignmentMode != models.AssignmentAssigned || task.CompletedBy != nil {
+		t.Fatalf("unexpected task: %+v", task)
+	}
+
+	duplicate := *created
+	duplicate.TaskID = uuid.NewString()
+	_, err := r.Tasks.CreateTask(ctx, &duplicate, nil)
+	requireErrorType(t, err, errors.AlreadyExists)
+
+	_, err = r.Tasks.GetTaskByID(ctx, uuid.New())
+	requireErrorType(t, err, errors.NotFound)
+}
+
+func testUpdateTask(t *testing.T, r Repositories) {
+	other := createTask(t, r, "other", nil)
+	task := createTask(t, r, "write report", nil)
+
+	task.Title = "write a long report"
+	task.Reward = 25.5
+	updated, err := r.Tasks.UpdateTask(ctx, task)
+	requireNoError(t, err, "update task")
+	if updated.Title != "write a long report" || updated.Reward != 25.5 {
+		t.Fatalf("unexpected updated task: %+v", updated)
+	}
+	if stored := getTask(t, r, task.TaskID); stored.Title != "write a long report" || stored.Reward != 25.5 {
+		t.Fatalf("update is not stored: %+v", stored)
+	}
+
+	task.Title, task.Description = other.Title, other.Description
+	_, err = r.Tasks.UpdateTask(ctx, task)
+	requireErrorType(t, err, errors.AlreadyExists)
+
+	missing := *task
+	missing.TaskID = uuid.NewString()
+	missing.Title = "missing"
+	_, err = r.Tasks.UpdateTask(ctx, &missing)
+	requireErrorType(t, err, errors.NotFound)
+}
+
+// taskIDs возвращает идентификаторы задач
+func taskIDs(tasks []models.Task) []string {
+	ids := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.TaskID)
+	}
+	return ids
+}
+
+// compareTasks сравнивает задачи в порядке выборки: от новых к старым
+func compareTasks(a, b *models.Task) int {
+	if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+		return c
+	}
+	return strings.Compare(b.TaskID, a.TaskID)
+}
+
+func testGetTasks(t *testing.T, r Repositories) {
+	var created []*models.Task
+	for _, title := range []string{"monthly report", "weekly report", "call client", "yearly report", "fix bug"} {
+		created = append(created, createTask(t, r, title, nil))
+	}
+	slices.SortFunc(created, compareTasks)
+	var want []string
+	for _, task := range created {
+		want = append(want, task.TaskID)
+	}
+
+	checkPages(t, want, 2, func(t *testing.T, page pagination.Page) ([]string, pagination.Info) {
+		response, err := r.Tasks.GetTasks(ctx, &models.TaskFilter{Pagination: &page})
+		requireNoError(t, err, "get tasks page")
+		return taskIDs(response.Tasks), response.Info
+	})
+
+	response, err := r.Tasks.GetTasks(ctx, &models.TaskFilter{Title: "REPORT", Page: 1, PageSize: 2})
+	requireNoError(t, err, "get tasks")
+	if response.TotalItems != 3 || response.TotalPages != 2 || len(response.Tasks) != 2 {
+		t.Fatalf("unexpected first page: %d tasks of %d, %d pages", len(response.Tasks), response.TotalItems, response.TotalPages)
+	}
+	response, err = r.Tasks.GetTasks(ctx, &models.TaskFilter{Title: "REPORT", Page: 2, PageSize: 2})
+	requireNoError(t, err, "get tasks")
+	if len(response.Tasks) != 1 {
+		t.Fatalf("last page has %d tasks, want 1", len(response.Tasks))
+	}
+	_, err = r.Tasks.GetTasks(ctx, &models.TaskFilter{Title: "REPORT", Page: 3, PageSize: 2})
+	requireErrorType(t, err, errors.NotFound)
+
+	user := createUser(t, r, "alice")
+	_, _, err = r.Tasks.UpdateTaskStatus(ctx, want[0], int(models.InProgress), uuid.MustParse(user.ID), "test", time.Time{})
+	requireNoError(t, err, "start task")
+	response, err = r.Tasks.GetTasks(ctx, &models.TaskFilter{Statuses: []models.TaskStatus{models.InProgress}})
+	requireNoError(t, err, "get tasks by status")
+	if got := taskIDs(response.Tasks); !slices.Equal(got, want[:1]) {
+		t.Fatalf("tasks in progress: got %v, want %v", got, want[:1])
+	}
+}
+
+func testTaskStatusTransitions(t *testing.T, r Repositories) {
+	user := createUser(t, r, "alice")
+	userID := uuid.MustParse(user.ID)
+	task := createTask(t, r, "write report", nil)
+
+	var change *models.BalanceUpdate
+	transition := func(status models.TaskStatus) (*models.Task, error) {
+		updated, balance, err := r.Tasks.UpdateTaskStatus(ctx, task.TaskID, int(status), userID, "tester", time.Time{})
+		change = balance
+		return updated, err
+	}
+	_, err := transition(models.InProgress)
+	requireNoError(t, err, "start task")
+	if change != nil {
+		t.Fatalf("starting a task changed the balance: %+v", change)
+	}
+	// Переход в текущий статус ничего не меняет
+	_, err = transition(models.InProgress)
+	requireNoError(t, err, "repeat transition")
+
+	completed, err := transition(models.Completed)
+	requireNoError(t, err, "complete task")
+	if completed.Status != models.Completed || completed.CompletedBy == nil || *completed.CompletedBy != user.ID {
+		t.Fatalf("unexpected completed task: %+v", completed)
+	}
+	if stored := getUser(t, r, user.ID); stored.Balance != 10 || stored.TasksCompleted != 1 {
+		t.Fatalf("reward is not credited: balance %v, tasks completed %d", stored.Balance, stored.TasksCompleted)
+	}
+	if want := (models.BalanceUpdate{UserID: user.ID, Balance: 10, Delta: 10}); change == nil || *change != want {
+		t.Fatalf("reward change %+v, want %+v", change, want)
+	}
+
+	reopened, err := transition(models.InProgress)
+	requireNoError(t, err, "reopen task")
+	if reopened.CompletedBy != nil {
+		t.Fatalf("reopened task is still completed by %s", *reopened.CompletedBy)
+	}
+	if stored := getUser(t, r, user.ID); stored.Balance != 0 || stored.TasksCompleted != 0 {
+		t.Fatalf("reward is not reversed: balance %v, tasks completed %d", stored.Balance, stored.TasksCompleted)
+	}
+	if want := (models.BalanceUpdate{UserID: user.ID, Balance: 0, Delta: -10}); change == nil || *change != want {
+		t.Fatalf("reversal change %+v, want %+v", change, want)
+	}
+
+	_, err = transition(models.Completed)
+	requireNoError(t, err, "complete task again")
+	_, err = transition(models.Canceled)
+	requireErrorType(t, err, errors.Validation)
+
+	history, err := r.Tasks.GetTaskStatusHistory(ctx, uuid.MustParse(task.TaskID))
+	requireNoError(t, err, "get status history")
+	wantStatuses := []models.TaskStatus{models.InProgress, models.Completed, models.InProgress, models.Completed}
+	wantDeltas := []float64{0, 10, -10, 10}
+	if len(history) != len(wantStatuses) {
+		t.Fatalf("history has %d entries, want %d: %+v", len(history), len(wantStatuses), history)
+	}
+	for i, change := range history {
+		if change.ToStatus != wantStatuses[i] || change.RewardDelta != wantDeltas[i] || change.Actor != "tester" {
+			t.Fatalf("history entry %d: %+v", i, change)
+		}
+		if change.UserID == nil || *change.UserID != user.ID {
+			t.Fatalf("history entry %d has user %v", i, change.UserID)
+		}
+	}
+
+	_, _, err = r.Tasks.UpdateTaskStatus(ctx, uuid.NewString(), int(models.InProgress), userID, "tester", time.Time{})
+	requireErrorType(t, err, errors.NotFound)
+}
+
+func testSubmissions(t *testing.T, r Repositories) {
+	user := createUser(t, r, "alice")
+	withEvidence := func(task *models.Task) { task.RequiresEvidence = true }
+	task := createTask(t, r, "publish post", withEvidence)
+
+	_, _, err := r.Tasks.UpdateTaskStatus(ctx, task.TaskID, int(models.Completed), uuid.MustParse(user.ID), "tester", time.Time{})
+	requireErrorType(t, err, errors.Conflict)
+
+	submit := func(taskID string) (*models.Submission, error) {
+		return r.Tasks.CreateSubmission(ctx, &models.Submission{
+			TaskID:       taskID,
+			UserID:       user.ID,
+			EvidenceType: models.EvidenceURL,
+			Evidence:     "https://example.com/post",
+		}, time.Time{})
+	}
+	submission, err := submit(task.TaskID)
+	requireNoError(t, err, "create submission")
+	if submission.ID == 0 || submission.Status != models.SubmissionPending {
+		t.Fatalf("unexpected submission: %+v", submission)
+	}
+	_, err = submit(task.TaskID)
+	requireErrorType(t, err, errors.AlreadyExists)
+
+	pending, err := r.Tasks.GetSubmissions(ctx, &models.SubmissionFilter{Status: models.SubmissionPending, Limit: 10})
+	requireNoError(t, err, "get submissions")
+	if len(pending) != 1 || pending[0].ID != submission.ID {
+		t.Fatalf("unexpected pending submissions: %+v", pending)
+	}
+
+	approved, change, err := r.Tasks.ApproveSubmission(ctx, submission.ID, "looks good", "moderator")
+	requireNoError(t, err, "approve submission")
+	if approved.Status != models.SubmissionApproved || approved.ReviewedBy == nil || *approved.ReviewedBy != "moderator" {
+		t.Fatalf("unexpected approved submission: %+v", approved)
+	}
+	if stored := getTask(t, r, task.TaskID); stored.Status != models.Completed {
+		t.Fatalf("approved task has status %s", stored.Status)
+	}
+	if stored := getUser(t, r, user.ID); stored.Balance != 10 {
+		t.Fatalf("approval credited %v, want 10", stored.Balance)
+	}
+	if change == nil || change.UserID != user.ID || change.Balance != 10 || change.Delta != 10 {
+		t.Fatalf("unexpected approval balance change: %+v", change)
+	}
+	_, _, err = r.Tasks.ApproveSubmission(ctx, submission.ID, "", "moderator")
+	requireErrorType(t, err, errors.Conflict)
+	_, err = submit(task.TaskID)
+	requireErrorType(t, err, errors.Conflict)
+
+	other := createTask(t, r, "record video", withEvidence)
+	submission, err = submit(other.TaskID)
+	requireNoError(t, err, "create submission")
+	rejected, err := r.Tasks.RejectSubmission(ctx, submission.ID, "no video", "moderator")
+	requireNoError(t, err, "reject submission")
+	if rejected.Status != models.SubmissionRejected || rejected.Reason != "no video" {
+		t.Fatalf("unexpected rejected submission: %+v", rejected)
+	}
+	if stored := getTask(t, r, other.TaskID); stored.Status != models.NotStarted {
+		t.Fatalf("rejected task has status %s", stored.Status)
+	}
+
+	_, err = r.Tasks.RejectSubmission(ctx, submission.ID+100, "", "moderator")
+	requireErrorType(t, err, errors.NotFound)
+}
+
+func testClaims(t *testing.T, r Repositories) {
+	alice := createUser(t, r, "alice")
+	bob := createUser(t, r, "bob")
+	open := createTask(t, r, "open task", func(task *models.Task) { task.AssignmentMode = models.AssignmentOpen })
+	maxClaims := 1
+	capped := createTask(t, r, "capped task", func(task *models.Task) {
+		task.AssignmentMode = models.AssignmentCapped
+		task.MaxClaims = &maxClaims
+	})
+	assigned := createTask(t, r, "assigned task", nil, bob.ID)
+
+	claim, err := r.Tasks.ClaimTask(ctx, open.TaskID, alice.ID, time.Time{})
+	requireNoError(t, err, "claim open task")
+	if claim.Progress != models.InProgress || claim.UserID != alice.ID {
+		t.Fatalf("unexpected claim: %+v", claim)
+	}
+	again, err := r.Tasks.ClaimTask(ctx, open.TaskID, alice.ID, time.Time{})
+	requireNoError(t, err, "claim open task again")
+	if !again.ClaimedAt.Equal(claim.ClaimedAt) {
+		t.Fatalf("repeated claim changed claim time")
+	}
+
+	_, err = r.Tasks.ClaimTask(ctx, capped.TaskID, alice.ID, time.Time{})
+	requireNoError(t, err, "claim capped task")
+	_, err = r.Tasks.ClaimTask(ctx, capped.TaskID, bob.ID, time.Time{})
+	requireErrorType(t, err, errors.Conflict)
+	_, err = r.Tasks.ClaimTask(ctx, assigned.TaskID, alice.ID, time.Time{})
+	requireErrorType(t, err, errors.Forbidden)
+	_, err = r.Tasks.ClaimTask(ctx, open.TaskID, uuid.NewString(), time.Time{})
+	requireErrorType(t, err, errors.NotFound)
+
+	// Выполнение открытой задачи меняет только личный прогресс взявшего ее пользователя
+	_, _, err = r.Tasks.UpdateTaskStatus(ctx, open.TaskID, int(models.Completed), uuid.MustParse(alice.ID), "tester", time.Time{})
+	requireNoError(t, err, "complete claimed task")
+	if stored := getTask(t, r, open.TaskID); stored.Status != models.NotStarted {
+		t.Fatalf("shared task status changed to %s", stored.Status)
+	}
+	if stored := getUser(t, r, alice.ID); stored.Balance != 10 || stored.TasksCompleted != 1 {
+		t.Fatalf("reward is not credited: balance %v, tasks completed %d", stored.Balance, stored.TasksCompleted)
+	}
+	_, _, err = r.Tasks.UpdateTaskStatus(ctx, open.TaskID, int(models.Completed), uuid.MustParse(bob.ID), "tester", time.Time{})
+	requireErrorType(t, err, errors.Forbidden)
+	_, _, err = r.Tasks.UpdateTaskStatus(ctx, assigned.TaskID, int(models.InProgress), uuid.MustParse(alice.ID), "tester", time.Time{})
+	requireErrorType(t, err, errors.Forbidden)
+
+	tasks, err := r.Tasks.GetUserTasks(ctx, alice.ID, 0)
+	requireNoError(t, err, "get user tasks")
+	progress := make(map[string]models.TaskStatus)
+	for _, task := range tasks {
+		progress[task.TaskID] = task.Progress
+	}
+	wantProgress := map[string]models.TaskStatus{open.TaskID: models.Completed, capped.TaskID: models.InProgress}
+	if !maps.Equal(progress, wantProgress) {
+		t.Fatalf("user tasks progress: got %v, want %v", progress, wantProgress)
+	}
+	tasks, err = r.Tasks.GetUserTasks(ctx, bob.ID, models.NotStarted)
+	requireNoError(t, err, "get user tasks")
+	if got := len(tasks); got != 1 || tasks[0].TaskID != assigned.TaskID {
+		t.Fatalf("unexpected tasks of an assigned user: %+v", tasks)
+	}
+
+	completed, err := r.Tasks.GetCompletedTaskIDs(ctx, alice.ID, []string{open.TaskID, capped.TaskID})
+	requireNoError(t, err, "get completed tasks")
+	if want := map[string]bool{open.TaskID: true}; !maps.Equal(completed, want) {
+		t.Fatalf("completed tasks: got %v, want %v", completed, want)
+	}
+}
+
+func testDependencies(t *testing.T, r Repositories) {
+	a := createTask(t, r, "a", nil)
+	b := createTask(t, r, "b", nil)
+	c := createTask(t, r, "c", nil)
+
+	requireNoError(t, r.Tasks.AddTaskDependencies(ctx, a.TaskID, []string{b.TaskID}), "add dependency")
+	requireNoError(t, r.Tasks.AddTaskDependencies(ctx, b.TaskID, []string{c.TaskID}), "add dependency")
+	requireErrorType(t, r.Tasks.AddTaskDependencies(ctx, c.TaskID, []string{a.TaskID}), errors.Validation)
+	requireErrorType(t, r.Tasks.AddTaskDependencies(ctx, a.TaskID, []string{a.TaskID}), errors.Validation)
+	requireErrorType(t, r.Tasks.AddTaskDependencies(ctx, a.TaskID, []string{uuid.NewString()}), errors.NotFound)
+
+	dependencies, err := r.Tasks.GetTaskDependencies(ctx, []string{a.TaskID, b.TaskID, c.TaskID})
+	requireNoError(t, err, "get dependencies")
+	want := map[string][]string{a.TaskID: {b.TaskID}, b.TaskID: {c.TaskID}}
+	if !maps.EqualFunc(dependencies, want, slices.Equal) {
+		t.Fatalf("dependencies: got %v, want %v", dependencies, want)
+	}
+
+	requireNoError(t, r.Tasks.RemoveTaskDependency(ctx, a.TaskID, b.TaskID), "remove dependency")
+	requireErrorType(t, r.Tasks.RemoveTaskDependency(ctx, a.TaskID, b.TaskID), errors.NotFound)
+	// После удаления ребра обратная зависимость больше не замыкает цикл
+	requireNoError(t, r.Tasks.AddTaskDependencies(ctx, c.TaskID, []string{a.TaskID}), "add dependency")
+}
+
+// Срок проверяется под блокировкой задачи: после него задачу нельзя взять, начать, выполнить
+// или отправить по ней заявку, но заявку, отправленную до срока, можно одобрить
+func testDueDeadline(t *testing.T, r Repositories) {
+	user := createUser(t, r, "alice")
+	userID := uuid.MustParse(user.ID)
+	due := time.Now().Add(-time.Hour)
+	before, after := due.Add(-time.Minute), due.Add(time.Minute)
+	pastDue := func(task *models.Task) { task.DueDate = &due }
+	task := createTask(t, r, "past due", pastDue)
+	open := createTask(t, r, "open past due", func(task *models.Task) {
+		pastDue(task)
+		task.AssignmentMode = models.AssignmentOpen
+	})
+	moderated := createTask(t, r, "moderated past due", func(task *models.Task) {
+		pastDue(task)
+		task.RequiresEvidence = true
+	})
+
+	_, _, err := r.Tasks.UpdateTaskStatus(ctx, task.TaskID, int(models.InProgress), userID, "tester", after)
+	requireErrorType(t, err, errors.Conflict)
+	_, _, err = r.Tasks.UpdateTaskStatus(ctx, task.TaskID, int(models.Completed), userID, "tester", after)
+	requireErrorType(t, err, errors.Conflict)
+	_, err = r.Tasks.ClaimTask(ctx, open.TaskID, user.ID, after)
+	requireErrorType(t, err, errors.Conflict)
+	submission := &models.Submission{TaskID: moderated.TaskID, UserID: user.ID, EvidenceType: models.EvidenceText, Evidence: "done"}
+	_, err = r.Tasks.CreateSubmission(ctx, submission, after)
+	requireErrorType(t, err, errors.Conflict)
+	if stored := getUser(t, r, user.ID); stored.Balance != 0 || stored.TasksCompleted != 0 {
+		t.Fatalf("past due task is credited: balance %v, tasks completed %d", stored.Balance, stored.TasksCompleted)
+	}
+
+	// До истечения отсрочки задача еще доступна
+	_, _, err = r.Tasks.UpdateTaskStatus(ctx, task.TaskID, int(models.Completed), userID, "tester", before)
+	requireNoError(t, err, "complete task within grace")
+	_, err = r.Tasks.ClaimTask(ctx, open.TaskID, user.ID, before)
+	requireNoError(t, err, "claim task within grace")
+	created, err := r.Tasks.CreateSubmission(ctx, submission, before)
+	requireNoError(t, err, "submit within grace")
+
+	// Повторное открытие выполненной задачи после срока тоже запрещено
+	_, _, err = r.Tasks.UpdateTaskStatus(ctx, task.TaskID, int(models.InProgress), userID, "tester", after)
+	requireErrorType(t, err, errors.Conflict)
+
+	_, change, err := r.Tasks.ApproveSubmission(ctx, created.ID, "", "moderator")
+	requireNoError(t, err, "approve submission after the due date")
+	if change == nil || change.Delta != 10 {
+		t.Fatalf("unexpected approval balance change: %+v", change)
+	}
+}
+
+func testExpiryAndReminders(t *testing.T, r Repositories) {
+	user := createUser(t, r, "alice")
+	now := time.Now()
+	dueSoon := now.Add(time.Hour)
+	dueLater := now.Add(48 * time.Hour)
+	soon := createTask(t, r, "due soon", func(task *models.Task) {
+		task.DueDate = &dueSoon
+		task.AssigneeID = &user.ID
+	})
+	later := createTask(t, r, "due later", func(task *models.Task) {
+		task.DueDate = &dueLater
+		task.AssigneeID = &user.ID
+	})
+
+	reminders, err := r.Tasks.GetDueReminders(ctx, now, now.Add(2*time.Hour), 10)
+	requireNoError(t, err, "get due reminders")
+	if len(reminders) != 1 || reminders[0].TaskID != soon.TaskID || reminders[0].UserID != user.ID ||
+		reminders[0].Email != user.Email || !reminders[0].DueDate.Equal(dueSoon.Truncate(time.Microsecond)) {
+		t.Fatalf("unexpected reminders: %+v", reminders)
+	}
+	requireNoError(t, r.Tasks.MarkDueReminderSent(ctx, soon.TaskID, user.ID), "mark reminder sent")
+	reminders, err = r.Tasks.GetDueReminders(ctx, now, now.Add(2*time.Hour), 10)
+	requireNoError(t, err, "get due reminders")
+	if len(reminders) != 0 {
+		t.Fatalf("sent reminder is returned again: %+v", reminders)
+	}
+
+	expired, err := r.Tasks.ExpireOverdueTasks(ctx, now.Add(2*time.Hour), "scheduler")
+	requireNoError(t, err, "expire tasks")
+	if expired != 1 {
+		t.Fatalf("expired %d tasks, want 1", expired)
+	}
+	if stored := getTask(t, r, soon.TaskID); stored.Status != models.Expired {
+		t.Fatalf("overdue task has status %s", stored.Status)
+	}
+	if stored := getTask(t, r, later.TaskID); stored.Status != models.NotStarted {
+		t.Fatalf("task with a later due date has status %s", stored.Status)
+	}
+	history, err := r.Tasks.GetTaskStatusHistory(ctx, uuid.MustParse(soon.TaskID))
+	requireNoError(t, err, "get status history")
+	if len(history) != 1 || history[0].ToStatus != models.Expired || history[0].Actor != "scheduler" || history[0].UserID != nil {
+		t.Fatalf("unexpected expiry history: %+v", history)
+	}
+
+	expired, err = r.Tasks.ExpireOverdueTasks(ctx, now.Add(2*time.Hour), "scheduler")
+	requireNoError(t, err, "expire tasks again")
+	if expired != 0 {
+		t.Fatalf("expired %d tasks again", expired)
+	}
+}
+
+func testSoftDeleteTask(t *testing.T, r Repositories) {
+	kept := createTask(t, r, "kept", nil)
+	task := createTask(t, r, "deleted", nil)
+	id := uuid.MustParse(task.TaskID)
+
+	requireNoError(t, r.Tasks.DeleteTask(ctx, id), "delete task")
+	_, err := r.Tasks.GetTaskByID(ctx, id)
+	requireErrorType(t, err, errors.NotFound)
+	requireErrorType(t, r.Tasks.DeleteTask(ctx, id), errors.NotFound)
+
+	response, err := r.Tasks.GetTasks(ctx, &models.TaskFilter{})
+	requireNoError(t, err, "get tasks")
+	if got := taskIDs(response.Tasks); !slices.Equal(got, []string{kept.TaskID}) {
+		t.Fatalf("tasks without deleted: got %v", got)
+	}
+	response, err = r.Tasks.GetTasks(ctx, &models.TaskFilter{IncludeDeleted: true})
+	requireNoError(t, err, "get tasks")
+	if got := len(response.Tasks); got != 2 {
+		t.Fatalf("got %d tasks with deleted, want 2", got)
+	}
+
+	restored, err := r.Tasks.RestoreTask(ctx, id)
+	requireNoError(t, err, "restore task")
+	if restored.DeletedAt != nil {
+		t.Fatalf("restored task has deletion time %v", restored.DeletedAt)
+	}
+	_, err = r.Tasks.RestoreTask(ctx, id)
+	requireErrorType(t, err, errors.NotFound)
+
+	requireNoError(t, r.Tasks.DeleteTask(ctx, id), "delete task")
+	count, err := r.Tasks.PurgeDeletedTasks(ctx, time.Now().Add(time.Hour))
+	requireNoError(t, err, "purge tasks")
+	if count != 1 {
+		t.Fatalf("purged %d tasks, want 1", count)
+	}
+	_, err = r.Tasks.RestoreTask(ctx, id)
+	requireErrorType(t, err, errors.NotFound)
+	getTask(t, r, kept.TaskID)
+}
+
+func testCopyTasks(t *testing.T, r Repositories) {
+	existing := createTask(t, r, "report", nil)
+	now := time.Now()
+	copied := []*models.Task{
+		{TaskID: uuid.NewString(), Title: "report", Description: "another report", Status: models.NotStarted, Reward: 5, CreatedAt: now, UpdatedAt: now},
+		{TaskID: uuid.NewString(), Title: "review", Description: "review code", Status: models.NotStarted, Reward: 7, CreatedAt: now, UpdatedAt: now},
+	}
+	requireNoError(t, r.Tasks.CopyTasks(ctx, copied), "copy tasks")
+	if task := getTask(t, r, copied[1].TaskID); task.Reward != 7 || task.AssignmentMode != models.AssignmentAssigned {
+		t.Fatalf("unexpected copied task: %+v", task)
+	}
+
+	keys, err := r.Tasks.GetExistingTaskKeys(ctx, []string{"report", "missing"})
+	requireNoError(t, err, "get existing task keys")
+	want := map[models.TaskKey]bool{
+		{Title: "report", Description: existing.Description}: true,
+		{Title: "report", Description: "another report"}:     true,
+	}
+	if !maps.Equal(keys, want) {
+		t.Fatalf("existing task keys: got %v, want %v", keys, want)
+	}
+
+	// Пакет с занятым идентификатором не вставляется целиком
+	conflicting := []*models.Task{
+		{TaskID: uuid.NewString(), Title: "new", Description: "new task", Status: models.NotStarted, CreatedAt: now, UpdatedAt: now},
+		{TaskID: existing.TaskID, Title: "clash", Description: "clash", Status: models.NotStarted, CreatedAt: now, UpdatedAt: now},
+	}
+	if err := r.Tasks.CopyTasks(ctx, conflicting); err == nil {
+		t.Fatalf("task with a taken id was copied")
+	}
+	_, err = r.Tasks.GetTaskByID(ctx, uuid.MustParse(conflicting[0].TaskID))
+	requireErrorType(t, err, errors.NotFound)
+}
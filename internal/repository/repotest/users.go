@@ -0,0 +1,365 @@
+package repotest
+
+import (
+	"database/sql"
+	stderrors "errors"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
+	"maps"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var userCases = []contractCase{
+	{"CreateAndGet", testCreateAndGetUser},
+	{"EmailUniqueAmongActiveUsers", testUserEmailUniqueness},
+	{"Update", testUpdateUser},
+	{"SoftDeleteAndRestore", testSoftDeleteUser},
+	{"Purge", testPurgeUsers},
+	{"Balance", testUserBalance},
+	{"TransactionRollback", testUserTransaction},
+	{"ListFilterSortAndPages", testGetUsers},
+	{"ByStatus", testUsersByStatus},
+	{"Leaderboard", testLeaderboard},
+	{"ExistingEmailsAndCopy", testCopyUsers},
+}
+
+func testCreateAndGetUser(t *testing.T, r Repositories) {
+	created := createUser(t, r, "alice")
+	if created.CreatedAt.IsZero() {
+		t.Fatalf("created user has no creation time")
+	}
+
+	user := getUser(t, r, created.ID)
+	if user.Username != "alice" || user.Email != "alice@example.com" || user.Status != models.Active {
+		t.Fatalf("unexpected user: %+v", user)
+	}
+	if user.Balance != 0 || user.DeletedAt != nil {
+		t.Fatalf("new user has balance %v and deletion time %v", user.Balance, user.DeletedAt)
+	}
+
+	byEmail, err := r.Users.GetUserByEmail(ctx, "alice@example.com")
+	requireNoError(t, err, "get user by email")
+	if byEmail.ID != created.ID {
+		t.Fatalf("GetUserByEmail returned %s, want %s", byEmail.ID, created.ID)
+	}
+
+	if _, err := r.Users.GetUserByID(ctx, uuid.New()); !stderrors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetUserByID of unknown user: expected sql.ErrNoRows, got %v", err)
+	}
+	if _, err := r.Users.GetUserByEmail(ctx, "nobody@example.com"); !stderrors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("GetUserByEmail of unknown user: expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func testUserEmailUniqueness(t *testing.T, r Repositories) {
+	first := createUser(t, r, "alice")
+	duplicate := &models.User{ID: uuid.NewString(), Username: "alice2", Email: first.Email, Status: models.Active}
+	if _, err := r.Users.CreateUser(ctx, duplicate); err == nil {
+		t.Fatalf("user with a taken email was created")
+	}
+
+	// Адрес удаленного пользователя освобождается, но его восстановление становится конфликтом
+	requireNoError(t, r.Users.DeleteUser(ctx, uuid.MustParse(first.ID)), "delete user")
+	_, err := r.Users.CreateUser(ctx, duplicate)
+	requireNoError(t, err, "create user with a released email")
+	_, err = r.Users.RestoreUser(ctx, uuid.MustParse(first.ID))
+	requireErrorType(t, err, errors.AlreadyExists)
+}
+
+func testUpdateUser(t *testing.T, r Repositories) {
+	user := getUser(t, r, createUser(t, r, "alice").ID)
+	user.Username = "alice_updated"
+	user.Bio = "bio"
+	user.TimeZone = "Europe/Moscow"
+	user.Balance = 12.5
+	user.ReferralCode = "REF1"
+	updated, err := r.Users.UpdateUser(ctx, user)
+	requireNoError(t, err, "update user")
+	if updated.Username != "alice_updated" || updated.Balance != 12.5 {
+		t.Fatalf("unexpected updated user: %+v", updated)
+	}
+
+	stored := getUser(t, r, user.ID)
+	if stored.Username != "alice_updated" || stored.Bio != "bio" || stored.TimeZone != "Europe/Moscow" ||
+		stored.Balance != 12.5 || stored.ReferralCode != "REF1" {
+		t.Fatalf("update is not stored: %+v", stored)
+	}
+
+	stored.Balance = -1
+	if _, err := r.Users.UpdateUser(ctx, stored); err == nil {
+		t.Fatalf("negative balance was stored")
+	}
+	if balance := getUser(t, r, user.ID).Balance; balance != 12.5 {
+		t.Fatalf("failed update changed balance to %v", balance)
+	}
+
+	missing := *user
+	missing.ID = uuid.NewString()
+	if _, err := r.Users.UpdateUser(ctx, &missing); !stderrors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("UpdateUser of unknown user: expected sql.ErrNoRows, got %v", err)
+	}
+}
+
+func testSoftDeleteUser(t *testing.T, r Repositories) {
+	user := createUser(t, r, "alice")
+	id := uuid.MustParse(user.ID)
+	requireNoError(t, r.Users.DeleteUser(ctx, id), "delete user")
+
+	if _, err := r.Users.GetUserByID(ctx, id); !stderrors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("deleted user is still returned: %v", err)
+	}
+	requireErrorType(t, r.Users.DeleteUser(ctx, id), errors.NotFound)
+
+	if ids := listUserIDs(t, r, &models.UserFilter{}); len(ids) != 0 {
+		t.Fatalf("deleted user is listed: %v", ids)
+	}
+	if ids := listUserIDs(t, r, &models.UserFilter{IncludeDeleted: true}); !slices.Equal(ids, []string{user.ID}) {
+		t.Fatalf("deleted user is not listed with IncludeDeleted: %v", ids)
+	}
+
+	restored, err := r.Users.RestoreUser(ctx, id)
+	requireNoError(t, err, "restore user")
+	if restored.DeletedAt != nil {
+		t.Fatalf("restored user has deletion time %v", restored.DeletedAt)
+	}
+	getUser(t, r, user.ID)
+
+	_, err = r.Users.RestoreUser(ctx, id)
+	requireErrorType(t, err, errors.NotFound)
+}
+
+func testPurgeUsers(t *testing.T, r Repositories) {
+	kept := createUser(t, r, "alice")
+	purged := createUser(t, r, "bob")
+	requireNoError(t, r.Users.DeleteUser(ctx, uuid.MustParse(purged.ID)), "delete user")
+
+	count, err := r.Users.PurgeDeletedUsers(ctx, time.Now().Add(-time.Hour))
+	requireNoError(t, err, "purge users")
+	if count != 0 {
+		t.Fatalf("purged %d users deleted after the threshold", count)
+	}
+
+	count, err = r.Users.PurgeDeletedUsers(ctx, time.Now().Add(time.Hour))
+	requireNoError(t, err, "purge users")
+	if count != 1 {
+		t.Fatalf("purged %d users, want 1", count)
+	}
+	_, err = r.Users.RestoreUser(ctx, uuid.MustParse(purged.ID))
+	requireErrorType(t, err, errors.NotFound)
+	getUser(t, r, kept.ID)
+}
+
+func testUserBalance(t *testing.T, r Repositories) {
+	user := createUser(t, r, "alice")
+	id := uuid.MustParse(user.ID)
+	requireNoError(t, r.Users.UpdateBalance(ctx, id, 10.25), "update balance")
+	requireNoError(t, r.Users.UpdateBalance(ctx, id, 5), "update balance")
+	requireNoError(t, r.Users.WithTransaction(ctx, func(tx *sql.Tx) error {
+		return r.Users.UpdateBalanceAndReferralsTx(ctx, tx, id, 1.5, 1)
+	}), "update balance and referrals")
+
+	summary, err := r.Users.GetUserSummary(ctx, id)
+	requireNoError(t, err, "get user summary")
+	if summary.Balance != 16.75 || summary.Referrals != 1 || summary.Username != "alice" {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+
+	// Изменение баланса несуществующего пользователя не считается ошибкой
+	requireNoError(t, r.Users.UpdateBalance(ctx, uuid.New(), 1), "update balance of unknown user")
+}
+
+func testUserTransaction(t *testing.T, r Repositories) {
+	rollback := stderrors.New("rollback")
+	rolledBack := &models.User{ID: uuid.NewString(), Username: "alice", Email: "alice@example.com", Status: models.Active}
+	err := r.Users.WithTransaction(ctx, func(tx *sql.Tx) error {
+		if _, err := r.Users.CreateUserTx(ctx, tx, rolledBack); err != nil {
+			return err
+		}
+		return rollback
+	})
+	if !stderrors.Is(err, rollback) {
+		t.Fatalf("WithTransaction returned %v, want the error of the function", err)
+	}
+	if _, err := r.Users.GetUserByID(ctx, uuid.MustParse(rolledBack.ID)); !stderrors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("user created in a rolled back transaction exists: %v", err)
+	}
+
+	committed := &models.User{ID: uuid.NewString(), Username: "bob", Email: "bob@example.com", Status: models.Active}
+	requireNoError(t, r.Users.WithTransaction(ctx, func(tx *sql.Tx) error {
+		if _, err := r.Users.CreateUserTx(ctx, tx, committed); err != nil {
+			return err
+		}
+		return r.Users.UpdateBalanceAndReferralsTx(ctx, tx, uuid.MustParse(committed.ID), 3, 2)
+	}), "commit transaction")
+	if user := getUser(t, r, committed.ID); user.Balance != 3 || user.Referrals != 2 {
+		t.Fatalf("committed changes are not stored: %+v", user)
+	}
+}
+
+// listUserIDs возвращает идентификаторы пользователей первой страницы выборки
+func listUserIDs(t *testing.T, r Repositories, filter *models.UserFilter) []string {
+	t.Helper()
+	response, err := r.Users.GetUsers(ctx, filter)
+	requireNoError(t, err, "get users")
+	ids := make([]string, 0, len(response.Users))
+	for _, user := range response.Users {
+		ids = append(ids, user.ID)
+	}
+	return ids
+}
+
+func testGetUsers(t *testing.T, r Repositories) {
+	balances := map[string]float64{"alice": 10, "bob": 20, "carol": 30, "dave": 40, "erin": 50}
+	ids := make(map[string]string)
+	for _, name := range []string{"alice", "bob", "carol", "dave", "erin"} {
+		user := &models.User{ID: uuid.NewString(), Username: name, Email: name + "@example.com", Status: models.Active}
+		if name == "dave" || name == "erin" {
+			user.Email = name + "@other.org"
+		}
+		_, err := r.Users.CreateUser(ctx, user)
+		requireNoError(t, err, "create user")
+		requireNoError(t, r.Users.UpdateBalance(ctx, uuid.MustParse(user.ID), balances[name]), "update balance")
+		ids[name] = user.ID
+	}
+
+	sortByBalance := []models.UserSort{{Field: models.UserSortBalance, Desc: true}}
+	want := []string{ids["erin"], ids["dave"], ids["carol"], ids["bob"], ids["alice"]}
+	checkPages(t, want, 2, func(t *testing.T, page pagination.Page) ([]string, pagination.Info) {
+		response, err := r.Users.GetUsers(ctx, &models.UserFilter{Sort: sortByBalance, Pagination: page})
+		requireNoError(t, err, "get users page")
+		pageIDs := make([]string, 0, len(response.Users))
+		for _, user := range response.Users {
+			pageIDs = append(pageIDs, user.ID)
+		}
+		return pageIDs, response.Info
+	})
+
+	minBalance := 20.0
+	got := listUserIDs(t, r, &models.UserFilter{EmailDomain: "EXAMPLE.com", MinBalance: &minBalance, Sort: sortByBalance})
+	if want := []string{ids["carol"], ids["bob"]}; !slices.Equal(got, want) {
+		t.Fatalf("filtered users: got %v, want %v", got, want)
+	}
+	got = listUserIDs(t, r, &models.UserFilter{Username: "AR"})
+	if want := []string{ids["carol"]}; !slices.Equal(got, want) {
+		t.Fatalf("users filtered by name: got %v, want %v", got, want)
+	}
+
+	// Курсор одной сортировки не подходит для другой
+	response, err := r.Users.GetUsers(ctx, &models.UserFilter{Sort: sortByBalance, Pagination: pagination.Page{Limit: 2}})
+	requireNoError(t, err, "get users page")
+	_, err = r.Users.GetUsers(ctx, &models.UserFilter{
+		Sort:       []models.UserSort{{Field: models.UserSortUsername}, {Field: models.UserSortBalance}},
+		Pagination: pagination.Page{Limit: 2, Cursor: decodeCursor(t, response.NextCursor)},
+	})
+	requireErrorType(t, err, errors.BadRequest)
+}
+
+func testUsersByStatus(t *testing.T, r Repositories) {
+	createUser(t, r, "alice")
+	pending := &models.User{ID: uuid.NewString(), Username: "bob", Email: "bob@example.com", Status: models.Pending}
+	_, err := r.Users.CreateUser(ctx, pending)
+	requireNoError(t, err, "create user")
+
+	users, err := r.Users.GetUsersByStatus(ctx, models.Pending)
+	requireNoError(t, err, "get users by status")
+	if len(users) != 1 || users[0].ID != pending.ID {
+		t.Fatalf("unexpected pending users: %+v", users)
+	}
+}
+
+func testLeaderboard(t *testing.T, r Repositories) {
+	if _, err := r.Users.GetLeaderByBalance(ctx); !stderrors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("leader of an empty leaderboard: expected sql.ErrNoRows, got %v", err)
+	}
+
+	// При равном балансе выше тот, кто выполнил больше задач; пользователь без баланса - последний
+	setStats := func(name string, balance float64, tasksCompleted int) string {
+		user := getUser(t, r, createUser(t, r, name).ID)
+		user.Balance = balance
+		user.TasksCompleted = tasksCompleted
+		_, err := r.Users.UpdateUser(ctx, user)
+		requireNoError(t, err, "update user")
+		return user.ID
+	}
+	second := setStats("alice", 50, 1)
+	first := setStats("bob", 50, 3)
+	noBalance := createUser(t, r, "carol").ID
+	third := setStats("dave", 0, 0)
+	want := []string{first, second, third, noBalance}
+
+	top, err := r.Users.GetTopUsers(ctx, 10, 0)
+	requireNoError(t, err, "get top users")
+	checkRanks(t, top, want, 1)
+
+	top, err = r.Users.GetTopUsers(ctx, 2, 1)
+	requireNoError(t, err, "get top users with offset")
+	checkRanks(t, top, want[1:3], 2)
+
+	leader, err := r.Users.GetLeaderByBalance(ctx)
+	requireNoError(t, err, "get leader")
+	if leader.ID != first || leader.Rank != 1 {
+		t.Fatalf("unexpected leader %s with rank %d", leader.ID, leader.Rank)
+	}
+
+	checkPages(t, want, 3, func(t *testing.T, page pagination.Page) ([]string, pagination.Info) {
+		top, info, err := r.Users.GetTopUsersPage(ctx, page)
+		requireNoError(t, err, "get top users page")
+		ids := make([]string, 0, len(top))
+		for _, user := range top {
+			if wantRank := slices.Index(want, user.ID) + 1; user.Rank != wantRank {
+				t.Fatalf("user %s has rank %d on a page, want %d", user.ID, user.Rank, wantRank)
+			}
+			ids = append(ids, user.ID)
+		}
+		return ids, info
+	})
+}
+
+// checkRanks проверяет порядок пользователей топа и их ранги, начиная с firstRank
+func checkRanks(t *testing.T, top []models.TopUser, want []string, firstRank int) {
+	t.Helper()
+	if len(top) != len(want) {
+		t.Fatalf("got %d top users, want %d", len(top), len(want))
+	}
+	for i, user := range top {
+		if user.ID != want[i] || user.Rank != firstRank+i {
+			t.Fatalf("top user %d: got %s with rank %d, want %s with rank %d", i, user.ID, user.Rank, want[i], firstRank+i)
+		}
+	}
+}
+
+func testCopyUsers(t *testing.T, r Repositories) {
+	createUser(t, r, "alice")
+	now := time.Now()
+	copied := []*models.User{
+		{ID: uuid.NewString(), Username: "bob", Email: "bob@example.com", Status: models.Active, CreatedAt: now, UpdatedAt: now},
+		{ID: uuid.NewString(), Username: "carol", Email: "carol@example.com", Status: models.Active, CreatedAt: now, UpdatedAt: now},
+	}
+	requireNoError(t, r.Users.CopyUsers(ctx, copied), "copy users")
+
+	existing, err := r.Users.GetExistingEmails(ctx, []string{"Alice@Example.com", "bob@example.com", "carol@example.com", "dave@example.com"})
+	requireNoError(t, err, "get existing emails")
+	want := map[string]bool{"alice@example.com": true, "bob@example.com": true, "carol@example.com": true}
+	if !maps.Equal(existing, want) {
+		t.Fatalf("existing emails: got %v, want %v", existing, want)
+	}
+
+	// Пакет с занятым адресом не вставляется целиком
+	conflicting := []*models.User{
+		{ID: uuid.NewString(), Username: "dave", Email: "dave@example.com", Status: models.Active, CreatedAt: now, UpdatedAt: now},
+		{ID: uuid.NewString(), Username: "alice2", Email: "alice@example.com", Status: models.Active, CreatedAt: now, UpdatedAt: now},
+	}
+	if err := r.Users.CopyUsers(ctx, conflicting); err == nil {
+		t.Fatalf("users with a taken email were copied")
+	}
+	existing, err = r.Users.GetExistingEmails(ctx, []string{"dave@example.com"})
+	requireNoError(t, err, "get existing emails")
+	if existing["dave@example.com"] {
+		t.Fatalf("part of a failed batch was copied")
+	}
+}
@@ -1,8 +1,13 @@
 package router
 
 import (
+	"github.com/ZnNr/user-reward-controller/internal/audit"
 	"github.com/ZnNr/user-reward-controller/internal/handlers"
+	"github.com/ZnNr/user-reward-controller/internal/handlers/v2"
 	"github.com/ZnNr/user-reward-controller/internal/logging"
+	"github.com/ZnNr/user-reward-controller/internal/openapi"
+	"github.com/ZnNr/user-reward-controller/internal/problem"
+	"github.com/ZnNr/user-reward-controller/internal/service/auth"
 	"github.com/gorilla/mux"
 	"go.uber.org/zap"
 )
@@ -12,35 +17,85 @@ func NewRouter(
 	taskHandler *handlers.TaskHandler,
 	userHandler *handlers.UserHandler,
 	referralHandler *handlers.ReferralHandler,
+	eventsHandler *handlers.EventsHandler,
+	importHandler *handlers.ImportHandler,
+	exportHandler *handlers.ExportHandler,
+	privacyHandler *handlers.PrivacyHandler,
+	auditHandler *handlers.AuditHandler,
+	verificationHandler *handlers.VerificationHandler,
+	submissionHandler *handlers.SubmissionHandler,
+	campaignHandler *handlers.CampaignHandler,
+	questHandler *handlers.QuestHandler,
+	jobHandler *handlers.JobHandler,
+	searchHandler *handlers.SearchHandler,
+	userHandlerV2 *v2.UserHandler,
+	taskHandlerV2 *v2.TaskHandler,
+	referralHandlerV2 *v2.ReferralHandler,
+	trustedProxies audit.TrustedProxies,
 	logger *zap.Logger,
 ) *mux.Router {
 	r := mux.NewRouter()
+	// Запросы без маршрута, как и остальные ошибки, получают ответ application/problem+json
+	r.NotFoundHandler = problem.NotFoundHandler()
+	r.MethodNotAllowedHandler = problem.MethodNotAllowedHandler()
 	//r.Use(auth.AuthMiddleware)
+	// Идентификатор запроса нужен логированию и журналу аудита, поэтому он назначается первым
+	r.Use(logging.RequestIDMiddleware)
 	// Миддлвары для логирования
 	r.Use(logging.LoggingMiddleware(logger))
+	// Владелец токена (если передан) для проверки прав и журнала аудита
+	r.Use(auth.PrincipalMiddleware)
+	// Инициатор, идентификатор запроса и IP клиента для журнала аудита
+	r.Use(audit.Middleware(auth.ActorFromRequest, trustedProxies))
+	// Проверка параметров и тела запроса по спецификации OpenAPI до вызова обработчиков
+	r.Use(openapi.Middleware(openapi.MustLoad()))
+
+	// Спецификация API и страница Swagger UI
+	r.HandleFunc("/openapi.json", openapi.SpecHandler).Methods("GET")
+	r.HandleFunc("/docs", openapi.DocsHandler).Methods("GET")
 
 	// Регистрируем маршруты для задач (Tasks)
-	r.HandleFunc("/tasks", taskHandler.GetTasks).Methods("GET")                                     // Получить все задачи
-	r.HandleFunc("/tasks/{task_id}", taskHandler.GetTaskByID).Methods("GET")                        // Получить задачу по ID
-	r.HandleFunc("/tasks", taskHandler.CreateTask).Methods("POST")                                  // Создать новую задачу
-	r.HandleFunc("/tasks/{task_id}", taskHandler.UpdateTask).Methods("PUT")                         // Обновить задачу
-	r.HandleFunc("/tasks/{task_id}", taskHandler.DeleteTask).Methods("DELETE")                      // Удалить задачу
-	r.HandleFunc("/tasks/{task_id}/status/{userId}", taskHandler.UpdateTaskStatus).Methods("PATCH") // Обновляет статус задачи , в случае завершения задачи увеличивает счетчик выполненых заданий у пользователя
-	r.HandleFunc("/tasks/{task_id}/description", taskHandler.GetDescription).Methods("GET")         // Получить описание задачи с возможностью пагинации
-
-	// Регистрируем маршруты для пользователей (Users)
+	r.HandleFunc("/tasks", taskHandler.GetTasks).Methods("GET")                                                   // Получить все задачи
+	r.HandleFunc("/tasks/{task_id}", taskHandler.GetTaskByID).Methods("GET")                                      // Получить задачу по ID
+	r.HandleFunc("/tasks", taskHandler.CreateTask).Methods("POST")                                                // Создать новую задачу
+	r.HandleFunc("/tasks/{task_id}", taskHandler.UpdateTask).Methods("PUT")                                       // Обновить задачу
+	r.HandleFunc("/tasks/{task_id}", taskHandler.DeleteTask).Methods("DELETE")                                    // Удалить задачу (мягкое удаление)
+	r.HandleFunc("/tasks/{task_id}/restore", taskHandler.RestoreTask).Methods("POST")                             // Восстановить удаленную задачу
+	r.HandleFunc("/tasks/{task_id}/status/{user_id}", taskHandler.UpdateTaskStatus).Methods("PATCH")              // Переводит задачу в новый статус; при выполнении начисляет вознаграждение, при повторном открытии возвращает его
+	r.HandleFunc("/tasks/{task_id}/history", taskHandler.GetTaskHistory).Methods("GET")                           // История переходов статуса задачи
+	r.HandleFunc("/tasks/{task_id}/claim", taskHandler.ClaimTask).Methods("POST")                                 // Взять открытую задачу или задачу с лимитом
+	r.HandleFunc("/tasks/{task_id}/submissions", submissionHandler.Submit).Methods("POST")                        // Отправить заявку на выполнение с доказательством
+	r.HandleFunc("/tasks/{task_id}/description", taskHandler.GetDescription).Methods("GET")                       // Получить описание задачи с возможностью пагинации
+	r.HandleFunc("/tasks/{task_id}/dependencies", taskHandler.GetDependencies).Methods("GET")                     // Задачи, которые нужно выполнить раньше
+	r.HandleFunc("/tasks/{task_id}/dependencies", taskHandler.AddDependencies).Methods("POST")                    // Добавить зависимости (без циклов)
+	r.HandleFunc("/tasks/{task_id}/dependencies/{depends_on_id}", taskHandler.RemoveDependency).Methods("DELETE") // Удалить зависимость
+
+	// Регистрируем маршруты для пользователей (Users).
+	// Статические пути регистрируются раньше /users/{user_id}, иначе mux примет их за идентификатор пользователя
+	r.HandleFunc("/users/email", userHandler.GetUserByEmail).Methods("GET")
+	r.HandleFunc("/users/invite", userHandler.InviteUser).Methods("POST")
+	r.HandleFunc("/users/leader", userHandler.GetLeaderByBalance).Methods("GET") // вывод лидера по балансу
+	r.HandleFunc("/users/leaderboard", userHandler.GetTopUsers).Methods("GET")   // топ пользователей с самым большим балансом
+	r.HandleFunc("/users/verify", verificationHandler.Verify).Methods("POST")    // подтверждение email по токену
 	r.HandleFunc("/users", userHandler.GetUsers).Methods("GET")
 	r.HandleFunc("/users/{user_id}", userHandler.GetUserByID).Methods("GET")
 	r.HandleFunc("/users", userHandler.CreateUser).Methods("POST")
 	r.HandleFunc("/users/{user_id}", userHandler.UpdateUser).Methods("PUT")
 	r.HandleFunc("/users/{user_id}", userHandler.DeleteUser).Methods("DELETE")
-	r.HandleFunc("/users/email", userHandler.GetUserByEmail).Methods("GET")
+	r.HandleFunc("/users/{user_id}/restore", userHandler.RestoreUser).Methods("POST")
 	r.HandleFunc("/users/{user_id}/balance", userHandler.UpdateBalance).Methods("PUT")
 	r.HandleFunc("/users/{user_id}/full-info", userHandler.GetUserFullInfo).Methods("GET") // вся доступная информация о пользователе
 	r.HandleFunc("/users/{user_id}/summary", userHandler.GetUserSummary).Methods("GET")
-	r.HandleFunc("/users/invite", userHandler.InviteUser).Methods("POST")
-	r.HandleFunc("/users/leader", userHandler.GetLeaderByBalance).Methods("GET") // вывод лидера по балансу
-	r.HandleFunc("/users/leaderboard", userHandler.GetTopUsers).Methods("GET")   // топ пользователей с самым большим балансом
+	r.HandleFunc("/users/{user_id}/tasks", taskHandler.GetUserTasks).Methods("GET")                               // взятые, назначенные и выполненные задачи пользователя
+	r.HandleFunc("/users/{user_id}/tasks/{task_id}/availability", taskHandler.GetTaskAvailability).Methods("GET") // доступность задачи с учетом зависимостей
+	r.HandleFunc("/users/{user_id}/quests/{quest_id}", questHandler.GetUserQuestProgress).Methods("GET")          // прогресс пользователя по квесту
+
+	// Повторная отправка письма подтверждения email
+	r.HandleFunc("/users/{user_id}/verification", verificationHandler.Resend).Methods("POST")
+
+	// Запросы субъектов персональных данных (GDPR): выгрузка и анонимизация
+	r.HandleFunc("/users/{user_id}/data-export", privacyHandler.ExportUserData).Methods("GET")
+	r.HandleFunc("/users/{user_id}/anonymize", privacyHandler.AnonymizeUser).Methods("POST")
 
 	// Регистрируем маршруты для рефералов
 	r.HandleFunc("/referrals", referralHandler.GetReferralsByUserID).Methods("GET")      // Изменено на GetReferralsByUserID
@@ -49,5 +104,74 @@ func NewRouter(
 	r.HandleFunc("/referrals/{referral_id}", referralHandler.UpdateReferral).Methods("PUT")    // Изменено на UpdateReferral
 	r.HandleFunc("/referrals/{referral_id}", referralHandler.DeleteReferral).Methods("DELETE") // Изменено на DeleteReferral
 
+	// Полнотекстовый поиск по задачам и пользователям
+	r.HandleFunc("/search", searchHandler.Search).Methods("GET")
+
+	// Поток событий (SSE) с обновлениями таблицы лидеров и балансов
+	r.HandleFunc("/events/stream", eventsHandler.Stream).Methods("GET")
+
+	// Массовый импорт пользователей и задач из CSV/JSONL
+	r.HandleFunc("/admin/import/users", importHandler.ImportUsers).Methods("POST")
+	r.HandleFunc("/admin/import/tasks", importHandler.ImportTasks).Methods("POST")
+
+	// Потоковая выгрузка пользователей, задач и журнала баланса
+	r.HandleFunc("/admin/export/{kind:users|tasks|ledger}", exportHandler.Export).Methods("GET")
+
+	// Журнал аудита административных действий
+	r.HandleFunc("/admin/audit", auditHandler.GetEntries).Methods("GET")
+	r.HandleFunc("/admin/audit/verify", auditHandler.Verify).Methods("GET")
+
+	// История запусков фоновых заданий
+	r.HandleFunc("/admin/jobs/runs", jobHandler.GetRuns).Methods("GET")
+
+	// Кампании с расписанием и бюджетом вознаграждений
+	r.HandleFunc("/campaigns", campaignHandler.GetCampaigns).Methods("GET")
+	r.HandleFunc("/campaigns", campaignHandler.CreateCampaign).Methods("POST")
+	r.HandleFunc("/campaigns/{campaign_id}", campaignHandler.GetCampaign).Methods("GET")
+	r.HandleFunc("/campaigns/{campaign_id}", campaignHandler.UpdateCampaign).Methods("PUT")
+	r.HandleFunc("/campaigns/{campaign_id}/stats", campaignHandler.GetCampaignStats).Methods("GET")
+
+	// Квесты - цепочки задач с бонусом за выполнение всех задач
+	r.HandleFunc("/quests", questHandler.GetQuests).Methods("GET")
+	r.HandleFunc("/quests", questHandler.CreateQuest).Methods("POST")
+	r.HandleFunc("/quests/{quest_id}", questHandler.GetQuest).Methods("GET")
+
+	// Очередь модерации заявок на выполнение задач
+	r.HandleFunc("/moderation/submissions", submissionHandler.GetQueue).Methods("GET")
+	r.HandleFunc("/moderation/submissions/{submission_id:[0-9]+}/approve", submissionHandler.Approve).Methods("POST")
+	r.HandleFunc("/moderation/submissions/{submission_id:[0-9]+}/reject", submissionHandler.Reject).Methods("POST")
+
+	// API второй версии: ресурсы в snake_case, ответы в конверте {"data", "meta"}.
+	// Маршруты первой версии сохраняют прежние имена полей для существующих клиентов.
+	// Для запросов без маршрута под /v2, в том числе с неподдерживаемым методом, mux вызывает NotFoundHandler
+	api := r.PathPrefix("/v2").Subrouter()
+
+	api.HandleFunc("/users", userHandlerV2.GetUsers).Methods("GET")
+	api.HandleFunc("/users", userHandlerV2.CreateUser).Methods("POST")
+	api.HandleFunc("/users/{user_id}", userHandlerV2.GetUser).Methods("GET")
+	api.HandleFunc("/users/{user_id}", userHandlerV2.UpdateUser).Methods("PATCH")
+	api.HandleFunc("/users/{user_id}", userHandlerV2.DeleteUser).Methods("DELETE")
+	api.HandleFunc("/users/{user_id}/restore", userHandlerV2.RestoreUser).Methods("POST")
+	api.HandleFunc("/users/{user_id}/summary", userHandlerV2.GetUserSummary).Methods("GET")
+	api.HandleFunc("/users/{user_id}/balance", userHandlerV2.AdjustBalance).Methods("POST")
+	api.HandleFunc("/users/{user_id}/tasks", taskHandlerV2.GetUserTasks).Methods("GET")
+	api.HandleFunc("/leaderboard", userHandlerV2.GetLeaderboard).Methods("GET")
+	api.HandleFunc("/invitations", userHandlerV2.CreateInvitation).Methods("POST")
+
+	api.HandleFunc("/tasks", taskHandlerV2.GetTasks).Methods("GET")
+	api.HandleFunc("/tasks", taskHandlerV2.CreateTask).Methods("POST")
+	api.HandleFunc("/tasks/{task_id}", taskHandlerV2.GetTask).Methods("GET")
+	api.HandleFunc("/tasks/{task_id}", taskHandlerV2.UpdateTask).Methods("PATCH")
+	api.HandleFunc("/tasks/{task_id}", taskHandlerV2.DeleteTask).Methods("DELETE")
+	api.HandleFunc("/tasks/{task_id}/restore", taskHandlerV2.RestoreTask).Methods("POST")
+	api.HandleFunc("/tasks/{task_id}/status", taskHandlerV2.ChangeStatus).Methods("PUT")
+	api.HandleFunc("/tasks/{task_id}/history", taskHandlerV2.GetTaskHistory).Methods("GET")
+
+	api.HandleFunc("/referrals", referralHandlerV2.GetReferrals).Methods("GET")
+	api.HandleFunc("/referrals", referralHandlerV2.CreateReferral).Methods("POST")
+	api.HandleFunc("/referrals/{referral_id}", referralHandlerV2.GetReferral).Methods("GET")
+	api.HandleFunc("/referrals/{referral_id}", referralHandlerV2.UpdateReferral).Methods("PATCH")
+	api.HandleFunc("/referrals/{referral_id}", referralHandlerV2.DeleteReferral).Methods("DELETE")
+
 	return r
 }
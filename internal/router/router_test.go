@@ -0,0 +1,109 @@
+package router
+
+import (
+	"github.com/ZnNr/user-reward-controller/internal/openapi"
+	"github.com/ZnNr/user-reward-controller/internal/problem"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// Каждый маршрут должен быть описан в спецификации OpenAPI, а каждая операция спецификации - обслуживаться
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	doc, err := openapi.Load()
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	// Обработчики не вызываются, поэтому достаточно нулевых указателей
+	r := NewRouter(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, zap.NewNop())
+
+	routed := make(map[string]bool)
+	err = r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		template, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			// Префикс подмаршрутизатора (/v2) сам запросы не обслуживает
+			return nil
+		}
+		path := openapi.PathTemplate(template)
+		for _, method := range methods {
+			routed[method+" "+path] = true
+			if doc.Operation(method, path) == nil {
+				t.Errorf("%s %s is not documented in openapi.json", method, path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk routes: %v", err)
+	}
+
+	var documented []string
+	for path, item := range doc.Paths {
+		for method := range item {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(documented)
+	for _, operation := range documented {
+		if !routed[operation] {
+			t.Errorf("%s is documented but not routed", operation)
+		}
+	}
+}
+
+// Запросы, для которых нет маршрута, получают ответ в формате problem+json
+func TestUnmatchedRoutes(t *testing.T) {
+	r := NewRouter(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, zap.NewNop())
+	tests := []struct {
+		method, target string
+		status         int
+	}{
+		{"GET", "/v2/nowhere", http.StatusNotFound},
+		{"PUT", "/v2/users", http.StatusNotFound},
+		{"GET", "/nowhere", http.StatusNotFound},
+		{"PUT", "/users", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: status %d, want %d", tt.method, tt.target, rec.Code, tt.status)
+		}
+		if contentType := rec.Header().Get("Content-Type"); contentType != problem.ContentType {
+			t.Errorf("%s %s: Content-Type %q, want %q", tt.method, tt.target, contentType, problem.ContentType)
+		}
+	}
+}
+
+// Статические пути /users/... не должны перехватываться шаблоном /users/{user_id}
+func TestStaticUserRoutes(t *testing.T) {
+	r := NewRouter(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, zap.NewNop())
+	tests := []struct {
+		method, path string
+	}{
+		{"GET", "/users/email"},
+		{"POST", "/users/invite"},
+		{"GET", "/users/leader"},
+		{"GET", "/users/leaderboard"},
+		{"POST", "/users/verify"},
+	}
+	for _, tt := range tests {
+		var match mux.RouteMatch
+		if !r.Match(httptest.NewRequest(tt.method, tt.path, nil), &match) || match.Route == nil {
+			t.Errorf("%s %s is not routed", tt.method, tt.path)
+			continue
+		}
+		if template, _ := match.Route.GetPathTemplate(); template != tt.path {
+			t.Errorf("%s %s is routed to %s", tt.method, tt.path, template)
+		}
+	}
+}
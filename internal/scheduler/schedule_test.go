@@ -0,0 +1,74 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	// 1 января 2024 года - понедельник
+	at := func(value string) time.Time {
+		parsed, err := time.Parse("2006-01-02 15:04", value)
+		if err != nil {
+			t.Fatalf("parse %q: %v", value, err)
+		}
+		return parsed
+	}
+	tests := []struct {
+		name  string
+		spec  string
+		after string
+		want  string
+	}{
+		{"step", "*/15 * * * *", "2024-01-01 00:07", "2024-01-01 00:15"},
+		{"range with step", "0 9-17/4 * * *", "2024-01-01 10:00", "2024-01-01 13:00"},
+		{"value with step", "5/20 * * * *", "2024-01-01 00:26", "2024-01-01 00:45"},
+		{"list", "5,35 * * * *", "2024-01-01 00:06", "2024-01-01 00:35"},
+		{"strictly after", "0 0 * * *", "2024-01-01 00:00", "2024-01-02 00:00"},
+		{"next month", "30 2 1 * *", "2024-01-01 03:00", "2024-02-01 02:30"},
+		{"leap day", "0 0 29 2 *", "2024-03-01 00:00", "2028-02-29 00:00"},
+		{"sunday as 7", "0 0 * * 7", "2024-01-01 00:00", "2024-01-07 00:00"},
+		{"day of month or day of week", "0 0 13 * 5", "2024-01-01 00:00", "2024-01-05 00:00"},
+		{"day of month or day of week, month day first", "0 0 3 * 5", "2024-01-01 00:00", "2024-01-03 00:00"},
+		{"starred day of month requires both", "0 0 */2 * 1", "2024-01-01 00:00", "2024-01-15 00:00"},
+		{"starred day of week requires both", "0 0 1 * */2", "2024-01-01 00:00", "2024-02-01 00:00"},
+		{"descriptor", "@hourly", "2024-01-01 00:30", "2024-01-01 01:00"},
+		{"interval", "@every 1h", "2024-01-01 00:30", "2024-01-01 01:00"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("parse %q: %v", tt.spec, err)
+			}
+			if got := s.Next(at(tt.after)); !got.Equal(at(tt.want)) {
+				t.Fatalf("%q after %s: got %s, want %s", tt.spec, tt.after, got.Format("2006-01-02 15:04 Mon"), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+		"1-b * * * *",
+		"@every 500ms",
+		"@every soon",
+		"@weekdays",
+	} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("%q parsed without error", spec)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+// Package scheduler запускает фоновые задания по расписанию. При нескольких репликах каждый запуск
+// выполняет только одна из них: реплика должна захватить блокировку задания и первой записать запуск в историю.
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"sync"
+
+	"go.uber.org/zap"
+	"time"
+)
+
+// finishTimeout ограничивает запись результата запуска, выполняемую уже после отмены контекста
+const finishTimeout = 5 * time.Second
+
+// JobFunc выполняет задание; ctx отменяется при остановке планировщика
+type JobFunc func(ctx context.Context) error
+
+// job - зарегистрированное задание
+type job struct {
+	name     string
+	schedule Schedule
+	run      JobFunc
+}
+
+// Scheduler запускает зарегистрированные задания по расписанию до вызова Stop
+type Scheduler struct {
+	repo     repository.JobRepository
+	instance string
+	jobs     []job
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+	logger   *zap.Logger
+}
+
+// New создает планировщик. instance - имя реплики, записываемое в историю запусков.
+func New(repo repository.JobRepository, instance string, logger *zap.Logger) *Scheduler {
+	return &Scheduler{
+		repo:     repo,
+		instance: instance,
+		logger:   logger,
+	}
+}
+
+// Register добавляет задание с расписанием spec (см. Parse). Задания регистрируются до Start.
+func (s *Scheduler) Register(name, spec string, run JobFunc) error {
+	schedule, err := Parse(spec)
+	if err != nil {
+		return fmt.Errorf("job %s: %w", name, err)
+	}
+	for _, existing := range s.jobs {
+		if existing.name == name {
+			return fmt.Errorf("job %s is already registered", name)
+		}
+	}
+	s.jobs = append(s.jobs, job{name: name, schedule: schedule, run: run})
+	return nil
+}
+
+// Start запускает задания в фоне
+func (s *Scheduler) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go func(j job) {
+			defer s.wg.Done()
+			s.loop(ctx, j)
+		}(j)
+	}
+	s.logger.Info("Scheduler started", zap.Int("jobs", len(s.jobs)), zap.String("instance", s.instance))
+}
+
+// Stop отменяет выполняющиеся задания и дожидается их завершения
+func (s *Scheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+	s.logger.Info("Scheduler stopped")
+}
+
+// loop ожидает время очередного запуска задания и выполняет его, пока не будет отменен ctx
+func (s *Scheduler) loop(ctx context.Context, j job) {
+	next := j.schedule.Next(time.Now())
+	for !next.IsZero() {
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		s.execute(ctx, j, next)
+
+		// Пропущенные за время долгого выполнения запуски не догоняются
+		now := time.Now()
+		if now.Before(next) {
+			now = next
+		}
+		next = j.schedule.Next(now)
+	}
+	s.logger.Warn("Job schedule has no future runs", zap.String("job", j.name))
+}
+
+// execute выполняет запуск задания, запланированный на scheduledAt, если эта реплика стала его лидером
+func (s *Scheduler) execute(ctx context.Context, j job, scheduledAt time.Time) {
+	logger := s.logger.With(zap.String("job", j.name), zap.Time("scheduledAt", scheduledAt))
+
+	unlock, acquired, err := s.repo.TryLockJob(ctx, j.name)
+	if err != nil {
+		logger.Error("Failed to acquire job lock", zap.Error(err))
+		return
+	}
+	if !acquired {
+		logger.Debug("Job is running on another instance")
+		return
+	}
+	defer unlock()
+
+	run := &models.JobRun{
+		JobName:     j.name,
+		ScheduledAt: scheduledAt,
+		Status:      models.JobRunRunning,
+		Instance:    s.instance,
+	}
+	started, err := s.repo.StartJobRun(ctx, run)
+	if err != nil {
+		logger.Error("Failed to record job run", zap.Error(err))
+		return
+	}
+	if !started {
+		logger.Debug("Job run has already been executed by another instance")
+		return
+	}
+
+	runErr := runJob(ctx, j.run)
+	finishedAt := time.Now()
+	run.FinishedAt = &finishedAt
+	switch {
+	case runErr == nil:
+		run.Status = models.JobRunSucceeded
+	case ctx.Err() != nil:
+		run.Status = models.JobRunCanceled
+		run.Error = runErr.Error()
+	default:
+		run.Status = models.JobRunFailed
+		run.Error = runErr.Error()
+	}
+
+	// Результат записывается и после остановки планировщика, чтобы запуск не остался в состоянии running
+	finishCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), finishTimeout)
+	defer cancel()
+	if err := s.repo.FinishJobRun(finishCtx, run); err != nil {
+		logger.Error("Failed to record job run result", zap.Error(err))
+	}
+
+	logger.Debug("Job run finished", zap.String("status", string(run.Status)),
+		zap.Duration("duration", finishedAt.Sub(run.StartedAt)))
+}
+
+// runJob выполняет задание, превращая панику в ошибку, чтобы она не остановила приложение
+func runJob(ctx context.Context, run JobFunc) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return run(ctx)
+}
@@ -0,0 +1,328 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/config"
+	"github.com/ZnNr/user-reward-controller/internal/service/auth"
+	"github.com/ZnNr/user-reward-controller/internal/testdb"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Сквозные тесты HTTP API. Каждый сценарий из testdata/e2e/scenarios повторяет папку коллекции
+// postman/collection.json: приложение поднимается над отдельной схемой базы (TEST_DATABASE_URL)
+// с данными из testdata/e2e/fixtures.sql, после чего шаги сценария выполняются по порядку.
+//
+// Шаг сценария - запрос и ожидаемый ответ:
+//
+//	{
+//	  "name": "create user",
+//	  "method": "POST",
+//	  "path": "/users",
+//	  "body": {"Username": "alice", "Email": "alice@example.com"},
+//	  "status": 201,
+//	  "expect": {"Username": "alice"},
+//	  "capture": {"alice_id": "ID"}
+//	}
+//
+// expect сравнивается с JSON-ответом как подмножество: у объектов проверяются только указанные поля,
+// массивы должны совпадать по длине и поэлементно. text задает ожидаемый ответ, который не является JSON.
+// capture сохраняет значения ответа по пути через точку ("users.0.ID") в переменные, которые
+// подставляются в path, body и expect следующих шагов как {{name}}.
+// as выполняет запрос с токеном: "admin" - от имени администратора, иначе от имени пользователя
+// с указанным subject (допускает {{name}}). Без as запрос выполняется анонимно.
+
+const e2eDir = "testdata/e2e"
+
+// scenario - сценарий проверки API
+type scenario struct {
+	Name  string `json:"name"`
+	Steps []step `json:"steps"`
+}
+
+// step - запрос сценария и ожидаемый ответ
+type step struct {
+	Name    string            `json:"name"`
+	Method  string            `json:"method"`
+	Path    string            `json:"path"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+	Status  int               `json:"status"`
+	Expect  json.RawMessage   `json:"expect,omitempty"`
+	Text    *string           `json:"text,omitempty"`
+	Capture map[string]string `json:"capture,omitempty"`
+	As      string            `json:"as,omitempty"`
+}
+
+func TestAPIScenarios(t *testing.T) {
+	// Сценарии разбираются до подключения к базе, чтобы ошибки в них находились и без TEST_DATABASE_URL
+	scenarios := loadScenarios(t)
+	for _, sc := range scenarios {
+		t.Run(sc.Name, func(t *testing.T) {
+			baseURL := startTestApp(t)
+			vars := make(map[string]string)
+			for i, st := range sc.Steps {
+				if !runStep(t, baseURL, st, vars) {
+					t.Fatalf("scenario stopped at step %d (%s)", i+1, st.Name)
+				}
+			}
+		})
+	}
+}
+
+// loadScenarios читает все сценарии в порядке имен файлов
+func loadScenarios(t *testing.T) []scenario {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(e2eDir, "scenarios", "*.json"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no scenarios found in %s: %v", e2eDir, err)
+	}
+	sort.Strings(files)
+
+	scenarios := make([]scenario, 0, len(files))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("read %s: %v", file, err)
+		}
+		var sc scenario
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&sc); err != nil {
+			t.Fatalf("parse %s: %v", file, err)
+		}
+		if sc.Name == "" {
+			sc.Name = strings.TrimSuffix(filepath.Base(file), ".json")
+		}
+		for i, st := range sc.Steps {
+			if st.Method == "" || st.Path == "" || st.Status == 0 {
+				t.Fatalf("%s: step %d (%s) must have method, path and status", file, i+1, st.Name)
+			}
+		}
+		scenarios = append(scenarios, sc)
+	}
+	return scenarios
+}
+
+// startTestApp поднимает приложение над новой схемой с тестовыми данными и возвращает адрес HTTP API
+func startTestApp(t *testing.T) string {
+	t.Helper()
+
+	dsn := testdb.NewSchema(t)
+	seedFixtures(t, dsn)
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	cfg.DBURL = dsn
+	cfg.MigrationsDir, err = filepath.Abs(filepath.Join("..", "..", "migration"))
+	if err != nil {
+		t.Fatalf("migrations dir: %v", err)
+	}
+	cfg.MailSender = "log"
+
+	app := New(cfg, zap.NewNop())
+	if err := app.Initialize(); err != nil {
+		t.Fatalf("initialize app: %v", err)
+	}
+	srv := httptest.NewServer(app.httpServer.Handler)
+	t.Cleanup(func() {
+		srv.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := app.Shutdown(ctx); err != nil {
+			t.Errorf("shutdown app: %v", err)
+		}
+	})
+	return srv.URL
+}
+
+// seedFixtures заполняет схему данными, на которые ссылаются сценарии
+func seedFixtures(t *testing.T, dsn string) {
+	t.Helper()
+	fixtures, err := os.ReadFile(filepath.Join(e2eDir, "fixtures.sql"))
+	if err != nil {
+		t.Fatalf("read fixtures: %v", err)
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(string(fixtures)); err != nil {
+		t.Fatalf("seed fixtures: %v", err)
+	}
+}
+
+var placeholder = regexp.MustCompile(`\{\{(\w+)\}\}`)
+
+// substitute подставляет значения переменных вместо {{name}}; неизвестная переменная - ошибка сценария
+func substitute(t *testing.T, s string, vars map[string]string) string {
+	t.Helper()
+	return placeholder.ReplaceAllStringFunc(s, func(match string) string {
+		name := placeholder.FindStringSubmatch(match)[1]
+		value, ok := vars[name]
+		if !ok {
+			t.Fatalf("variable %q is not captured yet", name)
+		}
+		return value
+	})
+}
+
+// runStep выполняет шаг сценария и проверяет ответ; возвращает false, если проверка не прошла
+func runStep(t *testing.T, baseURL string, st step, vars map[string]string) bool {
+	t.Helper()
+
+	var body io.Reader
+	if len(st.Body) > 0 {
+		body = strings.NewReader(substitute(t, string(st.Body), vars))
+	}
+	path := substitute(t, st.Path, vars)
+	req, err := http.NewRequest(st.Method, baseURL+path, body)
+	if err != nil {
+		t.Fatalf("%s: build request: %v", st.Name, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if st.As != "" {
+		principal := auth.Principal{Subject: substitute(t, st.As, vars)}
+		if st.As == auth.RoleAdmin {
+			principal.Role = auth.RoleAdmin
+		}
+		token, err := auth.IssueToken(principal)
+		if err != nil {
+			t.Fatalf("%s: issue token: %v", st.Name, err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s: %s %s: %v", st.Name, st.Method, path, err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("%s: read response: %v", st.Name, err)
+	}
+
+	if resp.StatusCode != st.Status {
+		t.Errorf("%s: %s %s: status %d, want %d; body: %s", st.Name, st.Method, path, resp.StatusCode, st.Status, raw)
+		return false
+	}
+	if st.Text != nil && strings.TrimSpace(string(raw)) != *st.Text {
+		t.Errorf("%s: body %q, want %q", st.Name, raw, *st.Text)
+		return false
+	}
+	if len(st.Expect) == 0 && len(st.Capture) == 0 {
+		return true
+	}
+
+	var got interface{}
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Errorf("%s: response is not JSON: %v; body: %s", st.Name, err, raw)
+		return false
+	}
+	if len(st.Expect) > 0 {
+		var want interface{}
+		if err := json.Unmarshal([]byte(substitute(t, string(st.Expect), vars)), &want); err != nil {
+			t.Fatalf("%s: parse expect: %v", st.Name, err)
+		}
+		if err := matchJSON("$", want, got); err != nil {
+			t.Errorf("%s: %v; body: %s", st.Name, err, raw)
+			return false
+		}
+	}
+	for name, path := range st.Capture {
+		value, err := lookup(got, path)
+		if err != nil {
+			t.Errorf("%s: capture %s: %v", st.Name, name, err)
+			return false
+		}
+		vars[name] = value
+	}
+	return true
+}
+
+// matchJSON проверяет, что got содержит want: объекты сравниваются по полям want, массивы поэлементно
+func matchJSON(path string, want, got interface{}) error {
+	switch w := want.(type) {
+	case map[string]interface{}:
+		g, ok := got.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: got %v, want an object", path, got)
+		}
+		for key, value := range w {
+			field, ok := g[key]
+			if !ok {
+				return fmt.Errorf("%s.%s is missing", path, key)
+			}
+			if err := matchJSON(path+"."+key, value, field); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		g, ok := got.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: got %v, want an array", path, got)
+		}
+		if len(g) != len(w) {
+			return fmt.Errorf("%s: got %d elements, want %d", path, len(g), len(w))
+		}
+		for i := range w {
+			if err := matchJSON(fmt.Sprintf("%s[%d]", path, i), w[i], g[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		if !reflect.DeepEqual(want, got) {
+			return fmt.Errorf("%s: got %v, want %v", path, got, want)
+		}
+		return nil
+	}
+}
+
+// lookup возвращает значение ответа по пути через точку; индексы массивов записываются числами
+func lookup(value interface{}, path string) (string, error) {
+	for _, part := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			field, ok := v[part]
+			if !ok {
+				return "", fmt.Errorf("field %q is missing", part)
+			}
+			value = field
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(v) {
+				return "", fmt.Errorf("index %q is out of range", part)
+			}
+			value = v[i]
+		default:
+			return "", fmt.Errorf("cannot select %q from %v", part, value)
+		}
+	}
+	if s, ok := value.(string); ok {
+		return s, nil
+	}
+	encoded, err := json.Marshal(value)
+	return string(encoded), err
+}
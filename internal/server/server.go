@@ -3,15 +3,25 @@ package server
 import (
 	"context"
 	"database/sql"
+	stderrors "errors"
 	"fmt"
 	"github.com/ZnNr/user-reward-controller/config"
+	"github.com/ZnNr/user-reward-controller/internal/audit"
+	"github.com/ZnNr/user-reward-controller/internal/events"
+	"github.com/ZnNr/user-reward-controller/internal/grpcserver"
 	"github.com/ZnNr/user-reward-controller/internal/handlers"
+	"github.com/ZnNr/user-reward-controller/internal/handlers/v2"
+	"github.com/ZnNr/user-reward-controller/internal/mail"
 	"github.com/ZnNr/user-reward-controller/internal/repository/database"
 	"github.com/ZnNr/user-reward-controller/internal/router"
+	"github.com/ZnNr/user-reward-controller/internal/scheduler"
 	"github.com/ZnNr/user-reward-controller/internal/service"
 
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"net"
 	"net/http"
+	"path/filepath"
 	"time"
 
 	"github.com/golang-migrate/migrate/v4"
@@ -27,7 +37,28 @@ type App struct {
 	config     *config.Config
 	logger     *zap.Logger
 	db         *sql.DB
+	broker     *events.Broker
+	balances   *service.BalanceNotifier
 	httpServer *http.Server
+	grpcServer *grpc.Server
+
+	taskSvc         *service.TaskService
+	userSvc         *service.UserService
+	referralSvc     *service.ReferralService
+	importSvc       *service.ImportService
+	exportSvc       *service.ExportService
+	privacySvc      *service.PrivacyService
+	auditSvc        *service.AuditService
+	verificationSvc *service.VerificationService
+	submissionSvc   *service.SubmissionService
+	campaignSvc     *service.CampaignService
+	questSvc        *service.QuestService
+	retention       *service.RetentionService
+	dueDates        *service.DueDateService
+	jobSvc          *service.JobService
+	searchSvc       *service.SearchService
+
+	scheduler *scheduler.Scheduler // Запускает фоновые задания на одной из реплик
 }
 
 // New конструктор нового экземпляра приложения
@@ -48,10 +79,20 @@ func (a *App) Initialize() error {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
+	if err := a.initServices(); err != nil {
+		return fmt.Errorf("failed to initialize services: %w", err)
+	}
+
+	if err := a.initScheduler(); err != nil {
+		return fmt.Errorf("failed to initialize scheduler: %w", err)
+	}
+
 	if err := a.initHTTPServer(); err != nil {
 		return fmt.Errorf("failed to initialize HTTP server: %w", err)
 	}
 
+	a.initGRPCServer()
+
 	return nil
 }
 
@@ -74,7 +115,7 @@ func (a *App) runMigrations() error {
 	}
 
 	m, err := migrate.NewWithDatabaseInstance(
-		"file://migration",
+		"file://"+filepath.ToSlash(a.config.MigrationsDir),
 		"postgres", driver)
 	if err != nil {
 		a.logger.Error("Failed to create migration instance", zap.Error(err))
@@ -91,25 +132,99 @@ func (a *App) runMigrations() error {
 	return nil
 }
 
-// initHTTPServer инициализирует HTTP сервер
-func (a *App) initHTTPServer() error {
+// initServices инициализирует репозитории и сервисы, общие для HTTP и gRPC API
+func (a *App) initServices() error {
 	// Инициализируем репозитории
 	taskRepo := database.NewPostgresTaskRepository(a.db)
 	userRepo := database.NewPostgresUserRepository(a.db) // Создайте репозиторий для пользователей
 	referralRepo := database.NewReferralRepository(a.db) // Создайте репозиторий для рефералов
+	exportRepo := database.NewPostgresExportRepository(a.db)
+	privacyRepo := database.NewPostgresPrivacyRepository(a.db)
+	auditRepo := database.NewPostgresAuditRepository(a.db)
+	verificationRepo := database.NewPostgresVerificationRepository(a.db)
+	campaignRepo := database.NewPostgresCampaignRepository(a.db)
+	questRepo := database.NewPostgresQuestRepository(a.db)
+	jobRepo := database.NewPostgresJobRepository(a.db)
+	searchRepo := database.NewPostgresSearchRepository(a.db)
+
+	// Отправка писем подтверждения email
+	sender, err := mail.NewSender(a.config.MailSender, a.config.MailDir, a.logger)
+	if err != nil {
+		return err
+	}
+
+	// Брокер событий для SSE-подписчиков
+	a.broker = events.NewBroker(a.config.EventsHistorySize, a.config.EventsBufferSize, a.logger)
 
 	// Инициализируем сервисы
-	taskSvc := service.NewTaskService(taskRepo, a.logger)
-	userSvc := service.NewUserService(userRepo, a.logger)             // Создайте сервис для пользователей
-	referralSvc := service.NewReferralService(referralRepo, a.logger) // Создайте сервис для рефералов
+	a.auditSvc = service.NewAuditService(auditRepo, a.logger)
+	a.balances = service.NewBalanceNotifier(userRepo, a.broker, a.config.EventsLeaderboard, a.logger)
+	a.taskSvc = service.NewTaskService(taskRepo, a.auditSvc, a.balances, a.config.DueGracePeriod, a.logger)
+	a.submissionSvc = service.NewSubmissionService(taskRepo, a.auditSvc, a.balances, a.config.DueGracePeriod, a.logger)
+	a.campaignSvc = service.NewCampaignService(campaignRepo, a.logger)
+	a.questSvc = service.NewQuestService(questRepo, taskRepo, a.logger)
+	a.verificationSvc = service.NewVerificationService(verificationRepo, sender, a.config.VerificationTokenTTL, a.config.VerificationResend, a.config.VerificationURL, a.logger)
+	a.userSvc = service.NewUserService(userRepo, a.balances, a.auditSvc, a.verificationSvc, a.logger) // Создайте сервис для пользователей
+	a.referralSvc = service.NewReferralService(referralRepo, a.logger)                                // Создайте сервис для рефералов
+	a.importSvc = service.NewImportService(userRepo, taskRepo, a.logger)
+	a.exportSvc = service.NewExportService(exportRepo, a.logger)
+	a.privacySvc = service.NewPrivacyService(privacyRepo, a.logger)
+	a.retention = service.NewRetentionService(userRepo, taskRepo, a.config.SoftDeleteRetention, a.logger)
+	a.dueDates = service.NewDueDateService(taskRepo, sender, a.config.DueGracePeriod, a.config.DueReminderLead, a.logger)
+	a.jobSvc = service.NewJobService(jobRepo, a.config.JobHistoryRetention, a.logger)
+	a.searchSvc = service.NewSearchService(searchRepo, a.logger)
+	a.scheduler = scheduler.New(jobRepo, a.config.JobInstance, a.logger)
+	return nil
+}
+
+// initScheduler регистрирует фоновые задания
+func (a *App) initScheduler() error {
+	jobs := []struct {
+		name string
+		spec string
+		run  scheduler.JobFunc
+	}{
+		{"soft-delete-purge", a.config.PurgeSchedule, a.retention.Purge},
+		{"campaign-status-sync", a.config.CampaignSyncSchedule, a.campaignSvc.SyncStatuses},
+		{"due-date-sweep", a.config.DueSweepSchedule, a.dueDates.Sweep},
+		{"job-history-purge", a.config.JobHistorySchedule, a.jobSvc.PurgeHistory},
+	}
+	for _, job := range jobs {
+		if err := a.scheduler.Register(job.name, job.spec, job.run); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+// initHTTPServer инициализирует HTTP сервер
+func (a *App) initHTTPServer() error {
 	// Создаем обработчики
-	taskHandler := handlers.NewTaskHandler(taskSvc, a.logger)
-	userHandler := handlers.NewUserHandler(userSvc, a.logger)             // Создайте обработчик для пользователей
-	referralHandler := handlers.NewReferralHandler(referralSvc, a.logger) // Создайте обработчик для рефералов
+	taskHandler := handlers.NewTaskHandler(a.taskSvc, a.logger)
+	userHandler := handlers.NewUserHandler(a.userSvc, a.logger)             // Создайте обработчик для пользователей
+	referralHandler := handlers.NewReferralHandler(a.referralSvc, a.logger) // Создайте обработчик для рефералов
+	eventsHandler := handlers.NewEventsHandler(a.broker, a.config.EventsHeartbeat, a.logger)
+	importHandler := handlers.NewImportHandler(a.importSvc, a.logger)
+	exportHandler := handlers.NewExportHandler(a.exportSvc, a.logger)
+	privacyHandler := handlers.NewPrivacyHandler(a.privacySvc, a.logger)
+	auditHandler := handlers.NewAuditHandler(a.auditSvc, a.logger)
+	verificationHandler := handlers.NewVerificationHandler(a.verificationSvc, a.userSvc, a.logger)
+	submissionHandler := handlers.NewSubmissionHandler(a.submissionSvc, a.logger)
+	campaignHandler := handlers.NewCampaignHandler(a.campaignSvc, a.logger)
+	questHandler := handlers.NewQuestHandler(a.questSvc, a.logger)
+	jobHandler := handlers.NewJobHandler(a.jobSvc, a.logger)
+	searchHandler := handlers.NewSearchHandler(a.searchSvc, a.logger)
+	userHandlerV2 := v2.NewUserHandler(a.userSvc, a.logger)
+	taskHandlerV2 := v2.NewTaskHandler(a.taskSvc, a.logger)
+	referralHandlerV2 := v2.NewReferralHandler(a.referralSvc, a.logger)
+
+	trustedProxies, err := audit.ParseTrustedProxies(a.config.TrustedProxies)
+	if err != nil {
+		return err
+	}
 
 	// Создаем роутер и добавляем маршруты для всех обработчиков
-	r := router.NewRouter(taskHandler, userHandler, referralHandler, a.logger) // Импортируйте новый роутер без хендлеров
+	r := router.NewRouter(taskHandler, userHandler, referralHandler, eventsHandler, importHandler, exportHandler, privacyHandler, auditHandler, verificationHandler, submissionHandler, campaignHandler, questHandler, jobHandler, searchHandler, userHandlerV2, taskHandlerV2, referralHandlerV2, trustedProxies, a.logger) // Импортируйте новый роутер без хендлеров
 
 	// Создаем HTTP сервер
 	a.httpServer = &http.Server{
@@ -122,8 +237,45 @@ func (a *App) initHTTPServer() error {
 	return nil
 }
 
+// initGRPCServer инициализирует gRPC сервер поверх тех же сервисов
+func (a *App) initGRPCServer() {
+	a.grpcServer = grpcserver.NewServer(a.userSvc, a.taskSvc, a.referralSvc, a.logger)
+}
+
 // Run запуск приложения
 func (a *App) Run() error {
+	a.startBackgroundJobs()
+
+	errCh := make(chan error, 2)
+	go func() { errCh <- a.runHTTPServer() }()
+	go func() { errCh <- a.runGRPCServer() }()
+
+	// Возвращаем первую ошибку; при штатной остановке оба сервера завершаются без ошибок
+	for i := 0; i < cap(errCh); i++ {
+		if err := <-errCh; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startBackgroundJobs запускает фоновые задания по расписанию и пересчет таблицы лидеров для подписчиков
+func (a *App) startBackgroundJobs() {
+	a.scheduler.Start()
+	a.balances.Start()
+}
+
+// stopBackgroundJobs отменяет выполняющиеся фоновые задания и дожидается их завершения
+func (a *App) stopBackgroundJobs() {
+	a.balances.Stop()
+	if a.scheduler == nil {
+		return
+	}
+	a.scheduler.Stop()
+}
+
+// runHTTPServer запускает HTTP сервер
+func (a *App) runHTTPServer() error {
 	a.logger.Info("Starting server", zap.String("port", a.config.ServerPort))
 	if err := a.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("failed to start server: %w", err)
@@ -131,17 +283,55 @@ func (a *App) Run() error {
 	return nil
 }
 
-// Shutdown gracefully останавливает приложение
+// runGRPCServer запускает gRPC сервер на отдельном порту
+func (a *App) runGRPCServer() error {
+	listener, err := net.Listen("tcp", ":"+a.config.GRPCPort)
+	if err != nil {
+		return fmt.Errorf("failed to listen gRPC port: %w", err)
+	}
+
+	a.logger.Info("Starting gRPC server", zap.String("port", a.config.GRPCPort))
+	if err := a.grpcServer.Serve(listener); err != nil && err != grpc.ErrServerStopped {
+		return fmt.Errorf("failed to start gRPC server: %w", err)
+	}
+	return nil
+}
+
+// Shutdown gracefully останавливает приложение. Все шаги остановки выполняются, даже если
+// предыдущие завершились ошибкой; ошибки объединяются.
 func (a *App) Shutdown(ctx context.Context) error {
 	a.logger.Info("Shutting down server...")
 
+	// Закрываем SSE-подписки, иначе Shutdown будет ждать завершения долгоживущих потоков
+	a.broker.Close()
+
+	var errs []error
 	if err := a.httpServer.Shutdown(ctx); err != nil {
-		return fmt.Errorf("failed to shutdown server: %w", err)
+		errs = append(errs, fmt.Errorf("failed to shutdown server: %w", err))
 	}
 
+	a.stopGRPCServer(ctx)
+	a.stopBackgroundJobs()
+
 	if err := a.db.Close(); err != nil {
-		return fmt.Errorf("failed to close database connection: %w", err)
+		errs = append(errs, fmt.Errorf("failed to close database connection: %w", err))
 	}
 
-	return nil
+	return stderrors.Join(errs...)
+}
+
+// stopGRPCServer дожидается завершения активных gRPC вызовов, а по истечении контекста прерывает их
+func (a *App) stopGRPCServer(ctx context.Context) {
+	stopped := make(chan struct{})
+	go func() {
+		a.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		a.logger.Warn("gRPC graceful stop timed out, forcing stop")
+		a.grpcServer.Stop()
+	}
 }
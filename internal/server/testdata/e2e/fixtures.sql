@@ -0,0 +1,9 @@
+-- Данные, на которые опираются сценарии сквозных тестов (internal/server/e2e_test.go)
+
+-- Активный пользователь с историей: участвует в рейтинге между пользователями сценариев
+INSERT INTO Users (ID, Username, Email, Balance, Referrals, TasksCompleted, Status)
+VALUES ('6f1c1d36-3c1e-4b7a-9d55-0a7c2e9f4b10', 'dora', 'dora@example.com', 30, 0, 2, 1);
+
+-- Задача, доступная для чтения без предварительного создания
+INSERT INTO tasks (task_id, title, description, status, reward)
+VALUES ('0b5d8f3e-8a2c-4f61-b7a4-3c9e1d2f6a71', 'Fixture task', 'Seeded by fixtures.sql', 1, 5);
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/ZnNr/user-reward-controller/internal/audit"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"github.com/ZnNr/user-reward-controller/internal/service/auth"
+
+	"go.uber.org/zap"
+	"time"
+)
+
+// Ограничения выборки журнала аудита
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// AuditRecorder записывает административные действия в журнал аудита
+type AuditRecorder interface {
+	Record(ctx context.Context, action, targetType, targetID string, before, after interface{})
+}
+
+// AuditService ведет журнал аудита с цепочкой хешей
+type AuditService struct {
+	repo   repository.AuditRepository
+	logger *zap.Logger
+}
+
+// NewAuditService создает новый экземпляр AuditService
+func NewAuditService(repo repository.AuditRepository, logger *zap.Logger) *AuditService {
+	return &AuditService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Record сохраняет действие с разницей между состояниями before и after.
+// Инициатор, идентификатор запроса и IP берутся из контекста. Действие к этому моменту
+// уже выполнено, поэтому ошибка записи не возвращается вызывающему, а пишется в лог.
+func (s *AuditService) Record(ctx context.Context, action, targetType, targetID string, before, after interface{}) {
+	beforeDiff, afterDiff, err := diffJSON(before, after)
+	if err != nil {
+		s.logger.Error("Failed to build audit diff", zap.String("action", action), zap.Error(err))
+		return
+	}
+
+	md := audit.FromContext(ctx)
+	entry := &models.AuditEntry{
+		Actor:      md.Actor,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Before:     beforeDiff,
+		After:      afterDiff,
+		RequestID:  md.RequestID,
+		IP:         md.IP,
+		// Postgres хранит время с точностью до микросекунд; хеш должен совпадать после чтения из базы
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+
+	// Запись не должна теряться, если клиент разорвал соединение сразу после выполнения действия
+	if err := s.repo.AppendEntry(context.WithoutCancel(ctx), entry); err != nil {
+		s.logger.Error("Failed to write audit entry",
+			zap.String("action", action),
+			zap.String("target", targetID),
+			zap.String("actor", md.Actor),
+			zap.Error(err))
+		return
+	}
+	s.logger.Info("Audit entry recorded",
+		zap.Int64("id", entry.ID),
+		zap.String("action", action),
+		zap.String("target", targetID),
+		zap.String("actor", md.Actor))
+}
+
+// GetEntries возвращает записи журнала аудита по фильтру; доступно только администраторам
+func (s *AuditService) GetEntries(ctx context.Context, filter *models.AuditFilter) ([]models.AuditEntry, error) {
+	if !auth.IsAdmin(ctx) {
+		return nil, errors.NewForbidden("only administrators can read the audit log", nil)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	if filter.Limit > maxAuditLimit {
+		return nil, errors.NewBadRequest("limit cannot exceed 1000", nil)
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return nil, errors.NewBadRequest("from must be earlier than to", nil)
+	}
+
+	entries, err := s.repo.GetEntries(ctx, filter)
+	if err != nil {
+		s.logger.Error("Failed to query audit log", zap.Error(err))
+		return nil, err
+	}
+	return entries, nil
+}
+
+// Verify пересчитывает хеши всех записей и проверяет, что цепочка не нарушена; доступно только администраторам
+func (s *AuditService) Verify(ctx context.Context) (*models.AuditVerification, error) {
+	if !auth.IsAdmin(ctx) {
+		return nil, errors.NewForbidden("only administrators can read the audit log", nil)
+	}
+	result := &models.AuditVerification{Valid: true}
+	err := s.repo.ScanEntries(ctx, func(entry *models.AuditEntry) error {
+		if !result.Valid {
+			return nil
+		}
+		result.Checked++
+
+		// Содержимое затертых записей изменено намеренно; цепочку по-прежнему подтверждает ContentHash
+		switch {
+		case entry.PrevHash != result.LastHash:
+			result.Violation = "prev_hash does not match the previous entry"
+		case entry.RedactedAt == nil && entry.ComputeContentHash() != entry.ContentHash:
+			result.Violation = "entry content does not match its hash"
+		case entry.ComputeHash() != entry.Hash:
+			result.Violation = "entry does not match its hash"
+		default:
+			result.LastHash = entry.Hash
+			return nil
+		}
+		result.Valid = false
+		result.BrokenAt = entry.ID
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("Failed to verify audit log", zap.Error(err))
+		return nil, err
+	}
+
+	if !result.Valid {
+		s.logger.Warn("Audit log hash chain is broken", zap.Int64("entryID", result.BrokenAt), zap.String("violation", result.Violation))
+	}
+	return result, nil
+}
+
+// diffJSON сериализует before и after в JSON-объекты и оставляет только различающиеся поля.
+// Если одно из состояний отсутствует (nil), другое сохраняется целиком.
+func diffJSON(before, after interface{}) (json.RawMessage, json.RawMessage, error) {
+	beforeFields, err := toJSONFields(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterFields, err := toJSONFields(after)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if beforeFields != nil && afterFields != nil {
+		for key, value := range beforeFields {
+			if other, ok := afterFields[key]; ok && bytes.Equal(value, other) {
+				delete(beforeFields, key)
+				delete(afterFields, key)
+			}
+		}
+	}
+
+	beforeJSON, err := marshalFields(beforeFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	afterJSON, err := marshalFields(afterFields)
+	if err != nil {
+		return nil, nil, err
+	}
+	return beforeJSON, afterJSON, nil
+}
+
+// toJSONFields преобразует значение в набор полей JSON-объекта; nil остается nil.
+func toJSONFields(value interface{}) (map[string]json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// marshalFields сериализует поля с сортировкой ключей, чтобы результат был детерминированным.
+func marshalFields(fields map[string]json.RawMessage) (json.RawMessage, error) {
+	if fields == nil {
+		return nil, nil
+	}
+	return json.Marshal(fields)
+}
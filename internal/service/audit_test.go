@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// auditRepo хранит журнал аудита в памяти с цепочкой хешей
+type auditRepo struct {
+	entries []models.AuditEntry
+}
+
+func (r *auditRepo) AppendEntry(ctx context.Context, entry *models.AuditEntry) error {
+	entry.ID = int64(len(r.entries) + 1)
+	if len(r.entries) > 0 {
+		entry.PrevHash = r.entries[len(r.entries)-1].Hash
+	}
+	entry.ContentHash = entry.ComputeContentHash()
+	entry.Hash = entry.ComputeHash()
+	r.entries = append(r.entries, *entry)
+	return nil
+}
+
+func (r *auditRepo) GetEntries(ctx context.Context, filter *models.AuditFilter) ([]models.AuditEntry, error) {
+	var entries []models.AuditEntry
+	for i := len(r.entries) - 1; i >= 0; i-- {
+		if filter.TargetID == "" || r.entries[i].TargetID == filter.TargetID {
+			entries = append(entries, r.entries[i])
+		}
+	}
+	return entries, nil
+}
+
+func (r *auditRepo) ScanEntries(ctx context.Context, fn func(*models.AuditEntry) error) error {
+	for i := range r.entries {
+		if err := fn(&r.entries[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestAuditLogRequiresAdmin(t *testing.T) {
+	audits := NewAuditService(&auditRepo{}, zap.NewNop())
+	audits.Record(context.Background(), models.AuditUserStatusChange, models.AuditTargetUser, "erin", nil, nil)
+
+	for name, ctx := range map[string]context.Context{"anonymous": context.Background(), "user": userContext("erin")} {
+		if _, err := audits.GetEntries(ctx, &models.AuditFilter{}); !errors.IsErrorType(err, errors.Forbidden) {
+			t.Errorf("%s reads the audit log: %v", name, err)
+		}
+		if _, err := audits.Verify(ctx); !errors.IsErrorType(err, errors.Forbidden) {
+			t.Errorf("%s verifies the audit log: %v", name, err)
+		}
+	}
+
+	entries, err := audits.GetEntries(adminContext(), &models.AuditFilter{})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("admin reads the audit log: %v, %v", entries, err)
+	}
+	if result, err := audits.Verify(adminContext()); err != nil || !result.Valid {
+		t.Fatalf("admin verifies the audit log: %+v, %v", result, err)
+	}
+}
@@ -2,50 +2,90 @@ package auth
 
 import (
 	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/problem"
 	"github.com/golang-jwt/jwt/v4"
 	"net/http"
 	"strings"
 )
 
+// secretKey - ключ подписи JWT токенов
+const secretKey = "your_secret_key"
+
 // AuthMiddleware проверяет JWT токен
 func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Извлечение токена из заголовков
 		tokenString := r.Header.Get("Authorization")
 		if tokenString == "" {
-			http.Error(w, errors.ErrMsgInvalidToken, http.StatusUnauthorized)
+			problem.WriteError(w, r, errors.NewInvalidToken(errors.ErrMsgInvalidToken, nil))
 			return
 		}
 
-		tokenString = strings.TrimSpace(strings.TrimPrefix(tokenString, "Bearer "))
-
-		// Парсинг и валидация токена
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Проверка алгоритма токена
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, errors.NewInvalidToken("token uses invalid signing method", nil)
-			}
-			return []byte("your_secret_key"), nil
-		})
-
-		if err != nil {
+		if err := ValidateToken(tokenString); err != nil {
 			// Проверка на недействительный токен
 			if errors.IsInvalidToken(err) {
-				http.Error(w, errors.ErrMsgInvalidToken, http.StatusUnauthorized)
+				problem.WriteError(w, r, errors.NewInvalidToken(errors.ErrMsgInvalidToken, err))
 				return
 			}
-			http.Error(w, "Unauthorized: token parsing error", http.StatusUnauthorized)
+			problem.WriteError(w, r, errors.NewInvalidToken("token parsing error", err))
 			return
 		}
 
-		// Проверка токена на валидность
-		if token.Valid {
-			// Токен валиден, продолжаем выполнение следующего обработчика
-			next.ServeHTTP(w, r)
-			return
-		} else {
-			http.Error(w, errors.ErrMsgInvalidToken, http.StatusUnauthorized)
-			return
+		// Токен валиден, продолжаем выполнение следующего обработчика
+		next.ServeHTTP(w, r)
+	})
+}
+
+// anonymousActor - актор запросов без действительного токена
+const anonymousActor = "anonymous"
+
+// ValidateToken разбирает значение заголовка авторизации (с префиксом "Bearer " или без него)
+// и проверяет подпись JWT токена. Используется HTTP middleware и gRPC интерсепторами.
+func ValidateToken(header string) error {
+	_, err := parseToken(header)
+	return err
+}
+
+// Subject проверяет токен и возвращает его subject (claim "sub").
+func Subject(header string) (string, error) {
+	principal, err := principalFromHeader(header)
+	if err != nil {
+		return "", err
+	}
+	return principal.Subject, nil
+}
+
+// ActorFromRequest возвращает идентификатор инициатора HTTP запроса для журнала аудита.
+// Использует данные, сохраненные PrincipalMiddleware.
+func ActorFromRequest(r *http.Request) string {
+	if principal, ok := PrincipalFromContext(r.Context()); ok {
+		return principal.Subject
+	}
+	return anonymousActor
+}
+
+// parseToken разбирает и проверяет JWT токен из значения заголовка авторизации.
+func parseToken(header string) (*jwt.Token, error) {
+	tokenString := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+	if tokenString == "" {
+		return nil, errors.NewInvalidToken(errors.ErrMsgInvalidToken, nil)
+	}
+
+	// Парсинг и валидация токена
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		// Проверка алгоритма токена
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.NewInvalidToken("token uses invalid signing method", nil)
 		}
+		return []byte(secretKey), nil
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Проверка токена на валидность
+	if !token.Valid {
+		return nil, errors.NewInvalidToken(errors.ErrMsgInvalidToken, nil)
+	}
+	return token, nil
 }
@@ -0,0 +1,83 @@
+package auth
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/audit"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/logging"
+	"net"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Ключи метаданных gRPC
+const (
+	authorizationKey = "authorization" // Токен клиента
+	requestIDKey     = "x-request-id"  // Идентификатор запроса
+)
+
+// UnaryAuthInterceptor проверяет JWT токен для унарных gRPC вызовов, аналогично AuthMiddleware,
+// и сохраняет в контексте данные об инициаторе вызова для журнала аудита
+func UnaryAuthInterceptor(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	principal, err := authorize(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return handler(withAuditMetadata(WithPrincipal(ctx, principal), principal.Subject), req)
+}
+
+// StreamAuthInterceptor проверяет JWT токен для потоковых gRPC вызовов, аналогично AuthMiddleware
+func StreamAuthInterceptor(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if _, err := authorize(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// authorize извлекает токен из метаданных запроса, проверяет его и возвращает его владельца.
+func authorize(ctx context.Context) (Principal, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return Principal{}, status.Error(codes.Unauthenticated, errors.ErrMsgInvalidToken)
+	}
+
+	values := md.Get(authorizationKey)
+	if len(values) == 0 || values[0] == "" {
+		return Principal{}, status.Error(codes.Unauthenticated, errors.ErrMsgInvalidToken)
+	}
+
+	principal, err := principalFromHeader(values[0])
+	if err != nil {
+		if errors.IsInvalidToken(err) {
+			return Principal{}, status.Error(codes.Unauthenticated, errors.ErrMsgInvalidToken)
+		}
+		return Principal{}, status.Error(codes.Unauthenticated, "unauthorized: token parsing error")
+	}
+	return principal, nil
+}
+
+// withAuditMetadata дополняет контекст вызова актором, идентификатором запроса и адресом клиента.
+func withAuditMetadata(ctx context.Context, actor string) context.Context {
+	requestID := uuid.NewString()
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDKey); len(values) > 0 && values[0] != "" {
+			requestID = values[0]
+		}
+	}
+
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+
+	ctx = logging.WithRequestID(ctx, requestID)
+	return audit.WithMetadata(ctx, audit.Metadata{Actor: actor, RequestID: requestID, IP: ip})
+}
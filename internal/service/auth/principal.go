@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// RoleAdmin - значение claim "role" токена администратора
+const RoleAdmin = "admin"
+
+// Principal описывает владельца проверенного токена
+type Principal struct {
+	Subject string // Claim "sub"
+	Role    string // Claim "role"
+}
+
+// IsAdmin проверяет, что токен выдан администратору
+func (p Principal) IsAdmin() bool {
+	return p.Role == RoleAdmin
+}
+
+type principalKey struct{}
+
+// WithPrincipal возвращает контекст с владельцем токена
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext возвращает владельца токена, если запрос был авторизован
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(Principal)
+	return principal, ok
+}
+
+// IsAdmin проверяет, что запрос выполняется от имени администратора
+func IsAdmin(ctx context.Context) bool {
+	principal, ok := PrincipalFromContext(ctx)
+	return ok && principal.IsAdmin()
+}
+
+// IsUserOrAdmin проверяет, что запрос выполняется самим пользователем userID или администратором
+func IsUserOrAdmin(ctx context.Context, userID string) bool {
+	principal, ok := PrincipalFromContext(ctx)
+	return ok && (principal.IsAdmin() || principal.Subject == userID)
+}
+
+// PrincipalMiddleware сохраняет в контексте владельца действительного токена из заголовка Authorization.
+// Запросы без токена или с недействительным токеном пропускаются анонимно; отклонять их - задача AuthMiddleware.
+func PrincipalMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if header := r.Header.Get("Authorization"); header != "" {
+			if principal, err := principalFromHeader(header); err == nil {
+				r = r.WithContext(WithPrincipal(r.Context(), principal))
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// IssueToken подписывает токен с subject и ролью владельца. Используется тестами и служебными утилитами,
+// сами токены выдает внешний сервис авторизации.
+func IssueToken(principal Principal) (string, error) {
+	claims := jwt.MapClaims{"sub": principal.Subject}
+	if principal.Role != "" {
+		claims["role"] = principal.Role
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secretKey))
+}
+
+// principalFromHeader проверяет токен и извлекает из него subject и роль.
+func principalFromHeader(header string) (Principal, error) {
+	token, err := parseToken(header)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	principal := Principal{Subject: anonymousActor}
+	if claims, ok := token.Claims.(jwt.MapClaims); ok {
+		if sub, ok := claims["sub"].(string); ok && sub != "" {
+			principal.Subject = sub
+		}
+		if role, ok := claims["role"].(string); ok {
+			principal.Role = role
+		}
+	}
+	return principal, nil
+}
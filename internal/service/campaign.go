@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"github.com/ZnNr/user-reward-controller/internal/service/auth"
+	"github.com/ZnNr/user-reward-controller/internal/validation"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"time"
+)
+
+// Ограничения выборки кампаний
+const (
+	defaultCampaignLimit = 100
+	maxCampaignLimit     = 1000
+)
+
+// CampaignService управляет кампаниями и переключает их статусы по расписанию
+type CampaignService struct {
+	repo   repository.CampaignRepository
+	logger *zap.Logger
+}
+
+// NewCampaignService создает новый экземпляр CampaignService
+func NewCampaignService(repo repository.CampaignRepository, logger *zap.Logger) *CampaignService {
+	return &CampaignService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// CreateCampaign создает кампанию; доступно только администраторам
+func (s *CampaignService) CreateCampaign(ctx context.Context, req *models.CampaignRequest) (*models.Campaign, error) {
+	if !auth.IsAdmin(ctx) {
+		return nil, errors.NewForbidden("only administrators can manage campaigns", nil)
+	}
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	campaign, err := s.repo.CreateCampaign(ctx, &models.Campaign{
+		CampaignID:  uuid.New().String(),
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		StartsAt:    *req.StartsAt,
+		EndsAt:      *req.EndsAt,
+		Budget:      *req.Budget,
+		Status:      models.CampaignStatusAt(*req.StartsAt, *req.EndsAt, time.Now()),
+	})
+	if err != nil {
+		s.logger.Error("Failed to create campaign", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Campaign created", zap.String("campaignID", campaign.CampaignID), zap.String("status", string(campaign.Status)))
+	return campaign, nil
+}
+
+// UpdateCampaign обновляет кампанию; доступно только администраторам
+func (s *CampaignService) UpdateCampaign(ctx context.Context, id string, req *models.CampaignRequest) (*models.Campaign, error) {
+	if !auth.IsAdmin(ctx) {
+		return nil, errors.NewForbidden("only administrators can manage campaigns", nil)
+	}
+	if err := validateCampaignID(id); err != nil {
+		return nil, err
+	}
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	campaign, err := s.repo.UpdateCampaign(ctx, &models.Campaign{
+		CampaignID:  id,
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		StartsAt:    *req.StartsAt,
+		EndsAt:      *req.EndsAt,
+		Budget:      *req.Budget,
+		Status:      models.CampaignStatusAt(*req.StartsAt, *req.EndsAt, time.Now()),
+	})
+	if err != nil {
+		s.logger.Error("Failed to update campaign", zap.String("campaignID", id), zap.Error(err))
+		return nil, err
+	}
+	return campaign, nil
+}
+
+// GetCampaign возвращает кампанию по ID
+func (s *CampaignService) GetCampaign(ctx context.Context, id string) (*models.Campaign, error) {
+	if err := validateCampaignID(id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetCampaignByID(ctx, id)
+}
+
+// GetCampaigns возвращает кампании по фильтру
+func (s *CampaignService) GetCampaigns(ctx context.Context, filter *models.CampaignFilter) ([]models.Campaign, error) {
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, errors.NewBadRequest(fmt.Sprintf("unknown campaign status %q", filter.Status), nil)
+	}
+	if filter.Offset < 0 {
+		return nil, errors.NewBadRequest("offset cannot be negative", nil)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultCampaignLimit
+	}
+	if filter.Limit > maxCampaignLimit {
+		filter.Limit = maxCampaignLimit
+	}
+	return s.repo.GetCampaigns(ctx, filter)
+}
+
+// GetCampaignStats возвращает участников, выполнения и израсходованный бюджет кампании
+func (s *CampaignService) GetCampaignStats(ctx context.Context, id string) (*models.CampaignStats, error) {
+	if err := validateCampaignID(id); err != nil {
+		return nil, err
+	}
+	return s.repo.GetCampaignStats(ctx, id)
+}
+
+// SyncStatuses активирует начавшиеся и завершает истекшие кампании.
+func (s *CampaignService) SyncStatuses(ctx context.Context) error {
+	changed, err := s.repo.SyncCampaignStatuses(ctx, time.Now())
+	if err != nil {
+		s.logger.Error("Failed to sync campaign statuses", zap.Error(err))
+		return err
+	}
+	if changed > 0 {
+		s.logger.Info("Campaign statuses updated by schedule", zap.Int64("campaigns", changed))
+	}
+	return nil
+}
+
+// validateCampaignID проверяет идентификатор кампании
+func validateCampaignID(id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return errors.NewBadRequest("invalid campaign ID", err)
+	}
+	return nil
+}
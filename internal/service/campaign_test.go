@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository/memory"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Вознаграждение за задачу кампании ограничено остатком бюджета и возвращается в бюджет при повторном открытии;
+// задачу кампании, которая еще не началась, выполнить нельзя
+func TestCampaignBudget(t *testing.T) {
+	store := memory.NewStore()
+	users := NewUserService(memory.NewUserRepository(store), nil, nil, nil, zap.NewNop())
+	tasks := NewTaskService(memory.NewTaskRepository(store), nil, nil, 0, zap.NewNop())
+	campaigns := NewCampaignService(memory.NewCampaignRepository(store), zap.NewNop())
+
+	createCampaign := func(name string, startsAt time.Time) string {
+		t.Helper()
+		endsAt := startsAt.Add(24 * time.Hour)
+		campaign, err := campaigns.CreateCampaign(adminContext(), &models.CampaignRequest{
+			Name: name, StartsAt: &startsAt, EndsAt: &endsAt, Budget: ptr(20.0),
+		})
+		if err != nil {
+			t.Fatalf("create campaign: %v", err)
+		}
+		return campaign.CampaignID
+	}
+	active := createCampaign("Spring", time.Now().Add(-time.Hour))
+	scheduled := createCampaign("Summer", time.Now().Add(time.Hour))
+
+	createTask := func(title, campaignID string) string {
+		t.Helper()
+		task, err := tasks.CreateTask(context.Background(), &models.CreateTaskRequest{BaseTaskRequest: models.BaseTaskRequest{
+			Title: title, Reward: 15, CampaignID: &campaignID,
+		}})
+		if err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+		return task.TaskID
+	}
+	review, deploy, announce := createTask("Review", active), createTask("Deploy", active), createTask("Announce", scheduled)
+	if _, err := tasks.CreateTask(context.Background(), &models.CreateTaskRequest{BaseTaskRequest: models.BaseTaskRequest{
+		Title: "Orphan", CampaignID: ptr(uuid.NewString()),
+	}}); !errors.IsErrorType(err, errors.NotFound) {
+		t.Fatalf("create a task of an unknown campaign: %v", err)
+	}
+
+	createUser := func(name string) string {
+		t.Helper()
+		user, err := users.CreateUser(context.Background(), &models.CreateUserRequest{Username: name, Email: name + "@example.com"})
+		if err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		return user.ID
+	}
+	erin, frank := createUser("erin"), createUser("frank")
+
+	tests := []struct {
+		name    string
+		taskID  string
+		userID  string
+		status  models.TaskStatus
+		want    errors.ErrorType
+		balance float64
+		spent   float64
+	}{
+		{"full reward", review, erin, models.Completed, "", 15, 15},
+		{"partial reward at budget exhaustion", deploy, frank, models.Completed, "", 5, 20},
+		{"refund after reopen", review, erin, models.InProgress, "", 0, 5},
+		{"reward from the refunded budget", review, erin, models.Completed, "", 15, 20},
+		{"campaign not started", announce, erin, models.Completed, errors.Conflict, 15, 20},
+	}
+	for _, tt := range tests {
+		_, err := tasks.UpdateTaskStatus(context.Background(), tt.taskID, tt.status, uuid.MustParse(tt.userID))
+		if tt.want == "" && err != nil || tt.want != "" && !errors.IsErrorType(err, tt.want) {
+			t.Fatalf("%s: got %v, want %s", tt.name, err, tt.want)
+		}
+		user, err := users.GetUserByID(context.Background(), tt.userID)
+		if err != nil {
+			t.Fatalf("%s: get user: %v", tt.name, err)
+		}
+		stats, err := campaigns.GetCampaignStats(context.Background(), active)
+		if err != nil {
+			t.Fatalf("%s: campaign stats: %v", tt.name, err)
+		}
+		if user.Balance != tt.balance || stats.PointsSpent != tt.spent || stats.Exhausted != (tt.spent == 20) {
+			t.Fatalf("%s: balance %v, stats %+v, want balance %v, spent %v", tt.name, user.Balance, stats, tt.balance, tt.spent)
+		}
+	}
+}
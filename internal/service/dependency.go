@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"github.com/ZnNr/user-reward-controller/internal/service/auth"
+	"github.com/ZnNr/user-reward-controller/internal/validation"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// errTaskLocked возвращается при попытке взять или выполнить задачу до выполнения ее зависимостей
+const errTaskLocked = "task is locked until its dependencies are completed"
+
+// AddDependencies добавляет задаче зависимости; доступно только администраторам
+func (s *TaskService) AddDependencies(ctx context.Context, taskID string, req *models.DependencyRequest) ([]string, error) {
+	if !auth.IsAdmin(ctx) {
+		return nil, errors.NewForbidden("only administrators can manage task dependencies", nil)
+	}
+	if _, err := uuid.Parse(taskID); err != nil {
+		return nil, errors.NewBadRequest("invalid task ID", err)
+	}
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+	for _, id := range req.DependsOn {
+		if id == taskID {
+			return nil, errors.NewValidation("task cannot depend on itself", nil)
+		}
+	}
+
+	if err := s.repo.AddTaskDependencies(ctx, taskID, req.DependsOn); err != nil {
+		s.logger.Error("Failed to add task dependencies", zap.String("taskID", taskID), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Task dependencies added", zap.String("taskID", taskID), zap.Strings("dependsOn", req.DependsOn))
+	return s.GetDependencies(ctx, taskID)
+}
+
+// RemoveDependency удаляет зависимость задачи; доступно только администраторам
+func (s *TaskService) RemoveDependency(ctx context.Context, taskID, dependsOnID string) error {
+	if !auth.IsAdmin(ctx) {
+		return errors.NewForbidden("only administrators can manage task dependencies", nil)
+	}
+	if _, err := uuid.Parse(taskID); err != nil {
+		return errors.NewBadRequest("invalid task ID", err)
+	}
+	if _, err := uuid.Parse(dependsOnID); err != nil {
+		return errors.NewBadRequest("invalid dependency task ID", err)
+	}
+
+	if err := s.repo.RemoveTaskDependency(ctx, taskID, dependsOnID); err != nil {
+		s.logger.Error("Failed to remove task dependency", zap.String("taskID", taskID), zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Task dependency removed", zap.String("taskID", taskID), zap.String("dependsOn", dependsOnID))
+	return nil
+}
+
+// GetDependencies возвращает прямые зависимости задачи
+func (s *TaskService) GetDependencies(ctx context.Context, taskID string) ([]string, error) {
+	if _, err := s.GetTaskByID(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	dependencies, err := s.repo.GetTaskDependencies(ctx, []string{taskID})
+	if err != nil {
+		s.logger.Error("Failed to get task dependencies", zap.String("taskID", taskID), zap.Error(err))
+		return nil, err
+	}
+	if dependencies[taskID] == nil {
+		return []string{}, nil
+	}
+	return dependencies[taskID], nil
+}
+
+// GetTaskAvailability возвращает доступность задачи для пользователя с учетом ее зависимостей
+func (s *TaskService) GetTaskAvailability(ctx context.Context, userID, taskID string) (*models.TaskAvailability, error) {
+	if err := validateUUID(userID); err != nil {
+		return nil, err
+	}
+	if _, err := s.GetTaskByID(ctx, taskID); err != nil {
+		return nil, err
+	}
+
+	availability, err := computeAvailability(ctx, s.repo, userID, []string{taskID})
+	if err != nil {
+		s.logger.Error("Failed to compute task availability", zap.String("taskID", taskID), zap.Error(err))
+		return nil, err
+	}
+	return &availability[0], nil
+}
+
+// ensureTaskAvailable возвращает Conflict, если пользователь еще не выполнил зависимости задачи
+func ensureTaskAvailable(ctx context.Context, repo repository.TaskRepository, userID, taskID string) error {
+	availability, err := computeAvailability(ctx, repo, userID, []string{taskID})
+	if err != nil {
+		return err
+	}
+	if !availability[0].Available {
+		return errors.NewConflict(errTaskLocked, nil)
+	}
+	return nil
+}
+
+// computeAvailability вычисляет доступность задач для пользователя: задача доступна,
+// когда пользователь выполнил все ее прямые зависимости. Результат следует порядку taskIDs.
+func computeAvailability(ctx context.Context, repo repository.TaskRepository, userID string, taskIDs []string) ([]models.TaskAvailability, error) {
+	dependencies, err := repo.GetTaskDependencies(ctx, taskIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := append([]string{}, taskIDs...)
+	for _, dependsOn := range dependencies {
+		ids = append(ids, dependsOn...)
+	}
+	completed, err := repo.GetCompletedTaskIDs(ctx, userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	availability := make([]models.TaskAvailability, len(taskIDs))
+	for i, taskID := range taskIDs {
+		availability[i] = models.TaskAvailability{TaskID: taskID, Completed: completed[taskID]}
+		for _, dependsOn := range dependencies[taskID] {
+			if !completed[dependsOn] {
+				availability[i].BlockedBy = append(availability[i].BlockedBy, dependsOn)
+			}
+		}
+		availability[i].Available = len(availability[i].BlockedBy) == 0
+	}
+	return availability, nil
+}
@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository/memory"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Зависимость, замыкающая цикл напрямую или через другие задачи, отклоняется
+func TestAddDependenciesCycles(t *testing.T) {
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		task      string
+		dependsOn []string
+		want      errors.ErrorType
+		deps      int // Количество прямых зависимостей задачи после запроса
+	}{
+		{"self", adminContext(), "a", []string{"a"}, errors.Validation, 0},
+		{"direct cycle", adminContext(), "a", []string{"b"}, errors.Validation, 0},
+		{"indirect cycle", adminContext(), "a", []string{"c"}, errors.Validation, 0},
+		{"cycle through one of several", adminContext(), "a", []string{"d", "c"}, errors.Validation, 0},
+		{"new branch", adminContext(), "d", []string{"c", "a"}, "", 2},
+		{"existing dependency", adminContext(), "c", []string{"b"}, "", 1},
+		{"unknown task", adminContext(), "d", []string{"unknown"}, errors.NotFound, 0},
+		{"not an administrator", userContext("erin"), "d", []string{"a"}, errors.Forbidden, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tasks := NewTaskService(memory.NewTaskRepository(memory.NewStore()), nil, nil, 0, zap.NewNop())
+			ids := map[string]string{"unknown": uuid.NewString()}
+			for _, name := range []string{"a", "b", "c", "d"} {
+				task, err := tasks.CreateTask(context.Background(), &models.CreateTaskRequest{BaseTaskRequest: models.BaseTaskRequest{Title: name}})
+				if err != nil {
+					t.Fatalf("create task: %v", err)
+				}
+				ids[name] = task.TaskID
+			}
+			// c зависит от b, b - от a
+			for _, edge := range [][2]string{{"b", "a"}, {"c", "b"}} {
+				if _, err := tasks.AddDependencies(adminContext(), ids[edge[0]], &models.DependencyRequest{DependsOn: []string{ids[edge[1]]}}); err != nil {
+					t.Fatalf("add dependency %s -> %s: %v", edge[0], edge[1], err)
+				}
+			}
+
+			dependsOn := make([]string, len(tt.dependsOn))
+			for i, name := range tt.dependsOn {
+				dependsOn[i] = ids[name]
+			}
+			_, err := tasks.AddDependencies(tt.ctx, ids[tt.task], &models.DependencyRequest{DependsOn: dependsOn})
+			if tt.want == "" && err != nil || tt.want != "" && !errors.IsErrorType(err, tt.want) {
+				t.Fatalf("got %v, want %s", err, tt.want)
+			}
+
+			got, err := tasks.GetDependencies(context.Background(), ids[tt.task])
+			if err != nil || len(got) != tt.deps {
+				t.Fatalf("dependencies of %s: %v, %v, want %d", tt.task, got, err, tt.deps)
+			}
+		})
+	}
+}
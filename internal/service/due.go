@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/audit"
+	"github.com/ZnNr/user-reward-controller/internal/mail"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+
+	"go.uber.org/zap"
+	"time"
+)
+
+// dueReminderBatch - максимальное количество напоминаний, отправляемых за один запуск
+const dueReminderBatch = 500
+
+// DueDateService переводит просроченные задачи в Expired и напоминает исполнителям о приближении срока
+type DueDateService struct {
+	repo         repository.TaskRepository
+	sender       mail.Sender
+	grace        time.Duration
+	reminderLead time.Duration
+	logger       *zap.Logger
+}
+
+// NewDueDateService создает новый экземпляр DueDateService.
+// grace - отсрочка после срока, в течение которой задачу еще можно выполнить;
+// reminderLead - за сколько до срока напоминать исполнителям (0 отключает напоминания).
+func NewDueDateService(repo repository.TaskRepository, sender mail.Sender, grace, reminderLead time.Duration, logger *zap.Logger) *DueDateService {
+	return &DueDateService{
+		repo:         repo,
+		sender:       sender,
+		grace:        grace,
+		reminderLead: reminderLead,
+		logger:       logger,
+	}
+}
+
+// Sweep просрочивает задачи, срок которых с учетом отсрочки истек, и отправляет напоминания.
+func (s *DueDateService) Sweep(ctx context.Context) error {
+	now := time.Now()
+
+	expired, err := s.repo.ExpireOverdueTasks(ctx, now.Add(-s.grace), audit.FromContext(ctx).Actor)
+	if err != nil {
+		s.logger.Error("Failed to expire overdue tasks", zap.Error(err))
+		return err
+	}
+	if expired > 0 {
+		s.logger.Info("Overdue tasks expired", zap.Int64("expired", expired))
+	}
+
+	if s.reminderLead <= 0 {
+		return nil
+	}
+	return s.sendReminders(ctx, now)
+}
+
+// sendReminders отправляет исполнителям напоминания о задачах со сроком в ближайшие reminderLead.
+// Напоминание отмечается отправленным только после успешной отправки письма.
+func (s *DueDateService) sendReminders(ctx context.Context, now time.Time) error {
+	reminders, err := s.repo.GetDueReminders(ctx, now, now.Add(s.reminderLead), dueReminderBatch)
+	if err != nil {
+		s.logger.Error("Failed to get due reminders", zap.Error(err))
+		return err
+	}
+
+	for _, reminder := range reminders {
+		msg := mail.Message{
+			To:      reminder.Email,
+			Subject: fmt.Sprintf("Task %q is due soon", reminder.Title),
+			Body: fmt.Sprintf("Hello, %s!\n\nThe task %q is due at %s.\n",
+				reminder.Username, reminder.Title, reminder.DueDate.UTC().Format(time.RFC1123)),
+		}
+		if err := s.sender.Send(ctx, msg); err != nil {
+			s.logger.Error("Failed to send due reminder", zap.String("taskID", reminder.TaskID),
+				zap.String("userID", reminder.UserID), zap.Error(err))
+			continue
+		}
+		if err := s.repo.MarkDueReminderSent(ctx, reminder.TaskID, reminder.UserID); err != nil {
+			s.logger.Error("Failed to mark due reminder", zap.String("taskID", reminder.TaskID), zap.Error(err))
+			return err
+		}
+	}
+
+	if len(reminders) > 0 {
+		s.logger.Info("Due reminders sent", zap.Int("reminders", len(reminders)))
+	}
+	return nil
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"github.com/ZnNr/user-reward-controller/internal/service/auth"
+
+	"go.uber.org/zap"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ExportService выгружает пользователей, задачи и журнал баланса в потоковом режиме
+type ExportService struct {
+	repo   repository.ExportRepository
+	logger *zap.Logger
+}
+
+// NewExportService создает новый экземпляр ExportService
+func NewExportService(repo repository.ExportRepository, logger *zap.Logger) *ExportService {
+	return &ExportService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// ValidateExport проверяет права и параметры выгрузки до начала записи ответа.
+// Выгрузки содержат персональные данные и журнал баланса, поэтому доступны только администраторам.
+func ValidateExport(ctx context.Context, kind models.ExportKind, format models.ExportFormat, filter *models.ExportFilter) error {
+	if !auth.IsAdmin(ctx) {
+		return errors.NewForbidden("only administrators can export data", nil)
+	}
+	switch kind {
+	case models.ExportUsers, models.ExportTasks, models.ExportLedger:
+	default:
+		return errors.NewNotFound(fmt.Sprintf("unknown export %q", kind), nil)
+	}
+	switch format {
+	case models.ExportFormatCSV, models.ExportFormatNDJSON:
+	default:
+		return errors.NewBadRequest("format must be csv or ndjson", nil)
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		return errors.NewBadRequest("from must be earlier than to", nil)
+	}
+	if kind == models.ExportLedger && filter.Status != 0 {
+		return errors.NewBadRequest("status filter is not supported for ledger export", nil)
+	}
+	if kind != models.ExportLedger && filter.UserID != "" {
+		return errors.NewBadRequest("user_id filter is supported only for ledger export", nil)
+	}
+	if filter.UserID != "" {
+		if err := validateUUID(filter.UserID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Export записывает выбранный набор данных в w по мере чтения из базы данных.
+func (s *ExportService) Export(ctx context.Context, kind models.ExportKind, format models.ExportFormat, filter *models.ExportFilter, w io.Writer) error {
+	if err := ValidateExport(ctx, kind, format, filter); err != nil {
+		return err
+	}
+
+	start := time.Now()
+	var count int
+	var err error
+	switch kind {
+	case models.ExportUsers:
+		enc := newExportEncoder(w, format, userCSVHeader, userCSVRecord)
+		err = s.repo.ExportUsers(ctx, filter, func(user *models.User) error {
+			count++
+			return enc.write(user)
+		})
+		err = enc.finish(err)
+	case models.ExportTasks:
+		enc := newExportEncoder(w, format, taskCSVHeader, taskCSVRecord)
+		err = s.repo.ExportTasks(ctx, filter, func(task *models.Task) error {
+			count++
+			return enc.write(task)
+		})
+		err = enc.finish(err)
+	case models.ExportLedger:
+		enc := newExportEncoder(w, format, ledgerCSVHeader, ledgerCSVRecord)
+		err = s.repo.ExportLedger(ctx, filter, func(entry *models.LedgerEntry) error {
+			count++
+			return enc.write(entry)
+		})
+		err = enc.finish(err)
+	}
+	if err != nil {
+		s.logger.Error("Export failed", zap.String("kind", string(kind)), zap.Int("rows", count), zap.Error(err))
+		return err
+	}
+
+	s.logger.Info("Export finished",
+		zap.String("kind", string(kind)),
+		zap.String("format", string(format)),
+		zap.Int("rows", count),
+		zap.Duration("duration", time.Since(start)))
+	return nil
+}
+
+// exportEncoder сериализует записи в CSV или NDJSON
+type exportEncoder[T any] struct {
+	csv       *csv.Writer
+	json      *json.Encoder
+	header    []string
+	toRecord  func(*T) []string
+	wroteHead bool
+}
+
+func newExportEncoder[T any](w io.Writer, format models.ExportFormat, header []string, toRecord func(*T) []string) *exportEncoder[T] {
+	enc := &exportEncoder[T]{header: header, toRecord: toRecord}
+	if format == models.ExportFormatCSV {
+		enc.csv = csv.NewWriter(w)
+	} else {
+		enc.json = json.NewEncoder(w)
+	}
+	return enc
+}
+
+// write сериализует одну запись; заголовок CSV записывается перед первой строкой.
+func (e *exportEncoder[T]) write(item *T) error {
+	if e.json != nil {
+		return e.json.Encode(item)
+	}
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	return e.csv.Write(e.toRecord(item))
+}
+
+func (e *exportEncoder[T]) writeHeader() error {
+	if e.wroteHead {
+		return nil
+	}
+	e.wroteHead = true
+	return e.csv.Write(e.header)
+}
+
+// finish дописывает заголовок для пустой выгрузки CSV и сбрасывает буфер.
+func (e *exportEncoder[T]) finish(err error) error {
+	if e.csv == nil || err != nil {
+		return err
+	}
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+	e.csv.Flush()
+	return e.csv.Error()
+}
+
+var userCSVHeader = []string{"id", "username", "email", "balance", "referrals", "referral_code", "tasks_completed",
+	"created_at", "updated_at", "last_visit", "visit_count", "bio", "time_zone", "status"}
+
+func userCSVRecord(u *models.User) []string {
+	return []string{u.ID, u.Username, u.Email, formatAmount(u.Balance), strconv.Itoa(u.Referrals), u.ReferralCode,
+		strconv.Itoa(u.TasksCompleted), formatTime(u.CreatedAt), formatTime(u.UpdatedAt), formatTime(u.LastVisit),
+		strconv.Itoa(u.VisitCount), u.Bio, u.TimeZone, u.Status.String()}
+}
+
+var taskCSVHeader = []string{"task_id", "title", "description", "created_at", "updated_at", "due_date", "status", "assignee_id", "reward"}
+
+func taskCSVRecord(t *models.Task) []string {
+	var dueDate, assignee string
+	if t.DueDate != nil {
+		dueDate = formatTime(*t.DueDate)
+	}
+	if t.AssigneeID != nil {
+		assignee = *t.AssigneeID
+	}
+	return []string{t.TaskID, t.Title, t.Description, formatTime(t.CreatedAt), formatTime(t.UpdatedAt), dueDate,
+		t.Status.String(), assignee, formatAmount(t.Reward)}
+}
+
+var ledgerCSVHeader = []string{"id", "user_id", "amount", "balance_after", "reason", "created_at"}
+
+func ledgerCSVRecord(e *models.LedgerEntry) []string {
+	return []string{strconv.FormatInt(e.ID, 10), e.UserID, formatAmount(e.Amount), formatAmount(e.BalanceAfter),
+		e.Reason, formatTime(e.CreatedAt)}
+}
+
+// formatTime форматирует время в RFC3339; нулевое время выводится пустой строкой.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// formatAmount форматирует денежную сумму с двумя знаками после запятой.
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
+
+// ParseExportStatus разбирает фильтр статуса выгрузки: числовой код или название статуса пользователя либо задачи.
+func ParseExportStatus(kind models.ExportKind, raw string) (int, error) {
+	switch kind {
+	case models.ExportUsers:
+		status, err := parseUserStatus(raw)
+		if err != nil {
+			return 0, errors.NewBadRequest(err.Error(), nil)
+		}
+		return int(status), nil
+	case models.ExportTasks:
+		status, err := parseTaskStatus(raw)
+		if err != nil {
+			return 0, errors.NewBadRequest(err.Error(), nil)
+		}
+		return int(status), nil
+	default:
+		return 0, errors.NewBadRequest("status filter is not supported for ledger export", nil)
+	}
+}
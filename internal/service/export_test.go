@@ -0,0 +1,55 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// exportRepo отдает одного пользователя и запоминает, что чтение началось
+type exportRepo struct {
+	read bool
+}
+
+func (r *exportRepo) ExportUsers(ctx context.Context, filter *models.ExportFilter, fn func(*models.User) error) error {
+	r.read = true
+	return fn(&models.User{ID: "9a0e6a5e-2b9f-4c1d-8e2a-7d3b5c4f1e09", Username: "erin", Email: "erin@example.com"})
+}
+
+func (r *exportRepo) ExportTasks(ctx context.Context, filter *models.ExportFilter, fn func(*models.Task) error) error {
+	r.read = true
+	return nil
+}
+
+func (r *exportRepo) ExportLedger(ctx context.Context, filter *models.ExportFilter, fn func(*models.LedgerEntry) error) error {
+	r.read = true
+	return nil
+}
+
+func TestExportRequiresAdmin(t *testing.T) {
+	repo := &exportRepo{}
+	exports := NewExportService(repo, zap.NewNop())
+
+	for _, kind := range []models.ExportKind{models.ExportUsers, models.ExportTasks, models.ExportLedger} {
+		var out bytes.Buffer
+		err := exports.Export(userContext("erin"), kind, models.ExportFormatNDJSON, &models.ExportFilter{}, &out)
+		if !errors.IsErrorType(err, errors.Forbidden) {
+			t.Fatalf("%s export by a user: %v", kind, err)
+		}
+		if repo.read || out.Len() > 0 {
+			t.Fatalf("%s export started streaming before the permission check", kind)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := exports.Export(adminContext(), models.ExportUsers, models.ExportFormatNDJSON, &models.ExportFilter{}, &out); err != nil {
+		t.Fatalf("admin export: %v", err)
+	}
+	if !bytes.Contains(out.Bytes(), []byte("erin@example.com")) {
+		t.Fatalf("admin export: %s", out.String())
+	}
+}
@@ -0,0 +1,454 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"github.com/ZnNr/user-reward-controller/internal/service/auth"
+	"github.com/ZnNr/user-reward-controller/internal/validation"
+
+	"go.uber.org/zap"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// importBatchSize - количество строк, записываемых одной транзакцией COPY
+const importBatchSize = 1000
+
+// maxImportLineSize - максимальная длина одной строки JSONL
+const maxImportLineSize = 1 << 20
+
+// errBatchInsert - сообщение отчета для строк пакета, не записанного в базу; причина пишется только в лог
+const errBatchInsert = "batch insert failed"
+
+// ImportService выполняет массовый импорт пользователей и задач
+type ImportService struct {
+	userRepo repository.UserRepository
+	taskRepo repository.TaskRepository
+	logger   *zap.Logger
+}
+
+// NewImportService создает новый экземпляр ImportService
+func NewImportService(userRepo repository.UserRepository, taskRepo repository.TaskRepository, logger *zap.Logger) *ImportService {
+	return &ImportService{
+		userRepo: userRepo,
+		taskRepo: taskRepo,
+		logger:   logger,
+	}
+}
+
+// pendingRow связывает подготовленную к вставке запись с номером строки во входном потоке
+type pendingRow[T any] struct {
+	row  int
+	item *T
+}
+
+// ImportUsers читает пользователей из потока, проверяет каждую строку так же, как CreateUser,
+// и вставляет корректные строки пакетами. В режиме dryRun данные только проверяются.
+func (s *ImportService) ImportUsers(ctx context.Context, src io.Reader, format models.ImportFormat, dryRun bool) (*models.ImportReport, error) {
+	if !auth.IsAdmin(ctx) {
+		return nil, errors.NewForbidden("only administrators can import data", nil)
+	}
+	report := &models.ImportReport{DryRun: dryRun, Errors: []models.ImportRowError{}}
+	seen := make(map[string]int) // email -> номер строки, в которой он впервые встретился
+	var batch []pendingRow[models.User]
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		defer func() { batch = batch[:0] }()
+		return s.flushUsers(ctx, batch, report)
+	}
+
+	err := readImportRows(src, format, userFromCSV, func(row int, req *models.CreateUserRequest, rowErr error) error {
+		report.Total++
+		if rowErr != nil {
+			report.AddError(row, rowErr.Error())
+			return nil
+		}
+		if err := validation.Struct(req); err != nil {
+			report.AddError(row, err.Error())
+			return nil
+		}
+		email := strings.ToLower(req.Email)
+		if first, ok := seen[email]; ok {
+			report.AddError(row, fmt.Sprintf("duplicate email, first seen in row %d", first))
+			return nil
+		}
+		seen[email] = row
+
+		now := time.Now()
+		batch = append(batch, pendingRow[models.User]{row: row, item: &models.User{
+			ID:           generateUserID(),
+			Username:     req.Username,
+			Email:        req.Email,
+			ReferralCode: req.ReferralCode,
+			Bio:          req.Bio,
+			TimeZone:     req.TimeZone,
+			Status:       importUserStatus(req.Status),
+			CreatedAt:    now,
+			UpdatedAt:    now,
+		}})
+		if len(batch) >= importBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		s.logger.Error("User import aborted", zap.Int("rows", report.Total), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("User import finished",
+		zap.Bool("dryRun", dryRun),
+		zap.Int("total", report.Total),
+		zap.Int("imported", report.Imported),
+		zap.Int("failed", report.Failed))
+	return report, nil
+}
+
+// flushUsers отбрасывает строки с уже занятыми адресами и записывает остальные одной транзакцией.
+// Адреса сравниваются без учета регистра, как и дубликаты внутри файла.
+func (s *ImportService) flushUsers(ctx context.Context, batch []pendingRow[models.User], report *models.ImportReport) error {
+	emails := make([]string, len(batch))
+	for i, p := range batch {
+		emails[i] = p.item.Email
+	}
+	existing, err := s.userRepo.GetExistingEmails(ctx, emails)
+	if err != nil {
+		return err
+	}
+
+	users := make([]*models.User, 0, len(batch))
+	rows := make([]int, 0, len(batch))
+	for _, p := range batch {
+		if existing[strings.ToLower(p.item.Email)] {
+			report.AddError(p.row, "user with this email already exists")
+			continue
+		}
+		users = append(users, p.item)
+		rows = append(rows, p.row)
+	}
+	report.Valid += len(users)
+	if report.DryRun || len(users) == 0 {
+		return nil
+	}
+
+	if err := s.userRepo.CopyUsers(ctx, users); err != nil {
+		s.logger.Error("Failed to copy users batch", zap.Int("size", len(users)),
+			zap.Int("firstRow", rows[0]), zap.Int("lastRow", rows[len(rows)-1]), zap.Error(err))
+		for _, row := range rows {
+			report.AddError(row, errBatchInsert)
+		}
+		report.Valid -= len(users)
+		return nil
+	}
+	report.Imported += len(users)
+	return nil
+}
+
+// ImportTasks читает задачи из потока, проверяет каждую строку так же, как CreateTask,
+// и вставляет корректные строки пакетами. Срок выполнения может быть в прошлом: выгрузка
+// содержит и просроченные задачи. В режиме dryRun данные только проверяются.
+func (s *ImportService) ImportTasks(ctx context.Context, src io.Reader, format models.ImportFormat, dryRun bool) (*models.ImportReport, error) {
+	if !auth.IsAdmin(ctx) {
+		return nil, errors.NewForbidden("only administrators can import data", nil)
+	}
+	report := &models.ImportReport{DryRun: dryRun, Errors: []models.ImportRowError{}}
+	seen := make(map[models.TaskKey]int)
+	var batch []pendingRow[models.Task]
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		defer func() { batch = batch[:0] }()
+		return s.flushTasks(ctx, batch, report)
+	}
+
+	err := readImportRows(src, format, taskFromCSV, func(row int, req *models.CreateTaskRequest, rowErr error) error {
+		report.Total++
+		if rowErr != nil {
+			report.AddError(row, rowErr.Error())
+			return nil
+		}
+		if err := validation.Existing(req); err != nil {
+			report.AddError(row, err.Error())
+			return nil
+		}
+		key := models.TaskKey{Title: req.Title, Description: req.Description}
+		if first, ok := seen[key]; ok {
+			report.AddError(row, fmt.Sprintf("duplicate task, first seen in row %d", first))
+			return nil
+		}
+		seen[key] = row
+
+		status, err := validateAndSetStatus(req.Status)
+		if err != nil {
+			report.AddError(row, err.Error())
+			return nil
+		}
+
+		now := time.Now()
+		batch = append(batch, pendingRow[models.Task]{row: row, item: &models.Task{
+			TaskID:      generateTaskID(),
+			Title:       req.Title,
+			Description: req.Description,
+			CreatedAt:   now,
+			UpdatedAt:   now,
+			DueDate:     req.DueDate,
+			Status:      status,
+			AssigneeID:  req.AssigneeID,
+			Reward:      req.Reward,
+		}})
+		if len(batch) >= importBatchSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		s.logger.Error("Task import aborted", zap.Int("rows", report.Total), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Task import finished",
+		zap.Bool("dryRun", dryRun),
+		zap.Int("total", report.Total),
+		zap.Int("imported", report.Imported),
+		zap.Int("failed", report.Failed))
+	return report, nil
+}
+
+// flushTasks отбрасывает дубликаты существующих задач и записывает остальные одной транзакцией.
+func (s *ImportService) flushTasks(ctx context.Context, batch []pendingRow[models.Task], report *models.ImportReport) error {
+	titles := make([]string, len(batch))
+	for i, p := range batch {
+		titles[i] = p.item.Title
+	}
+	existing, err := s.taskRepo.GetExistingTaskKeys(ctx, titles)
+	if err != nil {
+		return err
+	}
+
+	tasks := make([]*models.Task, 0, len(batch))
+	rows := make([]int, 0, len(batch))
+	for _, p := range batch {
+		if existing[models.TaskKey{Title: p.item.Title, Description: p.item.Description}] {
+			report.AddError(p.row, "a task with the same title and description already exists")
+			continue
+		}
+		tasks = append(tasks, p.item)
+		rows = append(rows, p.row)
+	}
+	report.Valid += len(tasks)
+	if report.DryRun || len(tasks) == 0 {
+		return nil
+	}
+
+	if err := s.taskRepo.CopyTasks(ctx, tasks); err != nil {
+		s.logger.Error("Failed to copy tasks batch", zap.Int("size", len(tasks)),
+			zap.Int("firstRow", rows[0]), zap.Int("lastRow", rows[len(rows)-1]), zap.Error(err))
+		for _, row := range rows {
+			report.AddError(row, errBatchInsert)
+		}
+		report.Valid -= len(tasks)
+		return nil
+	}
+	report.Imported += len(tasks)
+	return nil
+}
+
+// readImportRows последовательно декодирует строки потока в T и передает их в handle.
+// Ошибки отдельных строк передаются в handle, ошибки чтения потока прерывают импорт.
+func readImportRows[T any](src io.Reader, format models.ImportFormat, fromCSV func(map[string]string) (*T, error), handle func(row int, item *T, err error) error) error {
+	switch format {
+	case models.ImportFormatJSONL:
+		return readJSONLRows(src, handle)
+	case models.ImportFormatCSV:
+		return readCSVRows(src, fromCSV, handle)
+	default:
+		return errors.NewBadRequest(fmt.Sprintf("unsupported import format %q", format), nil)
+	}
+}
+
+func readJSONLRows[T any](src io.Reader, handle func(row int, item *T, err error) error) error {
+	scanner := bufio.NewScanner(src)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+
+	row := 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		row++
+
+		var item T
+		if err := json.Unmarshal([]byte(line), &item); err != nil {
+			if err := handle(row, nil, fmt.Errorf("invalid JSON: %w", err)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := handle(row, &item, nil); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return errors.NewBadRequest("failed to read import stream", err)
+	}
+	return nil
+}
+
+func readCSVRows[T any](src io.Reader, fromCSV func(map[string]string) (*T, error), handle func(row int, item *T, err error) error) error {
+	reader := csv.NewReader(src)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return errors.NewBadRequest("failed to read CSV header", err)
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+	}
+
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		var parseErr *csv.ParseError
+		if stderrors.As(err, &parseErr) {
+			if err := handle(row, nil, fmt.Errorf("invalid CSV: %w", parseErr.Err)); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return errors.NewBadRequest("failed to read import stream", err)
+		}
+		if len(record) != len(header) {
+			if err := handle(row, nil, fmt.Errorf("expected %d fields, got %d", len(header), len(record))); err != nil {
+				return err
+			}
+			continue
+		}
+
+		values := make(map[string]string, len(header))
+		for i, column := range header {
+			values[column] = strings.TrimSpace(record[i])
+		}
+		item, err := fromCSV(values)
+		if err := handle(row, item, err); err != nil {
+			return err
+		}
+	}
+}
+
+// userFromCSV преобразует строку CSV (username,email,referral_code,bio,time_zone,status) в запрос на создание пользователя.
+func userFromCSV(values map[string]string) (*models.CreateUserRequest, error) {
+	req := &models.CreateUserRequest{
+		Username:     values["username"],
+		Email:        values["email"],
+		ReferralCode: values["referral_code"],
+		Bio:          values["bio"],
+		TimeZone:     values["time_zone"],
+	}
+	if raw := values["status"]; raw != "" {
+		status, err := parseUserStatus(raw)
+		if err != nil {
+			return nil, err
+		}
+		req.Status = status
+	}
+	return req, nil
+}
+
+// taskFromCSV преобразует строку CSV (title,description,due_date,status,assignee_id,reward) в запрос на создание задачи.
+func taskFromCSV(values map[string]string) (*models.CreateTaskRequest, error) {
+	req := &models.CreateTaskRequest{BaseTaskRequest: models.BaseTaskRequest{
+		Title:       values["title"],
+		Description: values["description"],
+	}}
+	if raw := values["due_date"]; raw != "" {
+		dueDate, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid due_date %q: expected RFC3339", raw)
+		}
+		req.DueDate = &dueDate
+	}
+	if raw := values["status"]; raw != "" {
+		status, err := parseTaskStatus(raw)
+		if err != nil {
+			return nil, err
+		}
+		req.Status = status
+	}
+	if raw := values["assignee_id"]; raw != "" {
+		req.AssigneeID = &raw
+	}
+	if raw := values["reward"]; raw != "" {
+		reward, err := strconv.ParseFloat(raw, 64)
+		if err != nil || reward < 0 {
+			return nil, fmt.Errorf("invalid reward %q: expected non-negative number", raw)
+		}
+		req.Reward = reward
+	}
+	return req, nil
+}
+
+// parseUserStatus принимает числовой код или название статуса пользователя.
+func parseUserStatus(raw string) (models.UserStatus, error) {
+	if code, err := strconv.Atoi(raw); err == nil {
+		return models.UserStatus(code), nil
+	}
+	for _, status := range []models.UserStatus{models.Active, models.Suspended, models.Banned, models.Pending} {
+		if strings.EqualFold(status.String(), raw) {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown user status %q", raw)
+}
+
+// parseTaskStatus принимает числовой код или название статуса задачи.
+func parseTaskStatus(raw string) (models.TaskStatus, error) {
+	if code, err := strconv.Atoi(raw); err == nil {
+		return models.TaskStatus(code), nil
+	}
+	for _, status := range []models.TaskStatus{models.NotStarted, models.InProgress, models.Completed, models.Canceled, models.Expired} {
+		if strings.EqualFold(status.String(), raw) {
+			return status, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown task status %q", raw)
+}
+
+// importUserStatus сохраняет статус из файла: импорт переносит существующих пользователей
+// и выполняется администратором, поэтому таблица переходов к нему не применяется. По умолчанию - Pending.
+func importUserStatus(status models.UserStatus) models.UserStatus {
+	switch status {
+	case models.Active, models.Suspended, models.Banned:
+		return status
+	default:
+		return models.Pending
+	}
+}
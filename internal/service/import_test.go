@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"github.com/ZnNr/user-reward-controller/internal/repository/memory"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func newImportService(t *testing.T) (*ImportService, *UserService) {
+	t.Helper()
+	store := memory.NewStore()
+	userRepo := memory.NewUserRepository(store)
+	return NewImportService(userRepo, memory.NewTaskRepository(store), zap.NewNop()),
+		NewUserService(userRepo, nil, nil, nil, zap.NewNop())
+}
+
+func TestImportRequiresAdmin(t *testing.T) {
+	imports, _ := newImportService(t)
+	for name, ctx := range map[string]context.Context{"anonymous": context.Background(), "user": userContext("erin")} {
+		if _, err := imports.ImportUsers(ctx, strings.NewReader("username,email\nerin,erin@example.com\n"), models.ImportFormatCSV, false); !errors.IsErrorType(err, errors.Forbidden) {
+			t.Errorf("%s imports users: %v", name, err)
+		}
+		if _, err := imports.ImportTasks(ctx, strings.NewReader("title\nReview\n"), models.ImportFormatCSV, false); !errors.IsErrorType(err, errors.Forbidden) {
+			t.Errorf("%s imports tasks: %v", name, err)
+		}
+	}
+}
+
+// Занятый адрес в другом регистре отклоняется так же, как дубликат внутри файла
+func TestImportUsersEmailCase(t *testing.T) {
+	imports, users := newImportService(t)
+	if _, err := users.CreateUser(context.Background(), &models.CreateUserRequest{Username: "foo", Email: "foo@example.com"}); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	src := "username,email\nfoo2,Foo@Example.com\nbar,bar@example.com\nbar2,BAR@example.com\n"
+	report, err := imports.ImportUsers(adminContext(), strings.NewReader(src), models.ImportFormatCSV, false)
+	if err != nil {
+		t.Fatalf("import users: %v", err)
+	}
+	if report.Imported != 1 || report.Failed != 2 {
+		t.Fatalf("imported %d, failed %d: %+v", report.Imported, report.Failed, report.Errors)
+	}
+	if report.Errors[0].Row != 3 || !strings.HasPrefix(report.Errors[0].Error, "duplicate email") {
+		t.Fatalf("in-file duplicate: %+v", report.Errors[0])
+	}
+	if report.Errors[1].Row != 1 || report.Errors[1].Error != "user with this email already exists" {
+		t.Fatalf("existing email: %+v", report.Errors[1])
+	}
+}
+
+// Выгрузка содержит просроченные задачи: прошедший срок при импорте не ошибка
+func TestImportTasksPastDueDate(t *testing.T) {
+	imports, _ := newImportService(t)
+	src := "title,due_date\nReview,2020-01-02T15:04:05Z\nDeploy,\n"
+	report, err := imports.ImportTasks(adminContext(), strings.NewReader(src), models.ImportFormatCSV, false)
+	if err != nil {
+		t.Fatalf("import tasks: %v", err)
+	}
+	if report.Imported != 2 || report.Failed != 0 {
+		t.Fatalf("imported %d, failed %d: %+v", report.Imported, report.Failed, report.Errors)
+	}
+}
+
+// failingCopyRepository отклоняет пакетную вставку ошибкой базы с именем ограничения
+type failingCopyRepository struct {
+	repository.UserRepository
+}
+
+func (failingCopyRepository) CopyUsers(context.Context, []*models.User) error {
+	return errors.NewInternal("failed to copy users",
+		stderrors.New(`pq: duplicate key value violates unique constraint "idx_users_email_active"`))
+}
+
+// Причина сбоя пакета остается в логе сервера и не попадает в отчет клиенту
+func TestImportBatchErrorHidesCause(t *testing.T) {
+	store := memory.NewStore()
+	imports := NewImportService(failingCopyRepository{memory.NewUserRepository(store)}, memory.NewTaskRepository(store), zap.NewNop())
+
+	src := "username,email\nerin,erin@example.com\nfrank,frank@example.com\n"
+	report, err := imports.ImportUsers(adminContext(), strings.NewReader(src), models.ImportFormatCSV, false)
+	if err != nil {
+		t.Fatalf("import users: %v", err)
+	}
+	if report.Imported != 0 || report.Failed != 2 {
+		t.Fatalf("imported %d, failed %d: %+v", report.Imported, report.Failed, report.Errors)
+	}
+	for _, rowErr := range report.Errors {
+		if rowErr.Error != "batch insert failed" {
+			t.Fatalf("row %d reports %q", rowErr.Row, rowErr.Error)
+		}
+	}
+}
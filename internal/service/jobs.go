@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"github.com/ZnNr/user-reward-controller/internal/service/auth"
+
+	"go.uber.org/zap"
+	"time"
+)
+
+// Ограничения выборки истории запусков
+const (
+	defaultJobRunLimit = 100
+	maxJobRunLimit     = 1000
+)
+
+// JobService отдает историю запусков фоновых заданий и очищает устаревшие записи
+type JobService struct {
+	repo      repository.JobRepository
+	retention time.Duration
+	logger    *zap.Logger
+}
+
+// NewJobService создает новый экземпляр JobService; retention - срок хранения истории запусков
+func NewJobService(repo repository.JobRepository, retention time.Duration, logger *zap.Logger) *JobService {
+	return &JobService{
+		repo:      repo,
+		retention: retention,
+		logger:    logger,
+	}
+}
+
+// GetRuns возвращает запуски заданий по фильтру, начиная с последних; доступно только администраторам
+func (s *JobService) GetRuns(ctx context.Context, filter *models.JobRunFilter) ([]models.JobRun, error) {
+	if !auth.IsAdmin(ctx) {
+		return nil, errors.NewForbidden("only administrators can view job runs", nil)
+	}
+	switch filter.Status {
+	case "", models.JobRunRunning, models.JobRunSucceeded, models.JobRunFailed, models.JobRunCanceled:
+	default:
+		return nil, errors.NewBadRequest("unknown job run status", nil)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultJobRunLimit
+	}
+	if filter.Limit > maxJobRunLimit {
+		filter.Limit = maxJobRunLimit
+	}
+	return s.repo.GetJobRuns(ctx, filter)
+}
+
+// PurgeHistory удаляет записи о запусках старше срока хранения
+func (s *JobService) PurgeHistory(ctx context.Context) error {
+	purged, err := s.repo.PurgeJobRuns(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		s.logger.Error("Failed to purge job run history", zap.Error(err))
+		return err
+	}
+	if purged > 0 {
+		s.logger.Info("Purged job run history", zap.Int64("runs", purged))
+	}
+	return nil
+}
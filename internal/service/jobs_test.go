@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// jobRepo отдает фиксированную историю запусков
+type jobRepo struct {
+	runs []models.JobRun
+}
+
+func (r *jobRepo) TryLockJob(ctx context.Context, name string) (func(), bool, error) {
+	return func() {}, true, nil
+}
+
+func (r *jobRepo) StartJobRun(ctx context.Context, run *models.JobRun) (bool, error) {
+	return true, nil
+}
+
+func (r *jobRepo) FinishJobRun(ctx context.Context, run *models.JobRun) error {
+	return nil
+}
+
+func (r *jobRepo) GetJobRuns(ctx context.Context, filter *models.JobRunFilter) ([]models.JobRun, error) {
+	return r.runs, nil
+}
+
+func (r *jobRepo) PurgeJobRuns(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func TestJobRunsRequireAdmin(t *testing.T) {
+	jobs := NewJobService(&jobRepo{runs: []models.JobRun{{JobName: "purge", Status: models.JobRunSucceeded}}}, time.Hour, zap.NewNop())
+
+	for name, ctx := range map[string]context.Context{"anonymous": context.Background(), "user": userContext("erin")} {
+		if _, err := jobs.GetRuns(ctx, &models.JobRunFilter{}); !errors.IsErrorType(err, errors.Forbidden) {
+			t.Errorf("%s lists job runs: %v", name, err)
+		}
+	}
+	runs, err := jobs.GetRuns(adminContext(), &models.JobRunFilter{})
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("admin lists job runs: %v, %v", runs, err)
+	}
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ZnNr/user-reward-controller/internal/events"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"go.uber.org/zap"
+)
+
+// leaderboardSize - количество позиций таблицы лидеров, изменения которых рассылаются подписчикам
+const leaderboardSize = 10
+
+// leaderboardTracker хранит последний известный порядок таблицы лидеров для вычисления изменений рангов
+type leaderboardTracker struct {
+	mu    sync.Mutex
+	ranks map[string]int // userID -> ранг
+}
+
+// diff сравнивает новый топ с сохраненным, запоминает его и возвращает изменения рангов.
+func (t *leaderboardTracker) diff(top []models.TopUser) []models.RankChange {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ranks := make(map[string]int, len(top))
+	var changes []models.RankChange
+	for _, user := range top {
+		ranks[user.ID] = user.Rank
+		if oldRank := t.ranks[user.ID]; oldRank != user.Rank {
+			changes = append(changes, models.RankChange{UserID: user.ID, OldRank: oldRank, NewRank: user.Rank})
+		}
+	}
+	for userID, oldRank := range t.ranks {
+		if _, ok := ranks[userID]; !ok {
+			changes = append(changes, models.RankChange{UserID: userID, OldRank: oldRank})
+		}
+	}
+	t.ranks = ranks
+	return changes
+}
+
+// BalanceNotifier рассылает подписчикам изменения балансов и таблицы лидеров.
+// Один экземпляр используется всеми сервисами, изменяющими балансы, чтобы изменения рангов
+// вычислялись относительно одной и той же последней разосланной таблицы.
+// Событие баланса рассылается сразу, а таблица лидеров пересчитывается вне запроса: изменения
+// помечают ее устаревшей, и фоновый цикл (Start) пересчитывает ее не чаще раза в interval,
+// объединяя все изменения за это время. Методы nil-получателя ничего не делают.
+type BalanceNotifier struct {
+	repo        repository.UserRepository
+	events      events.Publisher
+	leaderboard leaderboardTracker
+	interval    time.Duration
+	stale       chan struct{} // Непустой, если таблица лидеров изменилась после последнего пересчета
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+	logger      *zap.Logger
+}
+
+// NewBalanceNotifier создает новый экземпляр BalanceNotifier, пересчитывающий таблицу лидеров не чаще раза в interval
+func NewBalanceNotifier(repo repository.UserRepository, publisher events.Publisher, interval time.Duration, logger *zap.Logger) *BalanceNotifier {
+	return &BalanceNotifier{
+		repo:     repo,
+		events:   publisher,
+		interval: interval,
+		stale:    make(chan struct{}, 1),
+		logger:   logger,
+	}
+}
+
+// Start запускает фоновый пересчет таблицы лидеров
+func (n *BalanceNotifier) Start() {
+	if n == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	n.cancel = cancel
+
+	n.wg.Add(1)
+	go func() {
+		defer n.wg.Done()
+		n.loop(ctx)
+	}()
+}
+
+// Stop останавливает фоновый пересчет и дожидается завершения текущего
+func (n *BalanceNotifier) Stop() {
+	if n == nil || n.cancel == nil {
+		return
+	}
+	n.cancel()
+	n.wg.Wait()
+}
+
+// loop раз в interval пересчитывает таблицу лидеров, если она помечена устаревшей
+func (n *BalanceNotifier) loop(ctx context.Context) {
+	ticker := time.NewTicker(n.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		select {
+		case <-n.stale:
+			n.refreshLeaderboard(ctx)
+		default:
+		}
+	}
+}
+
+// publishBalanceChange рассылает событие изменения баланса и помечает таблицу лидеров устаревшей.
+// Вызывается после фиксации изменения; nil означает, что баланс не изменился.
+func (n *BalanceNotifier) publishBalanceChange(ctx context.Context, change *models.BalanceUpdate) {
+	if n == nil || change == nil {
+		return
+	}
+
+	n.events.Publish(events.UserTopic(change.UserID), events.EventBalance, *change)
+	n.markLeaderboardStale()
+}
+
+// markLeaderboardStale помечает таблицу лидеров устаревшей; она будет пересчитана и разослана фоновым циклом.
+// Не блокируется: повторные пометки до пересчета объединяются в одну.
+func (n *BalanceNotifier) markLeaderboardStale() {
+	if n == nil {
+		return
+	}
+	select {
+	case n.stale <- struct{}{}:
+	default:
+	}
+}
+
+// refreshLeaderboard пересчитывает топ пользователей и рассылает событие, если ранги изменились.
+func (n *BalanceNotifier) refreshLeaderboard(ctx context.Context) {
+	top, err := n.repo.GetTopUsers(ctx, leaderboardSize, 0)
+	if err != nil {
+		n.logger.Error("Failed to refresh leaderboard for subscribers", zap.Error(err))
+		return
+	}
+	for i := range top {
+		top[i].Rank = i + 1
+	}
+
+	changes := n.leaderboard.diff(top)
+	if len(changes) == 0 {
+		return
+	}
+
+	n.events.Publish(events.TopicLeaderboard, events.EventLeaderboard, models.LeaderboardUpdate{
+		Changes: changes,
+		Top:     top,
+	})
+}
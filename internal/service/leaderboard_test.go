@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/events"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository/memory"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// chanPublisher передает разосланные события в канал; безопасен для фонового цикла BalanceNotifier
+type chanPublisher chan published
+
+func (p chanPublisher) Publish(topic, eventType string, payload interface{}) {
+	p <- published{topic: topic, eventType: eventType, payload: payload}
+}
+
+// Изменение баланса рассылается сразу, а таблица лидеров - фоновым циклом, один раз за серию изменений
+func TestLeaderboardRefreshCoalesces(t *testing.T) {
+	const interval = 10 * time.Millisecond
+	userRepo := memory.NewUserRepository(memory.NewStore())
+	publisher := make(chanPublisher, 16)
+	balances := NewBalanceNotifier(userRepo, publisher, interval, zap.NewNop())
+	users := NewUserService(userRepo, balances, nil, nil, zap.NewNop())
+
+	var ids []string
+	for _, name := range []string{"erin", "frank"} {
+		user, err := users.CreateUser(context.Background(), &models.CreateUserRequest{Username: name, Email: name + "@example.com"})
+		if err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		ids = append(ids, user.ID)
+	}
+	for i, amount := range []float64{5, 10, 20} {
+		if err := users.UpdateBalance(context.Background(), ids[i%2], amount); err != nil {
+			t.Fatalf("update balance: %v", err)
+		}
+		select {
+		case event := <-publisher:
+			if event.eventType != events.EventBalance {
+				t.Fatalf("balance change %d published %+v in the request", i, event)
+			}
+		default:
+			t.Fatalf("balance change %d is not published", i)
+		}
+	}
+
+	balances.Start()
+	defer balances.Stop()
+	select {
+	case event := <-publisher:
+		update, ok := event.payload.(models.LeaderboardUpdate)
+		if event.topic != events.TopicLeaderboard || !ok || len(update.Top) != 2 || update.Top[0].ID != ids[0] {
+			t.Fatalf("unexpected leaderboard event %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("leaderboard is not refreshed")
+	}
+
+	// Все изменения уже учтены одним пересчетом
+	time.Sleep(5 * interval)
+	select {
+	case event := <-publisher:
+		t.Fatalf("extra event %+v", event)
+	default:
+	}
+}
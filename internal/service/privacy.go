@@ -0,0 +1,108 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"github.com/ZnNr/user-reward-controller/internal/service/auth"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"io"
+)
+
+// PrivacyService выполняет запросы субъектов персональных данных: выгрузку и анонимизацию
+type PrivacyService struct {
+	repo   repository.PrivacyRepository
+	logger *zap.Logger
+}
+
+// NewPrivacyService создает новый экземпляр PrivacyService
+func NewPrivacyService(repo repository.PrivacyRepository, logger *zap.Logger) *PrivacyService {
+	return &PrivacyService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// ExportUserData собирает все данные, связанные с пользователем; доступно самому пользователю и администраторам
+func (s *PrivacyService) ExportUserData(ctx context.Context, id string) (*models.UserDataExport, error) {
+	if err := validateUUID(id); err != nil {
+		s.logger.Error("Invalid user ID", zap.Error(err))
+		return nil, err
+	}
+	if !auth.IsUserOrAdmin(ctx, id) {
+		return nil, errors.NewForbidden("only the user or an administrator can export user data", nil)
+	}
+
+	data, err := s.repo.GetUserData(ctx, uuid.MustParse(id))
+	if err != nil {
+		s.logger.Error("Failed to export user data", zap.String("userID", id), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("User data exported",
+		zap.String("userID", id),
+		zap.Int("tasks", len(data.Tasks)),
+		zap.Int("ledgerEntries", len(data.BalanceHistory)))
+	return data, nil
+}
+
+// WriteDataExportZip записывает выгрузку в ZIP-архив: по одному JSON-файлу на раздел.
+func WriteDataExportZip(w io.Writer, data *models.UserDataExport) error {
+	sections := []struct {
+		name    string
+		payload interface{}
+	}{
+		{"profile.json", data.Profile},
+		{"visits.json", data.Visits},
+		{"activity_log.json", data.ActivityLog},
+		{"tasks.json", data.Tasks},
+		{"referrals.json", data.Referrals},
+		{"submissions.json", data.Submissions},
+		{"balance_history.json", data.BalanceHistory},
+	}
+
+	archive := zip.NewWriter(w)
+	for _, section := range sections {
+		file, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     section.name,
+			Method:   zip.Deflate,
+			Modified: data.GeneratedAt,
+		})
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(file)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(section.payload); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// AnonymizeUser затирает персональные данные пользователя (Email, Username, Bio, реферальный код)
+// и его персональные данные в журнале аудита. Баланс, счетчики и журнал баланса сохраняются,
+// чтобы не нарушить агрегированную статистику. Доступно самому пользователю и администраторам.
+func (s *PrivacyService) AnonymizeUser(ctx context.Context, id string) (*models.User, error) {
+	if err := validateUUID(id); err != nil {
+		s.logger.Error("Invalid user ID", zap.Error(err))
+		return nil, err
+	}
+	if !auth.IsUserOrAdmin(ctx, id) {
+		return nil, errors.NewForbidden("only the user or an administrator can anonymize user data", nil)
+	}
+
+	user, err := s.repo.AnonymizeUser(ctx, uuid.MustParse(id))
+	if err != nil {
+		s.logger.Error("Failed to anonymize user", zap.String("userID", id), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("User anonymized", zap.String("userID", id))
+	return user, nil
+}
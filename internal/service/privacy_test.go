@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// privacyRepo отдает пустую выгрузку для любого пользователя
+type privacyRepo struct{}
+
+func (privacyRepo) GetUserData(ctx context.Context, id uuid.UUID) (*models.UserDataExport, error) {
+	return &models.UserDataExport{Profile: &models.User{ID: id.String()}}, nil
+}
+
+func (privacyRepo) AnonymizeUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return &models.User{ID: id.String()}, nil
+}
+
+func TestPrivacyRequestsRequireSubjectOrAdmin(t *testing.T) {
+	privacy := NewPrivacyService(privacyRepo{}, zap.NewNop())
+	subject := uuid.NewString()
+
+	for name, ctx := range map[string]context.Context{"anonymous": context.Background(), "another user": userContext(uuid.NewString())} {
+		if _, err := privacy.ExportUserData(ctx, subject); !errors.IsErrorType(err, errors.Forbidden) {
+			t.Errorf("%s exports user data: %v", name, err)
+		}
+		if _, err := privacy.AnonymizeUser(ctx, subject); !errors.IsErrorType(err, errors.Forbidden) {
+			t.Errorf("%s anonymizes a user: %v", name, err)
+		}
+	}
+	for name, ctx := range map[string]context.Context{"subject": userContext(subject), "admin": adminContext()} {
+		if _, err := privacy.ExportUserData(ctx, subject); err != nil {
+			t.Errorf("%s exports user data: %v", name, err)
+		}
+		if _, err := privacy.AnonymizeUser(ctx, subject); err != nil {
+			t.Errorf("%s anonymizes a user: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"github.com/ZnNr/user-reward-controller/internal/service/auth"
+	"github.com/ZnNr/user-reward-controller/internal/validation"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Ограничения выборки квестов
+const (
+	defaultQuestLimit = 100
+	maxQuestLimit     = 1000
+)
+
+// QuestService управляет квестами и вычисляет прогресс пользователей по ним
+type QuestService struct {
+	repo     repository.QuestRepository
+	taskRepo repository.TaskRepository
+	logger   *zap.Logger
+}
+
+// NewQuestService создает новый экземпляр QuestService
+func NewQuestService(repo repository.QuestRepository, taskRepo repository.TaskRepository, logger *zap.Logger) *QuestService {
+	return &QuestService{
+		repo:     repo,
+		taskRepo: taskRepo,
+		logger:   logger,
+	}
+}
+
+// CreateQuest создает квест из задач в указанном порядке; доступно только администраторам
+func (s *QuestService) CreateQuest(ctx context.Context, req *models.QuestRequest) (*models.Quest, error) {
+	if !auth.IsAdmin(ctx) {
+		return nil, errors.NewForbidden("only administrators can manage quests", nil)
+	}
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	tasks := make([]models.QuestTask, len(req.TaskIDs))
+	for i, id := range req.TaskIDs {
+		tasks[i] = models.QuestTask{TaskID: id, Position: i + 1}
+	}
+
+	quest, err := s.repo.CreateQuest(ctx, &models.Quest{
+		QuestID:     uuid.New().String(),
+		Title:       strings.TrimSpace(req.Title),
+		Description: req.Description,
+		Bonus:       req.Bonus,
+		Tasks:       tasks,
+	})
+	if err != nil {
+		s.logger.Error("Failed to create quest", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Quest created", zap.String("questID", quest.QuestID), zap.Int("tasks", len(quest.Tasks)))
+	return quest, nil
+}
+
+// GetQuest возвращает квест по ID
+func (s *QuestService) GetQuest(ctx context.Context, id string) (*models.Quest, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.NewBadRequest("invalid quest ID", err)
+	}
+	return s.repo.GetQuestByID(ctx, id)
+}
+
+// GetQuests возвращает страницу квестов
+func (s *QuestService) GetQuests(ctx context.Context, limit, offset int) ([]models.Quest, error) {
+	if offset < 0 {
+		return nil, errors.NewBadRequest("offset cannot be negative", nil)
+	}
+	if limit <= 0 {
+		limit = defaultQuestLimit
+	}
+	if limit > maxQuestLimit {
+		limit = maxQuestLimit
+	}
+	return s.repo.GetQuests(ctx, limit, offset)
+}
+
+// GetUserQuestProgress возвращает прогресс пользователя по задачам квеста и выплату бонуса
+func (s *QuestService) GetUserQuestProgress(ctx context.Context, userID, questID string) (*models.QuestProgress, error) {
+	if err := validateUUID(userID); err != nil {
+		return nil, err
+	}
+	quest, err := s.GetQuest(ctx, questID)
+	if err != nil {
+		return nil, err
+	}
+
+	taskIDs := make([]string, len(quest.Tasks))
+	for i, task := range quest.Tasks {
+		taskIDs[i] = task.TaskID
+	}
+	availability, err := computeAvailability(ctx, s.taskRepo, userID, taskIDs)
+	if err != nil {
+		s.logger.Error("Failed to compute quest progress", zap.String("questID", questID), zap.Error(err))
+		return nil, err
+	}
+
+	progress := &models.QuestProgress{
+		QuestID:    quest.QuestID,
+		UserID:     userID,
+		Title:      quest.Title,
+		Bonus:      quest.Bonus,
+		Tasks:      make([]models.QuestTaskProgress, len(quest.Tasks)),
+		TotalTasks: len(quest.Tasks),
+	}
+	for i, task := range quest.Tasks {
+		progress.Tasks[i] = models.QuestTaskProgress{
+			TaskID:    task.TaskID,
+			Title:     task.Title,
+			Position:  task.Position,
+			Completed: availability[i].Completed,
+			Available: availability[i].Available,
+			BlockedBy: availability[i].BlockedBy,
+		}
+		if availability[i].Completed {
+			progress.CompletedTasks++
+		}
+	}
+
+	completion, err := s.repo.GetQuestCompletion(ctx, questID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if completion != nil {
+		progress.Completed = true
+		progress.Bonus = completion.Bonus
+		progress.CompletedAt = &completion.CompletedAt
+	}
+	return progress, nil
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository/memory"
+	"testing"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Бонус за квест начисляется после выполнения всех его задач и возвращается при повторном открытии любой из них
+func TestQuestBonus(t *testing.T) {
+	store := memory.NewStore()
+	users := NewUserService(memory.NewUserRepository(store), nil, nil, nil, zap.NewNop())
+	tasks := NewTaskService(memory.NewTaskRepository(store), nil, nil, 0, zap.NewNop())
+	quests := NewQuestService(memory.NewQuestRepository(store), memory.NewTaskRepository(store), zap.NewNop())
+
+	user, err := users.CreateUser(context.Background(), &models.CreateUserRequest{Username: "erin", Email: "erin@example.com"})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	var taskIDs []string
+	for _, title := range []string{"Review", "Deploy"} {
+		task, err := tasks.CreateTask(context.Background(), &models.CreateTaskRequest{BaseTaskRequest: models.BaseTaskRequest{
+			Title: title, Reward: 5, AssignmentMode: models.AssignmentOpen,
+		}})
+		if err != nil {
+			t.Fatalf("create task: %v", err)
+		}
+		if _, err := tasks.ClaimTask(context.Background(), task.TaskID, user.ID); err != nil {
+			t.Fatalf("claim task: %v", err)
+		}
+		taskIDs = append(taskIDs, task.TaskID)
+	}
+	quest, err := quests.CreateQuest(adminContext(), &models.QuestRequest{Title: "Release", Bonus: 10, TaskIDs: taskIDs})
+	if err != nil {
+		t.Fatalf("create quest: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		task      int
+		status    models.TaskStatus
+		spend     float64
+		balance   float64
+		completed bool
+	}{
+		{"first task", 0, models.Completed, 0, 5, false},
+		{"last task", 1, models.Completed, 0, 20, true},
+		{"reopen the first task", 0, models.InProgress, 0, 5, false},
+		{"complete it again", 0, models.Completed, 0, 20, true},
+		{"reopen after spending", 1, models.InProgress, 18, 0, false},
+	}
+	for _, tt := range tests {
+		if tt.spend != 0 {
+			if err := users.UpdateBalance(context.Background(), user.ID, -tt.spend); err != nil {
+				t.Fatalf("%s: spend: %v", tt.name, err)
+			}
+		}
+		if _, err := tasks.UpdateTaskStatus(context.Background(), taskIDs[tt.task], tt.status, uuid.MustParse(user.ID)); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got, err := users.GetUserByID(context.Background(), user.ID)
+		if err != nil {
+			t.Fatalf("%s: get user: %v", tt.name, err)
+		}
+		progress, err := quests.GetUserQuestProgress(context.Background(), user.ID, quest.QuestID)
+		if err != nil {
+			t.Fatalf("%s: quest progress: %v", tt.name, err)
+		}
+		if got.Balance != tt.balance || progress.Completed != tt.completed {
+			t.Fatalf("%s: balance %v, quest completed %t, want %v, %t", tt.name, got.Balance, progress.Completed, tt.balance, tt.completed)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// Запросы на создание и изменение реферального кода проверяются сервисом, а не обработчиком
+func TestReferralRequestValidation(t *testing.T) {
+	const userID = "9a0e6a5e-2b9f-4c1d-8e2a-7d3b5c4f1e09"
+	referrals := NewReferralService(nil, zap.NewNop())
+
+	for name, req := range map[string]*models.CreateReferralRequest{
+		"no code":         {UserID: userID},
+		"invalid user ID": {UserID: "erin", Code: "ERIN"},
+	} {
+		if _, err := referrals.CreateReferral(req); !errors.IsErrorType(err, errors.Validation) {
+			t.Errorf("create with %s: %v", name, err)
+		}
+	}
+	referral, err := referrals.CreateReferral(&models.CreateReferralRequest{UserID: userID, Code: "ERIN"})
+	if err != nil {
+		t.Fatalf("create referral: %v", err)
+	}
+
+	if _, err := referrals.UpdateReferral(referral.ReferralID, &models.UpdateReferralRequest{}); !errors.IsErrorType(err, errors.Validation) {
+		t.Fatalf("update without a code: %v", err)
+	}
+	if got, err := referrals.UpdateReferral(referral.ReferralID, &models.UpdateReferralRequest{Code: "ERIN2"}); err != nil || got.Code != "ERIN2" {
+		t.Fatalf("update referral: %+v, %v", got, err)
+	}
+}
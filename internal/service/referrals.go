@@ -1,9 +1,13 @@
 package service
 
 import (
-	"errors"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
 	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
 	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"github.com/ZnNr/user-reward-controller/internal/validation"
+	"slices"
+	"strings"
 
 	"github.com/google/uuid"
 	"time"
@@ -25,11 +29,18 @@ func NewReferralService(repo repository.ReferralRepository, logger *zap.Logger)
 	}
 }
 
-func (s *ReferralService) CreateReferral(userID string, code string) (*models.Referral, error) {
+// CreateReferral создает реферальный код пользователя
+func (s *ReferralService) CreateReferral(req *models.CreateReferralRequest) (*models.Referral, error) {
+	if err := validation.Struct(req); err != nil {
+		s.logger.Error("Referral request validation failed", zap.Error(err))
+		return nil, err
+	}
+	userID, code := req.UserID, req.Code
+
 	// Проверка на дубликат
 	for _, referral := range s.referrals {
 		if referral.Code == code {
-			return nil, errors.New("referral code already exists")
+			return nil, errors.NewAlreadyExists("referral code already exists", nil)
 		}
 	}
 
@@ -50,26 +61,79 @@ func (s *ReferralService) CreateReferral(userID string, code string) (*models.Re
 func (s *ReferralService) GetReferral(referralID string) (*models.Referral, error) {
 	referral, found := s.referrals[referralID]
 	if !found {
-		return nil, errors.New("referral not found")
+		return nil, errors.NewNotFound("referral not found", nil)
 	}
 	return &referral, nil
 }
 
-// GetReferralsByUserID получает все рефералы для пользователя
-func (s *ReferralService) GetReferralsByUserID(userID string) ([]models.Referral, error) {
-	var userReferrals []models.Referral
+// GetReferralsByUserID получает страницу рефералов пользователя, начиная с новых
+func (s *ReferralService) GetReferralsByUserID(userID string, page pagination.Page) (*models.ReferralsResponse, error) {
+	var cursor *models.Referral
+	if page.Cursor != nil {
+		createdAt, err := page.Cursor.Time(0)
+		if err != nil {
+			return nil, err
+		}
+		referralID, err := page.Cursor.String(1)
+		if err != nil {
+			return nil, err
+		}
+		cursor = &models.Referral{ReferralID: referralID, CreatedAt: createdAt}
+	}
+
+	// Рефералы выбираются так же, как их выбирал бы запрос страницы: после курсора в порядке убывания
+	// или перед курсором в порядке возрастания
+	userReferrals := []models.Referral{}
 	for _, referral := range s.referrals {
-		if referral.UserID == userID {
-			userReferrals = append(userReferrals, referral)
+		if referral.UserID != userID {
+			continue
 		}
+		if cursor != nil {
+			if c := compareReferrals(referral, *cursor); page.Backward() && c <= 0 || !page.Backward() && c >= 0 {
+				continue
+			}
+		}
+		userReferrals = append(userReferrals, referral)
 	}
-	return userReferrals, nil
+	slices.SortFunc(userReferrals, func(a, b models.Referral) int {
+		if page.Backward() {
+			return compareReferrals(a, b)
+		}
+		return compareReferrals(b, a)
+	})
+	if page.Limit > 0 && len(userReferrals) > page.Limit+1 {
+		userReferrals = userReferrals[:page.Limit+1]
+	}
+
+	userReferrals, info := pagination.Paginate(userReferrals, page, func(referral models.Referral) []string {
+		return []string{pagination.FormatTime(referral.CreatedAt), referral.ReferralID}
+	})
+	return &models.ReferralsResponse{
+		Referrals: userReferrals,
+		Count:     len(userReferrals),
+		Info:      info,
+	}, nil
 }
 
-func (s *ReferralService) UpdateReferral(referralID string, code string) (*models.Referral, error) {
+// compareReferrals сравнивает рефералы по ключам сортировки: времени создания и ID
+func compareReferrals(a, b models.Referral) int {
+	if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+		return c
+	}
+	return strings.Compare(a.ReferralID, b.ReferralID)
+}
+
+// UpdateReferral заменяет реферальный код
+func (s *ReferralService) UpdateReferral(referralID string, req *models.UpdateReferralRequest) (*models.Referral, error) {
+	if err := validation.Struct(req); err != nil {
+		s.logger.Error("Referral request validation failed", zap.Error(err))
+		return nil, err
+	}
+	code := req.Code
+
 	referral, found := s.referrals[referralID]
 	if !found {
-		return nil, errors.New("referral not found")
+		return nil, errors.NewNotFound("referral not found", nil)
 	}
 	if referral.Code == code {
 		return &referral, nil // Код не изменился
@@ -85,7 +149,7 @@ func (s *ReferralService) UpdateReferral(referralID string, code string) (*model
 func (s *ReferralService) DeleteReferral(referralID string) error {
 	_, found := s.referrals[referralID]
 	if !found {
-		return errors.New("referral not found")
+		return errors.NewNotFound("referral not found", nil)
 	}
 	delete(s.referrals, referralID)
 	return nil
@@ -104,5 +168,5 @@ func (s *ReferralService) ValidateReferralCode(code string) (bool, error) {
 			return true, nil // Код действителен
 		}
 	}
-	return false, errors.New("invalid referral code")
+	return false, errors.NewValidation("invalid referral code", nil)
 }
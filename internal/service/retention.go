@@ -0,0 +1,52 @@
+package service
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+
+	"go.uber.org/zap"
+	"time"
+)
+
+// RetentionService окончательно удаляет пользователей и задачи, срок хранения которых после мягкого удаления истек
+type RetentionService struct {
+	userRepo  repository.UserRepository
+	taskRepo  repository.TaskRepository
+	retention time.Duration
+	logger    *zap.Logger
+}
+
+// NewRetentionService создает новый экземпляр RetentionService
+func NewRetentionService(userRepo repository.UserRepository, taskRepo repository.TaskRepository, retention time.Duration, logger *zap.Logger) *RetentionService {
+	return &RetentionService{
+		userRepo:  userRepo,
+		taskRepo:  taskRepo,
+		retention: retention,
+		logger:    logger,
+	}
+}
+
+// Purge удаляет записи, мягко удаленные раньше, чем retention назад.
+func (s *RetentionService) Purge(ctx context.Context) error {
+	before := time.Now().Add(-s.retention)
+
+	tasks, err := s.taskRepo.PurgeDeletedTasks(ctx, before)
+	if err != nil {
+		s.logger.Error("Failed to purge deleted tasks", zap.Error(err))
+		return err
+	}
+
+	users, err := s.userRepo.PurgeDeletedUsers(ctx, before)
+	if err != nil {
+		s.logger.Error("Failed to purge deleted users", zap.Error(err))
+		return err
+	}
+
+	if tasks > 0 || users > 0 {
+		s.logger.Info("Purged soft-deleted records",
+			zap.Int64("tasks", tasks),
+			zap.Int64("users", users),
+			zap.Time("deletedBefore", before))
+	}
+	return nil
+}
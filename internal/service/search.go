@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Ограничения поискового запроса
+const (
+	maxSearchQueryLength = 256
+	defaultSearchLimit   = 20
+	maxSearchLimit       = 100
+)
+
+// SearchService выполняет полнотекстовый поиск по задачам и пользователям
+type SearchService struct {
+	repo   repository.SearchRepository
+	logger *zap.Logger
+}
+
+// NewSearchService создает новый экземпляр SearchService
+func NewSearchService(repo repository.SearchRepository, logger *zap.Logger) *SearchService {
+	return &SearchService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// Search ищет задачи и пользователей по тексту запроса; по умолчанию ищет среди всех объектов
+func (s *SearchService) Search(ctx context.Context, filter *models.SearchFilter) (*models.SearchResponse, error) {
+	filter.Query = strings.TrimSpace(filter.Query)
+	if filter.Query == "" {
+		return nil, errors.NewBadRequest("search query cannot be empty", nil)
+	}
+	if len(filter.Query) > maxSearchQueryLength {
+		return nil, errors.NewBadRequest(fmt.Sprintf("search query cannot be longer than %d characters", maxSearchQueryLength), nil)
+	}
+	if filter.Type == "" {
+		filter.Type = models.SearchAll
+	}
+	if !filter.Type.IsValid() {
+		return nil, errors.NewBadRequest(fmt.Sprintf("unknown search type %q", filter.Type), nil)
+	}
+	if filter.Offset < 0 {
+		return nil, errors.NewBadRequest("offset cannot be negative", nil)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultSearchLimit
+	}
+	if filter.Limit > maxSearchLimit {
+		filter.Limit = maxSearchLimit
+	}
+
+	results, err := s.repo.Search(ctx, filter)
+	if err != nil {
+		s.logger.Error("Search failed", zap.String("query", filter.Query), zap.Error(err))
+		return nil, err
+	}
+
+	return &models.SearchResponse{
+		Query:   filter.Query,
+		Type:    filter.Type,
+		Results: results,
+		Limit:   filter.Limit,
+		Offset:  filter.Offset,
+	}, nil
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/audit"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"github.com/ZnNr/user-reward-controller/internal/service/auth"
+	"github.com/ZnNr/user-reward-controller/internal/validation"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"time"
+)
+
+// Ограничения выборки очереди модерации
+const (
+	defaultSubmissionLimit = 100
+	maxSubmissionLimit     = 1000
+)
+
+// SubmissionService принимает заявки на выполнение задач и ведет очередь модерации
+type SubmissionService struct {
+	repo     repository.TaskRepository
+	audit    AuditRecorder
+	balances *BalanceNotifier
+	dueGrace time.Duration // Отсрочка после срока, в течение которой еще принимаются заявки
+	logger   *zap.Logger
+}
+
+// NewSubmissionService создает новый экземпляр SubmissionService.
+// Заявки, отправленные до истечения срока, можно одобрить и после него.
+func NewSubmissionService(repo repository.TaskRepository, auditor AuditRecorder, balances *BalanceNotifier, dueGrace time.Duration, logger *zap.Logger) *SubmissionService {
+	return &SubmissionService{
+		repo:     repo,
+		audit:    auditor,
+		balances: balances,
+		dueGrace: dueGrace,
+		logger:   logger,
+	}
+}
+
+// Submit сохраняет заявку пользователя на выполнение задачи с доказательством
+func (s *SubmissionService) Submit(ctx context.Context, taskID string, req *models.CreateSubmissionRequest) (*models.Submission, error) {
+	if _, err := uuid.Parse(taskID); err != nil {
+		return nil, errors.NewBadRequest("invalid task ID", err)
+	}
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+	evidence, err := validateEvidence(req.EvidenceType, req.Evidence)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ensureTaskAvailable(ctx, s.repo, req.UserID, taskID); err != nil {
+		return nil, err
+	}
+
+	submission, err := s.repo.CreateSubmission(ctx, &models.Submission{
+		TaskID:       taskID,
+		UserID:       req.UserID,
+		EvidenceType: req.EvidenceType,
+		Evidence:     evidence,
+	}, time.Now().Add(-s.dueGrace))
+	if err != nil {
+		s.logger.Error("Failed to create submission", zap.String("taskID", taskID), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Submission created", zap.Int64("submissionID", submission.ID), zap.String("taskID", taskID))
+	return submission, nil
+}
+
+// validateEvidence проверяет доказательство выполнения: ссылка должна быть абсолютным http(s) URL.
+// Наличие, длина и вид доказательства проверяются по тегам запроса.
+func validateEvidence(kind models.EvidenceType, evidence string) (string, error) {
+	evidence = strings.TrimSpace(evidence)
+	if kind == models.EvidenceURL {
+		u, err := url.Parse(evidence)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", errors.NewFieldsValidation(validation.Message, []errors.FieldError{
+				{In: "body", Field: "evidence", Message: "must be an absolute http(s) URL"},
+			})
+		}
+	}
+	return evidence, nil
+}
+
+// GetQueue возвращает заявки для модерации; доступно только администраторам
+func (s *SubmissionService) GetQueue(ctx context.Context, filter *models.SubmissionFilter) ([]models.Submission, error) {
+	if !auth.IsAdmin(ctx) {
+		return nil, errors.NewForbidden("only administrators can moderate submissions", nil)
+	}
+	if filter.Status != "" && !filter.Status.IsValid() {
+		return nil, errors.NewBadRequest(fmt.Sprintf("unknown submission status %q", filter.Status), nil)
+	}
+	if filter.TaskID != "" {
+		if _, err := uuid.Parse(filter.TaskID); err != nil {
+			return nil, errors.NewBadRequest("invalid task ID", err)
+		}
+	}
+	if filter.Offset < 0 {
+		return nil, errors.NewBadRequest("offset cannot be negative", nil)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultSubmissionLimit
+	}
+	if filter.Limit > maxSubmissionLimit {
+		filter.Limit = maxSubmissionLimit
+	}
+
+	return s.repo.GetSubmissions(ctx, filter)
+}
+
+// Approve одобряет заявку; выполнение задачи засчитывается и вознаграждение начисляется пользователю
+func (s *SubmissionService) Approve(ctx context.Context, id string, req *models.ReviewSubmissionRequest) (*models.Submission, error) {
+	var change *models.BalanceUpdate
+	submission, err := s.review(ctx, id, req, models.AuditSubmissionApprove,
+		func(ctx context.Context, id int64, reason, reviewer string) (*models.Submission, error) {
+			approved, balance, err := s.repo.ApproveSubmission(ctx, id, reason, reviewer)
+			change = balance
+			return approved, err
+		})
+	if err != nil {
+		return nil, err
+	}
+	s.balances.publishBalanceChange(ctx, change)
+	return submission, nil
+}
+
+// Reject отклоняет заявку; причина отклонения обязательна
+func (s *SubmissionService) Reject(ctx context.Context, id string, req *models.ReviewSubmissionRequest) (*models.Submission, error) {
+	if strings.TrimSpace(req.Reason) == "" {
+		return nil, errors.NewValidation("reason is required to reject a submission", nil)
+	}
+	return s.review(ctx, id, req, models.AuditSubmissionReject, s.repo.RejectSubmission)
+}
+
+// review проверяет права модератора, применяет решение и записывает его в журнал аудита.
+func (s *SubmissionService) review(ctx context.Context, id string, req *models.ReviewSubmissionRequest, action string,
+	apply func(ctx context.Context, id int64, reason, reviewer string) (*models.Submission, error)) (*models.Submission, error) {
+	if !auth.IsAdmin(ctx) {
+		return nil, errors.NewForbidden("only administrators can moderate submissions", nil)
+	}
+	submissionID, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || submissionID <= 0 {
+		return nil, errors.NewBadRequest("invalid submission ID", err)
+	}
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+	reason := strings.TrimSpace(req.Reason)
+
+	reviewer := audit.FromContext(ctx).Actor
+	submission, err := apply(ctx, submissionID, reason, reviewer)
+	if err != nil {
+		s.logger.Error("Failed to review submission", zap.Int64("submissionID", submissionID), zap.String("action", action), zap.Error(err))
+		return nil, err
+	}
+
+	if s.audit != nil {
+		s.audit.Record(ctx, action, models.AuditTargetSubmission, id,
+			map[string]interface{}{"status": models.SubmissionPending},
+			map[string]interface{}{"status": submission.Status, "reason": submission.Reason})
+	}
+	s.logger.Info("Submission reviewed", zap.Int64("submissionID", submissionID), zap.String("status", string(submission.Status)))
+	return submission, nil
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/events"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository/memory"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Выплата по одобренной заявке рассылается подписчикам так же, как начисление при выполнении задачи
+func TestSubmissionPayoutPublishesBalance(t *testing.T) {
+	store := memory.NewStore()
+	userRepo := memory.NewUserRepository(store)
+	taskRepo := memory.NewTaskRepository(store)
+	publisher := &recordingPublisher{}
+	balances := NewBalanceNotifier(userRepo, publisher, time.Hour, zap.NewNop())
+	tasks := NewTaskService(taskRepo, nil, balances, 0, zap.NewNop())
+	submissions := NewSubmissionService(taskRepo, nil, balances, 0, zap.NewNop())
+
+	user, err := NewUserService(userRepo, nil, nil, nil, zap.NewNop()).
+		CreateUser(context.Background(), &models.CreateUserRequest{Username: "erin", Email: "erin@example.com"})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	task, err := tasks.CreateTask(context.Background(), &models.CreateTaskRequest{BaseTaskRequest: models.BaseTaskRequest{
+		Title: "Review", Reward: 15, RequiresEvidence: ptr(true),
+	}})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	submission, err := submissions.Submit(context.Background(), task.TaskID, &models.CreateSubmissionRequest{
+		UserID: user.ID, EvidenceType: models.EvidenceText, Evidence: "done",
+	})
+	if err != nil {
+		t.Fatalf("submit: %v", err)
+	}
+
+	if _, err := submissions.Approve(adminContext(), strconv.FormatInt(submission.ID, 10), &models.ReviewSubmissionRequest{}); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	want := models.BalanceUpdate{UserID: user.ID, Balance: 15, Delta: 15}
+	got := publisher.events
+	if len(got) != 1 || got[0].topic != events.UserTopic(user.ID) || got[0].eventType != events.EventBalance || got[0].payload != want {
+		t.Fatalf("approval published %+v, want balance %+v", got, want)
+	}
+}
+
+// Одобрение заявки выполняет задачу и начисляет вознаграждение, отклонение - нет;
+// без одобренной заявки задачу с обязательным доказательством выполнить нельзя
+func TestSubmissionModeration(t *testing.T) {
+	store := memory.NewStore()
+	users := NewUserService(memory.NewUserRepository(store), nil, nil, nil, zap.NewNop())
+	tasks := NewTaskService(memory.NewTaskRepository(store), nil, nil, 0, zap.NewNop())
+	submissions := NewSubmissionService(memory.NewTaskRepository(store), nil, nil, 0, zap.NewNop())
+	user, err := users.CreateUser(context.Background(), &models.CreateUserRequest{Username: "erin", Email: "erin@example.com"})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	task, err := tasks.CreateTask(context.Background(), &models.CreateTaskRequest{BaseTaskRequest: models.BaseTaskRequest{
+		Title: "Review", Reward: 15, RequiresEvidence: ptr(true),
+	}})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	complete := func() error {
+		_, err := tasks.UpdateTaskStatus(context.Background(), task.TaskID, models.Completed, uuid.MustParse(user.ID))
+		return err
+	}
+	submit := func() string {
+		t.Helper()
+		submission, err := submissions.Submit(context.Background(), task.TaskID, &models.CreateSubmissionRequest{
+			UserID: user.ID, EvidenceType: models.EvidenceText, Evidence: "done",
+		})
+		if err != nil {
+			t.Fatalf("submit: %v", err)
+		}
+		return strconv.FormatInt(submission.ID, 10)
+	}
+	expect := func(step string, balance float64, status models.TaskStatus) {
+		t.Helper()
+		got, err := users.GetUserByID(context.Background(), user.ID)
+		if err != nil {
+			t.Fatalf("get user: %v", err)
+		}
+		current, err := tasks.GetTaskByID(context.Background(), task.TaskID)
+		if err != nil {
+			t.Fatalf("get task: %v", err)
+		}
+		if got.Balance != balance || current.Status != status {
+			t.Fatalf("after %s: balance %v, task %s, want %v, %s", step, got.Balance, current.Status, balance, status)
+		}
+	}
+
+	if err := complete(); !errors.IsErrorType(err, errors.Conflict) {
+		t.Fatalf("complete without a submission: %v", err)
+	}
+
+	rejected := submit()
+	if _, err := submissions.Reject(adminContext(), rejected, &models.ReviewSubmissionRequest{}); !errors.IsErrorType(err, errors.Validation) {
+		t.Fatalf("reject without a reason: %v", err)
+	}
+	if _, err := submissions.Approve(userContext(user.ID), rejected, &models.ReviewSubmissionRequest{}); !errors.IsErrorType(err, errors.Forbidden) {
+		t.Fatalf("user approves a submission: %v", err)
+	}
+	submission, err := submissions.Reject(adminContext(), rejected, &models.ReviewSubmissionRequest{Reason: "no link"})
+	if err != nil || submission.Status != models.SubmissionRejected {
+		t.Fatalf("reject: %+v, %v", submission, err)
+	}
+	expect("rejection", 0, models.NotStarted)
+	if err := complete(); !errors.IsErrorType(err, errors.Conflict) {
+		t.Fatalf("complete with a rejected submission: %v", err)
+	}
+
+	submission, err = submissions.Approve(adminContext(), submit(), &models.ReviewSubmissionRequest{})
+	if err != nil || submission.Status != models.SubmissionApproved {
+		t.Fatalf("approve: %+v, %v", submission, err)
+	}
+	expect("approval", 15, models.Completed)
+}
@@ -2,9 +2,13 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/audit"
 	"github.com/ZnNr/user-reward-controller/internal/errors"
 	"github.com/ZnNr/user-reward-controller/internal/models"
 	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"github.com/ZnNr/user-reward-controller/internal/service/auth"
+	"github.com/ZnNr/user-reward-controller/internal/validation"
 
 	"strings"
 
@@ -14,14 +18,20 @@ import (
 )
 
 type TaskService struct {
-	repo   repository.TaskRepository
-	logger *zap.Logger
+	repo     repository.TaskRepository
+	audit    AuditRecorder
+	balances *BalanceNotifier
+	dueGrace time.Duration // Отсрочка после срока, в течение которой задачу еще можно выполнить
+	logger   *zap.Logger
 }
 
-func NewTaskService(repo repository.TaskRepository, logger *zap.Logger) *TaskService {
+func NewTaskService(repo repository.TaskRepository, auditor AuditRecorder, balances *BalanceNotifier, dueGrace time.Duration, logger *zap.Logger) *TaskService {
 	return &TaskService{
-		repo:   repo,
-		logger: logger,
+		repo:     repo,
+		audit:    auditor,
+		balances: balances,
+		dueGrace: dueGrace,
+		logger:   logger,
 	}
 }
 
@@ -31,7 +41,24 @@ func (s *TaskService) CreateTask(ctx context.Context, req *models.CreateTaskRequ
 		zap.String("title", req.Title),
 		zap.String("description", req.Description))
 
-	if err := validateTaskRequest(req); err != nil {
+	if err := validation.Struct(req); err != nil {
+		return nil, err
+	}
+
+	status, err := initialTaskStatus(req.Status)
+	if err != nil {
+		return nil, err
+	}
+
+	mode, err := validateAssignment(req.AssignmentMode, req.MaxClaims)
+	if err != nil {
+		return nil, err
+	}
+	if len(req.AssigneeIDs) > 0 && mode != models.AssignmentAssigned {
+		return nil, errors.NewValidation("assignee_ids can be set only for assigned tasks", nil)
+	}
+	campaignID, err := validateCampaignRef(req.CampaignID)
+	if err != nil {
 		return nil, err
 	}
 
@@ -41,22 +68,72 @@ func (s *TaskService) CreateTask(ctx context.Context, req *models.CreateTaskRequ
 		Description: req.Description,
 		CreatedAt:   time.Now(), // Установка текущего времени в качестве времени создания
 		DueDate:     req.DueDate,
-		// Установка статуса на основе проверки
-		Status:     validateAndSetStatus(req.Status),
-		AssigneeID: req.AssigneeID,
+		Status:      status,
+		AssigneeID:  req.AssigneeID,
+		Reward:      req.Reward,
+		// Режим назначения определяет, кто может взять задачу
+		AssignmentMode: mode,
+		MaxClaims:      req.MaxClaims,
+		CampaignID:     campaignID,
+	}
+	if req.RequiresEvidence != nil {
+		task.RequiresEvidence = *req.RequiresEvidence
 	}
 
-	return s.repo.CreateTask(ctx, task)
+	return s.repo.CreateTask(ctx, task, req.AssigneeIDs)
 }
 
-// ValidateAndSetStatus возвращает статус задачи на основании проверки входного значения
-func validateAndSetStatus(status models.TaskStatus) models.TaskStatus {
-	switch status {
-	case models.NotStarted, models.InProgress, models.Completed, models.Canceled:
-		return status
-	default:
-		return models.NotStarted // Статус по умолчанию
+// validateAndSetStatus проверяет статус задачи; незаданный статус заменяется на NotStarted
+func validateAndSetStatus(status models.TaskStatus) (models.TaskStatus, error) {
+	if status == 0 {
+		return models.NotStarted, nil // Статус по умолчанию
+	}
+	if !status.IsValid() {
+		return 0, errors.NewValidation(fmt.Sprintf("unknown task status %d", status), nil)
+	}
+	return status, nil
+}
+
+// initialTaskStatus проверяет статус новой задачи: выполненной или отмененной задача
+// может стать только через переход статуса, чтобы вознаграждение начислялось по графу переходов.
+func initialTaskStatus(status models.TaskStatus) (models.TaskStatus, error) {
+	status, err := validateAndSetStatus(status)
+	if err != nil {
+		return 0, err
+	}
+	if status != models.NotStarted && status != models.InProgress {
+		return 0, errors.NewValidation(fmt.Sprintf("task cannot be created with status %s", status), nil)
+	}
+	return status, nil
+}
+
+// validateAssignment проверяет режим назначения задачи и лимит взявших ее пользователей;
+// незаданный режим заменяется на assigned.
+func validateAssignment(mode models.TaskAssignmentMode, maxClaims *int) (models.TaskAssignmentMode, error) {
+	if mode == "" {
+		mode = models.AssignmentAssigned
+	}
+	if !mode.IsValid() {
+		return "", errors.NewValidation(fmt.Sprintf("unknown assignment mode %q", mode), nil)
+	}
+	if mode == models.AssignmentCapped && (maxClaims == nil || *maxClaims <= 0) {
+		return "", errors.NewValidation("max_claims must be positive for capped tasks", nil)
+	}
+	if mode != models.AssignmentCapped && maxClaims != nil {
+		return "", errors.NewValidation("max_claims can be set only for capped tasks", nil)
+	}
+	return mode, nil
+}
+
+// validateCampaignRef проверяет ссылку задачи на кампанию; пустая строка означает задачу вне кампании.
+func validateCampaignRef(campaignID *string) (*string, error) {
+	if campaignID == nil || *campaignID == "" {
+		return nil, nil
+	}
+	if _, err := uuid.Parse(*campaignID); err != nil {
+		return nil, errors.NewValidation("campaign_id must be a valid UUID", err)
 	}
+	return campaignID, nil
 }
 
 // UpdateTask обновляет существующую задачу.
@@ -66,41 +143,83 @@ func (s *TaskService) UpdateTask(ctx context.Context, id uuid.UUID, req *models.
 		zap.String("Title", req.Title),
 		zap.String("Description", req.Description))
 
+	if err := validation.Partial(req); err != nil {
+		return nil, err
+	}
+
 	task, err := s.repo.GetTaskByID(ctx, id)
 	if err != nil {
 		s.logger.Error("Task not found", zap.Error(err))
 		return nil, errors.NewNotFound("task not found", nil)
 	}
 
-	updateTaskFields(task, req)
+	if err := updateTaskFields(task, req); err != nil {
+		return nil, err
+	}
 	task.UpdatedAt = time.Now()
 
 	updatedTask, err := s.repo.UpdateTask(ctx, task)
 	if err != nil {
 		s.logger.Error("Failed to update task", zap.Error(err))
-		return nil, errors.NewInternal("failed to update task", err)
+		return nil, err
 	}
 
 	return updatedTask, nil
 }
 
-// updateTaskFields обновляет поля задачи на основании запроса
-func updateTaskFields(task *models.Task, req *models.UpdateTaskRequest) {
+// updateTaskFields обновляет поля задачи на основании запроса.
+// Статус через обновление задачи не меняется: переходы выполняются только через UpdateTaskStatus.
+func updateTaskFields(task *models.Task, req *models.UpdateTaskRequest) error {
+	if req.Status != 0 && req.Status != task.Status { // Если Status - это 0, значит, он не был установлен
+		return errors.NewValidation("task status cannot be changed by update, use PATCH /tasks/{task_id}/status/{user_id}", nil)
+	}
+	if len(req.AssigneeIDs) > 0 {
+		return errors.NewValidation("assignee_ids can be set only when the task is created", nil)
+	}
+	if req.AssignmentMode != "" || req.MaxClaims != nil {
+		mode := req.AssignmentMode
+		if mode == "" {
+			mode = task.AssignmentMode
+		}
+		mode, err := validateAssignment(mode, req.MaxClaims)
+		if err != nil {
+			return err
+		}
+		task.AssignmentMode = mode
+		task.MaxClaims = req.MaxClaims
+	}
+	if req.RequiresEvidence != nil {
+		task.RequiresEvidence = *req.RequiresEvidence
+	}
+	if req.CampaignID != nil {
+		campaignID, err := validateCampaignRef(req.CampaignID)
+		if err != nil {
+			return err
+		}
+		task.CampaignID = campaignID
+	}
 	if req.Title != "" {
 		task.Title = req.Title
 	}
 	if req.Description != "" {
 		task.Description = req.Description
 	}
-	if !req.DueDate.IsZero() {
+	if req.DueDate != nil && !req.DueDate.IsZero() {
+		// Прежний дедлайн можно прислать обратно, даже если он уже прошел; новый должен быть в будущем
+		if (task.DueDate == nil || !req.DueDate.Equal(*task.DueDate)) && !req.DueDate.After(time.Now()) {
+			return errors.NewFieldsValidation(validation.Message, []errors.FieldError{
+				{In: "body", Field: "due_date", Message: "must be in the future"},
+			})
+		}
 		task.DueDate = req.DueDate
 	}
-	if req.Status != 0 { // Если Status - это 0, значит, он не был установлен
-		task.Status = validateAndSetStatus(req.Status) // Валидация и установка нового статуса
-	}
 	if req.AssigneeID != nil && *req.AssigneeID != "" {
 		task.AssigneeID = req.AssigneeID
 	}
+	if req.Reward > 0 {
+		task.Reward = req.Reward
+	}
+	return nil
 }
 
 // UpdateTaskStatus обновляет статус существующей задачи.
@@ -115,22 +234,93 @@ func (s *TaskService) UpdateTaskStatus(ctx context.Context, taskID string, newSt
 		return nil, errors.NewBadRequest("invalid task ID", err)
 	}
 
-	// Используем validateAndSetStatus для валидации нового статуса
-	if newStatus < models.NotStarted || newStatus > models.Canceled {
+	if !newStatus.IsValid() {
 		s.logger.Error("Invalid status provided", zap.Int("newStatus", int(newStatus)))
 		return nil, errors.NewBadRequest("invalid status", nil)
 	}
 
-	updatedTask, err := s.repo.UpdateTaskStatus(ctx, taskIDParsed.String(), int(newStatus), userID)
+	if newStatus == models.Expired {
+		return nil, errors.NewValidation("tasks expire automatically after their due date", nil)
+	}
+	if newStatus == models.InProgress || newStatus == models.Completed {
+		if err := ensureTaskAvailable(ctx, s.repo, userID.String(), taskIDParsed.String()); err != nil {
+			s.logger.Error("Task is not available", zap.String("taskID", taskID), zap.Error(err))
+			return nil, err
+		}
+	}
+
+	// Допустимость перехода и срок выполнения проверяются в репозитории под блокировкой строки задачи
+	actor := audit.FromContext(ctx).Actor
+	updatedTask, change, err := s.repo.UpdateTaskStatus(ctx, taskIDParsed.String(), int(newStatus), userID, actor, time.Now().Add(-s.dueGrace))
 	if err != nil {
 		s.logger.Error("Failed to update task status", zap.Error(err))
 		return nil, err
 	}
+	// Вознаграждение, его возврат и бонусы за квесты и кампании изменяют баланс в той же транзакции
+	s.balances.publishBalanceChange(ctx, change)
 
 	s.logger.Info("Successfully updated task status", zap.String("taskID", taskID), zap.Int("newStatus", int(newStatus)))
 	return updatedTask, nil
 }
 
+// ClaimTask закрепляет открытую задачу или задачу с лимитом за пользователем
+func (s *TaskService) ClaimTask(ctx context.Context, taskID, userID string) (*models.TaskAssignment, error) {
+	if _, err := uuid.Parse(taskID); err != nil {
+		s.logger.Error("Invalid task ID", zap.Error(err))
+		return nil, errors.NewBadRequest("invalid task ID", err)
+	}
+	if err := validateUUID(userID); err != nil {
+		return nil, err
+	}
+
+	if err := ensureTaskAvailable(ctx, s.repo, userID, taskID); err != nil {
+		s.logger.Error("Task is not available", zap.String("taskID", taskID), zap.String("userID", userID), zap.Error(err))
+		return nil, err
+	}
+
+	assignment, err := s.repo.ClaimTask(ctx, taskID, userID, time.Now().Add(-s.dueGrace))
+	if err != nil {
+		s.logger.Error("Failed to claim task", zap.String("taskID", taskID), zap.String("userID", userID), zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Task claimed", zap.String("taskID", taskID), zap.String("userID", userID))
+	return assignment, nil
+}
+
+// GetUserTasks возвращает взятые, назначенные и выполненные пользователем задачи с его прогрессом
+func (s *TaskService) GetUserTasks(ctx context.Context, userID string, status models.TaskStatus) ([]models.UserTask, error) {
+	if err := validateUUID(userID); err != nil {
+		return nil, err
+	}
+	if status != 0 && !status.IsValid() {
+		return nil, errors.NewBadRequest(fmt.Sprintf("unknown task status %d", status), nil)
+	}
+
+	tasks, err := s.repo.GetUserTasks(ctx, userID, status)
+	if err != nil {
+		s.logger.Error("Failed to get user tasks", zap.String("userID", userID), zap.Error(err))
+		return nil, err
+	}
+	return tasks, nil
+}
+
+// GetTaskHistory возвращает историю переходов статуса задачи
+func (s *TaskService) GetTaskHistory(ctx context.Context, id string) ([]models.TaskStatusChange, error) {
+	taskID, err := uuid.Parse(id)
+	if err != nil {
+		s.logger.Error("Invalid task ID", zap.Error(err))
+		return nil, errors.NewBadRequest("invalid task ID", err)
+	}
+
+	history, err := s.repo.GetTaskStatusHistory(ctx, taskID)
+	if err != nil {
+		s.logger.Error("Failed to get task status history", zap.Error(err))
+		return nil, err
+	}
+	return history, nil
+}
+
 // GetTasks получает все задачи с возможностью фильтрации
 func (s *TaskService) GetTasks(ctx context.Context, filter *models.TaskFilter) (*models.TaskResponse, error) {
 	s.logger.Info("Fetching tasks with filter", zap.Any("filter", filter))
@@ -146,6 +336,10 @@ func (s *TaskService) GetTasks(ctx context.Context, filter *models.TaskFilter) (
 		//zap.Int("page", filter.Page),
 		//zap.Int("pageSize", filter.PageSize))
 	)
+	// Мягко удаленные задачи доступны только администраторам
+	if filter.IncludeDeleted && !auth.IsAdmin(ctx) {
+		return nil, errors.NewForbidden("only administrators can list deleted tasks", nil)
+	}
 	// Валидация параметров фильтра
 	if err := validateFilter(filter); err != nil {
 		s.logger.Warn("Invalid filter", zap.Error(err))
@@ -182,7 +376,7 @@ func (s *TaskService) GetTaskByID(ctx context.Context, id string) (*models.Task,
 	return task, nil
 }
 
-// DeleteTask удаляет задачу по ID
+// DeleteTask помечает задачу удаленной по ID
 func (s *TaskService) DeleteTask(ctx context.Context, id string) error {
 	if id == "" {
 		s.logger.Error("Task ID cannot be empty")
@@ -194,15 +388,46 @@ func (s *TaskService) DeleteTask(ctx context.Context, id string) error {
 		return errors.NewBadRequest("invalid task ID", err)
 	}
 
+	// Состояние задачи до удаления сохраняется в журнале аудита
+	task, err := s.repo.GetTaskByID(ctx, taskID)
+	if err != nil {
+		s.logger.Error("Failed to get task before delete", zap.Error(err))
+		return err
+	}
+
 	if err := s.repo.DeleteTask(ctx, taskID); err != nil {
 		s.logger.Error("Failed to delete task", zap.Error(err))
-		return errors.NewInternal("failed to delete task", err)
+		return err
 	}
 
+	if s.audit != nil {
+		s.audit.Record(ctx, models.AuditTaskDelete, models.AuditTargetTask, id, task, nil)
+	}
 	s.logger.Info("Task deleted successfully", zap.String("taskID", id))
 	return nil
 }
 
+// RestoreTask восстанавливает мягко удаленную задачу по ID; доступно только администраторам
+func (s *TaskService) RestoreTask(ctx context.Context, id string) (*models.Task, error) {
+	if !auth.IsAdmin(ctx) {
+		return nil, errors.NewForbidden("only administrators can restore tasks", nil)
+	}
+	taskID, err := uuid.Parse(id)
+	if err != nil {
+		s.logger.Error("Invalid task ID", zap.Error(err))
+		return nil, errors.NewBadRequest("invalid task ID", err)
+	}
+
+	task, err := s.repo.RestoreTask(ctx, taskID)
+	if err != nil {
+		s.logger.Error("Failed to restore task", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("Task restored successfully", zap.String("taskID", id))
+	return task, nil
+}
+
 // GetDescription получает описание задачи с опциональным фильтром.
 func (s *TaskService) GetDescription(ctx context.Context, id uuid.UUID, page, pageSize int) (*models.DescriptionResponse, error) {
 	s.logger.Info("Getting description",
@@ -246,15 +471,6 @@ func (s *TaskService) GetDescription(ctx context.Context, id uuid.UUID, page, pa
 	}, nil
 }
 
-// validateTaskRequest выполняет проверку валидности запроса на создание или обновление задачи.
-func validateTaskRequest(req *models.CreateTaskRequest) error {
-	if req.Title == "" {
-		return errors.NewValidation("task title cannot be empty", nil)
-	}
-
-	return nil
-}
-
 // generateTaskID создает уникальный идентификатор задачи
 func generateTaskID() string {
 	return uuid.New().String() // Генерация нового UUID
@@ -273,5 +489,10 @@ func validateFilter(filter *models.TaskFilter) error {
 			return errors.NewValidation("AssigneeID must be a valid UUID", nil)
 		}
 	}
+	if filter.CampaignID != "" {
+		if _, err := uuid.Parse(filter.CampaignID); err != nil {
+			return errors.NewValidation("CampaignID must be a valid UUID", nil)
+		}
+	}
 	return nil
 }
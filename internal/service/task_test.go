@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/events"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository/memory"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// published - событие, разосланное через recordingPublisher
+type published struct {
+	topic     string
+	eventType string
+	payload   interface{}
+}
+
+// recordingPublisher запоминает разосланные события
+type recordingPublisher struct {
+	events []published
+}
+
+func (p *recordingPublisher) Publish(topic, eventType string, payload interface{}) {
+	p.events = append(p.events, published{topic: topic, eventType: eventType, payload: payload})
+}
+
+func TestTaskRewardPublishesBalance(t *testing.T) {
+	store := memory.NewStore()
+	userRepo := memory.NewUserRepository(store)
+	publisher := &recordingPublisher{}
+	balances := NewBalanceNotifier(userRepo, publisher, time.Hour, zap.NewNop())
+	tasks := NewTaskService(memory.NewTaskRepository(store), nil, balances, 0, zap.NewNop())
+	user, err := NewUserService(userRepo, nil, nil, nil, zap.NewNop()).
+		CreateUser(context.Background(), &models.CreateUserRequest{Username: "erin", Email: "erin@example.com"})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	task, err := tasks.CreateTask(context.Background(), &models.CreateTaskRequest{BaseTaskRequest: models.BaseTaskRequest{Title: "Review", Reward: 15}})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	transition := func(status models.TaskStatus) []published {
+		t.Helper()
+		publisher.events = nil
+		if _, err := tasks.UpdateTaskStatus(context.Background(), task.TaskID, status, uuid.MustParse(user.ID)); err != nil {
+			t.Fatalf("move task to %s: %v", status, err)
+		}
+		return publisher.events
+	}
+	if got := transition(models.InProgress); len(got) != 0 {
+		t.Fatalf("starting a task published %+v", got)
+	}
+
+	tests := []struct {
+		status models.TaskStatus
+		want   models.BalanceUpdate
+	}{
+		{models.Completed, models.BalanceUpdate{UserID: user.ID, Balance: 15, Delta: 15}},
+		{models.InProgress, models.BalanceUpdate{UserID: user.ID, Balance: 0, Delta: -15}},
+	}
+	for _, tt := range tests {
+		got := transition(tt.status)
+		if len(got) == 0 || got[0].topic != events.UserTopic(user.ID) || got[0].eventType != events.EventBalance || got[0].payload != tt.want {
+			t.Fatalf("%s published %+v, want balance %+v", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestDeletedTasksRequireAdmin(t *testing.T) {
+	store := memory.NewStore()
+	tasks := NewTaskService(memory.NewTaskRepository(store), nil, nil, 0, zap.NewNop())
+	task, err := tasks.CreateTask(context.Background(), &models.CreateTaskRequest{BaseTaskRequest: models.BaseTaskRequest{Title: "Review"}})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+	if err := tasks.DeleteTask(context.Background(), task.TaskID); err != nil {
+		t.Fatalf("delete task: %v", err)
+	}
+
+	for name, ctx := range map[string]context.Context{"anonymous": context.Background(), "user": userContext("9a0e6a5e-2b9f-4c1d-8e2a-7d3b5c4f1e09")} {
+		if _, err := tasks.GetTasks(ctx, &models.TaskFilter{IncludeDeleted: true}); !errors.IsErrorType(err, errors.Forbidden) {
+			t.Errorf("%s lists deleted tasks: %v", name, err)
+		}
+		if _, err := tasks.RestoreTask(ctx, task.TaskID); !errors.IsErrorType(err, errors.Forbidden) {
+			t.Errorf("%s restores a task: %v", name, err)
+		}
+	}
+
+	listed, err := tasks.GetTasks(adminContext(), &models.TaskFilter{IncludeDeleted: true})
+	if err != nil || len(listed.Tasks) != 1 {
+		t.Fatalf("admin lists deleted tasks: %+v, %v", listed, err)
+	}
+	if _, err := tasks.RestoreTask(adminContext(), task.TaskID); err != nil {
+		t.Fatalf("admin restores a task: %v", err)
+	}
+}
+
+// Прошедший дедлайн можно прислать обратно без изменений, но нельзя назначить новым
+func TestUpdateTaskPastDueDate(t *testing.T) {
+	store := memory.NewStore()
+	repo := memory.NewTaskRepository(store)
+	tasks := NewTaskService(repo, nil, nil, 0, zap.NewNop())
+	past := time.Now().Add(-24 * time.Hour).Truncate(time.Second)
+	id := uuid.New()
+	_, err := repo.CreateTask(context.Background(), &models.Task{
+		TaskID: id.String(), Title: "Review", DueDate: &past, Status: models.NotStarted, AssignmentMode: models.AssignmentOpen,
+	}, nil)
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	unchanged := past.In(time.FixedZone("UTC+3", 3*60*60))
+	update := &models.UpdateTaskRequest{BaseTaskRequest: models.BaseTaskRequest{Title: "Review again", DueDate: &unchanged}}
+	if _, err := tasks.UpdateTask(context.Background(), id, update); err != nil {
+		t.Fatalf("update with the unchanged due date: %v", err)
+	}
+	if _, err := tasks.UpdateTask(context.Background(), id, &models.UpdateTaskRequest{}); err != nil {
+		t.Fatalf("update without a due date: %v", err)
+	}
+
+	earlier := past.Add(-time.Hour)
+	update = &models.UpdateTaskRequest{BaseTaskRequest: models.BaseTaskRequest{DueDate: &earlier}}
+	if _, err := tasks.UpdateTask(context.Background(), id, update); !errors.IsErrorType(err, errors.Validation) {
+		t.Fatalf("update with a new past due date: %v", err)
+	}
+	later := time.Now().Add(time.Hour)
+	update = &models.UpdateTaskRequest{BaseTaskRequest: models.BaseTaskRequest{DueDate: &later}}
+	if _, err := tasks.UpdateTask(context.Background(), id, update); err != nil {
+		t.Fatalf("update with a future due date: %v", err)
+	}
+}
+
+// Срок с учетом отсрочки передается репозиторию и проверяется там под блокировкой задачи
+func TestCompletePastDueTask(t *testing.T) {
+	store := memory.NewStore()
+	repo := memory.NewTaskRepository(store)
+	user, err := NewUserService(memory.NewUserRepository(store), nil, nil, nil, zap.NewNop()).
+		CreateUser(context.Background(), &models.CreateUserRequest{Username: "erin", Email: "erin@example.com"})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	due := time.Now().Add(-time.Hour)
+	id := uuid.New()
+	if _, err := repo.CreateTask(context.Background(), &models.Task{
+		TaskID: id.String(), Title: "Review", Reward: 10, DueDate: &due, Status: models.NotStarted, AssignmentMode: models.AssignmentAssigned,
+	}, nil); err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	strict := NewTaskService(repo, nil, nil, 0, zap.NewNop())
+	if _, err := strict.UpdateTaskStatus(context.Background(), id.String(), models.Completed, uuid.MustParse(user.ID)); !errors.IsErrorType(err, errors.Conflict) {
+		t.Fatalf("complete a past due task: %v", err)
+	}
+	lenient := NewTaskService(repo, nil, nil, 2*time.Hour, zap.NewNop())
+	if _, err := lenient.UpdateTaskStatus(context.Background(), id.String(), models.Completed, uuid.MustParse(user.ID)); err != nil {
+		t.Fatalf("complete a task within the grace period: %v", err)
+	}
+}
+
+// Повторное выполнение не начисляет вознаграждение дважды, а возврат после траты не опускает баланс ниже нуля
+func TestTaskRewardReversal(t *testing.T) {
+	store := memory.NewStore()
+	publisher := &recordingPublisher{}
+	users := NewUserService(memory.NewUserRepository(store), nil, nil, nil, zap.NewNop())
+	balances := NewBalanceNotifier(memory.NewUserRepository(store), publisher, time.Hour, zap.NewNop())
+	tasks := NewTaskService(memory.NewTaskRepository(store), nil, balances, 0, zap.NewNop())
+	user, err := users.CreateUser(context.Background(), &models.CreateUserRequest{Username: "erin", Email: "erin@example.com"})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	task, err := tasks.CreateTask(context.Background(), &models.CreateTaskRequest{BaseTaskRequest: models.BaseTaskRequest{Title: "Review", Reward: 15}})
+	if err != nil {
+		t.Fatalf("create task: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		status    models.TaskStatus
+		spend     float64
+		balance   float64
+		completed int
+		published bool
+	}{
+		{"start", models.InProgress, 0, 0, 0, false},
+		{"complete", models.Completed, 0, 15, 1, true},
+		{"complete again", models.Completed, 0, 15, 1, false},
+		{"reopen after spending", models.InProgress, 10, 0, 0, true},
+		{"reopen again", models.InProgress, 0, 0, 0, false},
+		{"complete after reopen", models.Completed, 0, 15, 1, true},
+	}
+	for _, tt := range tests {
+		if tt.spend != 0 {
+			if err := users.UpdateBalance(context.Background(), user.ID, -tt.spend); err != nil {
+				t.Fatalf("%s: spend: %v", tt.name, err)
+			}
+		}
+		publisher.events = nil
+		if _, err := tasks.UpdateTaskStatus(context.Background(), task.TaskID, tt.status, uuid.MustParse(user.ID)); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got, err := users.GetUserByID(context.Background(), user.ID)
+		if err != nil {
+			t.Fatalf("%s: get user: %v", tt.name, err)
+		}
+		if got.Balance != tt.balance || got.TasksCompleted != tt.completed {
+			t.Fatalf("%s: balance %v, completed %d, want %v, %d", tt.name, got.Balance, got.TasksCompleted, tt.balance, tt.completed)
+		}
+		if published := len(publisher.events) > 0; published != tt.published {
+			t.Fatalf("%s: published %+v", tt.name, publisher.events)
+		}
+	}
+}
+
+// Взятие задачи: лимит capped, повторное взятие и запрет работы без взятия или назначения
+func TestClaimTask(t *testing.T) {
+	store := memory.NewStore()
+	users := NewUserService(memory.NewUserRepository(store), nil, nil, nil, zap.NewNop())
+	tasks := NewTaskService(memory.NewTaskRepository(store), nil, nil, 0, zap.NewNop())
+	createUser := func(name string) string {
+		t.Helper()
+		user, err := users.CreateUser(context.Background(), &models.CreateUserRequest{Username: name, Email: name + "@example.com"})
+		if err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		return user.ID
+	}
+	erin, frank := createUser("erin"), createUser("frank")
+	createTask := func(title string, mode models.TaskAssignmentMode, maxClaims *int, assignees []string) string {
+		t.Helper()
+		task, err := tasks.CreateTask(context.Background(), &models.CreateTaskRequest{BaseTaskRequest: models.BaseTaskRequest{
+			Title: title, AssignmentMode: mode, MaxClaims: maxClaims, AssigneeIDs: assignees,
+		}})
+		if err != nil {
+			t.Fatalf("create %s task: %v", title, err)
+		}
+		return task.TaskID
+	}
+	capped := createTask("capped", models.AssignmentCapped, ptr(1), nil)
+	open := createTask("open", models.AssignmentOpen, nil, nil)
+	assigned := createTask("assigned", models.AssignmentAssigned, nil, []string{erin})
+	unassigned := createTask("unassigned", models.AssignmentAssigned, nil, nil)
+
+	first, err := tasks.ClaimTask(context.Background(), capped, erin)
+	if err != nil {
+		t.Fatalf("claim a capped task: %v", err)
+	}
+	again, err := tasks.ClaimTask(context.Background(), capped, erin)
+	if err != nil || *again != *first {
+		t.Fatalf("claim again: %+v, %v, want %+v", again, err, first)
+	}
+
+	tests := []struct {
+		name string
+		run  func() error
+		want errors.ErrorType
+	}{
+		{"claim over the limit", func() error { _, err := tasks.ClaimTask(context.Background(), capped, frank); return err }, errors.Conflict},
+		{"claim an assigned task", func() error { _, err := tasks.ClaimTask(context.Background(), assigned, frank); return err }, errors.Forbidden},
+		{"claim an unassigned task", func() error { _, err := tasks.ClaimTask(context.Background(), unassigned, frank); return err }, errors.Forbidden},
+		{"start an unclaimed shared task", func() error {
+			_, err := tasks.UpdateTaskStatus(context.Background(), open, models.InProgress, uuid.MustParse(frank))
+			return err
+		}, errors.Forbidden},
+		{"start a task assigned to another user", func() error {
+			_, err := tasks.UpdateTaskStatus(context.Background(), assigned, models.InProgress, uuid.MustParse(frank))
+			return err
+		}, errors.Forbidden},
+		{"start an own assigned task", func() error {
+			_, err := tasks.UpdateTaskStatus(context.Background(), assigned, models.InProgress, uuid.MustParse(erin))
+			return err
+		}, ""},
+		{"complete a claimed task", func() error {
+			_, err := tasks.UpdateTaskStatus(context.Background(), capped, models.Completed, uuid.MustParse(erin))
+			return err
+		}, ""},
+	}
+	for _, tt := range tests {
+		if err := tt.run(); tt.want == "" && err != nil || tt.want != "" && !errors.IsErrorType(err, tt.want) {
+			t.Errorf("%s: got %v, want %s", tt.name, err, tt.want)
+		}
+	}
+}
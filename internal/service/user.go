@@ -3,10 +3,14 @@ package service
 import (
 	"context"
 	"database/sql"
+	stderrors "errors"
 	"fmt"
 	"github.com/ZnNr/user-reward-controller/internal/errors"
 	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/pagination"
 	"github.com/ZnNr/user-reward-controller/internal/repository"
+	"github.com/ZnNr/user-reward-controller/internal/service/auth"
+	"github.com/ZnNr/user-reward-controller/internal/validation"
 
 	"github.com/google/uuid"
 	"go.uber.org/zap"
@@ -15,22 +19,43 @@ import (
 	"time"
 )
 
+// inviteBonusPoints - количество поинтов, начисляемых пригласившему пользователю
+const inviteBonusPoints = 10.0
+
 // UserService представляет собой службу управления пользователями
 type UserService struct {
-	repo   repository.UserRepository
-	logger *zap.Logger
+	repo     repository.UserRepository
+	balances *BalanceNotifier
+	audit    AuditRecorder
+	verifier *VerificationService
+	logger   *zap.Logger
 }
 
 // NewUserService создает новый экземпляр UserService
-func NewUserService(repo repository.UserRepository, logger *zap.Logger) *UserService {
+func NewUserService(repo repository.UserRepository, balances *BalanceNotifier, auditor AuditRecorder, verifier *VerificationService, logger *zap.Logger) *UserService {
 	return &UserService{
-		repo:   repo,
-		logger: logger,
+		repo:     repo,
+		balances: balances,
+		audit:    auditor,
+		verifier: verifier,
+		logger:   logger,
 	}
 }
 
-// GetUsers возвращает список пользователей, соответствующих заданному фильтру
-func (u *UserService) GetUsers(ctx context.Context, filter *models.User) (*models.UsersResponse, error) {
+// maxUserSortFields - максимальное количество полей сортировки списка пользователей
+const maxUserSortFields = 3
+
+// GetUsers возвращает страницу пользователей, соответствующих заданному фильтру.
+// Мягко удаленные пользователи включаются только при filter.IncludeDeleted; это доступно только администраторам.
+func (u *UserService) GetUsers(ctx context.Context, filter *models.UserFilter) (*models.UsersResponse, error) {
+	if filter.IncludeDeleted && !auth.IsAdmin(ctx) {
+		return nil, errors.NewForbidden("only administrators can list deleted users", nil)
+	}
+	if err := validateUserFilter(filter); err != nil {
+		u.logger.Warn("Invalid user filter", zap.Error(err))
+		return nil, err
+	}
+
 	users, err := u.repo.GetUsers(ctx, filter)
 	if err != nil {
 		u.logger.Error("Error getting users", zap.Error(err))
@@ -39,6 +64,42 @@ func (u *UserService) GetUsers(ctx context.Context, filter *models.User) (*model
 	return users, nil
 }
 
+// validateUserFilter проверяет согласованность границ диапазонов и полей сортировки фильтра
+func validateUserFilter(filter *models.UserFilter) error {
+	if filter.MinBalance != nil && filter.MaxBalance != nil && *filter.MinBalance > *filter.MaxBalance {
+		return errors.NewValidation("min_balance cannot be greater than max_balance", nil)
+	}
+	if filter.MinReferrals != nil && *filter.MinReferrals < 0 || filter.MaxReferrals != nil && *filter.MaxReferrals < 0 {
+		return errors.NewValidation("referral count cannot be negative", nil)
+	}
+	if filter.MinReferrals != nil && filter.MaxReferrals != nil && *filter.MinReferrals > *filter.MaxReferrals {
+		return errors.NewValidation("min_referrals cannot be greater than max_referrals", nil)
+	}
+	if filter.CreatedAfter != nil && filter.CreatedBefore != nil && filter.CreatedAfter.After(*filter.CreatedBefore) {
+		return errors.NewValidation("created_after cannot be later than created_before", nil)
+	}
+
+	filter.EmailDomain = strings.TrimPrefix(strings.TrimSpace(filter.EmailDomain), "@")
+	if strings.ContainsAny(filter.EmailDomain, "@ ") {
+		return errors.NewValidation("email_domain must be a domain name", nil)
+	}
+
+	if len(filter.Sort) > maxUserSortFields {
+		return errors.NewValidation(fmt.Sprintf("at most %d sort fields are allowed", maxUserSortFields), nil)
+	}
+	seen := make(map[models.UserSortField]bool, len(filter.Sort))
+	for _, sort := range filter.Sort {
+		if !sort.Field.IsValid() {
+			return errors.NewValidation(fmt.Sprintf("unsupported sort field %q", sort.Field), nil)
+		}
+		if seen[sort.Field] {
+			return errors.NewValidation(fmt.Sprintf("duplicate sort field %q", sort.Field), nil)
+		}
+		seen[sort.Field] = true
+	}
+	return nil
+}
+
 // GetUserByID получает пользователя по ID
 func (s *UserService) GetUserByID(ctx context.Context, id string) (*models.User, error) {
 	if ctx == nil {
@@ -62,17 +123,23 @@ func (s *UserService) GetUserByID(ctx context.Context, id string) (*models.User,
 func (s *UserService) CreateUser(ctx context.Context, req *models.CreateUserRequest) (*models.User, error) {
 	s.logger.Info("Creating new user", zap.String("Username", req.Username), zap.String("email", req.Email))
 
-	if err := validateUserRequest(req); err != nil {
+	if err := validation.Struct(req); err != nil {
 		s.logger.Error("User request validation failed", zap.Error(err))
 		return nil, err
 	}
 
+	status, err := initialUserStatus(req.Status)
+	if err != nil {
+		s.logger.Error("Invalid initial user status", zap.Error(err))
+		return nil, err
+	}
+
 	user := &models.User{
 		ID:           generateUserID(),
 		Email:        req.Email,
 		Username:     req.Username,
 		ReferralCode: req.ReferralCode,
-		Status:       validateAndSetUserStatus(req.Status),
+		Status:       status,
 		CreatedAt:    time.Now(),
 	}
 
@@ -83,28 +150,43 @@ func (s *UserService) CreateUser(ctx context.Context, req *models.CreateUserRequ
 	}
 
 	s.logger.Info("User created successfully", zap.String("userID", createdUser.ID))
+	s.sendVerification(ctx, createdUser)
 	return createdUser, nil
 }
 
-// ValidateAndSetStatus возвращает статус пользователя на основании проверки входного значения
-func validateAndSetUserStatus(status models.UserStatus) models.UserStatus {
-	switch status {
-	case models.Active, models.Suspended, models.Banned:
-		return status
-	default:
-		return models.Pending // Статус по умолчанию
+// sendVerification отправляет новому пользователю письмо подтверждения.
+// Ошибка не отменяет создание пользователя: письмо можно запросить повторно.
+func (s *UserService) sendVerification(ctx context.Context, user *models.User) {
+	if s.verifier == nil {
+		return
+	}
+	if err := s.verifier.SendVerification(ctx, user); err != nil {
+		s.logger.Warn("Verification email was not sent", zap.String("userID", user.ID), zap.Error(err))
 	}
 }
 
-// validateUserRequest проверяет корректность данных запроса на создание пользователя
-func validateUserRequest(req *models.CreateUserRequest) error {
-	if req.Username == "" {
-		return errors.NewBadRequest("username cannot be empty", nil)
+// ResendVerification повторно отправляет письмо подтверждения пользователю в статусе Pending.
+// Запросить письмо может только сам пользователь или администратор, не чаще интервала повторной отправки.
+func (s *UserService) ResendVerification(ctx context.Context, id string) error {
+	if !auth.IsUserOrAdmin(ctx, id) {
+		return errors.NewForbidden("only the user or an administrator can request a verification email", nil)
 	}
-	if req.Email == "" || !isValidEmail(req.Email) {
-		return errors.NewBadRequest("invalid email", nil)
+	if err := validateUUID(id); err != nil {
+		return err
 	}
-	return nil
+	if s.verifier == nil {
+		return errors.NewInternal("email verification is not configured", nil)
+	}
+
+	user, err := s.repo.GetUserByID(ctx, uuid.MustParse(id))
+	if err != nil {
+		s.logger.Error("User not found", zap.String("userID", id), zap.Error(err))
+		return errors.NewNotFound("user not found", nil)
+	}
+	if user.Status != models.Pending {
+		return errors.NewValidation(fmt.Sprintf("user is already in %s status", user.Status), nil)
+	}
+	return s.verifier.Resend(ctx, user)
 }
 
 // isValidEmail проверяет корректность email-адреса
@@ -125,6 +207,10 @@ func (s *UserService) UpdateUser(ctx context.Context, req *models.UpdateUserRequ
 		s.logger.Error("Invalid user ID", zap.Error(err))
 		return nil, err
 	}
+	if err := validation.Partial(req); err != nil {
+		s.logger.Error("User update validation failed", zap.Error(err))
+		return nil, err
+	}
 
 	userID := uuid.MustParse(req.UserID)
 	user, err := s.repo.GetUserByID(ctx, userID)
@@ -133,10 +219,19 @@ func (s *UserService) UpdateUser(ctx context.Context, req *models.UpdateUserRequ
 		return nil, errors.NewNotFound("user not found", nil)
 	}
 
+	previousBalance, previousStatus := user.Balance, user.Status
 	if err := updateUserFields(user, req); err != nil {
 		s.logger.Error("Failed to update user fields", zap.Error(err))
 		return nil, err
 	}
+	if err := checkUserTransition(ctx, previousStatus, user.Status); err != nil {
+		s.logger.Warn("Rejected user status transition",
+			zap.String("userID", req.UserID),
+			zap.String("from", previousStatus.String()),
+			zap.String("to", user.Status.String()),
+			zap.Error(err))
+		return nil, err
+	}
 
 	updatedUser, err := s.repo.UpdateUser(ctx, user)
 	if err != nil {
@@ -144,6 +239,14 @@ func (s *UserService) UpdateUser(ctx context.Context, req *models.UpdateUserRequ
 		return nil, errors.NewInternal("failed to update user", err)
 	}
 
+	if user.Balance != previousBalance {
+		s.recordAudit(ctx, models.AuditUserBalanceUpdate, user.ID, balanceSnapshot(previousBalance), balanceSnapshot(user.Balance))
+		s.balances.publishBalanceChange(ctx, &models.BalanceUpdate{UserID: user.ID, Balance: user.Balance, Delta: user.Balance - previousBalance})
+	}
+	if user.Status != previousStatus && (user.Status == models.Banned || user.Status == models.Suspended) {
+		s.recordAudit(ctx, models.AuditUserStatusChange, user.ID, statusSnapshot(previousStatus), statusSnapshot(user.Status))
+	}
+
 	return updatedUser, nil
 }
 
@@ -153,9 +256,6 @@ func updateUserFields(user *models.User, req *models.UpdateUserRequest) error {
 		user.Username = *req.Username
 	}
 	if req.Email != nil {
-		if !isValidEmail(*req.Email) {
-			return errors.NewBadRequest("invalid email", nil)
-		}
 		user.Email = *req.Email
 	}
 	if req.Balance != nil {
@@ -179,7 +279,7 @@ func updateUserFields(user *models.User, req *models.UpdateUserRequest) error {
 	return nil
 }
 
-// DeleteUser удаляет пользователя из базы данных
+// DeleteUser помечает пользователя удаленным; запись можно восстановить до окончания срока хранения
 func (u *UserService) DeleteUser(ctx context.Context, id string) error {
 	if err := validateUUID(id); err != nil {
 		u.logger.Error("Invalid user ID", zap.Error(err))
@@ -191,9 +291,47 @@ func (u *UserService) DeleteUser(ctx context.Context, id string) error {
 		u.logger.Error("Error deleting user", zap.String("id", id), zap.Error(err))
 		return err
 	}
+	u.balances.markLeaderboardStale()
 	return nil
 }
 
+// RestoreUser восстанавливает мягко удаленного пользователя; доступно только администраторам
+func (u *UserService) RestoreUser(ctx context.Context, id string) (*models.User, error) {
+	if !auth.IsAdmin(ctx) {
+		return nil, errors.NewForbidden("only administrators can restore users", nil)
+	}
+	if err := validateUUID(id); err != nil {
+		u.logger.Error("Invalid user ID", zap.Error(err))
+		return nil, err
+	}
+
+	user, err := u.repo.RestoreUser(ctx, uuid.MustParse(id))
+	if err != nil {
+		u.logger.Error("Error restoring user", zap.String("id", id), zap.Error(err))
+		return nil, err
+	}
+
+	u.logger.Info("User restored successfully", zap.String("userID", id))
+	u.balances.markLeaderboardStale()
+	return user, nil
+}
+
+// recordAudit записывает действие над пользователем в журнал аудита, если он подключен
+func (s *UserService) recordAudit(ctx context.Context, action, userID string, before, after interface{}) {
+	if s.audit != nil {
+		s.audit.Record(ctx, action, models.AuditTargetUser, userID, before, after)
+	}
+}
+
+// balanceSnapshot и statusSnapshot описывают состояние пользователя для журнала аудита
+func balanceSnapshot(balance float64) map[string]interface{} {
+	return map[string]interface{}{"Balance": balance}
+}
+
+func statusSnapshot(status models.UserStatus) map[string]interface{} {
+	return map[string]interface{}{"Status": status.String()}
+}
+
 // validateUUID проверяет корректность формата UUID
 func validateUUID(id string) error {
 	if id == "" {
@@ -209,7 +347,7 @@ func validateUUID(id string) error {
 func (u *UserService) GetUsersByStatus(ctx context.Context, status models.UserStatus) ([]*models.User, error) {
 	users, err := u.repo.GetUsersByStatus(ctx, status)
 	if err != nil {
-		u.logger.Error("error getting users by status", zap.String("status", string(status)), zap.Error(err))
+		u.logger.Error("error getting users by status", zap.String("status", status.String()), zap.Error(err))
 		return nil, err
 	}
 	return users, nil
@@ -228,11 +366,11 @@ func (s *UserService) GetUserByEmail(ctx context.Context, email string) (*models
 // UpdateBalance обновляет баланс пользователя на заданную сумму
 func (s *UserService) UpdateBalance(ctx context.Context, id string, amount float64) error {
 	// Конвертация строки id в UUID
-	userID, err := uuid.Parse(id)
-	if err != nil {
+	if err := validateUUID(id); err != nil {
 		s.logger.Error("invalid UUID format", zap.String("id", id), zap.Error(err))
-		return errors.NewInternal("invalid user ID format", err)
+		return err
 	}
+	userID := uuid.MustParse(id)
 
 	// Получаем пользователя по ID
 	user, err := s.repo.GetUserByID(ctx, userID)
@@ -242,6 +380,7 @@ func (s *UserService) UpdateBalance(ctx context.Context, id string, amount float
 	}
 
 	// Обновление баланса
+	previousBalance := user.Balance
 	if err := user.UpdateBalance(amount); err != nil {
 		return fmt.Errorf("error updating balance: %w", err)
 	}
@@ -254,6 +393,8 @@ func (s *UserService) UpdateBalance(ctx context.Context, id string, amount float
 
 	// Логирование успешного обновления
 	s.logger.Info("user balance updated", zap.String("id", id), zap.Float64("newBalance", user.Balance))
+	s.recordAudit(ctx, models.AuditUserBalanceUpdate, user.ID, balanceSnapshot(previousBalance), balanceSnapshot(user.Balance))
+	s.balances.publishBalanceChange(ctx, &models.BalanceUpdate{UserID: user.ID, Balance: user.Balance, Delta: amount})
 	return nil
 }
 
@@ -323,9 +464,13 @@ func (s *UserService) GetUserSummary(ctx context.Context, id string) (*models.Us
 	}
 
 	userSummary := &models.UserSummary{
-		ID:       user.ID,
-		Username: user.Username,
-		Email:    user.Email,
+		ID:             user.ID,
+		Username:       user.Username,
+		Email:          user.Email,
+		Balance:        user.Balance,
+		Referrals:      user.Referrals,
+		TasksCompleted: user.TasksCompleted,
+		CreatedAt:      user.CreatedAt,
 	}
 
 	return userSummary, nil
@@ -343,7 +488,9 @@ func (s *UserService) InviteUser(ctx context.Context, inviterID string, inviteeE
 		return errors.NewBadRequest("invalid email format", nil)
 	}
 
-	return s.repo.WithTransaction(ctx, func(tx *sql.Tx) error {
+	var inviterBalance float64
+	var invitee *models.User
+	err := s.repo.WithTransaction(ctx, func(tx *sql.Tx) error {
 		inviterUUID, err := uuid.Parse(inviterID)
 		if err != nil {
 			s.logger.Error("Invalid inviter ID", zap.Error(err))
@@ -360,8 +507,9 @@ func (s *UserService) InviteUser(ctx context.Context, inviterID string, inviteeE
 			return errors.NewNotFound("inviter not found", nil)
 		}
 
+		// Репозиторий сообщает об отсутствии пользователя ошибкой sql.ErrNoRows
 		existingInvitee, err := s.repo.GetUserByEmailTx(ctx, tx, inviteeEmail)
-		if err != nil && !errors.IsNotFound(err) {
+		if err != nil && !errors.IsNotFound(err) && !stderrors.Is(err, sql.ErrNoRows) {
 			s.logger.Error("Failed to check if invitee exists", zap.Error(err))
 			return errors.NewInternal("unable to check invitee existence", err)
 		}
@@ -371,18 +519,16 @@ func (s *UserService) InviteUser(ctx context.Context, inviterID string, inviteeE
 		}
 
 		inviteeUsername := inviteeEmail[:strings.Index(inviteeEmail, "@")]
-		invitee := models.BrandNewUser(inviteeEmail, inviteeUsername, models.Pending)
+		invitee = models.BrandNewUser(inviteeEmail, inviteeUsername, models.Pending)
+		invitee.ID = generateUserID()
 
-		if err, _ := s.repo.CreateUserTx(ctx, tx, invitee); err != nil {
-			s.logger.Error("Failed to create new user")
-			return errors.NewInternal("failed to create new user", nil)
+		if _, err := s.repo.CreateUserTx(ctx, tx, invitee); err != nil {
+			s.logger.Error("Failed to create new user", zap.Error(err))
+			return errors.NewInternal("failed to create new user", err)
 		}
 
-		const bonusPoints = 10.0
-		newBalance := inviter.Balance + bonusPoints
-		newReferrals := inviter.Referrals + 1
-
-		if err := s.repo.UpdateBalanceAndReferralsTx(ctx, tx, inviterUUID, newBalance, newReferrals); err != nil {
+		// Запрос прибавляет переданные значения к текущим, поэтому передаем приращения
+		if err := s.repo.UpdateBalanceAndReferralsTx(ctx, tx, inviterUUID, inviteBonusPoints, 1); err != nil {
 			s.logger.Error("Failed to update inviter's balance and referrals", zap.Error(err))
 			return errors.NewInternal("failed to update inviter's balance and referrals", err)
 		}
@@ -390,10 +536,18 @@ func (s *UserService) InviteUser(ctx context.Context, inviterID string, inviteeE
 		s.logger.Info("User invited successfully",
 			zap.String("inviterID", inviterID),
 			zap.String("inviteeEmail", inviteeEmail),
-			zap.Float64("bonusPoints", bonusPoints))
+			zap.Float64("bonusPoints", inviteBonusPoints))
 
+		inviterBalance = inviter.Balance + inviteBonusPoints
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	s.balances.publishBalanceChange(ctx, &models.BalanceUpdate{UserID: inviterID, Balance: inviterBalance, Delta: inviteBonusPoints})
+	s.sendVerification(ctx, invitee)
+	return nil
 }
 
 // Получить лидера по балансу
@@ -448,3 +602,21 @@ func (s *UserService) GetTopUsers(ctx context.Context, limit int, offset int) (*
 		Count: len(topUsers),
 	}, nil
 }
+
+// GetTopUsersPage возвращает страницу топа пользователей по курсору.
+// В отличие от GetTopUsers, стоимость запроса не зависит от того, насколько далеко страница от лидера.
+func (s *UserService) GetTopUsersPage(ctx context.Context, page pagination.Page) (*models.TopUsers, error) {
+	users, info, err := s.repo.GetTopUsersPage(ctx, page)
+	if err != nil {
+		s.logger.Error("Error getting top users page", zap.Error(err))
+		return nil, err
+	}
+	if users == nil {
+		users = []models.TopUser{}
+	}
+	return &models.TopUsers{
+		Users: users,
+		Count: len(users),
+		Info:  info,
+	}, nil
+}
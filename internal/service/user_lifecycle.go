@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/service/auth"
+)
+
+// transitionRule описывает условие, при котором разрешен переход между статусами пользователя
+type transitionRule int
+
+const (
+	transitionAllowed      transitionRule = iota + 1 // Переход доступен через обновление пользователя
+	transitionVerification                           // Переход выполняется только подтверждением email
+	transitionAdminOnly                              // Переход доступен только администратору
+)
+
+// userStatusKey - пара статусов (из, в)
+type userStatusKey struct {
+	from, to models.UserStatus
+}
+
+// userTransitions - таблица допустимых переходов жизненного цикла пользователя.
+// Переходы, отсутствующие в таблице, запрещены.
+var userTransitions = map[userStatusKey]transitionRule{
+	{models.Pending, models.Active}:   transitionVerification,
+	{models.Active, models.Suspended}: transitionAllowed,
+	{models.Suspended, models.Active}: transitionAllowed,
+	{models.Pending, models.Banned}:   transitionAdminOnly,
+	{models.Active, models.Banned}:    transitionAdminOnly,
+	{models.Suspended, models.Banned}: transitionAdminOnly,
+}
+
+// checkUserTransition проверяет, может ли инициатор запроса перевести пользователя из статуса from в статус to.
+func checkUserTransition(ctx context.Context, from, to models.UserStatus) error {
+	if from == to {
+		return nil
+	}
+	if to.String() == "Unknown" {
+		return errors.NewValidation(fmt.Sprintf("unknown user status %d", to), nil)
+	}
+
+	switch userTransitions[userStatusKey{from, to}] {
+	case transitionAllowed:
+		return nil
+	case transitionVerification:
+		return errors.NewValidation(fmt.Sprintf("transition from %s to %s is possible only via email verification", from, to), nil).WithCode(errors.CodeInvalidStatusTransition)
+	case transitionAdminOnly:
+		if !auth.IsAdmin(ctx) {
+			return errors.NewForbidden(fmt.Sprintf("only administrators can change user status to %s", to), nil)
+		}
+		return nil
+	default:
+		return errors.NewValidation(fmt.Sprintf("illegal user status transition from %s to %s", from, to), nil).WithCode(errors.CodeInvalidStatusTransition)
+	}
+}
+
+// initialUserStatus проверяет статус, запрошенный при создании пользователя:
+// новые пользователи всегда начинают с Pending и активируются подтверждением email.
+func initialUserStatus(status models.UserStatus) (models.UserStatus, error) {
+	if status == 0 || status == models.Pending {
+		return models.Pending, nil
+	}
+	return 0, errors.NewValidation(fmt.Sprintf("new users must start in %s status and be activated via email verification", models.Pending), nil)
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository/memory"
+	"github.com/ZnNr/user-reward-controller/internal/service/auth"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+// adminContext возвращает контекст запроса администратора
+func adminContext() context.Context {
+	return auth.WithPrincipal(context.Background(), auth.Principal{Subject: "admin", Role: auth.RoleAdmin})
+}
+
+// userContext возвращает контекст запроса обычного пользователя
+func userContext(subject string) context.Context {
+	return auth.WithPrincipal(context.Background(), auth.Principal{Subject: subject})
+}
+
+func TestDeletedUsersRequireAdmin(t *testing.T) {
+	store := memory.NewStore()
+	users := NewUserService(memory.NewUserRepository(store), nil, nil, nil, zap.NewNop())
+	user, err := users.CreateUser(context.Background(), &models.CreateUserRequest{Username: "erin", Email: "erin@example.com"})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if err := users.DeleteUser(context.Background(), user.ID); err != nil {
+		t.Fatalf("delete user: %v", err)
+	}
+
+	for name, ctx := range map[string]context.Context{"anonymous": context.Background(), "user": userContext(user.ID)} {
+		if _, err := users.GetUsers(ctx, &models.UserFilter{IncludeDeleted: true}); !errors.IsErrorType(err, errors.Forbidden) {
+			t.Errorf("%s lists deleted users: %v", name, err)
+		}
+		if _, err := users.RestoreUser(ctx, user.ID); !errors.IsErrorType(err, errors.Forbidden) {
+			t.Errorf("%s restores a user: %v", name, err)
+		}
+	}
+	if _, err := users.GetUsers(context.Background(), &models.UserFilter{}); err != nil {
+		t.Fatalf("list active users: %v", err)
+	}
+
+	listed, err := users.GetUsers(adminContext(), &models.UserFilter{IncludeDeleted: true})
+	if err != nil || len(listed.Users) != 1 {
+		t.Fatalf("admin lists deleted users: %+v, %v", listed, err)
+	}
+	if _, err := users.RestoreUser(adminContext(), user.ID); err != nil {
+		t.Fatalf("admin restores a user: %v", err)
+	}
+}
+
+// Репозитории сообщают об отсутствии пользователя ошибкой sql.ErrNoRows: приглашение нового адреса создает пользователя
+func TestInviteUser(t *testing.T) {
+	store := memory.NewStore()
+	users := NewUserService(memory.NewUserRepository(store), nil, nil, nil, zap.NewNop())
+	inviter, err := users.CreateUser(context.Background(), &models.CreateUserRequest{Username: "erin", Email: "erin@example.com"})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	if err := users.InviteUser(context.Background(), inviter.ID, "frank@example.com"); err != nil {
+		t.Fatalf("invite a new user: %v", err)
+	}
+	invitee, err := users.GetUserByEmail(context.Background(), "frank@example.com")
+	if err != nil || invitee.Status != models.Pending {
+		t.Fatalf("invitee: %+v, %v", invitee, err)
+	}
+	if updated, err := users.GetUserByID(context.Background(), inviter.ID); err != nil || updated.Balance != inviteBonusPoints || updated.Referrals != 1 {
+		t.Fatalf("inviter is not rewarded: %+v, %v", updated, err)
+	}
+
+	if err := users.InviteUser(context.Background(), inviter.ID, "frank@example.com"); !errors.IsErrorType(err, errors.AlreadyExists) {
+		t.Fatalf("invite an existing user: %v", err)
+	}
+}
+
+// Сводка содержит баланс, рефералов, выполненные задачи и дату создания пользователя
+func TestGetUserSummary(t *testing.T) {
+	store := memory.NewStore()
+	users := NewUserService(memory.NewUserRepository(store), nil, nil, nil, zap.NewNop())
+	inviter, err := users.CreateUser(context.Background(), &models.CreateUserRequest{Username: "erin", Email: "erin@example.com"})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if err := users.InviteUser(context.Background(), inviter.ID, "frank@example.com"); err != nil {
+		t.Fatalf("invite a user: %v", err)
+	}
+	if err := users.UpdateBalance(context.Background(), inviter.ID, 5); err != nil {
+		t.Fatalf("update balance: %v", err)
+	}
+
+	summary, err := users.GetUserSummary(context.Background(), inviter.ID)
+	if err != nil {
+		t.Fatalf("get summary: %v", err)
+	}
+	if summary.ID != inviter.ID || summary.Balance != inviteBonusPoints+5 || summary.Referrals != 1 || summary.CreatedAt.IsZero() {
+		t.Fatalf("unexpected summary: %+v", summary)
+	}
+}
+
+// Бонус за приглашение прибавляется к текущему балансу и числу рефералов, а не заменяет их
+func TestInviteUserAddsBonus(t *testing.T) {
+	store := memory.NewStore()
+	users := NewUserService(memory.NewUserRepository(store), nil, nil, nil, zap.NewNop())
+	inviter, err := users.CreateUser(context.Background(), &models.CreateUserRequest{Username: "erin", Email: "erin@example.com"})
+	if err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	if err := users.UpdateBalance(context.Background(), inviter.ID, 25); err != nil {
+		t.Fatalf("update balance: %v", err)
+	}
+
+	for _, email := range []string{"frank@example.com", "grace@example.com"} {
+		if err := users.InviteUser(context.Background(), inviter.ID, email); err != nil {
+			t.Fatalf("invite %s: %v", email, err)
+		}
+	}
+	updated, err := users.GetUserByID(context.Background(), inviter.ID)
+	if err != nil || updated.Balance != 25+2*inviteBonusPoints || updated.Referrals != 2 {
+		t.Fatalf("inviter after two invitations: %+v, %v", updated, err)
+	}
+}
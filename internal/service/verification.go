@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/mail"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+	"sync"
+	"time"
+)
+
+// verificationTokenBytes - длина случайной части токена подтверждения
+const verificationTokenBytes = 32
+
+// VerificationService выдает и погашает токены подтверждения email
+type VerificationService struct {
+	repo      repository.VerificationRepository
+	sender    mail.Sender
+	ttl       time.Duration
+	verifyURL string
+	resends   *resendLimiter
+	logger    *zap.Logger
+}
+
+// NewVerificationService создает новый экземпляр VerificationService.
+// verifyURL - адрес страницы подтверждения, к которому дописывается токен;
+// resendInterval - минимальный интервал между повторными отправками письма одному пользователю.
+func NewVerificationService(repo repository.VerificationRepository, sender mail.Sender, ttl, resendInterval time.Duration, verifyURL string, logger *zap.Logger) *VerificationService {
+	return &VerificationService{
+		repo:      repo,
+		sender:    sender,
+		ttl:       ttl,
+		verifyURL: verifyURL,
+		resends:   &resendLimiter{interval: resendInterval, sent: make(map[string]time.Time)},
+		logger:    logger,
+	}
+}
+
+// Resend повторно отправляет письмо подтверждения не чаще одного раза за интервал повторной отправки
+func (s *VerificationService) Resend(ctx context.Context, user *models.User) error {
+	if wait := s.resends.reserve(user.ID, time.Now()); wait > 0 {
+		s.logger.Warn("Verification email resend throttled", zap.String("userID", user.ID), zap.Duration("wait", wait))
+		return errors.NewTooManyRequests(fmt.Sprintf("verification email was sent recently, retry in %s", wait.Round(time.Second)), nil)
+	}
+	return s.SendVerification(ctx, user)
+}
+
+// resendLimiter ограничивает частоту повторной отправки писем одному пользователю.
+// Состояние хранится в памяти процесса, поэтому лимит действует в пределах одной реплики.
+type resendLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	sent     map[string]time.Time // userID -> время последней отправки
+}
+
+// reserve запоминает отправку пользователю в момент now, если интервал с предыдущей истек,
+// и возвращает 0; иначе возвращает оставшееся время ожидания.
+func (l *resendLimiter) reserve(userID string, now time.Time) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for id, sentAt := range l.sent {
+		if now.Sub(sentAt) >= l.interval {
+			delete(l.sent, id)
+		}
+	}
+	if sentAt, ok := l.sent[userID]; ok {
+		return l.interval - now.Sub(sentAt)
+	}
+	l.sent[userID] = now
+	return 0
+}
+
+// SendVerification выдает пользователю новый токен и отправляет письмо со ссылкой подтверждения
+func (s *VerificationService) SendVerification(ctx context.Context, user *models.User) error {
+	token, err := newVerificationToken()
+	if err != nil {
+		return errors.NewInternal("failed to generate verification token", err)
+	}
+
+	expiresAt := time.Now().Add(s.ttl)
+	if err := s.repo.CreateToken(ctx, uuid.MustParse(user.ID), hashVerificationToken(token), expiresAt); err != nil {
+		s.logger.Error("Failed to store verification token", zap.String("userID", user.ID), zap.Error(err))
+		return err
+	}
+
+	msg := mail.Message{
+		To:      user.Email,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf("Hello, %s!\n\nTo activate your account open the link below:\n%s%s\n\nThe link expires at %s.\n",
+			user.Username, s.verifyURL, token, expiresAt.UTC().Format(time.RFC1123)),
+	}
+	if err := s.sender.Send(ctx, msg); err != nil {
+		s.logger.Error("Failed to send verification email", zap.String("userID", user.ID), zap.Error(err))
+		return errors.NewInternal("failed to send verification email", err)
+	}
+
+	s.logger.Info("Verification email sent", zap.String("userID", user.ID))
+	return nil
+}
+
+// Verify погашает токен и активирует пользователя (переход Pending -> Active)
+func (s *VerificationService) Verify(ctx context.Context, token string) (*models.User, error) {
+	if token == "" {
+		return nil, errors.NewBadRequest("verification token cannot be empty", nil)
+	}
+
+	user, err := s.repo.ActivateByToken(ctx, hashVerificationToken(token))
+	if err != nil {
+		s.logger.Warn("Email verification failed", zap.Error(err))
+		return nil, err
+	}
+
+	s.logger.Info("User activated via email verification", zap.String("userID", user.ID))
+	return user, nil
+}
+
+// newVerificationToken генерирует случайный токен, пригодный для использования в URL
+func newVerificationToken() (string, error) {
+	buf := make([]byte, verificationTokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashVerificationToken возвращает SHA-256 токена; в базе хранится только хеш
+func hashVerificationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
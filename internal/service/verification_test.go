@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/mail"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"github.com/ZnNr/user-reward-controller/internal/repository/memory"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// verificationRepo - репозиторий токенов, который только принимает новые токены
+type verificationRepo struct{}
+
+func (verificationRepo) CreateToken(ctx context.Context, userID uuid.UUID, tokenHash string, expiresAt time.Time) error {
+	return nil
+}
+
+func (verificationRepo) ActivateByToken(ctx context.Context, tokenHash string) (*models.User, error) {
+	return nil, errors.NewValidation("verification token is invalid or expired", nil)
+}
+
+// recordingSender запоминает адресатов отправленных писем
+type recordingSender struct {
+	sent []string
+}
+
+func (s *recordingSender) Send(ctx context.Context, msg mail.Message) error {
+	s.sent = append(s.sent, msg.To)
+	return nil
+}
+
+func TestResendVerification(t *testing.T) {
+	sender := &recordingSender{}
+	verifier := NewVerificationService(verificationRepo{}, sender, time.Hour, time.Hour, "http://localhost/verify?token=", zap.NewNop())
+	users := NewUserService(memory.NewUserRepository(memory.NewStore()), nil, nil, verifier, zap.NewNop())
+	create := func(name string) *models.User {
+		t.Helper()
+		user, err := users.CreateUser(context.Background(), &models.CreateUserRequest{Username: name, Email: name + "@example.com"})
+		if err != nil {
+			t.Fatalf("create user: %v", err)
+		}
+		return user
+	}
+	erin, frank := create("erin"), create("frank")
+	sender.sent = nil
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		user string
+		want errors.ErrorType
+	}{
+		{"anonymous", context.Background(), erin.ID, errors.Forbidden},
+		{"another user", userContext(frank.ID), erin.ID, errors.Forbidden},
+		{"the user", userContext(erin.ID), erin.ID, ""},
+		{"the user again", userContext(erin.ID), erin.ID, errors.TooManyRequests},
+		{"admin within the interval", adminContext(), erin.ID, errors.TooManyRequests},
+		{"admin for another user", adminContext(), frank.ID, ""},
+	}
+	for _, tt := range tests {
+		err := users.ResendVerification(tt.ctx, tt.user)
+		if tt.want == "" && err != nil || tt.want != "" && !errors.IsErrorType(err, tt.want) {
+			t.Fatalf("%s: got %v, want %s", tt.name, err, tt.want)
+		}
+	}
+	if want := []string{erin.Email, frank.Email}; len(sender.sent) != len(want) || sender.sent[0] != want[0] || sender.sent[1] != want[1] {
+		t.Fatalf("sent %q, want %q", sender.sent, want)
+	}
+}
+
+func TestResendLimiter(t *testing.T) {
+	limiter := &resendLimiter{interval: time.Minute, sent: make(map[string]time.Time)}
+	start := time.Now()
+	if wait := limiter.reserve("erin", start); wait != 0 {
+		t.Fatalf("first resend waits %s", wait)
+	}
+	if wait := limiter.reserve("erin", start.Add(20*time.Second)); wait != 40*time.Second {
+		t.Fatalf("resend within the interval waits %s", wait)
+	}
+	if wait := limiter.reserve("frank", start.Add(20*time.Second)); wait != 0 {
+		t.Fatalf("another user waits %s", wait)
+	}
+	if wait := limiter.reserve("erin", start.Add(time.Minute)); wait != 0 {
+		t.Fatalf("resend after the interval waits %s", wait)
+	}
+}
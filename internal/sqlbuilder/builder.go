@@ -0,0 +1,218 @@
+// Package sqlbuilder составляет запросы SELECT с условиями только для заданных фильтров.
+// Значения всегда передаются параметрами ($1, $2, ...); в текст запроса попадают лишь выражения,
+// заданные в коде репозитория, поэтому пользовательский ввод не может изменить запрос.
+package sqlbuilder
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// SortKey - выражение сортировки и ее направление
+type SortKey struct {
+	Expr string
+	Desc bool
+}
+
+// Builder собирает запрос SELECT. Условия объединяются через AND.
+type Builder struct {
+	columns    string
+	from       string
+	conditions []string
+	args       []interface{}
+	orderBy    []string
+	limit      interface{}
+	offset     interface{}
+}
+
+// Select начинает запрос, выбирающий columns
+func Select(columns string) *Builder {
+	return &Builder{columns: columns}
+}
+
+// From задает таблицу запроса
+func (b *Builder) From(from string) *Builder {
+	b.from = from
+	return b
+}
+
+// Param добавляет значение параметра и возвращает его плейсхолдер для выражений, которые нельзя
+// записать через Where
+func (b *Builder) Param(value interface{}) string {
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// Where добавляет условие; каждый "?" в нем заменяется плейсхолдером очередного значения
+func (b *Builder) Where(condition string, values ...interface{}) *Builder {
+	if strings.Count(condition, "?") != len(values) {
+		panic("sqlbuilder: placeholder count does not match values in " + strconv.Quote(condition))
+	}
+	var sb strings.Builder
+	for _, r := range condition {
+		if r == '?' {
+			sb.WriteString(b.Param(values[0]))
+			values = values[1:]
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	b.conditions = append(b.conditions, sb.String())
+	return b
+}
+
+// WhereIf добавляет условие, только если ok
+func (b *Builder) WhereIf(ok bool, condition string, values ...interface{}) *Builder {
+	if ok {
+		b.Where(condition, values...)
+	}
+	return b
+}
+
+// Eq добавляет условие равенства expr значению, если значение задано (не nil и не нулевое)
+func (b *Builder) Eq(expr string, value interface{}) *Builder {
+	return b.WhereIf(!isEmpty(value), expr+" = ?", value)
+}
+
+// Contains добавляет поиск подстроки без учета регистра, если text не пуст
+func (b *Builder) Contains(expr string, text string) *Builder {
+	return b.WhereIf(text != "", expr+" ILIKE '%' || ? || '%'", text)
+}
+
+// Range ограничивает expr снизу значением from и сверху значением to включительно.
+// Незаданная (nil или нулевая) граница не ограничивает выборку.
+func (b *Builder) Range(expr string, from, to interface{}) *Builder {
+	b.WhereIf(!isEmpty(from), expr+" >= ?", from)
+	return b.WhereIf(!isEmpty(to), expr+" <= ?", to)
+}
+
+// In добавляет условие принадлежности expr списку значений. Пустой список не ограничивает выборку.
+func (b *Builder) In(expr string, values ...interface{}) *Builder {
+	if len(values) == 0 {
+		return b
+	}
+	placeholders := make([]string, len(values))
+	for i, value := range values {
+		placeholders[i] = b.Param(value)
+	}
+	b.conditions = append(b.conditions, expr+" IN ("+strings.Join(placeholders, ", ")+")")
+	return b
+}
+
+// After ограничивает выборку записями, следующими в порядке keys после записи с ключами values,
+// или предшествующими ей при backward. Используется для постраничной выборки по курсору.
+func (b *Builder) After(keys []SortKey, values []interface{}, backward bool) *Builder {
+	if len(keys) != len(values) {
+		panic("sqlbuilder: sort keys do not match cursor values")
+	}
+	placeholders := make([]string, len(values))
+	for i, value := range values {
+		placeholders[i] = b.Param(value)
+	}
+
+	// Запись отличается от курсора первым несовпадающим ключом в нужную сторону
+	alternatives := make([]string, len(keys))
+	for i, key := range keys {
+		parts := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			parts = append(parts, keys[j].Expr+" = "+placeholders[j])
+		}
+		op := " > "
+		if key.Desc != backward {
+			op = " < "
+		}
+		parts = append(parts, key.Expr+op+placeholders[i])
+		alternatives[i] = "(" + strings.Join(parts, " AND ") + ")"
+	}
+	b.conditions = append(b.conditions, "("+strings.Join(alternatives, " OR ")+")")
+	return b
+}
+
+// OrderBy добавляет выражения сортировки в том виде, в каком они записываются в ORDER BY
+func (b *Builder) OrderBy(exprs ...string) *Builder {
+	b.orderBy = append(b.orderBy, exprs...)
+	return b
+}
+
+// OrderByKeys добавляет сортировку по keys; при reverse направление каждого ключа меняется на обратное
+func (b *Builder) OrderByKeys(keys []SortKey, reverse bool) *Builder {
+	for _, key := range keys {
+		if key.Desc != reverse {
+			b.orderBy = append(b.orderBy, key.Expr+" DESC")
+		} else {
+			b.orderBy = append(b.orderBy, key.Expr+" ASC")
+		}
+	}
+	return b
+}
+
+// Limit ограничивает количество строк; nil означает отсутствие ограничения
+func (b *Builder) Limit(limit interface{}) *Builder {
+	b.limit = limit
+	return b
+}
+
+// Offset пропускает первые offset строк
+func (b *Builder) Offset(offset int) *Builder {
+	b.offset = offset
+	return b
+}
+
+// Build возвращает текст запроса и значения его параметров
+func (b *Builder) Build() (string, []interface{}) {
+	args := append([]interface{}(nil), b.args...)
+	param := func(value interface{}) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("SELECT " + b.columns + " FROM " + b.from)
+	b.writeWhere(&sb)
+	if len(b.orderBy) > 0 {
+		sb.WriteString(" ORDER BY " + strings.Join(b.orderBy, ", "))
+	}
+	if b.limit != nil {
+		sb.WriteString(" LIMIT " + param(b.limit))
+	}
+	if b.offset != nil {
+		sb.WriteString(" OFFSET " + param(b.offset))
+	}
+	return sb.String(), args
+}
+
+// BuildCount возвращает запрос количества строк с теми же условиями, без сортировки и ограничений
+func (b *Builder) BuildCount() (string, []interface{}) {
+	var sb strings.Builder
+	sb.WriteString("SELECT COUNT(*) FROM " + b.from)
+	b.writeWhere(&sb)
+	return sb.String(), append([]interface{}(nil), b.args...)
+}
+
+func (b *Builder) writeWhere(sb *strings.Builder) {
+	if len(b.conditions) > 0 {
+		sb.WriteString(" WHERE " + strings.Join(b.conditions, " AND "))
+	}
+}
+
+// Values преобразует срез в список значений для In
+func Values[T any](values []T) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, value := range values {
+		result[i] = value
+	}
+	return result
+}
+
+// isEmpty сообщает, что значение не задано: nil, nil-указатель или нулевое значение типа
+func isEmpty(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Pointer {
+		return v.IsNil()
+	}
+	return v.IsZero()
+}
@@ -0,0 +1,159 @@
+package sqlbuilder
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuild(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	var noTime *time.Time
+
+	tests := []struct {
+		name     string
+		builder  *Builder
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "no conditions",
+			builder:  Select("id").From("tasks"),
+			wantSQL:  "SELECT id FROM tasks",
+			wantArgs: []interface{}{},
+		},
+		{
+			name:     "empty filters are skipped",
+			builder:  Select("id").From("tasks").Eq("status", 0).Eq("owner", "").Contains("title", "").Range("created_at", noTime, nil).In("status"),
+			wantSQL:  "SELECT id FROM tasks",
+			wantArgs: []interface{}{},
+		},
+		{
+			name: "conditions are numbered in order",
+			builder: Select("id").From("tasks").
+				Contains("title", "report").
+				Eq("assignee_id", "u1").
+				Where("deleted_at IS NULL"),
+			wantSQL:  "SELECT id FROM tasks WHERE title ILIKE '%' || $1 || '%' AND assignee_id = $2 AND deleted_at IS NULL",
+			wantArgs: []interface{}{"report", "u1"},
+		},
+		{
+			name:     "open range",
+			builder:  Select("id").From("tasks").Range("created_at", &from, noTime),
+			wantSQL:  "SELECT id FROM tasks WHERE created_at >= $1",
+			wantArgs: []interface{}{&from},
+		},
+		{
+			name:     "closed range",
+			builder:  Select("id").From("users").Range("balance", 10.0, 20.0),
+			wantSQL:  "SELECT id FROM users WHERE balance >= $1 AND balance <= $2",
+			wantArgs: []interface{}{10.0, 20.0},
+		},
+		{
+			name:     "in list",
+			builder:  Select("id").From("tasks").In("status", Values([]int{1, 3})...).Eq("campaign_id", "c1"),
+			wantSQL:  "SELECT id FROM tasks WHERE status IN ($1, $2) AND campaign_id = $3",
+			wantArgs: []interface{}{1, 3, "c1"},
+		},
+		{
+			name:     "where with several values",
+			builder:  Select("id").From("users").Where("(a = ? OR b < ?)", 1, 2).WhereIf(false, "c = ?", 3),
+			wantSQL:  "SELECT id FROM users WHERE (a = $1 OR b < $2)",
+			wantArgs: []interface{}{1, 2},
+		},
+		{
+			name:     "order, limit and offset",
+			builder:  Select("id").From("tasks").Eq("status", 2).OrderBy("created_at DESC").Limit(10).Offset(20),
+			wantSQL:  "SELECT id FROM tasks WHERE status = $1 ORDER BY created_at DESC LIMIT $2 OFFSET $3",
+			wantArgs: []interface{}{2, 10, 20},
+		},
+		{
+			name:     "nil limit is omitted",
+			builder:  Select("id").From("tasks").Limit(nil),
+			wantSQL:  "SELECT id FROM tasks",
+			wantArgs: []interface{}{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := tt.builder.Build()
+			if sql != tt.wantSQL {
+				t.Errorf("SQL:\n got: %s\nwant: %s", sql, tt.wantSQL)
+			}
+			if len(args) == 0 {
+				args = []interface{}{}
+			}
+			if !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("args: got %v, want %v", args, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestKeyset(t *testing.T) {
+	keys := []SortKey{{Expr: "balance", Desc: true}, {Expr: "id"}}
+
+	tests := []struct {
+		name     string
+		backward bool
+		wantSQL  string
+	}{
+		{
+			name:    "after cursor",
+			wantSQL: "SELECT id FROM users WHERE deleted_at IS NULL AND ((balance < $1) OR (balance = $1 AND id > $2)) ORDER BY balance DESC, id ASC LIMIT $3",
+		},
+		{
+			name:     "before cursor",
+			backward: true,
+			wantSQL:  "SELECT id FROM users WHERE deleted_at IS NULL AND ((balance > $1) OR (balance = $1 AND id < $2)) ORDER BY balance ASC, id DESC LIMIT $3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := Select("id").From("users").
+				Where("deleted_at IS NULL").
+				After(keys, []interface{}{5.0, "u1"}, tt.backward).
+				OrderByKeys(keys, tt.backward).
+				Limit(11).
+				Build()
+			if sql != tt.wantSQL {
+				t.Errorf("SQL:\n got: %s\nwant: %s", sql, tt.wantSQL)
+			}
+			if want := []interface{}{5.0, "u1", 11}; !reflect.DeepEqual(args, want) {
+				t.Errorf("args: got %v, want %v", args, want)
+			}
+		})
+	}
+}
+
+func TestBuildCount(t *testing.T) {
+	b := Select("id").From("tasks").Contains("description", "docs").OrderBy("created_at DESC").Limit(10)
+
+	sql, args := b.BuildCount()
+	if want := "SELECT COUNT(*) FROM tasks WHERE description ILIKE '%' || $1 || '%'"; sql != want {
+		t.Errorf("SQL:\n got: %s\nwant: %s", sql, want)
+	}
+	if want := []interface{}{"docs"}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args: got %v, want %v", args, want)
+	}
+
+	// Запрос количества не должен менять параметры основного запроса
+	sql, args = b.Build()
+	if want := "SELECT id FROM tasks WHERE description ILIKE '%' || $1 || '%' ORDER BY created_at DESC LIMIT $2"; sql != want {
+		t.Errorf("SQL:\n got: %s\nwant: %s", sql, want)
+	}
+	if want := []interface{}{"docs", 10}; !reflect.DeepEqual(args, want) {
+		t.Errorf("args: got %v, want %v", args, want)
+	}
+}
+
+func TestWherePanicsOnPlaceholderMismatch(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic for mismatched placeholders")
+		}
+	}()
+	Select("id").From("tasks").Where("a = ? AND b = ?", 1)
+}
@@ -0,0 +1,123 @@
+// Package testdb создает для тестов временные схемы PostgreSQL с примененными миграциями.
+// Адрес базы задается переменной окружения TEST_DATABASE_URL (URL или строка key=value для lib/pq);
+// если она не задана, тесты, которым нужна база, пропускаются. Каждая схема удаляется по завершении теста,
+// поэтому тесты не мешают друг другу и не оставляют данных в базе.
+package testdb
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// EnvURL - переменная окружения с адресом тестовой базы
+const EnvURL = "TEST_DATABASE_URL"
+
+// NewSchema создает схему с примененными миграциями и возвращает строку подключения к базе,
+// в которой эта схема выбрана путем поиска (search_path)
+func NewSchema(t testing.TB) string {
+	t.Helper()
+
+	dsn := os.Getenv(EnvURL)
+	if dsn == "" {
+		t.Skipf("%s is not set", EnvURL)
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("testdb: open database: %v", err)
+	}
+	schema := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if _, err := admin.Exec(`CREATE SCHEMA ` + schema); err != nil {
+		admin.Close()
+		t.Fatalf("testdb: create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`); err != nil {
+			t.Errorf("testdb: drop schema %s: %v", schema, err)
+		}
+		admin.Close()
+	})
+
+	schemaDSN, err := withSearchPath(dsn, schema)
+	if err != nil {
+		t.Fatalf("testdb: %v", err)
+	}
+	if err := migrateUp(schemaDSN); err != nil {
+		t.Fatalf("testdb: apply migrations: %v", err)
+	}
+	return schemaDSN
+}
+
+// New создает схему с примененными миграциями и возвращает подключение к ней.
+// Подключение закрывается по завершении теста.
+func New(t testing.TB) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("postgres", NewSchema(t))
+	if err != nil {
+		t.Fatalf("testdb: open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// withSearchPath добавляет в строку подключения путь поиска схем; lib/pq передает его серверу
+// как параметр сеанса каждого соединения пула
+func withSearchPath(dsn, schema string) (string, error) {
+	if !strings.HasPrefix(dsn, "postgres://") && !strings.HasPrefix(dsn, "postgresql://") {
+		return dsn + " search_path=" + schema, nil
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", fmt.Errorf("parse %s: %w", EnvURL, err)
+	}
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// migrateUp применяет миграции из каталога migration репозитория
+func migrateUp(dsn string) error {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return err
+	}
+	// Драйвер миграций закрывает подключение вместе с собой
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	if err != nil {
+		db.Close()
+		return err
+	}
+	m, err := migrate.NewWithDatabaseInstance("file://"+filepath.ToSlash(migrationsDir()), "postgres", driver)
+	if err != nil {
+		driver.Close()
+		return err
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+	return nil
+}
+
+// migrationsDir возвращает путь к каталогу миграций относительно исходного файла пакета,
+// чтобы миграции находились независимо от рабочего каталога теста
+func migrationsDir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Join(filepath.Dir(file), "..", "..", "migration")
+}
@@ -0,0 +1,394 @@
+// Package validation проверяет запросы по тегам validate их полей и возвращает все нарушения
+// одной ошибкой errors.Validation.
+//
+// Правила тега перечисляются через запятую и применяются по порядку; поле получает не больше
+// одного нарушения. Имя поля в ошибке берется из тега json, вложенные поля разделяются точкой,
+// элементы массива - индексом в квадратных скобках.
+//
+//	required    значение задано: не пустая строка (пробелы не считаются), не nil, не нулевое значение
+//	omitempty   остальные правила не применяются к незаданному значению (nil-указатель, пустая строка, 0)
+//	dive        следующие правила применяются к каждому элементу массива
+//	min, max    длина строки в символах, число элементов массива или значение числа
+//	gt, gte     число больше (или равно) параметра
+//	lt, lte     число меньше (или равно) параметра
+//	oneof       значение из перечисленных через пробел
+//	gtfield     значение (число или время) больше значения поля структуры, имя которого указано параметром
+//	email       адрес электронной почты
+//	uuid        UUID
+//	iana_tz     часовой пояс из базы IANA, например Europe/Moscow
+//	future      время позже текущего; не проверяется в Partial и Existing: изменение и импорт
+//	            могут передать уже наступившую дату существующей записи
+package validation
+
+import (
+	"fmt"
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"net/mail"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // База часовых поясов встраивается, чтобы iana_tz не зависела от образа
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Message - описание ошибки, нарушения которой перечисляются в ее полях
+const Message = "request validation failed"
+
+// check проверяет значение поля по правилу с параметром param; parent - структура, содержащая поле.
+// Возвращает описание нарушения или пустую строку.
+type check func(value reflect.Value, param string, parent reflect.Value) string
+
+var (
+	checkGte = compare(func(v, p float64) bool { return v >= p }, "must be greater than or equal to %s")
+	checkLte = compare(func(v, p float64) bool { return v <= p }, "must be less than or equal to %s")
+)
+
+var checks = map[string]check{
+	"min":     checkMin,
+	"max":     checkMax,
+	"gt":      compare(func(v, p float64) bool { return v > p }, "must be greater than %s"),
+	"gte":     checkGte,
+	"lt":      compare(func(v, p float64) bool { return v < p }, "must be less than %s"),
+	"lte":     checkLte,
+	"oneof":   checkOneOf,
+	"gtfield": checkGtField,
+	"email":   checkEmail,
+	"uuid":    checkUUID,
+	"iana_tz": checkTimeZone,
+	"future":  checkFuture,
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// Struct проверяет все поля структуры v (или указателя на нее) по их тегам validate.
+// Возвращает nil или *errors.Error типа Validation со списком нарушений по полям.
+func Struct(v interface{}) error {
+	return validate(v, false, false)
+}
+
+// Partial проверяет запрос на частичное изменение: незаданные поля не изменяются,
+// поэтому правило required не применяется, а остальные правила проверяют только заданные поля.
+// Правила относительно текущего момента (future) не применяются: запрос может вернуть прежнее значение.
+func Partial(v interface{}) error {
+	return validate(v, true, true)
+}
+
+// Existing проверяет существующую запись, например восстанавливаемую из выгрузки: как Struct,
+// но без правил относительно текущего момента (future), которым исторические записи уже не соответствуют.
+func Existing(v interface{}) error {
+	return validate(v, false, true)
+}
+
+func validate(v interface{}, partial, existing bool) error {
+	value := indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validation: %T is not a struct", v))
+	}
+	s := &validator{partial: partial, existing: existing}
+	s.validateStruct(value, "")
+	if len(s.errors) > 0 {
+		return errors.NewFieldsValidation(Message, s.errors)
+	}
+	return nil
+}
+
+// rule - одно правило тега validate
+type rule struct {
+	name  string
+	param string
+}
+
+func parseRules(tag string) []rule {
+	var rules []rule
+	for _, part := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(part, "=")
+		rules = append(rules, rule{name: name, param: param})
+	}
+	return rules
+}
+
+// validator собирает нарушения по полям одного запроса
+type validator struct {
+	partial  bool // Не применять required
+	existing bool // Не применять правила относительно текущего момента
+	errors   []errors.FieldError
+}
+
+func (v *validator) fail(field, message string) {
+	v.errors = append(v.errors, errors.FieldError{In: "body", Field: field, Message: message})
+}
+
+// validateStruct проверяет поля структуры; поля встроенных структур проверяются как собственные,
+// как их и раскрывает encoding/json.
+func (v *validator) validateStruct(value reflect.Value, parent string) {
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		tag := sf.Tag.Get("validate")
+		if tag == "-" {
+			continue
+		}
+		field := value.Field(i)
+		if sf.Anonymous && tag == "" {
+			if embedded := indirect(field); embedded.Kind() == reflect.Struct {
+				v.validateStruct(embedded, parent)
+			}
+			continue
+		}
+
+		name := joinField(parent, fieldName(sf))
+		if tag != "" {
+			v.validateField(field, parseRules(tag), name, value)
+		}
+		if nested := indirect(field); nested.Kind() == reflect.Struct && nested.Type() != timeType {
+			v.validateStruct(nested, name)
+		}
+	}
+}
+
+// validateField применяет к полю правила по порядку до первого нарушения
+func (v *validator) validateField(field reflect.Value, rules []rule, name string, parent reflect.Value) {
+	for i, r := range rules {
+		switch r.name {
+		case "omitempty":
+			if isEmpty(field) {
+				return
+			}
+		case "required":
+			if isEmpty(field) {
+				if !v.partial {
+					v.fail(name, "is required")
+				}
+				return
+			}
+		case "dive":
+			items := indirect(field)
+			if items.Kind() != reflect.Slice && items.Kind() != reflect.Array {
+				panic(fmt.Sprintf("validation: dive on %s of kind %s", name, items.Kind()))
+			}
+			for j := 0; j < items.Len(); j++ {
+				v.validateField(items.Index(j), rules[i+1:], fmt.Sprintf("%s[%d]", name, j), parent)
+			}
+			return
+		case "future":
+			if v.existing {
+				continue
+			}
+			fallthrough
+		default:
+			c, exists := checks[r.name]
+			if !exists {
+				panic(fmt.Sprintf("validation: unknown rule %q on %s", r.name, name))
+			}
+			value := indirect(field)
+			if !value.IsValid() {
+				// nil-указатель без required означает незаданное значение
+				return
+			}
+			if message := c(value, r.param, parent); message != "" {
+				v.fail(name, message)
+				return
+			}
+		}
+	}
+}
+
+// isEmpty проверяет, что значение не задано; строка из одних пробелов считается незаданной
+func isEmpty(value reflect.Value) bool {
+	if value.Kind() == reflect.String {
+		return strings.TrimSpace(value.String()) == ""
+	}
+	return value.IsZero()
+}
+
+// indirect разыменовывает указатели и интерфейсы; для nil возвращает нулевое reflect.Value
+func indirect(value reflect.Value) reflect.Value {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	return value
+}
+
+// fieldName возвращает имя поля в JSON
+func fieldName(sf reflect.StructField) string {
+	if name, _, _ := strings.Cut(sf.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return sf.Name
+}
+
+// joinField добавляет имя поля к пути через точку
+func joinField(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// number возвращает значение числового поля
+func number(value reflect.Value) (float64, bool) {
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(value.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(value.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return value.Float(), true
+	}
+	return 0, false
+}
+
+func parseParam(rule, param string) float64 {
+	f, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validation: %s requires a number, got %q", rule, param))
+	}
+	return f
+}
+
+func checkMin(value reflect.Value, param string, _ reflect.Value) string {
+	limit := parseParam("min", param)
+	switch value.Kind() {
+	case reflect.String:
+		if float64(utf8.RuneCountInString(value.String())) < limit {
+			return fmt.Sprintf("must be at least %s characters long", param)
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if float64(value.Len()) < limit {
+			return fmt.Sprintf("must contain at least %s items", param)
+		}
+	default:
+		return checkGte(value, param, reflect.Value{})
+	}
+	return ""
+}
+
+func checkMax(value reflect.Value, param string, _ reflect.Value) string {
+	limit := parseParam("max", param)
+	switch value.Kind() {
+	case reflect.String:
+		if float64(utf8.RuneCountInString(value.String())) > limit {
+			return fmt.Sprintf("must be at most %s characters long", param)
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if float64(value.Len()) > limit {
+			return fmt.Sprintf("must contain at most %s items", param)
+		}
+	default:
+		return checkLte(value, param, reflect.Value{})
+	}
+	return ""
+}
+
+// compare создает правило сравнения числа с параметром
+func compare(ok func(value, param float64) bool, message string) check {
+	return func(value reflect.Value, param string, _ reflect.Value) string {
+		n, isNumber := number(value)
+		if !isNumber {
+			panic(fmt.Sprintf("validation: cannot compare %s with a number", value.Type()))
+		}
+		if !ok(n, parseParam("comparison", param)) {
+			return fmt.Sprintf(message, param)
+		}
+		return ""
+	}
+}
+
+func checkOneOf(value reflect.Value, param string, _ reflect.Value) string {
+	allowed := strings.Fields(param)
+	var actual string
+	if value.Kind() == reflect.String {
+		actual = value.String()
+	} else if n, isNumber := number(value); isNumber {
+		actual = strconv.FormatFloat(n, 'f', -1, 64)
+	} else {
+		panic(fmt.Sprintf("validation: oneof on %s", value.Type()))
+	}
+	for _, a := range allowed {
+		if a == actual {
+			return ""
+		}
+	}
+	if value.Kind() == reflect.String {
+		quoted := make([]string, len(allowed))
+		for i, a := range allowed {
+			quoted[i] = strconv.Quote(a)
+		}
+		allowed = quoted
+	}
+	return "must be one of " + strings.Join(allowed, ", ")
+}
+
+func checkGtField(value reflect.Value, param string, parent reflect.Value) string {
+	sf, exists := parent.Type().FieldByName(param)
+	if !exists {
+		panic(fmt.Sprintf("validation: gtfield refers to unknown field %s", param))
+	}
+	other := indirect(parent.FieldByIndex(sf.Index))
+	if !other.IsValid() {
+		// Поле для сравнения не задано; его обязательность проверяется его собственными правилами
+		return ""
+	}
+	if value.Type() == timeType && other.Type() == timeType {
+		if !value.Interface().(time.Time).After(other.Interface().(time.Time)) {
+			return "must be later than " + fieldName(sf)
+		}
+		return ""
+	}
+	n, isNumber := number(value)
+	m, otherIsNumber := number(other)
+	if !isNumber || !otherIsNumber {
+		panic(fmt.Sprintf("validation: gtfield cannot compare %s with %s", value.Type(), other.Type()))
+	}
+	if n <= m {
+		return "must be greater than " + fieldName(sf)
+	}
+	return ""
+}
+
+func checkEmail(value reflect.Value, _ string, _ reflect.Value) string {
+	address, err := mail.ParseAddress(value.String())
+	if err != nil || address.Address != value.String() {
+		return "must be a valid email address"
+	}
+	return ""
+}
+
+func checkUUID(value reflect.Value, _ string, _ reflect.Value) string {
+	if _, err := uuid.Parse(value.String()); err != nil {
+		return "must be a valid UUID"
+	}
+	return ""
+}
+
+// checkTimeZone принимает только имена зон IANA: пустая строка и Local для LoadLocation
+// означают UTC и часовой пояс сервера, а не зону пользователя.
+func checkTimeZone(value reflect.Value, _ string, _ reflect.Value) string {
+	name := value.String()
+	if name == "" || name == "Local" {
+		return "must be a valid IANA time zone"
+	}
+	if _, err := time.LoadLocation(name); err != nil {
+		return "must be a valid IANA time zone"
+	}
+	return ""
+}
+
+func checkFuture(value reflect.Value, _ string, _ reflect.Value) string {
+	if value.Type() != timeType {
+		panic(fmt.Sprintf("validation: future on %s", value.Type()))
+	}
+	if !value.Interface().(time.Time).After(time.Now()) {
+		return "must be in the future"
+	}
+	return ""
+}
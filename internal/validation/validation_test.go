@@ -0,0 +1,134 @@
+package validation
+
+import (
+	"github.com/ZnNr/user-reward-controller/internal/errors"
+	"github.com/ZnNr/user-reward-controller/internal/models"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+// fields возвращает нарушения ошибки валидации в виде "поле: сообщение"
+func fields(t *testing.T, err error) []string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	e, ok := errors.As(err)
+	if !ok || e.Type != errors.Validation || e.Message != Message {
+		t.Fatalf("unexpected error %v", err)
+	}
+	var got []string
+	for _, f := range e.Fields {
+		if f.In != "body" {
+			t.Fatalf("field %s in %q", f.Field, f.In)
+		}
+		got = append(got, f.Field+": "+f.Message)
+	}
+	return got
+}
+
+func TestStruct(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	tests := []struct {
+		name string
+		req  interface{}
+		want []string
+	}{
+		{"valid user", &models.CreateUserRequest{Username: "erin", Email: "erin@example.com", TimeZone: "Europe/Moscow"}, nil},
+		{"all user violations at once", &models.CreateUserRequest{Username: " ", Email: "erin", TimeZone: "Mars/Olympus", Status: 7}, []string{
+			"Username: is required",
+			"Email: must be a valid email address",
+			"TimeZone: must be a valid IANA time zone",
+			"Status: must be one of 1, 2, 3, 4",
+		}},
+		{"long title and past due date", &models.CreateTaskRequest{BaseTaskRequest: models.BaseTaskRequest{
+			Title: strings.Repeat("я", 256), DueDate: &past, Reward: -1,
+		}}, []string{
+			"title: must be at most 255 characters long",
+			"due_date: must be in the future",
+			"reward: must be greater than or equal to 0",
+		}},
+		{"valid task", &models.CreateTaskRequest{BaseTaskRequest: models.BaseTaskRequest{
+			Title: "Review", DueDate: &future, AssignmentMode: models.AssignmentCapped, MaxClaims: ptr(3),
+		}}, nil},
+		{"assignees and mode", &models.CreateTaskRequest{BaseTaskRequest: models.BaseTaskRequest{
+			Title: "Review", AssignmentMode: "everyone", MaxClaims: ptr(-1),
+			AssigneeIDs: []string{"9a0e6a5e-2b9f-4c1d-8e2a-7d3b5c4f1e09", "erin"},
+		}}, []string{
+			`assignment_mode: must be one of "assigned", "open", "capped"`,
+			"max_claims: must be greater than 0",
+			"assignee_ids[1]: must be a valid UUID",
+		}},
+		{"campaign schedule", &models.CampaignRequest{Name: "Spring", StartsAt: &future, EndsAt: &past, Budget: ptr(0.0)}, []string{
+			"ends_at: must be later than starts_at",
+		}},
+		{"campaign required pointers", &models.CampaignRequest{Name: "Spring"}, []string{
+			"starts_at: is required",
+			"ends_at: is required",
+			"budget: is required",
+		}},
+		{"quest", &models.QuestRequest{Title: "Onboarding", Bonus: -5}, []string{
+			"bonus: must be greater than or equal to 0",
+			"task_ids: is required",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fields(t, Struct(tt.req))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPartial(t *testing.T) {
+	req := &models.UpdateTaskRequest{}
+	if err := Partial(req); err != nil {
+		t.Fatalf("empty update: %v", err)
+	}
+	if err := Struct(req); err == nil {
+		t.Fatal("Struct must require the title")
+	}
+
+	got := fields(t, Partial(&models.UpdateUserRequest{
+		UserID: "9a0e6a5e-2b9f-4c1d-8e2a-7d3b5c4f1e09", Username: ptr(""), TimeZone: ptr("Local"),
+	}))
+	want := []string{"Username: must be at least 1 characters long", "TimeZone: must be a valid IANA time zone"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+// Изменение и импорт могут передать уже наступившую дату существующей задачи
+func TestPastDueDate(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	if err := Partial(&models.UpdateTaskRequest{BaseTaskRequest: models.BaseTaskRequest{DueDate: &past}}); err != nil {
+		t.Fatalf("partial: %v", err)
+	}
+	req := &models.CreateTaskRequest{BaseTaskRequest: models.BaseTaskRequest{DueDate: &past, Reward: -1}}
+	got := fields(t, Existing(req))
+	want := []string{"title: is required", "reward: must be greater than or equal to 0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+// Теги всех моделей запросов должны разбираться: неизвестное правило - ошибка программиста
+func TestRequestModelTags(t *testing.T) {
+	requests := []interface{}{
+		&models.CreateUserRequest{}, &models.UpdateUserRequest{}, &models.CreateTaskRequest{}, &models.UpdateTaskRequest{},
+		&models.CreateReferralRequest{}, &models.UpdateReferralRequest{}, &models.QuestRequest{}, &models.DependencyRequest{},
+		&models.CampaignRequest{}, &models.CreateSubmissionRequest{}, &models.ReviewSubmissionRequest{},
+	}
+	for _, req := range requests {
+		Struct(req)
+		Partial(req)
+		Existing(req)
+	}
+}
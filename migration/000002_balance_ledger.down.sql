@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS trg_users_balance_ledger ON Users;
+DROP FUNCTION IF EXISTS record_balance_change();
+DROP TABLE IF EXISTS balance_ledger CASCADE;
@@ -0,0 +1,38 @@
+-- Журнал изменений баланса пользователей
+CREATE TABLE balance_ledger (
+                                id BIGSERIAL PRIMARY KEY,
+                                user_id VARCHAR(255) NOT NULL,
+                                amount DECIMAL(15, 2) NOT NULL,
+                                balance_after DECIMAL(15, 2) NOT NULL,
+                                reason VARCHAR(100) NOT NULL,
+                                created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_balance_ledger_user_id ON balance_ledger(user_id);
+CREATE INDEX idx_balance_ledger_created_at ON balance_ledger(created_at);
+
+-- Записывает каждое изменение баланса в журнал независимо от того, каким запросом оно выполнено.
+-- Причина берется из параметра транзакции app.ledger_reason, если он установлен.
+CREATE OR REPLACE FUNCTION record_balance_change() RETURNS TRIGGER AS $$
+DECLARE
+    old_balance DECIMAL(15, 2) := 0;
+BEGIN
+    IF TG_OP = 'UPDATE' THEN
+        old_balance := COALESCE(OLD.Balance, 0);
+    END IF;
+
+    IF COALESCE(NEW.Balance, 0) <> old_balance THEN
+        INSERT INTO balance_ledger (user_id, amount, balance_after, reason)
+        VALUES (NEW.ID,
+                COALESCE(NEW.Balance, 0) - old_balance,
+                COALESCE(NEW.Balance, 0),
+                COALESCE(NULLIF(current_setting('app.ledger_reason', true), ''), 'balance_update'));
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_users_balance_ledger
+    AFTER INSERT OR UPDATE OF Balance ON Users
+    FOR EACH ROW
+EXECUTE FUNCTION record_balance_change();
@@ -0,0 +1,11 @@
+-- Мягко удаленные записи не переживают откат миграции
+DELETE FROM Users WHERE DeletedAt IS NOT NULL;
+DELETE FROM tasks WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_tasks_deleted_at;
+DROP INDEX IF EXISTS idx_users_deleted_at;
+DROP INDEX IF EXISTS idx_users_email_active;
+ALTER TABLE Users ADD CONSTRAINT users_email_key UNIQUE (Email);
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE Users DROP COLUMN IF EXISTS DeletedAt;
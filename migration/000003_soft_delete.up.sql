@@ -0,0 +1,11 @@
+-- Мягкое удаление пользователей и задач
+ALTER TABLE Users ADD COLUMN DeletedAt TIMESTAMP;
+ALTER TABLE tasks ADD COLUMN deleted_at TIMESTAMP WITH TIME ZONE;
+
+-- Адрес электронной почты должен быть уникален только среди неудаленных пользователей
+ALTER TABLE Users DROP CONSTRAINT IF EXISTS users_email_key;
+CREATE UNIQUE INDEX idx_users_email_active ON Users(Email) WHERE DeletedAt IS NULL;
+
+-- Индексы для задания очистки по сроку хранения
+CREATE INDEX idx_users_deleted_at ON Users(DeletedAt) WHERE DeletedAt IS NOT NULL;
+CREATE INDEX idx_tasks_deleted_at ON tasks(deleted_at) WHERE deleted_at IS NOT NULL;
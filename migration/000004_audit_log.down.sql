@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS trg_audit_log_no_truncate ON audit_log;
+DROP TRIGGER IF EXISTS trg_audit_log_immutable ON audit_log;
+DROP FUNCTION IF EXISTS reject_audit_log_change();
+DROP TABLE IF EXISTS audit_log CASCADE;
@@ -0,0 +1,48 @@
+-- Неизменяемый журнал аудита административных действий
+CREATE TABLE audit_log (
+                           id BIGSERIAL PRIMARY KEY,
+                           actor VARCHAR(255) NOT NULL,
+                           action VARCHAR(100) NOT NULL,
+                           target_type VARCHAR(50) NOT NULL,
+                           target_id VARCHAR(255) NOT NULL,
+                           before JSON,
+                           after JSON,
+                           request_id VARCHAR(128) NOT NULL DEFAULT '',
+                           ip VARCHAR(64) NOT NULL DEFAULT '',
+                           created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                           content_hash VARCHAR(64) NOT NULL,
+                           redacted_at TIMESTAMP WITH TIME ZONE,
+                           prev_hash VARCHAR(64) NOT NULL DEFAULT '',
+                           hash VARCHAR(64) NOT NULL UNIQUE
+);
+
+CREATE INDEX idx_audit_log_actor ON audit_log(actor);
+CREATE INDEX idx_audit_log_target ON audit_log(target_id);
+CREATE INDEX idx_audit_log_created_at ON audit_log(created_at);
+
+-- Записи журнала нельзя изменять или удалять. Единственное исключение - затирание персональных данных
+-- при анонимизации пользователя: меняются только before, after и ip, а цепочка хешей остается прежней,
+-- так как hash включает content_hash, а не само содержимое.
+CREATE OR REPLACE FUNCTION reject_audit_log_change() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'UPDATE' AND OLD.redacted_at IS NULL AND NEW.redacted_at IS NOT NULL
+        AND (NEW.id, NEW.actor, NEW.action, NEW.target_type, NEW.target_id, NEW.request_id,
+             NEW.created_at, NEW.content_hash, NEW.prev_hash, NEW.hash)
+        IS NOT DISTINCT FROM
+            (OLD.id, OLD.actor, OLD.action, OLD.target_type, OLD.target_id, OLD.request_id,
+             OLD.created_at, OLD.content_hash, OLD.prev_hash, OLD.hash) THEN
+        RETURN NEW;
+    END IF;
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_audit_log_immutable
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW
+EXECUTE FUNCTION reject_audit_log_change();
+
+CREATE TRIGGER trg_audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT
+EXECUTE FUNCTION reject_audit_log_change();
@@ -0,0 +1,10 @@
+-- Одноразовые токены подтверждения email; хранится только SHA-256 токена
+CREATE TABLE user_verification_tokens (
+                                          token_hash VARCHAR(64) PRIMARY KEY,
+                                          user_id VARCHAR(255) NOT NULL REFERENCES Users(ID) ON DELETE CASCADE,
+                                          expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                                          used_at TIMESTAMP WITH TIME ZONE,
+                                          created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_user_verification_tokens_user_id ON user_verification_tokens(user_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS task_status_history CASCADE;
+ALTER TABLE tasks DROP COLUMN IF EXISTS completed_by;
+ALTER TABLE tasks DROP COLUMN IF EXISTS reward;
@@ -0,0 +1,17 @@
+-- Вознаграждение за задачу и пользователь, которому оно начислено
+ALTER TABLE tasks ADD COLUMN reward DECIMAL(15, 2) NOT NULL DEFAULT 0 CHECK (reward >= 0);
+ALTER TABLE tasks ADD COLUMN completed_by VARCHAR(255);
+
+-- История переходов статуса задач
+CREATE TABLE task_status_history (
+                                     id BIGSERIAL PRIMARY KEY,
+                                     task_id VARCHAR(255) NOT NULL REFERENCES tasks(task_id) ON DELETE CASCADE,
+                                     from_status INT NOT NULL REFERENCES task_status(id),
+                                     to_status INT NOT NULL REFERENCES task_status(id),
+                                     actor VARCHAR(255) NOT NULL,
+                                     user_id VARCHAR(255),
+                                     reward_delta DECIMAL(15, 2) NOT NULL DEFAULT 0,
+                                     created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_task_status_history_task_id ON task_status_history(task_id);
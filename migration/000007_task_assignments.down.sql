@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS task_assignments;
+
+ALTER TABLE tasks DROP CONSTRAINT IF EXISTS tasks_capped_max_claims;
+ALTER TABLE tasks DROP COLUMN IF EXISTS max_claims;
+ALTER TABLE tasks DROP COLUMN IF EXISTS assignment_mode;
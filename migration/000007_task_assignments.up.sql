@@ -0,0 +1,18 @@
+-- Режим назначения задачи и лимит взявших ее пользователей
+ALTER TABLE tasks ADD COLUMN assignment_mode VARCHAR(16) NOT NULL DEFAULT 'assigned'
+    CHECK (assignment_mode IN ('assigned', 'open', 'capped'));
+ALTER TABLE tasks ADD COLUMN max_claims INT CHECK (max_claims > 0);
+ALTER TABLE tasks ADD CONSTRAINT tasks_capped_max_claims CHECK (assignment_mode <> 'capped' OR max_claims IS NOT NULL);
+
+-- Участие пользователей в задачах с собственным прогрессом выполнения
+CREATE TABLE task_assignments (
+                                  task_id VARCHAR(255) NOT NULL REFERENCES tasks(task_id) ON DELETE CASCADE,
+                                  user_id VARCHAR(255) NOT NULL REFERENCES Users(ID) ON DELETE CASCADE,
+                                  progress INT NOT NULL REFERENCES task_status(id),
+                                  claimed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                  progressed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                  completed_at TIMESTAMP WITH TIME ZONE,
+                                  PRIMARY KEY (task_id, user_id)
+);
+
+CREATE INDEX idx_task_assignments_user_id ON task_assignments(user_id);
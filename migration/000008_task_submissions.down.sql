@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS task_submissions;
+
+ALTER TABLE tasks DROP COLUMN IF EXISTS requires_evidence;
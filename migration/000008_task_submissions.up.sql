@@ -0,0 +1,20 @@
+-- Выполнение задачи засчитывается только после одобрения заявки с доказательством
+ALTER TABLE tasks ADD COLUMN requires_evidence BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Заявки на выполнение задач с доказательствами и результатом модерации
+CREATE TABLE task_submissions (
+                                  id BIGSERIAL PRIMARY KEY,
+                                  task_id VARCHAR(255) NOT NULL REFERENCES tasks(task_id) ON DELETE CASCADE,
+                                  user_id VARCHAR(255) NOT NULL REFERENCES Users(ID) ON DELETE CASCADE,
+                                  evidence_type VARCHAR(16) NOT NULL CHECK (evidence_type IN ('url', 'text')),
+                                  evidence TEXT NOT NULL,
+                                  status VARCHAR(16) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected')),
+                                  reason TEXT,
+                                  reviewed_by VARCHAR(255),
+                                  reviewed_at TIMESTAMP WITH TIME ZONE,
+                                  created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Не более одной заявки на модерации от пользователя по задаче
+CREATE UNIQUE INDEX idx_task_submissions_pending ON task_submissions(task_id, user_id) WHERE status = 'pending';
+CREATE INDEX idx_task_submissions_status ON task_submissions(status, created_at);
@@ -0,0 +1,3 @@
+ALTER TABLE tasks DROP COLUMN IF EXISTS campaign_id;
+
+DROP TABLE IF EXISTS campaigns;
@@ -0,0 +1,21 @@
+-- Маркетинговые кампании с расписанием и общим бюджетом вознаграждений
+CREATE TABLE campaigns (
+                           campaign_id VARCHAR(255) PRIMARY KEY NOT NULL,
+                           name VARCHAR(255) NOT NULL,
+                           description TEXT,
+                           starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                           ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                           budget DECIMAL(15, 2) NOT NULL CHECK (budget >= 0),
+                           spent DECIMAL(15, 2) NOT NULL DEFAULT 0 CHECK (spent >= 0),
+                           status VARCHAR(16) NOT NULL DEFAULT 'scheduled' CHECK (status IN ('scheduled', 'active', 'ended')),
+                           created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                           updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                           CHECK (ends_at > starts_at),
+                           CHECK (spent <= budget)
+);
+
+CREATE INDEX idx_campaigns_status ON campaigns(status);
+
+-- Задачи кампании
+ALTER TABLE tasks ADD COLUMN campaign_id VARCHAR(255) REFERENCES campaigns(campaign_id) ON DELETE SET NULL;
+CREATE INDEX idx_tasks_campaign_id ON tasks(campaign_id);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS quest_completions;
+DROP TABLE IF EXISTS quest_tasks;
+DROP TABLE IF EXISTS quests;
+DROP TABLE IF EXISTS task_dependencies;
@@ -0,0 +1,38 @@
+-- Зависимости между задачами: задача task_id доступна после выполнения depends_on_id
+CREATE TABLE task_dependencies (
+                                   task_id VARCHAR(255) NOT NULL REFERENCES tasks(task_id) ON DELETE CASCADE,
+                                   depends_on_id VARCHAR(255) NOT NULL REFERENCES tasks(task_id) ON DELETE CASCADE,
+                                   created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                   PRIMARY KEY (task_id, depends_on_id),
+                                   CHECK (task_id <> depends_on_id)
+);
+
+CREATE INDEX idx_task_dependencies_depends_on_id ON task_dependencies(depends_on_id);
+
+-- Квесты - цепочки задач с бонусом за выполнение всех задач
+CREATE TABLE quests (
+                        quest_id VARCHAR(255) PRIMARY KEY NOT NULL,
+                        title VARCHAR(255) NOT NULL,
+                        description TEXT,
+                        bonus DECIMAL(15, 2) NOT NULL DEFAULT 0 CHECK (bonus >= 0),
+                        created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                        updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE quest_tasks (
+                             quest_id VARCHAR(255) NOT NULL REFERENCES quests(quest_id) ON DELETE CASCADE,
+                             task_id VARCHAR(255) NOT NULL REFERENCES tasks(task_id) ON DELETE CASCADE,
+                             position INT NOT NULL,
+                             PRIMARY KEY (quest_id, task_id)
+);
+
+CREATE INDEX idx_quest_tasks_task_id ON quest_tasks(task_id);
+
+-- Выплаченные бонусы за квесты: не более одного на пользователя
+CREATE TABLE quest_completions (
+                                   quest_id VARCHAR(255) NOT NULL REFERENCES quests(quest_id) ON DELETE CASCADE,
+                                   user_id VARCHAR(255) NOT NULL REFERENCES Users(ID) ON DELETE CASCADE,
+                                   bonus DECIMAL(15, 2) NOT NULL,
+                                   completed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                   PRIMARY KEY (quest_id, user_id)
+);
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS task_due_reminders;
+DROP INDEX IF EXISTS idx_tasks_due_date_open;
+
+-- Просроченные задачи возвращаются в работу, чтобы удалить статус Expired
+UPDATE tasks SET status = 1 WHERE status = 5;
+UPDATE task_assignments SET progress = 1 WHERE progress = 5;
+DELETE FROM task_status_history WHERE from_status = 5 OR to_status = 5;
+DELETE FROM task_status WHERE id = 5;
@@ -0,0 +1,14 @@
+-- Просроченные задачи переводятся в статус Expired фоновым заданием
+INSERT INTO task_status (id, name) VALUES (5, 'Expired') ON CONFLICT DO NOTHING;
+SELECT setval(pg_get_serial_sequence('task_status', 'id'), (SELECT MAX(id) FROM task_status));
+
+-- Поиск задач с истекающим и истекшим сроком
+CREATE INDEX idx_tasks_due_date_open ON tasks(due_date) WHERE due_date IS NOT NULL AND deleted_at IS NULL;
+
+-- Отправленные напоминания о сроке: не более одного на исполнителя задачи
+CREATE TABLE task_due_reminders (
+                                    task_id VARCHAR(255) NOT NULL REFERENCES tasks(task_id) ON DELETE CASCADE,
+                                    user_id VARCHAR(255) NOT NULL REFERENCES Users(ID) ON DELETE CASCADE,
+                                    sent_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                    PRIMARY KEY (task_id, user_id)
+);
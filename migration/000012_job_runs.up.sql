@@ -0,0 +1,15 @@
+-- История запусков фоновых заданий; уникальность (job_name, scheduled_at) не дает
+-- двум репликам выполнить один и тот же запуск по расписанию
+CREATE TABLE job_runs (
+                          id BIGSERIAL PRIMARY KEY,
+                          job_name VARCHAR(100) NOT NULL,
+                          scheduled_at TIMESTAMP WITH TIME ZONE NOT NULL,
+                          started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                          finished_at TIMESTAMP WITH TIME ZONE,
+                          status VARCHAR(20) NOT NULL CHECK (status IN ('running', 'succeeded', 'failed', 'canceled')),
+                          error TEXT,
+                          instance VARCHAR(255) NOT NULL,
+                          UNIQUE (job_name, scheduled_at)
+);
+
+CREATE INDEX idx_job_runs_started_at ON job_runs(started_at);
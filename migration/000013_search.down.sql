@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_users_search_vector;
+ALTER TABLE Users DROP COLUMN IF EXISTS SearchVector;
+
+DROP INDEX IF EXISTS idx_tasks_search_vector;
+ALTER TABLE tasks DROP COLUMN IF EXISTS search_vector;
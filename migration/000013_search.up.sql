@@ -0,0 +1,17 @@
+-- Полнотекстовый поиск: заголовок задачи и имя пользователя весят больше описания и биографии.
+-- Конфигурация simple не зависит от языка текста и сохраняет слова без стемминга, что нужно для поиска по префиксу.
+ALTER TABLE tasks ADD COLUMN search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', COALESCE(title, '')), 'A') ||
+        setweight(to_tsvector('simple', COALESCE(description, '')), 'B')
+    ) STORED;
+
+CREATE INDEX idx_tasks_search_vector ON tasks USING GIN (search_vector);
+
+ALTER TABLE Users ADD COLUMN SearchVector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', COALESCE(Username, '')), 'A') ||
+        setweight(to_tsvector('simple', COALESCE(Bio, '')), 'B')
+    ) STORED;
+
+CREATE INDEX idx_users_search_vector ON Users USING GIN (SearchVector);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_referral_user_id_created_at;
+DROP INDEX IF EXISTS idx_users_leaderboard;
+DROP INDEX IF EXISTS idx_users_created_at_id;
+DROP INDEX IF EXISTS idx_tasks_created_at_task_id;
@@ -0,0 +1,9 @@
+-- Индексы в порядке ключей сортировки списков: страница по курсору читается с позиции курсора без OFFSET.
+CREATE INDEX idx_tasks_created_at_task_id ON tasks(created_at, task_id);
+
+CREATE INDEX idx_users_created_at_id ON Users(CreatedAt, ID);
+
+CREATE INDEX idx_users_leaderboard ON Users((COALESCE(Balance, -1)) DESC, (COALESCE(TasksCompleted, 0)) DESC, ID)
+    WHERE DeletedAt IS NULL;
+
+CREATE INDEX idx_referral_user_id_created_at ON referral(user_id, created_at, referral_id);